@@ -0,0 +1,120 @@
+// Package apperror รูปแบบ error เดียวที่ทุก handler ส่งกลับ
+//
+//	{"error": "<ข้อความตามภาษาของ client>", "code": "BOARD_NOT_FOUND", "fields": [...]}
+//
+// client ควรตัดสินใจจาก code ส่วน error เป็นข้อความสำหรับแสดงผล และคงชื่อ key เดิมไว้ให้ client รุ่นเก่า
+package apperror
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Code รหัส error ที่คงที่ ห้ามเปลี่ยนค่าเมื่อ client ใช้งานแล้ว
+type Code string
+
+// FieldError ปัญหาของ field หนึ่งใน request
+// Rule ใช้รูปแบบเดียวกับ tag binding เช่น required, max=255, oneof=0 1 2
+type FieldError struct {
+	Field string `json:"field"`
+	Rule  string `json:"rule"`
+}
+
+// Error error ที่ส่งกลับ client ได้ Err คือสาเหตุภายใน ใช้ log เท่านั้น ไม่ถูกส่งออกไป
+type Error struct {
+	Status int
+	Code   Code
+	Fields []FieldError
+	Err    error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("%s: %v", e.Code, e.Err)
+	}
+	return string(e.Code)
+}
+
+func (e *Error) Unwrap() error { return e.Err }
+
+// Is ให้ errors.Is(err, apperror.BoardNotFound) เทียบด้วย code
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap คืนสำเนาที่มีสาเหตุภายในแนบไว้ ค่าใน catalog ไม่ถูกแก้
+func (e *Error) Wrap(err error) *Error {
+	cp := *e
+	cp.Err = err
+	return &cp
+}
+
+// WithField คืนสำเนาที่เพิ่ม field ที่มีปัญหา
+func (e *Error) WithField(field, rule string) *Error {
+	cp := *e
+	cp.Fields = append(append([]FieldError(nil), e.Fields...), FieldError{Field: field, Rule: rule})
+	return &cp
+}
+
+// Response body ที่ส่งกลับ client
+type Response struct {
+	Error  string       `json:"error"`
+	Code   Code         `json:"code"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// Respond เขียน error และหยุด handler chain ใช้ได้ทั้งใน handler และ middleware
+// error ที่ไม่ใช่ *Error ถือเป็น INTERNAL_ERROR สาเหตุจะถูกแนบกับ c.Errors ให้ RequestLogger บันทึก
+func Respond(c *gin.Context, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		e = Internal.Wrap(err)
+	}
+	if e.Err != nil {
+		_ = c.Error(e.Err)
+	}
+	c.AbortWithStatusJSON(e.Status, Response{
+		Error:  Message(e.Code, Language(c.GetHeader("Accept-Language"))),
+		Code:   e.Code,
+		Fields: e.Fields,
+	})
+}
+
+// Validation แปลง error จาก ShouldBindJSON เป็น INVALID_INPUT พร้อมรายชื่อ field ตาม tag json
+func Validation(err error) *Error {
+	out := InvalidInput.Wrap(err)
+	var verrs validator.ValidationErrors
+	if errors.As(err, &verrs) {
+		for _, fe := range verrs {
+			rule := fe.Tag()
+			if fe.Param() != "" {
+				rule += "=" + fe.Param()
+			}
+			out.Fields = append(out.Fields, FieldError{Field: fe.Field(), Rule: rule})
+		}
+	}
+	return out
+}
+
+func init() {
+	// ให้ FieldError ใช้ชื่อ field ตาม tag json แบบที่ client ส่งมา แทนชื่อ field ของ struct
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(f reflect.StructField) string {
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			switch name {
+			case "-":
+				return ""
+			case "":
+				return f.Name
+			}
+			return name
+		})
+	}
+}
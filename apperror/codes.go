@@ -0,0 +1,129 @@
+package apperror
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+type message struct {
+	en string
+	th string
+}
+
+var catalog = map[Code]message{}
+
+func define(status int, code Code, en, th string) *Error {
+	if _, dup := catalog[code]; dup {
+		panic("apperror: duplicate code " + code)
+	}
+	catalog[code] = message{en: en, th: th}
+	return &Error{Status: status, Code: code}
+}
+
+// Language เลือกภาษาจาก Accept-Language รองรับ th และ en (ค่าเริ่มต้น)
+func Language(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(tag)
+		if tag == "th" || strings.HasPrefix(tag, "th-") {
+			return "th"
+		}
+		if tag == "en" || strings.HasPrefix(tag, "en-") {
+			return "en"
+		}
+	}
+	return "en"
+}
+
+// Message ข้อความของ code ในภาษาที่ขอ
+func Message(code Code, lang string) string {
+	m, ok := catalog[code]
+	if !ok {
+		return string(code)
+	}
+	if lang == "th" {
+		return m.th
+	}
+	return m.en
+}
+
+// Codes ทุก code ที่ประกาศไว้ เรียงตามตัวอักษร ใช้สร้าง enum ในเอกสาร API
+func Codes() []Code {
+	out := make([]Code, 0, len(catalog))
+	for code := range catalog {
+		out = append(out, code)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+// ทั่วไป
+var (
+	InvalidInput     = define(http.StatusBadRequest, "INVALID_INPUT", "Invalid input", "ข้อมูลที่ส่งมาไม่ถูกต้อง")
+	InvalidDate      = define(http.StatusBadRequest, "INVALID_DATE", "Invalid date format, use RFC3339 or a local date-time", "รูปแบบวันที่ไม่ถูกต้อง ต้องเป็น RFC3339 หรือวันเวลาท้องถิ่น")
+	NoFieldsToUpdate = define(http.StatusBadRequest, "NO_FIELDS_TO_UPDATE", "No fields to update", "ไม่มีข้อมูลที่ต้องแก้ไข")
+	Forbidden        = define(http.StatusForbidden, "FORBIDDEN", "Access denied", "ไม่มีสิทธิ์เข้าถึง")
+	AdminRequired    = define(http.StatusForbidden, "ADMIN_REQUIRED", "Admin role required", "ต้องเป็นผู้ดูแลระบบ")
+	RateLimited      = define(http.StatusTooManyRequests, "RATE_LIMITED", "Too many requests, please try again later", "ส่งคำขอบ่อยเกินไป กรุณาลองใหม่ภายหลัง")
+	Internal         = define(http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error", "ระบบขัดข้อง กรุณาลองใหม่อีกครั้ง")
+)
+
+// token และการเข้าสู่ระบบ (status ของ token คงไว้ตามเดิมเพราะ client ใช้ 403 เป็นสัญญาณให้ขอ access token ใหม่)
+var (
+	TokenMissing             = define(http.StatusUnauthorized, "TOKEN_MISSING", "Authorization header is missing", "ไม่พบ token ใน Authorization header")
+	TokenInvalid             = define(http.StatusForbidden, "TOKEN_INVALID", "Token is expired or invalid", "token หมดอายุหรือไม่ถูกต้อง")
+	TokenClaimsInvalid       = define(http.StatusUnauthorized, "TOKEN_CLAIMS_INVALID", "Invalid token claims", "ข้อมูลใน token ไม่ถูกต้อง")
+	RefreshTokenInvalid      = define(http.StatusUnauthorized, "REFRESH_TOKEN_INVALID", "Invalid refresh token", "refresh token ไม่ถูกต้อง")
+	RefreshTokenExpired      = define(http.StatusUnauthorized, "REFRESH_TOKEN_EXPIRED", "Refresh token has expired", "refresh token หมดอายุ")
+	RefreshTokenRevoked      = define(http.StatusForbidden, "REFRESH_TOKEN_REVOKED", "Refresh token has been revoked", "refresh token ถูกยกเลิกแล้ว")
+	InvalidCredentials       = define(http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid email or password", "อีเมลหรือรหัสผ่านไม่ถูกต้อง")
+	CurrentPasswordIncorrect = define(http.StatusUnauthorized, "CURRENT_PASSWORD_INCORRECT", "Current password is incorrect", "รหัสผ่านปัจจุบันไม่ถูกต้อง")
+	PasswordUnchanged        = define(http.StatusBadRequest, "PASSWORD_UNCHANGED", "New password must be different from current password", "รหัสผ่านใหม่ต้องไม่ซ้ำกับรหัสผ่านเดิม")
+	AccountInactive          = define(http.StatusUnauthorized, "ACCOUNT_INACTIVE", "User account is not active", "บัญชีนี้ถูกระงับการใช้งาน")
+	AccountDeleted           = define(http.StatusBadRequest, "ACCOUNT_DELETED", "User account is deleted", "บัญชีนี้ถูกลบแล้ว")
+	AccountNotVerified       = define(http.StatusForbidden, "ACCOUNT_NOT_VERIFIED", "User account is not verified", "บัญชีนี้ยังไม่ได้ยืนยันอีเมล")
+	EmailExists              = define(http.StatusConflict, "EMAIL_EXISTS", "Email already exists", "อีเมลนี้ถูกใช้งานแล้ว")
+	EmailNotFound            = define(http.StatusNotFound, "EMAIL_NOT_FOUND", "Email not found", "ไม่พบอีเมลนี้ในระบบ")
+	CaptchaFailed            = define(http.StatusBadRequest, "CAPTCHA_FAILED", "reCAPTCHA verification failed", "การยืนยัน reCAPTCHA ไม่ผ่าน")
+)
+
+// OTP
+var (
+	OTPInvalid          = define(http.StatusBadRequest, "OTP_INVALID", "Invalid OTP", "รหัส OTP ไม่ถูกต้อง")
+	OTPExpired          = define(http.StatusBadRequest, "OTP_EXPIRED", "OTP has expired", "รหัส OTP หมดอายุแล้ว")
+	OTPAlreadyUsed      = define(http.StatusBadRequest, "OTP_ALREADY_USED", "OTP has already been used", "รหัส OTP นี้ถูกใช้ไปแล้ว")
+	OTPReferenceInvalid = define(http.StatusNotFound, "OTP_REFERENCE_INVALID", "Invalid reference code", "รหัสอ้างอิงไม่ถูกต้อง")
+	OTPRateLimited      = define(http.StatusTooManyRequests, "OTP_RATE_LIMITED", "Too many OTP requests, please try again later", "ขอรหัส OTP บ่อยเกินไป กรุณาลองใหม่ภายหลัง")
+	EmailBlocked        = define(http.StatusTooManyRequests, "EMAIL_BLOCKED", "Too many OTP requests, this email is temporarily blocked", "ขอรหัส OTP บ่อยเกินไป อีเมลนี้ถูกระงับชั่วคราว")
+)
+
+// ไม่พบข้อมูล
+var (
+	UserNotFound         = define(http.StatusNotFound, "USER_NOT_FOUND", "User not found", "ไม่พบผู้ใช้")
+	BoardNotFound        = define(http.StatusNotFound, "BOARD_NOT_FOUND", "Board not found", "ไม่พบบอร์ด")
+	BoardMemberNotFound  = define(http.StatusNotFound, "BOARD_MEMBER_NOT_FOUND", "Board member not found", "ไม่พบสมาชิกในบอร์ด")
+	TaskNotFound         = define(http.StatusNotFound, "TASK_NOT_FOUND", "Task not found", "ไม่พบงาน")
+	ChecklistNotFound    = define(http.StatusNotFound, "CHECKLIST_NOT_FOUND", "Checklist not found", "ไม่พบรายการเช็กลิสต์")
+	AttachmentNotFound   = define(http.StatusNotFound, "ATTACHMENT_NOT_FOUND", "Attachment not found", "ไม่พบไฟล์แนบ")
+	AssignmentNotFound   = define(http.StatusNotFound, "ASSIGNMENT_NOT_FOUND", "Assignment not found", "ไม่พบการมอบหมายงาน")
+	NotificationNotFound = define(http.StatusNotFound, "NOTIFICATION_NOT_FOUND", "Notification not found", "ไม่พบการแจ้งเตือน")
+	InviteNotFound       = define(http.StatusNotFound, "INVITE_NOT_FOUND", "Invite not found", "ไม่พบคำเชิญ")
+	ReportNotFound       = define(http.StatusNotFound, "REPORT_NOT_FOUND", "Report not found", "ไม่พบรายงาน")
+	ShareTokenNotFound   = define(http.StatusNotFound, "SHARE_TOKEN_NOT_FOUND", "Share link not found", "ไม่พบลิงก์แชร์บอร์ด")
+	FCMTokenNotFound     = define(http.StatusNotFound, "FCM_TOKEN_NOT_FOUND", "Push token not found for user", "ผู้ใช้ยังไม่ได้เปิดรับการแจ้งเตือน")
+	DeviceNotFound       = define(http.StatusNotFound, "DEVICE_NOT_FOUND", "Device not registered", "ไม่พบอุปกรณ์นี้ในรายการรับการแจ้งเตือน")
+	InboxItemNotFound    = define(http.StatusNotFound, "INBOX_ITEM_NOT_FOUND", "Inbox item not found", "ไม่พบรายการแจ้งเตือนนี้")
+)
+
+// กฎของบอร์ดและงาน
+var (
+	NotBoardMember        = define(http.StatusForbidden, "NOT_BOARD_MEMBER", "You are not the owner or a member of this board", "คุณไม่ใช่เจ้าของหรือสมาชิกของบอร์ดนี้")
+	NotTaskOwner          = define(http.StatusForbidden, "NOT_TASK_OWNER", "You do not have permission to change this task", "คุณไม่มีสิทธิ์แก้ไขงานนี้")
+	AlreadyBoardMember    = define(http.StatusConflict, "ALREADY_BOARD_MEMBER", "User is already a member of this board", "ผู้ใช้เป็นสมาชิกของบอร์ดนี้อยู่แล้ว")
+	CannotAcceptOwnInvite = define(http.StatusBadRequest, "CANNOT_ACCEPT_OWN_INVITE", "Cannot accept your own invitation", "ไม่สามารถตอบรับคำเชิญของตัวเองได้")
+	TaskStatusUnchanged   = define(http.StatusBadRequest, "TASK_STATUS_UNCHANGED", "Task is already in this status", "งานอยู่ในสถานะนี้แล้ว")
+	NoDeletableTasks      = define(http.StatusForbidden, "NO_DELETABLE_TASKS", "None of the tasks can be deleted", "ไม่มีงานที่คุณลบได้")
+	ShareLinkExpired      = define(http.StatusGone, "SHARE_LINK_EXPIRED", "Share link has expired", "ลิงก์แชร์บอร์ดหมดอายุแล้ว")
+	ShareTokenInvalid     = define(http.StatusUnauthorized, "SHARE_TOKEN_INVALID", "Invalid share link", "ลิงก์แชร์บอร์ดไม่ถูกต้อง")
+)
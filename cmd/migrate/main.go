@@ -0,0 +1,88 @@
+// คำสั่งจัดการ schema ของ MySQL
+//
+//	go run ./cmd/migrate up
+//	go run ./cmd/migrate down -steps 1
+//	go run ./cmd/migrate status
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"mydayplanner/config"
+	"mydayplanner/connection"
+	"mydayplanner/migrations"
+	"os"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate <up|down|status|version> [-steps N]")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	command := os.Args[1]
+
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	steps := flags.Int("steps", 1, "number of migrations to roll back (down only)")
+	flags.Parse(os.Args[2:])
+
+	cfg, err := config.LoadDB()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := connection.DBConnection(cfg)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+
+	ctx := context.Background()
+	m := migrations.New(db)
+
+	switch command {
+	case "up":
+		done, err := m.Up(ctx)
+		for _, mig := range done {
+			fmt.Printf("applied %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(done) == 0 {
+			fmt.Println("schema is up to date")
+		}
+	case "down":
+		done, err := m.Down(ctx, *steps)
+		for _, mig := range done {
+			fmt.Printf("rolled back %04d_%s\n", mig.Version, mig.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, st := range statuses {
+			applied := "pending"
+			if st.AppliedAt != nil {
+				applied = st.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%04d_%-24s %s\n", st.Version, st.Name, applied)
+		}
+	case "version":
+		version, err := m.Version(ctx)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println(version)
+	default:
+		usage()
+	}
+}
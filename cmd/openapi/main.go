@@ -0,0 +1,36 @@
+// คำสั่งสร้าง openapi/openapi.json จาก route ที่ลงทะเบียนจริงใน connection.NewRouter
+//
+//	go generate ./openapi
+package main
+
+import (
+	"flag"
+	"log"
+	"mydayplanner/config"
+	"mydayplanner/connection"
+	"mydayplanner/openapi"
+	"os"
+
+	"github.com/gin-gonic/gin"
+)
+
+func main() {
+	out := flag.String("o", "openapi/openapi.json", "output file")
+	flag.Parse()
+
+	// ลงทะเบียน route อย่างเดียว ไม่มีการเชื่อมต่อ DB หรือ Firebase
+	gin.SetMode(gin.ReleaseMode)
+	router := connection.NewRouter(&connection.Deps{Config: &config.Config{}})
+
+	doc, err := openapi.Build(router.Routes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	spec, err := openapi.Marshal(doc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, spec, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
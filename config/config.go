@@ -0,0 +1,469 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/robfig/cron/v3"
+)
+
+// Config ค่าทั้งหมดของแอป โหลดครั้งเดียวตอนเริ่ม process แล้วส่งต่อให้ server และ scheduler
+type Config struct {
+	// Env development หรือ production (ค่าเริ่มต้น) ค่าที่ใช้ได้เฉพาะตอนพัฒนาถูกปฏิเสธนอก development
+	Env       string
+	Log       LogConfig
+	Server    ServerConfig
+	DB        DBConfig
+	Firebase  FirebaseConfig
+	JWT       JWTConfig
+	SMTP      SMTPConfig
+	Mail      MailConfig
+	TOTP      TOTPConfig
+	Recaptcha RecaptchaConfig
+	OTP       OTPConfig
+	Scheduler SchedulerConfig
+	RateLimit RateLimitConfig
+	Outbox    OutboxConfig
+	Push      PushConfig
+	Leader    LeaderConfig
+}
+
+type LogConfig struct {
+	// Level ระดับต่ำสุดที่ log: debug, info, warn, error
+	Level string
+}
+
+type ServerConfig struct {
+	Addr string
+	// ShutdownTimeout เวลาที่รอ request และ cron job ที่ค้างอยู่ตอนปิด process
+	ShutdownTimeout time.Duration
+	// TrustedProxies IP หรือ CIDR ของ reverse proxy ที่เชื่อ X-Forwarded-For ได้ ค่าว่างคือไม่เชื่อ proxy ใด
+	// IP ของผู้เรียกจึงเป็นที่อยู่ของ connection ซึ่ง client ปลอมไม่ได้ (rate limit ตาม IP ใช้ค่านี้)
+	TrustedProxies []string
+}
+
+type DBConfig struct {
+	DSN             string
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// FirebaseConfig service account ของโปรเจกต์ที่ใช้ Firestore และ FCM
+type FirebaseConfig struct {
+	CredentialsPath string
+}
+
+type JWTConfig struct {
+	AccessSecret  string
+	RefreshSecret string
+	AccessTTL     time.Duration
+	RefreshTTL    time.Duration
+}
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+}
+
+// MailConfig ช่องทางส่งอีเมลของระบบ
+type MailConfig struct {
+	// Sender smtp ส่งผ่าน SMTP, maildir เขียนเป็นไฟล์ลง MaildirPath,
+	// memory เก็บไว้ในหน่วยความจำ (ใช้ได้เฉพาะ APP_ENV=development)
+	Sender string
+	// DevMailbox เปิด /v1/dev/mailbox ที่อ่านอีเมลใน memory ได้โดยไม่ต้อง login รวมถึง OTP ทุกฉบับ
+	// ต้องตั้ง DEV_MAILBOX=true เองและใช้ได้เฉพาะ APP_ENV=development คู่กับ MAIL_SENDER=memory
+	DevMailbox bool
+	// From ผู้ส่งของทุกอีเมล ค่าเริ่มต้นเป็น SMTP_USERNAME
+	From        string
+	MaildirPath string
+}
+
+type TOTPConfig struct {
+	Secret string
+}
+
+// RecaptchaConfig ใช้ service account แยกจาก Firebase
+type RecaptchaConfig struct {
+	ProjectID       string
+	SiteKey         string
+	CredentialsPath string
+}
+
+// OTPConfig อายุของ OTP และเงื่อนไขการบล็อกอีเมลที่ขอ OTP ถี่เกินไป
+type OTPConfig struct {
+	TTL         time.Duration
+	MaxActive   int
+	BlockWindow time.Duration
+}
+
+// SchedulerConfig cron spec แบบมีวินาที (cron.WithSeconds)
+type SchedulerConfig struct {
+	NotificationSpec string
+	// MaxLag ถ้า job ส่งแจ้งเตือนไม่สำเร็จนานกว่านี้ /readyz จะตอบ 503
+	MaxLag time.Duration
+	// ReconcileSpec รอบตรวจ Firestore mirror เทียบกับ MySQL ตั้งเป็น off เพื่อปิด
+	ReconcileSpec string
+	// ReconcileApply ซ่อมเอกสารที่คลาดเคลื่อนด้วย ถ้า false แค่ log และนับ metrics
+	ReconcileApply bool
+}
+
+// RateLimitConfig backend ของ rate limiter และโควตาของแต่ละกลุ่ม route
+// อ่านจาก env ในรูปแบบ จำนวน/ช่วงเวลา เช่น 10/1m ตั้งจำนวนเป็น 0 เพื่อปิดการจำกัดของกลุ่มนั้น
+type RateLimitConfig struct {
+	// Backend memory นับแยกต่อ instance, mysql นับร่วมกันทุก instance ผ่านตาราง rate_limit_buckets
+	Backend string
+	Auth    Rate
+	Search  Rate
+	Report  Rate
+	Jobs    Rate
+}
+
+// Rate ยิงติดกันได้ Limit ครั้ง และโควตาคืนจนเต็มภายใน Per
+type Rate struct {
+	Limit int
+	Per   time.Duration
+}
+
+// OutboxConfig การส่งการเขียน Firestore ที่บันทึกไว้ในตาราง firestore_outbox
+type OutboxConfig struct {
+	// PollInterval ระยะห่างระหว่างรอบที่ relay อ่าน outbox
+	PollInterval time.Duration
+	// MaxAttempts เขียนไม่สำเร็จครบจำนวนนี้แล้วย้ายไป firestore_outbox_dead
+	MaxAttempts int
+	// MaxBackoff เวลารอก่อนลองใหม่เพิ่มเป็นเท่าตัวทุกครั้งแต่ไม่เกินค่านี้
+	MaxBackoff time.Duration
+}
+
+// LeaderConfig lease ในตาราง scheduler_leases ที่ให้ scheduler รันบน instance เดียว
+type LeaderConfig struct {
+	// LeaseTTL lease ที่ไม่ถูกต่อนานเท่านี้ instance อื่นจะรับไปได้ คือเวลาสูงสุดที่ไม่มี leader เมื่อ leader ตาย
+	LeaseTTL time.Duration
+	// RenewInterval ระยะห่างระหว่างการต่อ lease หรือพยายามรับ lease ต้องน้อยกว่า LeaseTTL
+	RenewInterval time.Duration
+}
+
+// PushConfig ช่องทางส่ง push notification
+type PushConfig struct {
+	// Provider fcm ส่งผ่าน Firebase, webhook POST ไปยัง WebhookURL, log แค่บันทึกไว้ไม่ส่งจริง (ใช้ได้เฉพาะ development)
+	Provider      string
+	WebhookURL    string
+	WebhookSecret string
+	// WebhookTimeout เวลาสูงสุดของแต่ละ request ไปยัง webhook
+	WebhookTimeout time.Duration
+	// PruneAfterFailures token ที่ส่งไม่สำเร็จติดกันครบจำนวนนี้ถูกลบออกจาก usersLogin
+	// ใช้กับความล้มเหลวชั่วคราว ส่วน token ที่ FCM แจ้งว่าใช้ไม่ได้แล้วถูกลบทันที
+	PruneAfterFailures int
+	// PruneMinAge ต้องล้มต่อเนื่องนานอย่างน้อยเท่านี้ด้วย กันไม่ให้ FCM ล่มชั่วคราวทำให้ token ดีถูกลบ
+	PruneMinAge time.Duration
+}
+
+// ValidationError รวมปัญหาทั้งหมดของ config ไว้ในรายงานเดียว
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Load อ่าน .env (ถ้ามี) และ environment variables แล้วตรวจสอบค่าที่จำเป็นทั้งหมด
+func Load() (*Config, error) {
+	// บน Render ไม่มีไฟล์ .env ค่าถูกตั้งใน environment อยู่แล้ว
+	if os.Getenv("RENDER") == "" {
+		if err := godotenv.Load(); err != nil {
+			slog.Warn("no .env file loaded, using process environment") // ใช้เฉพาะตอน dev
+		}
+	}
+	return FromEnv(os.Getenv)
+}
+
+// LoadDB โหลดเฉพาะค่าฐานข้อมูล สำหรับคำสั่งที่ไม่ได้รัน API เช่น migrate
+func LoadDB() (DBConfig, error) {
+	if os.Getenv("RENDER") == "" {
+		if err := godotenv.Load(); err != nil {
+			slog.Warn("no .env file loaded, using process environment") // ใช้เฉพาะตอน dev
+		}
+	}
+	r := &reader{getenv: os.Getenv}
+	cfg := readDB(r)
+	if len(r.problems) > 0 {
+		return DBConfig{}, &ValidationError{Problems: r.problems}
+	}
+	return cfg, nil
+}
+
+// FromEnv สร้าง Config จากฟังก์ชันอ่านค่า ใช้กับ os.Getenv หรือ map ในการทดสอบ
+func FromEnv(getenv func(string) string) (*Config, error) {
+	r := &reader{getenv: getenv}
+	mailSender := r.optional("MAIL_SENDER", "smtp")
+
+	cfg := &Config{
+		Env: r.optional("APP_ENV", "production"),
+		Log: LogConfig{
+			Level: r.optional("LOG_LEVEL", "info"),
+		},
+		Server: ServerConfig{
+			Addr:            ":" + r.optional("PORT", "8080"),
+			ShutdownTimeout: r.duration("SHUTDOWN_TIMEOUT", 30*time.Second),
+			TrustedProxies:  r.addresses("TRUSTED_PROXIES"),
+		},
+		DB: readDB(r),
+		Firebase: FirebaseConfig{
+			CredentialsPath: r.required("GOOGLE_APPLICATION_CREDENTIALS_1"),
+		},
+		JWT: JWTConfig{
+			AccessSecret:  r.required("JWT_SECRET_KEY"),
+			RefreshSecret: r.required("JWT_REFRESH_SECRET_KEY"),
+			AccessTTL:     r.duration("ACCESS_TOKEN_TTL", 60*time.Minute),
+			RefreshTTL:    r.duration("REFRESH_TOKEN_TTL", 7*24*time.Hour),
+		},
+		SMTP: readSMTP(r, mailSender == "smtp"),
+		Mail: MailConfig{
+			Sender:      mailSender,
+			From:        r.optional("MAIL_FROM", strings.TrimSpace(getenv("SMTP_USERNAME"))),
+			MaildirPath: r.optional("MAIL_MAILDIR", "tmp/maildir"),
+			DevMailbox:  r.boolean("DEV_MAILBOX", false),
+		},
+		TOTP: TOTPConfig{
+			Secret: r.required("TOTPsecret"),
+		},
+		Recaptcha: RecaptchaConfig{
+			ProjectID:       r.required("GOOGLE_CLOUD_PROJECT_ID"),
+			SiteKey:         r.required("RECAPTCHA_SITE_KEY"),
+			CredentialsPath: r.required("GOOGLE_APPLICATION_CREDENTIALS_2"),
+		},
+		OTP: OTPConfig{
+			TTL:         r.duration("OTP_TTL", 15*time.Minute),
+			MaxActive:   r.integer("OTP_MAX_ACTIVE", 3),
+			BlockWindow: r.duration("OTP_BLOCK_WINDOW", 10*time.Minute),
+		},
+		Scheduler: SchedulerConfig{
+			NotificationSpec: r.optional("NOTIFICATION_CRON", "0 * * * * *"),
+			MaxLag:           r.duration("SCHEDULER_MAX_LAG", 5*time.Minute),
+			ReconcileSpec:    r.optional("RECONCILE_CRON", "0 0 3 * * *"),
+			ReconcileApply:   r.boolean("RECONCILE_APPLY", false),
+		},
+		RateLimit: RateLimitConfig{
+			Backend: r.optional("RATE_LIMIT_BACKEND", "memory"),
+			Auth:    r.rate("RATE_LIMIT_AUTH", Rate{Limit: 10, Per: time.Minute}),
+			Search:  r.rate("RATE_LIMIT_SEARCH", Rate{Limit: 30, Per: time.Minute}),
+			Report:  r.rate("RATE_LIMIT_REPORT", Rate{Limit: 5, Per: 10 * time.Minute}),
+			Jobs:    r.rate("RATE_LIMIT_JOBS", Rate{Limit: 6, Per: time.Minute}),
+		},
+		Outbox: OutboxConfig{
+			PollInterval: r.duration("OUTBOX_POLL_INTERVAL", time.Second),
+			MaxAttempts:  r.integer("OUTBOX_MAX_ATTEMPTS", 10),
+			MaxBackoff:   r.duration("OUTBOX_MAX_BACKOFF", 5*time.Minute),
+		},
+		Push: PushConfig{
+			Provider:       r.optional("PUSH_PROVIDER", "fcm"),
+			WebhookURL:     r.optional("PUSH_WEBHOOK_URL", ""),
+			WebhookSecret:  r.optional("PUSH_WEBHOOK_SECRET", ""),
+			WebhookTimeout: r.duration("PUSH_WEBHOOK_TIMEOUT", 10*time.Second),
+
+			PruneAfterFailures: r.integer("PUSH_PRUNE_AFTER_FAILURES", 5),
+			PruneMinAge:        r.duration("PUSH_PRUNE_MIN_AGE", 72*time.Hour),
+		},
+		Leader: LeaderConfig{
+			LeaseTTL:      r.duration("LEADER_LEASE_TTL", 15*time.Second),
+			RenewInterval: r.duration("LEADER_RENEW_INTERVAL", 5*time.Second),
+		},
+	}
+
+	// TOTP ใช้ period เป็นวินาทีเต็ม
+	if cfg.OTP.TTL < time.Second {
+		r.fail("OTP_TTL must be at least 1s")
+	}
+	if cfg.OTP.MaxActive < 1 {
+		r.fail("OTP_MAX_ACTIVE must be at least 1")
+	}
+	if b := cfg.RateLimit.Backend; b != "memory" && b != "mysql" {
+		r.fail(fmt.Sprintf("RATE_LIMIT_BACKEND %q must be memory or mysql", b))
+	}
+	if cfg.Outbox.PollInterval <= 0 {
+		r.fail("OUTBOX_POLL_INTERVAL must be positive")
+	}
+	if cfg.Outbox.MaxAttempts < 1 {
+		r.fail("OUTBOX_MAX_ATTEMPTS must be at least 1")
+	}
+	if cfg.Leader.RenewInterval <= 0 || cfg.Leader.RenewInterval >= cfg.Leader.LeaseTTL {
+		r.fail("LEADER_RENEW_INTERVAL must be positive and shorter than LEADER_LEASE_TTL")
+	}
+	if e := cfg.Env; e != "development" && e != "production" {
+		r.fail(fmt.Sprintf("APP_ENV %q must be development or production", e))
+	}
+	if s := cfg.Mail.Sender; s != "smtp" && s != "maildir" && s != "memory" {
+		r.fail(fmt.Sprintf("MAIL_SENDER %q must be smtp, maildir or memory", s))
+	}
+	// อีเมลใน memory ไม่ถึงผู้ใช้จริง และ dev mailbox เปิดให้ใครก็อ่าน OTP ได้
+	if cfg.Mail.Sender == "memory" && cfg.Env != "development" {
+		r.fail("MAIL_SENDER memory is only allowed when APP_ENV is development")
+	}
+	if cfg.Mail.DevMailbox && (cfg.Env != "development" || cfg.Mail.Sender != "memory") {
+		r.fail("DEV_MAILBOX is only allowed when APP_ENV is development and MAIL_SENDER is memory")
+	}
+	if cfg.Mail.From == "" {
+		r.fail("MAIL_FROM is required when SMTP_USERNAME is not set")
+	}
+	switch cfg.Push.Provider {
+	case "fcm":
+	case "log":
+		// push.Recorder เก็บทุกข้อความไว้ในหน่วยความจำไม่มีวันลบ และผู้ใช้ไม่ได้รับ push
+		if cfg.Env != "development" {
+			r.fail("PUSH_PROVIDER log is only allowed when APP_ENV is development")
+		}
+	case "webhook":
+		if cfg.Push.WebhookURL == "" {
+			r.fail("PUSH_WEBHOOK_URL is required when PUSH_PROVIDER is webhook")
+		}
+	default:
+		r.fail(fmt.Sprintf("PUSH_PROVIDER %q must be fcm, webhook or log", cfg.Push.Provider))
+	}
+	if cfg.Push.PruneAfterFailures < 1 {
+		r.fail("PUSH_PRUNE_AFTER_FAILURES must be at least 1")
+	}
+	parser := cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	if _, err := parser.Parse(cfg.Scheduler.NotificationSpec); err != nil {
+		r.fail(fmt.Sprintf("NOTIFICATION_CRON %q is not a valid cron spec: %v", cfg.Scheduler.NotificationSpec, err))
+	}
+	if spec := cfg.Scheduler.ReconcileSpec; spec != "off" {
+		if _, err := parser.Parse(spec); err != nil {
+			r.fail(fmt.Sprintf("RECONCILE_CRON %q is not a valid cron spec: %v", spec, err))
+		}
+	}
+
+	if len(r.problems) > 0 {
+		return nil, &ValidationError{Problems: r.problems}
+	}
+	return cfg, nil
+}
+
+func readDB(r *reader) DBConfig {
+	return DBConfig{
+		DSN:             r.required("DB_DSN"),
+		MaxOpenConns:    r.integer("DB_MAX_OPEN_CONNS", 20),
+		MaxIdleConns:    r.integer("DB_MAX_IDLE_CONNS", 10),
+		ConnMaxLifetime: r.duration("DB_CONN_MAX_LIFETIME", 30*time.Minute),
+	}
+}
+
+// readSMTP ค่า SMTP จำเป็นเฉพาะเมื่อส่งอีเมลผ่าน SMTP จริง
+func readSMTP(r *reader, required bool) SMTPConfig {
+	read := r.required
+	if !required {
+		read = func(key string) string { return r.optional(key, "") }
+	}
+	return SMTPConfig{
+		Host:     read("SMTP_HOST"),
+		Port:     read("SMTP_PORT"),
+		Username: read("SMTP_USERNAME"),
+		Password: read("SMTP_PASSWORD"),
+	}
+}
+
+type reader struct {
+	getenv   func(string) string
+	problems []string
+}
+
+func (r *reader) fail(problem string) {
+	r.problems = append(r.problems, problem)
+}
+
+func (r *reader) required(key string) string {
+	value := strings.TrimSpace(r.getenv(key))
+	if value == "" {
+		r.fail(key + " is required")
+	}
+	return value
+}
+
+func (r *reader) optional(key, fallback string) string {
+	if value := strings.TrimSpace(r.getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (r *reader) duration(key string, fallback time.Duration) time.Duration {
+	value := strings.TrimSpace(r.getenv(key))
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		r.fail(fmt.Sprintf("%s %q is not a valid duration (e.g. 15m, 168h)", key, value))
+		return fallback
+	}
+	return d
+}
+
+func (r *reader) integer(key string, fallback int) int {
+	value := strings.TrimSpace(r.getenv(key))
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		r.fail(fmt.Sprintf("%s %q is not a valid integer", key, value))
+		return fallback
+	}
+	return n
+}
+
+func (r *reader) boolean(key string, fallback bool) bool {
+	value := strings.TrimSpace(r.getenv(key))
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		r.fail(fmt.Sprintf("%s %q is not a valid boolean", key, value))
+		return fallback
+	}
+	return b
+}
+
+// addresses อ่านรายการ IP หรือ CIDR คั่นด้วย comma เช่น 10.0.0.0/8,192.168.1.10
+func (r *reader) addresses(key string) []string {
+	var out []string
+	for _, value := range strings.Split(r.getenv(key), ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if net.ParseIP(value) == nil {
+			if _, _, err := net.ParseCIDR(value); err != nil {
+				r.fail(fmt.Sprintf("%s entry %q is not an IP address or CIDR", key, value))
+				continue
+			}
+		}
+		out = append(out, value)
+	}
+	return out
+}
+
+// rate อ่านค่าแบบ 10/1m ช่วงเวลาต้องไม่เกิน 24h เพราะ backend mysql ลบแถวที่ไม่ถูกใช้นานกว่านั้น
+func (r *reader) rate(key string, fallback Rate) Rate {
+	value := strings.TrimSpace(r.getenv(key))
+	if value == "" {
+		return fallback
+	}
+	limit, per, ok := strings.Cut(value, "/")
+	n, err := strconv.Atoi(strings.TrimSpace(limit))
+	d, derr := time.ParseDuration(strings.TrimSpace(per))
+	if !ok || err != nil || derr != nil || n < 0 || d <= 0 || d > 24*time.Hour {
+		r.fail(fmt.Sprintf("%s %q is not a valid rate (e.g. 10/1m, period up to 24h)", key, value))
+		return fallback
+	}
+	return Rate{Limit: n, Per: d}
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// env ค่าที่จำเป็นทั้งหมดพร้อม overrides ส่งอีเมลผ่าน memory เพื่อไม่ต้องตั้ง SMTP
+func env(overrides map[string]string) func(string) string {
+	values := map[string]string{
+		"DB_DSN":                           "user:pass@tcp(localhost:3306)/mydayplanner",
+		"GOOGLE_APPLICATION_CREDENTIALS_1": "firebase.json",
+		"GOOGLE_APPLICATION_CREDENTIALS_2": "recaptcha.json",
+		"GOOGLE_CLOUD_PROJECT_ID":          "mydayplanner",
+		"RECAPTCHA_SITE_KEY":               "site-key",
+		"JWT_SECRET_KEY":                   "access",
+		"JWT_REFRESH_SECRET_KEY":           "refresh",
+		"TOTPsecret":                       "JBSWY3DPEHPK3PXP",
+		"MAIL_FROM":                        "noreply@mydayplanner.test",
+		"MAIL_SENDER":                      "maildir",
+	}
+	for k, v := range overrides {
+		values[k] = v
+	}
+	return func(key string) string { return values[key] }
+}
+
+func TestTrustedProxies(t *testing.T) {
+	cfg, err := FromEnv(env(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.Server.TrustedProxies) != 0 {
+		t.Fatalf("trusted proxies = %v, want none by default", cfg.Server.TrustedProxies)
+	}
+
+	cfg, err = FromEnv(env(map[string]string{"TRUSTED_PROXIES": " 10.0.0.0/8, 192.168.1.10 ,"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cfg.Server.TrustedProxies, ","); got != "10.0.0.0/8,192.168.1.10" {
+		t.Fatalf("trusted proxies = %q", got)
+	}
+
+	if _, err := FromEnv(env(map[string]string{"TRUSTED_PROXIES": "10.0.0.0/8,proxy.internal"})); err == nil || !strings.Contains(err.Error(), "TRUSTED_PROXIES") {
+		t.Fatalf("FromEnv error = %v, want a problem about TRUSTED_PROXIES", err)
+	}
+}
+
+func TestDevOnlySettings(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		env     map[string]string
+		problem string
+	}{
+		{"production by default", nil, ""},
+		{"memory sender in development", map[string]string{"APP_ENV": "development", "MAIL_SENDER": "memory"}, ""},
+		{"dev mailbox in development", map[string]string{"APP_ENV": "development", "MAIL_SENDER": "memory", "DEV_MAILBOX": "true"}, ""},
+		{"memory sender in production", map[string]string{"MAIL_SENDER": "memory"}, "MAIL_SENDER memory"},
+		{"dev mailbox in production", map[string]string{"MAIL_SENDER": "memory", "DEV_MAILBOX": "true"}, "DEV_MAILBOX"},
+		{"dev mailbox without memory sender", map[string]string{"APP_ENV": "development", "DEV_MAILBOX": "true"}, "DEV_MAILBOX"},
+		{"log push provider in development", map[string]string{"APP_ENV": "development", "PUSH_PROVIDER": "log"}, ""},
+		{"log push provider in production", map[string]string{"PUSH_PROVIDER": "log"}, "PUSH_PROVIDER log"},
+		{"unknown environment", map[string]string{"APP_ENV": "staging"}, "APP_ENV"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := FromEnv(env(tc.env))
+			if tc.problem == "" {
+				if err != nil {
+					t.Fatalf("FromEnv: %v", err)
+				}
+				if tc.env == nil && (cfg.Env != "production" || cfg.Mail.DevMailbox) {
+					t.Fatalf("defaults = %q, dev mailbox %v; want production without the mailbox", cfg.Env, cfg.Mail.DevMailbox)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.problem) {
+				t.Fatalf("FromEnv error = %v, want a problem about %s", err, tc.problem)
+			}
+		})
+	}
+}
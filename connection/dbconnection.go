@@ -2,31 +2,34 @@ package connection
 
 import (
 	"fmt"
-	"os"
+	"log/slog"
+	"mydayplanner/config"
 
-	"github.com/joho/godotenv"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 )
 
-func DBConnection() (*gorm.DB, error) {
-	// โหลด .env
-	err := godotenv.Load()
+func DBConnection(cfg config.DBConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(mysql.Open(cfg.DSN), &gorm.Config{})
 	if err != nil {
-		fmt.Println("Warning: No .env file found or failed to load") // ใช้เฉพาะตอน dev
-	}
-
-	// ดึง DSN จาก ENV
-	dsn := os.Getenv("DB_DSN")
-	if dsn == "" {
-		return nil, fmt.Errorf("environment variable DB_DSN is not set")
+		return nil, fmt.Errorf("error connecting to database: %w", err)
 	}
 
-	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	// จำกัดขนาด pool เพราะ API และ scheduler ใช้ pool เดียวกัน
+	sqlDB, err := db.DB()
 	if err != nil {
-		return nil, fmt.Errorf("error connecting to database: %w", err)
+		return nil, fmt.Errorf("error getting database handle: %w", err)
+	}
+	if cfg.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
+	}
+	if cfg.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
+	}
+	if cfg.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 	}
 
-	fmt.Println("Database connection successful")
+	slog.Info("database connection successful")
 	return db, nil
 }
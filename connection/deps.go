@@ -0,0 +1,121 @@
+package connection
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"mydayplanner/config"
+	"mydayplanner/controller/health"
+	"mydayplanner/leader"
+	"mydayplanner/mail"
+	"mydayplanner/push"
+	"mydayplanner/pushtoken"
+	"mydayplanner/ratelimit"
+	"mydayplanner/store"
+	"net/http"
+
+	"firebase.google.com/go/v4/messaging"
+	"gorm.io/gorm"
+)
+
+// Deps ทรัพยากรที่สร้างครั้งเดียวตอนเริ่ม process และใช้ร่วมกันระหว่าง API กับ scheduler
+type Deps struct {
+	Config *config.Config
+	DB     *gorm.DB
+	FB     store.Store
+	// Push ช่องทางส่ง push ตาม PUSH_PROVIDER ที่ลบ token ซึ่งใช้ไม่ได้แล้วออกจาก usersLogin
+	Push push.Provider
+	// Mail ช่องทางส่งอีเมลตาม MAIL_SENDER
+	Mail mail.Sender
+	// NotificationJob ผลการรันของ SendNotificationJob ที่ /readyz อ่าน
+	NotificationJob *health.JobTracker
+	// Leader เลือก instance ที่รัน scheduler ถ้าเป็น nil instance นี้รัน scheduler เสมอ
+	Leader *leader.Elector
+	// RateLimiter ถ้าเป็น nil จะไม่จำกัดจำนวน request
+	RateLimiter *ratelimit.Limiter
+}
+
+// NewDeps เปิด MySQL pool, Firestore client และ push provider
+func NewDeps(ctx context.Context, cfg *config.Config) (*Deps, error) {
+	db, err := DBConnection(cfg.DB)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	firestoreClient, msg, err := FBConnection(ctx, cfg.Firebase)
+	if err != nil {
+		closeDB(db)
+		return nil, fmt.Errorf("failed to initialize Firebase: %w", err)
+	}
+
+	fb := store.NewFirestore(firestoreClient)
+	return &Deps{
+		Config: cfg,
+		DB:     db,
+		FB:     fb,
+		Push:   pushtoken.New(NewPushProvider(cfg.Push, msg), db, fb, cfg.Push),
+		Mail:   NewMailSender(cfg.Mail, cfg.SMTP),
+
+		NotificationJob: health.NewJobTracker(),
+		Leader:          leader.New(db, leader.SchedulerLease, cfg.Leader),
+		RateLimiter:     NewRateLimiter(cfg.RateLimit, db),
+	}, nil
+}
+
+// NewRateLimiter สร้าง limiter ตาม config class auth และ jobs ไม่มี token จึงนับตาม IP
+func NewRateLimiter(cfg config.RateLimitConfig, db *gorm.DB) *ratelimit.Limiter {
+	var backend ratelimit.Backend = ratelimit.NewMemory()
+	if cfg.Backend == "mysql" {
+		backend = ratelimit.NewMySQL(db)
+	}
+	return ratelimit.New(backend, map[ratelimit.Class]ratelimit.Policy{
+		ratelimit.ClassAuth:   {Limit: cfg.Auth.Limit, Per: cfg.Auth.Per, KeyBy: ratelimit.ByIP},
+		ratelimit.ClassSearch: {Limit: cfg.Search.Limit, Per: cfg.Search.Per, KeyBy: ratelimit.ByUser},
+		ratelimit.ClassReport: {Limit: cfg.Report.Limit, Per: cfg.Report.Per, KeyBy: ratelimit.ByUser},
+		ratelimit.ClassJobs:   {Limit: cfg.Jobs.Limit, Per: cfg.Jobs.Per, KeyBy: ratelimit.ByIP},
+	})
+}
+
+// NewPushProvider เลือก provider ตาม config ส่วน fcm ใช้ Messaging client ของ Firebase app ที่เปิดไว้แล้ว
+func NewPushProvider(cfg config.PushConfig, msg *messaging.Client) push.Provider {
+	switch cfg.Provider {
+	case "webhook":
+		return push.NewWebhook(cfg.WebhookURL, cfg.WebhookSecret, &http.Client{Timeout: cfg.WebhookTimeout})
+	case "log":
+		return push.NewRecorder()
+	default:
+		return push.NewFCM(msg)
+	}
+}
+
+// NewMailSender เลือก Sender ตาม config
+func NewMailSender(cfg config.MailConfig, smtp config.SMTPConfig) mail.Sender {
+	switch cfg.Sender {
+	case "maildir":
+		return mail.NewMaildir(cfg.MaildirPath, cfg.From)
+	case "memory":
+		return mail.NewMemory(cfg.From)
+	default:
+		return mail.NewSMTP(smtp, cfg.From)
+	}
+}
+
+// Close ปิด Firestore ก่อนแล้วจึงปิด MySQL pool
+// เรียกหลังจาก HTTP server และ cron หยุดรับงานแล้วเท่านั้น
+func (d *Deps) Close() {
+	if err := d.FB.Close(); err != nil {
+		slog.Error("failed to close Firestore client", "error", err)
+	}
+	closeDB(d.DB)
+}
+
+func closeDB(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		slog.Error("failed to get database handle", "error", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		slog.Error("failed to close database", "error", err)
+	}
+}
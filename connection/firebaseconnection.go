@@ -3,45 +3,35 @@ package connection
 import (
 	"context"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"mydayplanner/config"
 
 	"cloud.google.com/go/firestore"
-	firebase "firebase.google.com/go"
-	"github.com/joho/godotenv"
+	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/messaging"
 	"google.golang.org/api/option"
 )
 
-var FirestoreClient *firestore.Client
-
-func FBConnection() (*firestore.Client, error) {
-	// Load .env file
-	err := godotenv.Load()
+// FBConnection สร้าง Firebase app ครั้งเดียว แล้วคืน Firestore client และ Messaging client จาก app เดียวกัน
+func FBConnection(ctx context.Context, cfg config.FirebaseConfig) (*firestore.Client, *messaging.Client, error) {
+	// Initialize Firebase app with Firestore
+	app, err := firebase.NewApp(ctx, nil, option.WithCredentialsFile(cfg.CredentialsPath))
 	if err != nil {
-		fmt.Println("Warning: No .env file found or failed to load") // Use only in dev
-	}
-
-	// Get the path to the service account key from the environment variable
-	serviceAccountKeyPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_1")
-	if serviceAccountKeyPath == "" {
-		return nil, fmt.Errorf("environment variable GOOGLE_APPLICATION_CREDENTIALS is not set")
+		return nil, nil, fmt.Errorf("error initializing app: %w", err)
 	}
 
-	ctx := context.Background()
-
-	// Initialize Firebase app with Firestore
-	app, err := firebase.NewApp(ctx, nil, option.WithCredentialsFile(serviceAccountKeyPath))
+	client, err := app.Firestore(ctx)
 	if err != nil {
-		log.Fatalf("error initializing app: %v\n", err)
-		return nil, err
+		return nil, nil, fmt.Errorf("error getting Firestore client: %w", err)
 	}
 
-	client, err := app.Firestore(ctx)
+	// Messaging client ใช้ซ้ำได้ทั้ง process ไม่ต้องสร้างใหม่ทุกครั้งที่ส่ง
+	msg, err := app.Messaging(ctx)
 	if err != nil {
-		log.Fatalf("error getting Firestore client: %v\n", err)
-		return nil, err
+		client.Close()
+		return nil, nil, fmt.Errorf("error getting Messaging client: %w", err)
 	}
 
-	fmt.Println("Firestore connection successful")
-	return client, nil
+	slog.Info("firestore connection successful")
+	return client, msg, nil
 }
@@ -1,38 +1,106 @@
 package connection
 
 import (
-	"log"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
 	"mydayplanner/controller"
 	"mydayplanner/controller/admin"
 	"mydayplanner/controller/attachments"
 	"mydayplanner/controller/auth"
 	"mydayplanner/controller/board"
 	"mydayplanner/controller/checklist"
+	"mydayplanner/controller/dev"
+	"mydayplanner/controller/device"
+	"mydayplanner/controller/health"
+	"mydayplanner/controller/inbox"
 	"mydayplanner/controller/notification"
 	"mydayplanner/controller/report"
 	"mydayplanner/controller/shareboard"
 	"mydayplanner/controller/task"
 	"mydayplanner/controller/user"
+	"mydayplanner/mail"
+	"mydayplanner/middleware"
+	"mydayplanner/openapi"
+	"net"
+	"net/http"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
-func StartServer() {
-	router := gin.Default()
-
-	DB, err := DBConnection()
+// StartServer รัน HTTP server จน ctx ถูกยกเลิก แล้วรอ request ที่ค้างอยู่ให้เสร็จ
+// การปิด DB และ Firestore เป็นหน้าที่ของผู้สร้าง deps
+func StartServer(ctx context.Context, deps *Deps) error {
+	ln, err := net.Listen("tcp", deps.Config.Server.Addr)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		return fmt.Errorf("http server listen: %w", err)
 	}
-	FB, err := FBConnection()
-	if err != nil {
-		log.Fatalf("Failed to initialize Firestore client: %v", err)
+	return Serve(ctx, deps, ln)
+}
+
+// Serve ทำงานเหมือน StartServer แต่รับ listener ที่เปิดไว้แล้ว
+// integration test ใช้เปิด server บนพอร์ตว่างของ 127.0.0.1 โดยไม่ต้องกำหนดพอร์ตตายตัว
+func Serve(ctx context.Context, deps *Deps, ln net.Listener) error {
+	cfg := deps.Config
+
+	middleware.Configure(cfg.JWT)
+	middleware.ConfigureRateLimit(deps.RateLimiter)
+	auth.Configure(cfg, deps.Mail)
+
+	router := NewRouter(deps)
+
+	srv := &http.Server{
+		Handler: router,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errCh:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down HTTP server")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("http server shutdown: %w", err)
+	}
+	slog.Info("HTTP server stopped")
+	return nil
+}
+
+// NewRouter สร้าง gin.Engine พร้อม route ทั้งหมด แยกจาก StartServer เพื่อให้ตัวสร้าง OpenAPI
+// และ test อ่าน route table ได้โดยไม่ต้องเปิด server
+func NewRouter(deps *Deps) *gin.Engine {
+	cfg, DB, FB := deps.Config, deps.DB, deps.FB
+
+	// ใช้ RequestLogger แทน logger ข้อความของ gin.Default
+	router := gin.New()
+	// gin เชื่อ X-Forwarded-For จากทุก proxy ถ้าไม่ตั้ง client จึงเปลี่ยน IP ตัวเองเพื่อเลี่ยง rate limit ได้
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		// config ตรวจรูปแบบไว้แล้ว ถ้ายังผิดให้ใช้ที่อยู่ของ connection
+		slog.Error("invalid trusted proxies, trusting none", "error", err)
+		_ = router.SetTrustedProxies(nil)
 	}
+	router.Use(gin.Recovery(), middleware.RequestLogger(), middleware.Metrics())
 
 	router.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "Api is running!"})
 	})
+	health.HealthController(router, DB, FB, deps.NotificationJob, deps.Leader, cfg.Scheduler.MaxLag)
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	openapi.OpenAPIController(router)
 
 	router.Use(cors.Default())
 
@@ -57,8 +125,10 @@ func StartServer() {
 	task.AssignedController(router, DB, FB)
 
 	notification.NotificationTaskController(router, DB, FB)
-	notification.SendNotificationTaskController(router, DB, FB)
-	notification.RemindNotificationTaskController(router, DB, FB)
+	notification.SendNotificationTaskController(router, DB, FB, deps.Push)
+	notification.RemindNotificationTaskController(router, DB, FB, deps.Push)
+	notification.DeliveryLogController(router, DB)
+	inbox.InboxController(router, DB)
 
 	checklist.CreateChecklistController(router, DB, FB)
 	checklist.UpdateChecklistController(router, DB, FB)
@@ -71,6 +141,13 @@ func StartServer() {
 
 	controller.GetemailCTL(router, DB)
 	user.UserController(router, DB, FB)
+	user.QuietHoursController(router, DB)
+	device.DeviceController(router, DB, FB)
+
+	if box, ok := deps.Mail.(*mail.Memory); ok && cfg.Mail.DevMailbox {
+		slog.Warn("dev mailbox mounted: /v1/dev/mailbox exposes every captured mail, including OTPs, without authentication")
+		dev.MailboxController(router, box)
+	}
 
-	router.Run()
+	return router
 }
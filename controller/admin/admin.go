@@ -1,46 +1,57 @@
 package admin
 
 import (
+	"errors"
+	"io"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/reconcile"
 	"mydayplanner/services"
+	"mydayplanner/store"
 	"net/http"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-func AdminController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/admin", middleware.AccessTokenMiddleware())
+func AdminController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	disable := func(c *gin.Context) { DisableUser(c, db, fb) }
+	deleteAccount := func(c *gin.Context) { DeleteUser(c, db, fb) }
+	createAdmin := func(c *gin.Context) { CreateAdmin(c, db, fb) }
+	reconcileMirror := func(c *gin.Context) { Reconcile(c, db, fb) }
+
+	routes := router.Group("/v1/admin", middleware.AccessTokenMiddleware(), middleware.AdminMiddleware())
+	{
+		routes.PUT("/users/:id/active", disable)
+		routes.PUT("/users/:id/deleted", deleteAccount)
+		routes.POST("/admins", createAdmin)
+		routes.POST("/reconcile", reconcileMirror)
+	}
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	legacy := router.Group("/admin")
 	{
-		routes.PUT("/disableactive/:id", middleware.AdminMiddleware(), func(c *gin.Context) {
-			DisableUser(c, db, firestoreClient)
-		})
-		routes.PUT("/deleteaccount/:id", middleware.AdminMiddleware(), func(c *gin.Context) {
-			DeleteUser(c, db, firestoreClient)
-		})
-		routes.POST("/createadmin", middleware.AdminMiddleware(), func(c *gin.Context) {
-			CreateAdmin(c, db, firestoreClient)
-		})
+		legacy.PUT("/disableactive/:id", middleware.Deprecated("/v1/admin/users/:id/active"), middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), disable)
+		legacy.PUT("/deleteaccount/:id", middleware.Deprecated("/v1/admin/users/:id/deleted"), middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), deleteAccount)
+		legacy.POST("/createadmin", middleware.Deprecated("/v1/admin/admins"), middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), createAdmin)
 	}
 }
 
-func DisableUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DisableUser(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.Param("id")
 	if userId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID is required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("user_id", "required"))
 		return
 	}
 	// ค้นหาผู้ใช้ในฐานข้อมูลโดยใช้ email
 	user, err := services.GetUserdata(db, userId)
 	if err != nil {
-		c.JSON(404, gin.H{
-			"error": "User not found",
-		})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
@@ -52,24 +63,18 @@ func DisableUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 	// อัปเดตสถานะในฐานข้อมูลด้วยคำสั่ง SQL เดียว
 	if err := db.Model(&user).Update("is_active", newStatus).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user status"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// อัปเดตเฉพาะฟิลด์ active ใน Firestore
-	_, err = firestoreClient.Collection("usersLogin").Doc(user.Email).Update(c, []firestore.Update{
-		{
-			Path:  "active",
-			Value: newStatus,
-		},
-		{
-			Path:  "updatedAt",
-			Value: time.Now(),
-		},
+	err = fb.Logins().Update(c, user.Email, store.Fields{
+		"active":    newStatus,
+		"updatedAt": time.Now(),
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Firestore document"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -85,10 +90,10 @@ func DisableUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	})
 }
 
-func CreateAdmin(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func CreateAdmin(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var req dto.AdminRequest
 	if err := c.ShouldBindJSON(&req); err != nil || req.Email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request or email is required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("email", "required"))
 		return
 	}
 
@@ -97,18 +102,18 @@ func CreateAdmin(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	result := db.Where("email = ?", req.Email).First(&existingUser)
 	if result.Error == nil {
 		// พบผู้ใช้ในระบบแล้ว
-		c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
+		apperror.Respond(c, apperror.EmailExists)
 		return
 	} else if result.Error != gorm.ErrRecordNotFound {
 		// เกิดข้อผิดพลาดในการค้นหา
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
 	// แฮชรหัสผ่านโดยใช้ bcrypt
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.HashedPassword), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -127,7 +132,7 @@ func CreateAdmin(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 	// บันทึกข้อมูลผู้ใช้
 	if err := db.Create(&newUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -138,17 +143,17 @@ func CreateAdmin(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	})
 }
 
-func DeleteUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteUser(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.Param("id")
 	if userId == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID is required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("user_id", "required"))
 		return
 	}
 	// ค้นหาผู้ใช้ในฐานข้อมูลโดยใช้ email
 	var user model.User
 	result := db.First(&user, userId)
 	if result.Error != nil {
-		c.JSON(500, gin.H{"error": "Database error"})
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	}
 
@@ -160,20 +165,17 @@ func DeleteUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 	// อัปเดตสถานะในฐานข้อมูลด้วยคำสั่ง SQL เดียว
 	if err := db.Model(&user).Update("is_active", newStatus).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user status"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// อัปเดตเฉพาะฟิลด์ active ใน Firestore
-	_, err := firestoreClient.Collection("usersLogin").Doc(user.Email).Update(c, []firestore.Update{
-		{
-			Path:  "active",
-			Value: newStatus,
-		},
+	err := fb.Logins().Update(c, user.Email, store.Fields{
+		"active": newStatus,
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Firestore document"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -188,3 +190,33 @@ func DeleteUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		"status":  newStatus,
 	})
 }
+
+// Reconcile ตรวจ Firestore mirror เทียบกับ MySQL แล้วคืนรายงาน apply=true จะซ่อมผ่าน outbox ด้วย
+// body ว่างหมายถึงตรวจทุกบอร์ดกลุ่มและทุกผู้ใช้แบบ dry-run
+func Reconcile(c *gin.Context, db *gorm.DB, fb store.Store) {
+	var req dto.ReconcileRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+	if req.BoardID != 0 && req.UserID != 0 {
+		apperror.Respond(c, apperror.InvalidInput.WithField("board_id", "excluded_with=user_id"))
+		return
+	}
+
+	report, err := reconcile.Run(c.Request.Context(), db, fb, reconcile.Options{
+		BoardID: req.BoardID,
+		UserID:  req.UserID,
+		Apply:   req.Apply,
+	})
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	logging.FromContext(c).Info("reconcile finished",
+		"dry_run", report.DryRun,
+		"drifts", len(report.Drifts),
+		"repaired", report.Repaired,
+	)
+	c.JSON(http.StatusOK, report)
+}
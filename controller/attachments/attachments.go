@@ -4,43 +4,51 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/outbox"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func AttachmentsController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/attachment", middleware.AccessTokenMiddleware())
+func AttachmentsController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	create := func(c *gin.Context) { CreateAttachment(c, db, fb) }
+	del := func(c *gin.Context) { DeleteAttachment(c, db, fb) }
+
+	routes := router.Group("/v1/tasks/:taskid/attachments", middleware.AccessTokenMiddleware())
 	{
-		routes.POST("/create/:taskid", func(c *gin.Context) {
-			CreateAttachment(c, db, firestoreClient)
-		})
-		routes.DELETE("/delete/:taskid/:attachmentid", func(c *gin.Context) {
-			DeleteAttachment(c, db, firestoreClient)
-		})
+		routes.POST("", create)
+		routes.DELETE("/:attachmentid", del)
+	}
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	legacy := router.Group("/attachment")
+	{
+		legacy.POST("/create/:taskid", middleware.Deprecated("/v1/tasks/:taskid/attachments"), middleware.AccessTokenMiddleware(), create)
+		legacy.DELETE("/delete/:taskid/:attachmentid", middleware.Deprecated("/v1/tasks/:taskid/attachments/:attachmentid"), middleware.AccessTokenMiddleware(), del)
 	}
 }
 
-func CreateAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func CreateAttachment(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	taskIDStr := c.Param("taskid")
 
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	var req dto.CreateAttachmentsTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -55,9 +63,9 @@ func CreateAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		Where("task_id = ?", taskID).
 		First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -80,16 +88,16 @@ func CreateAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 				var board model.Board
 				if err := db.Where("board_id = ? AND create_by = ?", task.BoardID, userID).First(&board).Error; err != nil {
 					if errors.Is(err, gorm.ErrRecordNotFound) {
-						c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: not a board member or owner"})
+						apperror.Respond(c, apperror.NotBoardMember)
 					} else {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board ownership"})
+						apperror.Respond(c, apperror.Internal)
 					}
 					return
 				}
 				hasPermission = true
 				shouldSaveToFirestore = false
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch board user"})
+				apperror.Respond(c, apperror.Internal)
 				return
 			}
 		} else {
@@ -100,11 +108,11 @@ func CreateAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	}
 
 	if !hasPermission {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		apperror.Respond(c, apperror.Forbidden)
 		return
 	}
 
-	// สร้าง record ใน database
+	// สร้าง record ใน database และบันทึกการเขียน Firestore ลง outbox ใน transaction เดียวกัน
 	var attachment model.Attachment
 	err = db.Transaction(func(tx *gorm.DB) error {
 		attachment = model.Attachment{
@@ -119,21 +127,12 @@ func CreateAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 			return err
 		}
 
-		return nil
-	})
-
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create attachment"})
-		return
-	}
-
-	// บันทึกลง Firestore ถ้าเป็น board member
-	if shouldSaveToFirestore {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
+		// บันทึกลง Firestore ถ้าเป็น board member
+		if !shouldSaveToFirestore {
+			return nil
+		}
 		docID := strconv.Itoa(int(attachment.AttachmentID))
-		_, err := firestoreClient.Collection("BoardTasks").Doc(taskIDStr).Collection("Attachments").Doc(docID).Set(ctx, map[string]interface{}{
+		return outbox.Writer(tx).BoardTasks().SetItem(context.Background(), taskID, store.TaskAttachments, docID, store.Fields{
 			"attachment_id": attachment.AttachmentID,
 			"tasks_id":      attachment.TasksID,
 			"file_name":     attachment.FileName,
@@ -142,10 +141,11 @@ func CreateAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 			"upload_at":     attachment.UploadAt,
 			"update_at":     time.Now(),
 		})
+	})
 
-		if err != nil {
-			fmt.Printf("⚠️ Failed to save attachment %s to Firestore: %v\n", docID, err)
-		}
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -162,7 +162,7 @@ func CreateAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	})
 }
 
-func DeleteAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteAttachment(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	taskIDStr := c.Param("taskid")
 	attachmentIDStr := c.Param("attachmentid")
@@ -170,14 +170,14 @@ func DeleteAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	// แปลง taskID เป็น int
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	// แปลง attachmentID เป็น int
 	attachmentIDInt, err := strconv.Atoi(attachmentIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid attachment ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("attachment_id", "invalid"))
 		return
 	}
 
@@ -192,9 +192,9 @@ func DeleteAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		Where("attachment_id = ? AND tasks_id = ?", attachmentIDInt, taskID).
 		First(&existingAttachment).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Attachment not found or not belong to specified task"})
+			apperror.Respond(c, apperror.AttachmentNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch attachment"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -211,9 +211,9 @@ func DeleteAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		Where("task_id = ?", taskID).
 		First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -237,16 +237,16 @@ func DeleteAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 				var board model.Board
 				if err := db.Where("board_id = ? AND create_by = ?", task.BoardID, userID).First(&board).Error; err != nil {
 					if errors.Is(err, gorm.ErrRecordNotFound) {
-						c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: not a board member or board owner"})
+						apperror.Respond(c, apperror.NotBoardMember)
 					} else {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board ownership"})
+						apperror.Respond(c, apperror.Internal)
 					}
 					return
 				}
 				hasPermission = true
 				shouldDeleteFromFirestore = false
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch board user"})
+				apperror.Respond(c, apperror.Internal)
 				return
 			}
 		} else {
@@ -256,11 +256,11 @@ func DeleteAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	}
 
 	if !hasPermission {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		apperror.Respond(c, apperror.Forbidden)
 		return
 	}
 
-	// ลบ attachment ด้วย Transaction
+	// ลบ attachment ด้วย Transaction และลบจาก Firestore ผ่าน outbox ถ้าเป็นสมาชิกบอร์ด
 	err = db.Transaction(func(tx *gorm.DB) error {
 		result := tx.Where("attachment_id = ?", attachmentIDInt).Delete(&model.Attachment{})
 		if result.Error != nil {
@@ -269,28 +269,17 @@ func DeleteAttachment(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		if result.RowsAffected == 0 {
 			return fmt.Errorf("attachment not found or already deleted")
 		}
-		return nil
+		if !shouldDeleteFromFirestore {
+			return nil
+		}
+		return outbox.Writer(tx).BoardTasks().DeleteItem(context.Background(), taskID, store.TaskAttachments, strconv.Itoa(attachmentIDInt))
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete attachment"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
-	// ✅ ลบจาก Firestore ถ้าเป็นสมาชิกบอร์ด
-	if shouldDeleteFromFirestore {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		_, err := firestoreClient.Collection("BoardTasks").Doc(taskIDStr).Collection("Attachments").
-			Doc(strconv.Itoa(attachmentIDInt)).
-			Delete(ctx)
-
-		if err != nil {
-			fmt.Printf("⚠️ Failed to delete attachment from Firestore: %v\n", err)
-		}
-	}
-
 	// ตอบกลับ
 	c.JSON(http.StatusOK, gin.H{
 		"message":       "Attachment deleted successfully",
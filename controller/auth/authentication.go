@@ -4,58 +4,80 @@ import (
 	"context"
 	"crypto/sha256"
 	"errors"
-	"log"
+	"mydayplanner/apperror"
+	"mydayplanner/config"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
+	"mydayplanner/mail"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/ratelimit"
+	"mydayplanner/store"
 	"net"
 	"net/http"
-	"os"
 	"regexp"
-	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-func AuthController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/auth")
+var (
+	appConfig *config.Config
+	mailer    mail.Sender
+)
+
+// LookupMX ใช้ตรวจโดเมนของอีเมลตอนสมัครสมาชิก integration test แทนที่ได้เพราะรันโดยไม่มีเครือข่าย
+var LookupMX = net.LookupMX
+
+// Configure ตั้งค่า JWT, TOTP และ reCAPTCHA ของแพ็กเกจ auth และช่องทางส่งอีเมล OTP ต้องเรียกก่อนลงทะเบียน route
+func Configure(cfg *config.Config, sender mail.Sender) {
+	appConfig = cfg
+	mailer = sender
+}
+
+func AuthController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	signin := func(c *gin.Context) { Signin(c, db, fb) }
+	signup := func(c *gin.Context) { Signup(c, db, fb) }
+	signout := func(c *gin.Context) { Signout(c, db, fb) }
+	newAccessToken := func(c *gin.Context) { NewAccessToken(c, db, fb) }
+	google := func(c *gin.Context) { GoogleSignIn(c, db, fb) }
+	resetPassword := func(c *gin.Context) { ResetPassword(c, db, fb) }
+
+	routes := router.Group("/v1/auth")
 	{
-		routes.POST("/signin", func(c *gin.Context) {
-			Signin(c, db, firestoreClient)
-		})
-		routes.POST("/signup", func(c *gin.Context) {
-			Signup(c, db, firestoreClient)
-		})
-		routes.POST("/signout", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-			Signout(c, db, firestoreClient)
-		})
-		routes.POST("/newaccesstoken", middleware.RefreshTokenMiddleware(), func(c *gin.Context) {
-			NewAccessToken(c, db, firestoreClient)
-		})
-		routes.POST("/googlelogin", func(c *gin.Context) {
-			GoogleSignIn(c, db, firestoreClient)
-		})
-		routes.PUT("/resetpassword", func(c *gin.Context) {
-			ResetPassword(c, db, firestoreClient)
-		})
+		routes.POST("/signin", middleware.RateLimit(ratelimit.ClassAuth), signin)
+		routes.POST("/signup", middleware.RateLimit(ratelimit.ClassAuth), signup)
+		routes.POST("/signout", middleware.AccessTokenMiddleware(), signout)
+		routes.POST("/token", middleware.RefreshTokenMiddleware(), newAccessToken)
+		routes.POST("/google", middleware.RateLimit(ratelimit.ClassAuth), google)
+		routes.PUT("/password", middleware.RateLimit(ratelimit.ClassAuth), resetPassword)
+	}
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	legacy := router.Group("/auth")
+	{
+		legacy.POST("/signin", middleware.Deprecated("/v1/auth/signin"), middleware.RateLimit(ratelimit.ClassAuth), signin)
+		legacy.POST("/signup", middleware.Deprecated("/v1/auth/signup"), middleware.RateLimit(ratelimit.ClassAuth), signup)
+		legacy.POST("/signout", middleware.Deprecated("/v1/auth/signout"), middleware.AccessTokenMiddleware(), signout)
+		legacy.POST("/newaccesstoken", middleware.Deprecated("/v1/auth/token"), middleware.RefreshTokenMiddleware(), newAccessToken)
+		legacy.POST("/googlelogin", middleware.Deprecated("/v1/auth/google"), middleware.RateLimit(ratelimit.ClassAuth), google)
+		legacy.PUT("/resetpassword", middleware.Deprecated("/v1/auth/password"), middleware.RateLimit(ratelimit.ClassAuth), resetPassword)
 	}
 }
 
 func CreateAccessToken(userID uint, role string) (string, error) {
-	hmacSampleSecret := []byte(os.Getenv("JWT_SECRET_KEY"))
+	hmacSampleSecret := []byte(appConfig.JWT.AccessSecret)
 	claims := &model.AccessClaims{
 		UserID: userID,
 		Role:   role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "mydayplanner",
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(60 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(appConfig.JWT.AccessTTL)),
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -63,13 +85,13 @@ func CreateAccessToken(userID uint, role string) (string, error) {
 }
 
 func CreateRefreshToken(userID uint) (string, error) {
-	refreshTokenSecret := []byte(os.Getenv("JWT_REFRESH_SECRET_KEY"))
+	refreshTokenSecret := []byte(appConfig.JWT.RefreshSecret)
 	claims := &model.AccessRefresh{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			Issuer:    "mydayplanner",
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)), // Longer-lived token (7 days)
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(appConfig.JWT.RefreshTTL)), // Longer-lived token (ค่าเริ่มต้น 7 วัน)
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -102,7 +124,7 @@ func isValidEmail(email string) error {
 	domain := parts[1]
 
 	// Check for MX records
-	mxRecords, err := net.LookupMX(domain)
+	mxRecords, err := LookupMX(domain)
 	if err != nil || len(mxRecords) == 0 {
 		return errors.New("email domain does not have valid MX records")
 	}
@@ -110,16 +132,16 @@ func isValidEmail(email string) error {
 	return nil
 }
 
-func Signin(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func Signin(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var request dto.SigninRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// ตรวจสอบข้อมูลที่จำเป็น
 	if request.Email == "" || request.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email and password are required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("email", "required").WithField("password", "required"))
 		return
 	}
 
@@ -127,58 +149,58 @@ func Signin(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	var user model.User
 	if err := db.Where("email = ?", request.Email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
 
 	// ตรวจสอบรหัสผ่าน
 	if err := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(request.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid password"})
+		apperror.Respond(c, apperror.InvalidCredentials)
 		return
 	}
 
 	// ตรวจสอบสถานะบัญชีผู้ใช้
 	switch user.IsActive {
 	case "0":
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User account is not active", "status": "0"})
+		apperror.Respond(c, apperror.AccountInactive)
 		return
 	case "2":
-		c.JSON(http.StatusBadRequest, gin.H{"error": "User account is deleted", "status": "2"})
+		apperror.Respond(c, apperror.AccountDeleted)
 		return
 	}
 
 	// ตรวจสอบการยืนยันบัญชี
 	if user.IsVerify != "1" {
-		c.JSON(http.StatusForbidden, gin.H{"error": "User account is not verified", "role": user.Role})
+		apperror.Respond(c, apperror.AccountNotVerified)
 		return
 	}
 
 	// สร้าง tokens
 	accessToken, err := CreateAccessToken(uint(user.UserID), user.Role)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create access token"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	refreshToken, err := CreateRefreshToken(uint(user.UserID))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create refresh token"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// แฮช refresh token
 	hashedRefreshToken, err := HashRefreshToken(refreshToken)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash refresh token"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// กำหนดค่าเวลาสำหรับ token
 	now := time.Now()
-	expiresAt := now.Add(7 * 24 * time.Hour).Unix()
+	expiresAt := now.Add(appConfig.JWT.RefreshTTL).Unix()
 	issuedAt := now.Unix()
 
 	// สร้างข้อมูล refresh token
@@ -191,9 +213,8 @@ func Signin(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	}
 
 	// บันทึก refresh token ใน Firestore
-	userIDStr := strconv.Itoa(user.UserID)
-	if _, err := firestoreClient.Collection("refreshTokens").Doc(userIDStr).Set(c, refreshTokenData); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store refresh token"})
+	if err := fb.RefreshTokens().Save(c, user.UserID, refreshTokenData); err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -214,8 +235,8 @@ func Signin(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	}
 
 	// บันทึกข้อมูลการเข้าสู่ระบบใน Firestore
-	if _, err := firestoreClient.Collection("usersLogin").Doc(request.Email).Set(c, loginData, firestore.MergeAll); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update login status"})
+	if err := fb.Logins().Merge(c, request.Email, loginData); err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -229,25 +250,25 @@ func Signin(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	})
 }
 
-func Signup(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func Signup(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var request dto.SignupRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 	if err := isValidEmail(request.Email); err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
+		apperror.Respond(c, apperror.InvalidInput.Wrap(err).WithField("email", "email"))
 		return
 	}
 	var user model.User
 	result := db.Where("email = ?", request.Email).First(&user)
 	if result.Error == nil {
-		c.JSON(400, gin.H{"error": "Email already exists"})
+		apperror.Respond(c, apperror.EmailExists)
 		return
 	}
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to hash password"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -263,7 +284,7 @@ func Signup(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 
 	result = db.Create(&userData)
 	if result.Error != nil {
-		c.JSON(500, gin.H{"error": "Failed to create user"})
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	}
 	c.JSON(200, gin.H{
@@ -276,22 +297,21 @@ func Signup(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	})
 }
 
-func Signout(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func Signout(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 	var user model.User
 	result := db.First(&user, userId)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(404, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 			return
 		}
-		c.JSON(500, gin.H{"error": "Database error"})
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	}
-	docRef := firestoreClient.Collection("refreshTokens").Doc(strconv.Itoa(int(userId)))
-	_, err := docRef.Delete(c)
+	err := fb.RefreshTokens().Delete(c, int(userId))
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to delete refresh token"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	// อัปเดตข้อมูลการเข้าสู่ระบบใน Firestore ให้ login = 0
@@ -299,42 +319,36 @@ func Signout(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		"login": 0,
 	}
 
-	if _, err := firestoreClient.Collection("usersLogin").Doc(user.Email).Set(c, loginData, firestore.MergeAll); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to update login status"})
+	if err := fb.Logins().Merge(c, user.Email, loginData); err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	c.JSON(200, gin.H{"message": "Signout successfully"})
 }
 
-func NewAccessToken(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func NewAccessToken(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userID").(uint)
 	refreshToken := c.MustGet("refreshToken").(string)
-	docRef := firestoreClient.Collection("refreshTokens").Doc(strconv.Itoa(int(userId)))
-	docSnap, err := docRef.Get(c)
+	tokenData, err := fb.RefreshTokens().Get(c, int(userId))
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get refresh token from database"})
-		return
-	}
-	var tokenData model.TokenResponse
-	if err := docSnap.DataTo(&tokenData); err != nil {
-		c.JSON(500, gin.H{"error": "Failed to parse token data"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	// ตรวจสอบว่า token ถูก revoke หรือไม่
 	if tokenData.Revoked {
-		c.JSON(403, gin.H{"error": "Token has been revoked"})
+		apperror.Respond(c, apperror.RefreshTokenRevoked)
 		return
 	}
 
 	// ตรวจสอบว่า token หมดอายุหรือไม่ (เช็คอีกครั้งจากฐานข้อมูล)
 	if tokenData.CreatedAt+tokenData.ExpiresIn < time.Now().Unix() {
-		c.JSON(401, gin.H{"error": "Token has expired"})
+		apperror.Respond(c, apperror.RefreshTokenExpired)
 		return
 	}
 	// ตรวจสอบ token ที่ส่งมากับ hash ที่เก็บไว้
 	hash := sha256.Sum256([]byte(refreshToken))
 	if err := bcrypt.CompareHashAndPassword([]byte(tokenData.RefreshToken), hash[:]); err != nil {
-		c.JSON(401, gin.H{"error": "Invalid refresh token"})
+		apperror.Respond(c, apperror.RefreshTokenInvalid)
 		return
 	}
 
@@ -343,50 +357,41 @@ func NewAccessToken(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clie
 	result := db.First(&user, userId)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(404, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 			return
 		}
-		c.JSON(500, gin.H{"error": "Database error"})
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	}
 
 	// สร้าง access token ใหม่
 	newAccessToken, err := CreateAccessToken(uint(user.UserID), user.Role)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to create new access token"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	c.JSON(200, gin.H{"accessToken": newAccessToken})
 }
 
-func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func GoogleSignIn(c *gin.Context, db *gorm.DB, fb store.Store) {
 	// รับและตรวจสอบข้อมูลจาก Request
 	var req dto.GoogleSignInRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "กรุณาระบุข้อมูลให้ครบถ้วนและถูกต้อง",
-		})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// ตรวจสอบข้อมูลที่จำเป็น
 	if req.Email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"message": "กรุณาระบุอีเมล",
-		})
+		apperror.Respond(c, apperror.InvalidInput.WithField("email", "required"))
 		return
 	}
 
 	// เริ่ม transaction
 	tx := db.Begin()
 	if tx.Error != nil {
-		log.Printf("Error starting transaction: %v", tx.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "เกิดข้อผิดพลาดในระบบ โปรดลองใหม่อีกครั้ง",
-		})
+		logging.FromContext(c).Error("failed to start transaction", "error", tx.Error)
+		apperror.Respond(c, apperror.Internal.Wrap(tx.Error))
 		return
 	}
 
@@ -417,11 +422,8 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 
 		if err := tx.Create(&newUser).Error; err != nil {
 			tx.Rollback()
-			log.Printf("Failed to create user: %v", err)
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"success": false,
-				"message": "ไม่สามารถลงทะเบียนผู้ใช้ได้",
-			})
+			logging.FromContext(c).Error("failed to create user", "error", err)
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
@@ -430,11 +432,8 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	} else if result.Error != nil {
 		// กรณีเกิดข้อผิดพลาดอื่นๆ
 		tx.Rollback()
-		log.Printf("Database error: %v", result.Error)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "เกิดข้อผิดพลาดในการตรวจสอบข้อมูล",
-		})
+		logging.FromContext(c).Error("failed to query user", "error", result.Error)
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	} else {
 		// กรณีพบผู้ใช้ในระบบ
@@ -445,12 +444,9 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 				Update("is_verify", isVerify).Error; err != nil {
 
 				tx.Rollback()
-				log.Printf("Failed to update is_verify: %v", err)
+				logging.FromContext(c).Error("failed to update is_verify", "error", err)
 
-				c.JSON(http.StatusInternalServerError, gin.H{
-					"success": false,
-					"message": "เกิดข้อผิดพลาดในการอัปเดตสถานะยืนยันบัญชี",
-				})
+				apperror.Respond(c, apperror.Internal)
 				return
 			}
 
@@ -461,12 +457,9 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 					Update("hashed_password", "-").Error; err != nil {
 
 					tx.Rollback()
-					log.Printf("Failed to update hashed_password for admin: %v", err)
+					logging.FromContext(c).Error("failed to update hashed_password for admin", "error", err)
 
-					c.JSON(http.StatusInternalServerError, gin.H{
-						"success": false,
-						"message": "เกิดข้อผิดพลาดในการอัปเดตรหัสผ่านสำหรับแอดมิน",
-					})
+					apperror.Respond(c, apperror.Internal)
 					return
 				}
 			}
@@ -477,19 +470,11 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 		switch user.IsActive {
 		case "0":
 			tx.Rollback()
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "user account is not active",
-				"status":  "0",
-			})
+			apperror.Respond(c, apperror.AccountInactive)
 			return
 		case "2":
 			tx.Rollback()
-			c.JSON(http.StatusForbidden, gin.H{
-				"success": false,
-				"message": "user account is deleted",
-				"status":  "2",
-			})
+			apperror.Respond(c, apperror.AccountDeleted)
 			return
 		}
 
@@ -503,22 +488,16 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	accessToken, err := CreateAccessToken(userID, role)
 	if err != nil {
 		tx.Rollback()
-		log.Printf("Failed to create access token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "ไม่สามารถสร้างโทเค็นได้",
-		})
+		logging.FromContext(c).Error("failed to create access token", "error", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	refreshToken, err := CreateRefreshToken(userID)
 	if err != nil {
 		tx.Rollback()
-		log.Printf("Failed to create refresh token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "ไม่สามารถสร้างโทเค็นได้",
-		})
+		logging.FromContext(c).Error("failed to create refresh token", "error", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -526,16 +505,13 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	hashedRefreshToken, err := HashRefreshToken(refreshToken)
 	if err != nil {
 		tx.Rollback()
-		log.Printf("Failed to hash refresh token: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "เกิดข้อผิดพลาดในการประมวลผลโทเค็น",
-		})
+		logging.FromContext(c).Error("failed to hash refresh token", "error", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// กำหนดค่าเวลาสำหรับ token (7 วัน)
-	expiresAt := now.Add(7 * 24 * time.Hour).Unix()
+	expiresAt := now.Add(appConfig.JWT.RefreshTTL).Unix()
 	issuedAt := now.Unix()
 
 	// สร้างข้อมูล refresh token
@@ -549,25 +525,18 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 
 	// Commit transaction database
 	if err := tx.Commit().Error; err != nil {
-		log.Printf("Failed to commit transaction: %v", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "เกิดข้อผิดพลาดในการบันทึกข้อมูล",
-		})
+		logging.FromContext(c).Error("failed to commit transaction", "error", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	// บันทึกข้อมูลการเข้าสู่ระบบใน Firestore
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	// บันทึก refresh token ใน Firestore
-	userIDStr := strconv.Itoa(int(userID))
-	if _, err := firestoreClient.Collection("refreshTokens").Doc(userIDStr).Set(ctx, refreshTokenData); err != nil {
-		log.Printf("Failed to store refresh token in Firestore: %v", err)
+	if err := fb.RefreshTokens().Save(ctx, int(userID), refreshTokenData); err != nil {
+		logging.FromContext(c).Error("failed to store refresh token in Firestore", "error", err)
 		// ไม่ต้อง rollback เพราะ transaction ได้ commit ไปแล้ว
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"success": false,
-			"message": "เกิดข้อผิดพลาดในการบันทึกข้อมูลการเข้าสู่ระบบ",
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -585,8 +554,8 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if _, err := firestoreClient.Collection("usersLogin").Doc(req.Email).Set(ctx, firebaseData, firestore.MergeAll); err != nil {
-		log.Printf("Failed to update Firebase user data: %v", err)
+	if err := fb.Logins().Merge(ctx, req.Email, firebaseData); err != nil {
+		logging.FromContext(c).Error("failed to update Firestore login data", "error", err)
 		// ไม่ต้องส่งข้อผิดพลาดกลับไปยังผู้ใช้ เนื่องจากสามารถเข้าสู่ระบบได้แล้ว
 		// การอัปเดตข้อมูลนี้เป็นเพียงข้อมูลเสริม
 	}
@@ -623,10 +592,10 @@ func GoogleSignIn(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	})
 }
 
-func ResetPassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func ResetPassword(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var resetPassword dto.ResetPasswordRequest
 	if err := c.ShouldBindJSON(&resetPassword); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -634,21 +603,21 @@ func ResetPassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	result := db.Where("email = ?", resetPassword.Email).First(&user)
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+			apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		}
 		return
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(resetPassword.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	if err := db.Model(&user).Update("hashed_password", hashedPassword).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -3,11 +3,12 @@ package auth
 import (
 	"context"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
-	"os"
-	"strings"
+	"mydayplanner/logging"
+	"mydayplanner/middleware"
+	"mydayplanner/store"
 
-	"cloud.google.com/go/firestore"
 	recaptcha "cloud.google.com/go/recaptchaenterprise/v2/apiv1"
 	"cloud.google.com/go/recaptchaenterprise/v2/apiv1/recaptchaenterprisepb"
 	"github.com/gin-gonic/gin"
@@ -24,60 +25,45 @@ type ResponseData struct {
 	Message string   `json:"message,omitempty"`
 }
 
-func CaptchaController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/auth")
-	{
-		routes.POST("/captcha", func(c *gin.Context) {
-			VerifyCaptcha(c, db, firestoreClient)
-		})
-	}
+func CaptchaController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	verify := func(c *gin.Context) { VerifyCaptcha(c, db, fb) }
+	router.POST("/v1/auth/captcha", verify)
+	router.POST("/auth/captcha", middleware.Deprecated("/v1/auth/captcha"), verify)
 }
 
-func VerifyCaptcha(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func VerifyCaptcha(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var req dto.CaptchaRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{
-			"success": false,
-			"message": "Invalid request format",
-		})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// ตรวจสอบข้อมูลที่จำเป็น
 	if req.Token == "" {
-		c.JSON(400, gin.H{
-			"success": false,
-			"message": "Token is required",
-		})
+		apperror.Respond(c, apperror.InvalidInput.WithField("token", "required"))
 		return
 	}
 
 	// ดึง IP address ของผู้ใช้แบบมีประสิทธิภาพ
-	userIPAddress := getClientIP(c)
+	userIPAddress := middleware.ClientIP(c)
 	userAgent := c.Request.UserAgent()
 
-	// ดึงค่า env แบบรวมครั้งเดียว
-	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT_ID")
-	recaptchaKey := os.Getenv("RECAPTCHA_SITE_KEY")
-	credentialsPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_2")
+	// ค่า reCAPTCHA จาก config ที่โหลดตอนเริ่ม process
+	projectID := appConfig.Recaptcha.ProjectID
+	recaptchaKey := appConfig.Recaptcha.SiteKey
+	credentialsPath := appConfig.Recaptcha.CredentialsPath
 
 	// เรียกใช้ createAssessment เพื่อตรวจสอบ reCAPTCHA
 	result, err := createAssessment(c.Request.Context(), projectID, recaptchaKey, credentialsPath, req.Token, req.Action, userIPAddress, userAgent)
 
 	if err != nil {
-		fmt.Printf("❌ Error verifying reCAPTCHA: %v\n", err)
-		c.JSON(500, gin.H{
-			"success": false,
-			"message": "Internal server error",
-		})
+		logging.FromContext(c).Error("failed to verify reCAPTCHA", "error", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	if result == nil {
-		c.JSON(400, gin.H{
-			"success": false,
-			"message": "reCAPTCHA verification failed",
-		})
+		apperror.Respond(c, apperror.CaptchaFailed)
 		return
 	}
 
@@ -91,24 +77,11 @@ func VerifyCaptcha(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	})
 }
 
-// getClientIP ฟังก์ชั่นแยกออกมาเพื่อดึง IP แบบมีประสิทธิภาพ
-func getClientIP(c *gin.Context) string {
-	userIPAddress := c.ClientIP()
-	if userIPAddress == "" {
-		userIPAddress = c.Request.RemoteAddr
-	}
-	// ถ้ามีหลาย IP ให้ใช้ตัวแรก
-	if idx := strings.Index(userIPAddress, ","); idx != -1 {
-		userIPAddress = strings.TrimSpace(userIPAddress[:idx])
-	}
-	return userIPAddress
-}
-
 func createAssessment(ctx context.Context, projectID, recaptchaKey, credentialsPath, token, action, userIPAddress, userAgent string) (*dto.AssessmentResult, error) {
 	// สร้าง reCAPTCHA client โดยระบุไฟล์ credentials
 	client, err := recaptcha.NewClient(ctx, option.WithCredentialsFile(credentialsPath))
 	if err != nil {
-		fmt.Printf("❌ Error creating reCAPTCHA client: %v\n", err)
+		logging.FromContext(ctx).Error("failed to create reCAPTCHA client", "error", err)
 		return nil, err
 	}
 	defer client.Close()
@@ -130,24 +103,23 @@ func createAssessment(ctx context.Context, projectID, recaptchaKey, credentialsP
 	// เรียก API
 	response, err := client.CreateAssessment(ctx, req)
 	if err != nil {
-		fmt.Printf("❌ Error in createAssessment: %v\n", err)
+		logging.FromContext(ctx).Error("reCAPTCHA createAssessment failed", "error", err)
 		return nil, err
 	}
 
 	// ตรวจสอบความถูกต้องของ token
 	if response.TokenProperties == nil || !response.TokenProperties.Valid {
 		if response.TokenProperties != nil {
-			fmt.Printf("❌ Token invalid: %s\n", response.TokenProperties.InvalidReason)
+			logging.FromContext(ctx).Warn("reCAPTCHA token invalid", "reason", response.TokenProperties.InvalidReason.String())
 		} else {
-			fmt.Println("❌ Token properties are null or undefined.")
+			logging.FromContext(ctx).Warn("reCAPTCHA token properties are missing")
 		}
 		return nil, nil
 	}
 
 	// ตรวจสอบ action ถ้ามีการระบุ
 	if action != "" && response.TokenProperties.Action != action {
-		fmt.Printf("⚠️ Action mismatch: expected %s, but got %s\n",
-			action, response.TokenProperties.Action)
+		logging.FromContext(ctx).Warn("reCAPTCHA action mismatch", "expected", action, "actual", response.TokenProperties.Action)
 		return nil, nil
 	}
 
@@ -2,26 +2,24 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"math/rand"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
+	"mydayplanner/mail"
+	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
-	"net/smtp"
-	"os"
-	"strconv"
 	"strings"
 	"time"
 
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	"github.com/pquerna/otp"
 	"github.com/pquerna/otp/totp"
-	"google.golang.org/api/iterator"
 	"gorm.io/gorm"
 )
 
@@ -35,59 +33,47 @@ type OTPRecord struct {
 	// ลบ OTP string `firestore:"otp"` ออก เพราะไม่ต้องเก็บ OTP code จริงใน TOTP
 }
 
-func OTPController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/auth")
-	{
-		routes.POST("/IdentityOTP", func(c *gin.Context) {
-			IdentityOTP(c, db, firestoreClient)
-		})
-		routes.POST("/resetpasswordOTP", func(c *gin.Context) {
-			ResetpasswordOTP(c, db, firestoreClient)
-		})
-		routes.POST("/sendemail", func(c *gin.Context) {
-			Sendemail(c, db, firestoreClient)
-		})
-		routes.POST("/resendotp", func(c *gin.Context) {
-			ResendOTP(c, db, firestoreClient)
-		})
-		routes.PUT("/verifyOTP", func(c *gin.Context) {
-			VerifyOTP(c, db, firestoreClient)
-		})
-	}
-}
-
-func LoadEmailConfig() (*model.EmailConfig, error) {
-	// โหลด .env เฉพาะตอนรัน local (เมื่อ ENV "RENDER" ว่าง)
-	if os.Getenv("RENDER") == "" {
-		if err := godotenv.Load(); err != nil {
-			fmt.Println("Warning: .env file not loaded, fallback to OS env vars")
-		}
-	}
+func OTPController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	identity := func(c *gin.Context) { IdentityOTP(c, db, fb) }
+	passwordReset := func(c *gin.Context) { ResetpasswordOTP(c, db, fb) }
+	email := func(c *gin.Context) { Sendemail(c, db, fb) }
+	resend := func(c *gin.Context) { ResendOTP(c, db, fb) }
+	verify := func(c *gin.Context) { VerifyOTP(c, db, fb) }
 
-	config := &model.EmailConfig{
-		Host:     os.Getenv("SMTP_HOST"),
-		Port:     os.Getenv("SMTP_PORT"),
-		Username: os.Getenv("SMTP_USERNAME"),
-		Password: os.Getenv("SMTP_PASSWORD"),
+	routes := router.Group("/v1/auth/otp")
+	{
+		routes.POST("/identity", identity)
+		routes.POST("/password-reset", passwordReset)
+		routes.POST("/email", email)
+		routes.POST("/resend", resend)
+		routes.PUT("/verify", verify)
 	}
 
-	if config.Host == "" || config.Port == "" || config.Username == "" || config.Password == "" {
-		return nil, fmt.Errorf("missing required SMTP environment variables")
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	legacy := router.Group("/auth")
+	{
+		legacy.POST("/IdentityOTP", middleware.Deprecated("/v1/auth/otp/identity"), identity)
+		legacy.POST("/resetpasswordOTP", middleware.Deprecated("/v1/auth/otp/password-reset"), passwordReset)
+		legacy.POST("/sendemail", middleware.Deprecated("/v1/auth/otp/email"), email)
+		legacy.POST("/resendotp", middleware.Deprecated("/v1/auth/otp/resend"), resend)
+		legacy.PUT("/verifyOTP", middleware.Deprecated("/v1/auth/otp/verify"), verify)
 	}
+}
 
-	fmt.Printf("SMTP Config: Host=%s, Port=%s, Username=%s\n", config.Host, config.Port, config.Username)
-	return config, nil
+// otpPeriod อายุของ TOTP เป็นวินาที ตรงกับอายุ OTP record (ค่าเริ่มต้น 15 นาที)
+func otpPeriod() uint {
+	return uint(appConfig.OTP.TTL / time.Second)
 }
 
 func generateTOTP() (string, error) {
-	secret := os.Getenv("TOTPsecret")
+	secret := appConfig.TOTP.Secret
 	if secret == "" {
-		return "", fmt.Errorf("TOTP secret not found in environment")
+		return "", fmt.Errorf("TOTP secret not configured")
 	}
 
-	// สร้าง TOTP code ที่มีอายุ 15 นาที (900 seconds)
+	// สร้าง TOTP code ที่มีอายุเท่ากับ OTP record
 	code, err := totp.GenerateCodeCustom(secret, time.Now(), totp.ValidateOpts{
-		Period:    900, // 15 minutes in seconds
+		Period:    otpPeriod(),
 		Skew:      0,
 		Digits:    6,
 		Algorithm: otp.AlgorithmSHA256,
@@ -236,73 +222,47 @@ func generateEmailContent(OTP string, REF string) string {
 	return emailTemplate
 }
 
-func sendEmail(to, subject, body string) error {
-	// Load SMTP configuration
-	config, err := LoadEmailConfig()
-	if err != nil {
-		return fmt.Errorf("config loading error: %w", err)
-	}
-
-	// Validate SMTP configuration
-	if config.Host == "" || config.Port == "" || config.Username == "" || config.Password == "" {
-		return fmt.Errorf("incomplete SMTP configuration: host=%q, port=%q, username=%q",
-			config.Host, config.Port, config.Username)
-	}
-
-	// Set up authentication and server address
-	addr := config.Host + ":" + config.Port
-	auth := smtp.PlainAuth("", config.Username, config.Password, config.Host)
-
-	// Create email message
-	from := config.Username
-	mime := "MIME-version: 1.0;\nContent-Type: text/html; charset=\"UTF-8\";\n\n"
-	message := "From: " + from + "\n" +
-		"To: " + to + "\n" +
-		"Subject: " + subject + "\n" +
-		mime + "\n" +
-		body
+// generateEmailText เนื้อหาแบบ plain text ของอีเมล OTP สำหรับ client ที่ไม่แสดง HTML
+func generateEmailText(OTP string, REF string) string {
+	return "Myday-Planner\n\n" +
+		"สวัสดี! กรุณานำรหัส OTP ด้านล่าง ไปกรอกในหน้ายืนยัน\n\n" +
+		"OTP : " + OTP + "\n" +
+		"Ref : " + REF + "\n"
+}
 
-	// Send email with better error handling
-	fmt.Printf("Sending email to %s via %s...\n", to, addr)
-	err = smtp.SendMail(addr, auth, from, []string{to}, []byte(message))
+func sendEmail(ctx context.Context, to, subject, html, text string) error {
+	err := mailer.Send(ctx, mail.Message{
+		To:      []string{to},
+		Subject: subject,
+		HTML:    html,
+		Text:    text,
+	})
 	if err != nil {
-		return fmt.Errorf("SMTP send error: %w", err)
+		return fmt.Errorf("send email: %w", err)
 	}
-
-	fmt.Println("Email sent successfully")
 	return nil
 }
 
 // ฟังก์ชันตรวจสอบว่าอีเมลถูกบล็อกหรือไม่
-func isEmailBlocked(c context.Context, firestoreClient *firestore.Client, email string, recordfirebase string) (bool, error) {
-	// เข้าถึง document ของ email ใน collection หลัก
-	mainDoc := firestoreClient.Collection("EmailBlocked").Doc(email)
-	subCollection := mainDoc.Collection(fmt.Sprintf("EmailBlocked_%s", recordfirebase))
-	blockedRef := subCollection.Doc(email)
-
-	// ดึงข้อมูลจาก Firestore
-	blockedDoc, err := blockedRef.Get(c)
+func isEmailBlocked(c context.Context, fb store.Store, email string, recordfirebase string) (bool, error) {
+	// ดึงเวลาที่หมดการบล็อกจาก Firestore
+	expiresAt, err := fb.OTPRecords().BlockedUntil(c, email, recordfirebase)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
+		if errors.Is(err, store.ErrNotFound) {
 			return false, nil
 		}
 		return false, err
 	}
 
 	// ตรวจสอบข้อมูล
-	if blockedDoc.Exists() {
-		blockData := blockedDoc.Data()
-		expiresAt, ok := blockData["expiresAt"].(time.Time)
-		if ok {
-			if time.Now().Before(expiresAt) {
-				return true, nil
-			}
-
-			// ลบบันทึกถ้าหมดเวลาแล้ว
-			_, err = blockedRef.Delete(c)
-			if err != nil {
-				return false, err
-			}
+	if !expiresAt.IsZero() {
+		if time.Now().Before(expiresAt) {
+			return true, nil
+		}
+
+		// ลบบันทึกถ้าหมดเวลาแล้ว
+		if err := fb.OTPRecords().Unblock(c, email, recordfirebase); err != nil {
+			return false, err
 		}
 	}
 
@@ -310,41 +270,29 @@ func isEmailBlocked(c context.Context, firestoreClient *firestore.Client, email
 }
 
 // ฟังก์ชันตรวจสอบจำนวนครั้งที่ขอ OTP และบล็อกถ้าเกินกำหนด
-func checkAndBlockIfNeeded(c context.Context, firestoreClient *firestore.Client, email string, record string) (bool, error) {
-	// เข้าถึง subcollection ใน document ของ email
-	mainDoc := firestoreClient.Collection("OTPRecords").Doc(email)
-	subCollection := mainDoc.Collection(fmt.Sprintf("OTPRecords_%s", record))
-
-	// อ่านทุก document ใน subcollection
-	iter := subCollection.Documents(c)
-	defer iter.Stop()
+func checkAndBlockIfNeeded(c context.Context, fb store.Store, email string, record string) (bool, error) {
+	// อ่านทุก OTP record ของอีเมลนี้
+	records, err := fb.OTPRecords().List(c, email, record)
+	if err != nil {
+		return false, err
+	}
 
 	var otpCount int
 	currentTime := time.Now()
 
-	for {
-		doc, err := iter.Next()
-		if err == iterator.Done {
-			break
-		}
-		if err != nil {
-			return false, err
-		}
-
-		data := doc.Data()
-		expiresAt, ok := data["expiresAt"].(time.Time)
-		if !ok {
+	for _, otpRecord := range records {
+		if otpRecord.ExpiresAt.IsZero() {
 			otpCount++
 			continue
 		}
 
-		if currentTime.Before(expiresAt) {
+		if currentTime.Before(otpRecord.ExpiresAt) {
 			otpCount++
 		}
 	}
 
-	if otpCount >= 3 {
-		err := blockEmail(c, firestoreClient, email, record)
+	if otpCount >= appConfig.OTP.MaxActive {
+		err := blockEmail(c, fb, email, record)
 		if err != nil {
 			return false, err
 		}
@@ -355,46 +303,32 @@ func checkAndBlockIfNeeded(c context.Context, firestoreClient *firestore.Client,
 }
 
 // ฟังก์ชันบล็อกอีเมล
-func blockEmail(c context.Context, firestoreClient *firestore.Client, email string, record string) error {
+func blockEmail(c context.Context, fb store.Store, email string, record string) error {
 	blockTime := time.Now()
-	expireTime := blockTime.Add(10 * time.Minute)
-
-	blockData := map[string]interface{}{
-		"email":     email,
-		"createdAt": blockTime,
-		"expiresAt": expireTime,
-	}
-
-	mainDoc := firestoreClient.Collection("EmailBlocked").Doc(email)
-	subCollection := mainDoc.Collection(fmt.Sprintf("EmailBlocked_%s", record))
-	_, err := subCollection.Doc(email).Set(c, blockData)
-
-	return err
+	expireTime := blockTime.Add(appConfig.OTP.BlockWindow)
 
+	return fb.OTPRecords().Block(c, email, record, blockTime, expireTime)
 }
 
 // ฟังก์ชันบันทึกข้อมูล OTP ลงใน Firebase
-func saveTOTPRecord(c context.Context, firestoreClient *firestore.Client, email, ref string, record string) error {
-	expirationTime := time.Now().Add(15 * time.Minute)
-	totpData := map[string]interface{}{
-		"email":     email,
-		"reference": ref,
-		"is_used":   "0",
-		"createdAt": time.Now(),
-		"expiresAt": expirationTime,
-		"type":      "totp", // เพิ่มเพื่อระบุว่าเป็น TOTP
-	}
-
-	mainDoc := firestoreClient.Collection("OTPRecords").Doc(email)
-	subCollection := mainDoc.Collection(fmt.Sprintf("OTPRecords_%s", record))
-	_, err := subCollection.Doc(ref).Set(c, totpData)
-	return err
+func saveTOTPRecord(c context.Context, fb store.Store, email, ref string, record string) error {
+	expirationTime := time.Now().Add(appConfig.OTP.TTL)
+	totpData := model.OTPRecord{
+		Email:     email,
+		Reference: ref,
+		Is_used:   "0",
+		CreatedAt: time.Now(),
+		ExpiresAt: expirationTime,
+		Type:      "totp", // เพิ่มเพื่อระบุว่าเป็น TOTP
+	}
+
+	return fb.OTPRecords().Save(c, email, record, totpData)
 }
 
-func IdentityOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func IdentityOTP(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var req dto.IdentityOTPRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -402,29 +336,29 @@ func IdentityOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	var user model.User
 	result := db.Where("email = ?", req.Email).First(&user)
 	if result.Error != nil {
-		c.JSON(404, gin.H{"error": "Email not found"})
+		apperror.Respond(c, apperror.EmailNotFound)
 		return
 	}
 
 	// ตรวจสอบว่าอีเมลถูกบล็อกหรือไม่
-	blocked, err := isEmailBlocked(c, firestoreClient, req.Email, "verify")
+	blocked, err := isEmailBlocked(c, fb, req.Email, "verify")
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to check email status"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	if blocked {
-		c.JSON(403, gin.H{"error": "Too many OTP requests. Please try again later."})
+		apperror.Respond(c, apperror.OTPRateLimited)
 		return
 	}
 
 	// ตรวจสอบจำนวนครั้งที่ขอ OTP และบล็อกถ้าเกินกำหนด
-	shouldBlock, err := checkAndBlockIfNeeded(c, firestoreClient, req.Email, "verify")
+	shouldBlock, err := checkAndBlockIfNeeded(c, fb, req.Email, "verify")
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to check OTP request count"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	if shouldBlock {
-		c.JSON(403, gin.H{"error": "Too many OTP requests. Your email has been blocked temporarily."})
+		apperror.Respond(c, apperror.EmailBlocked)
 		return
 	}
 
@@ -436,10 +370,10 @@ func IdentityOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	})
 }
 
-func ResetpasswordOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func ResetpasswordOTP(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var req dto.ResetpasswordOTPRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -447,29 +381,29 @@ func ResetpasswordOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	var user model.User
 	result := db.Where("email = ?", req.Email).First(&user)
 	if result.Error != nil {
-		c.JSON(404, gin.H{"error": "Email not found"})
+		apperror.Respond(c, apperror.EmailNotFound)
 		return
 	}
 
 	// ตรวจสอบว่าอีเมลถูกบล็อกหรือไม่
-	blocked, err := isEmailBlocked(c, firestoreClient, req.Email, "resetpassword")
+	blocked, err := isEmailBlocked(c, fb, req.Email, "resetpassword")
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to check email status"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	if blocked {
-		c.JSON(403, gin.H{"error": "Too many OTP requests. Please try again later."})
+		apperror.Respond(c, apperror.OTPRateLimited)
 		return
 	}
 
 	// ตรวจสอบจำนวนครั้งที่ขอ OTP และบล็อกถ้าเกินกำหนด
-	shouldBlock, err := checkAndBlockIfNeeded(c, firestoreClient, req.Email, "resetpassword")
+	shouldBlock, err := checkAndBlockIfNeeded(c, fb, req.Email, "resetpassword")
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to check OTP request count"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	if shouldBlock {
-		c.JSON(403, gin.H{"error": "Too many OTP requests. Your email has been blocked temporarily."})
+		apperror.Respond(c, apperror.EmailBlocked)
 		return
 	}
 
@@ -481,10 +415,10 @@ func ResetpasswordOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	})
 }
 
-func Sendemail(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func Sendemail(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var req dto.SendemailRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -492,19 +426,20 @@ func Sendemail(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	var user model.User
 	result := db.Where("email = ?", req.Email).First(&user)
 	if result.Error != nil {
-		c.JSON(404, gin.H{"error": "Email not found"})
+		apperror.Respond(c, apperror.EmailNotFound)
 		return
 	}
 
 	// สร้าง TOTP แทน OTP แบบสุ่ม
 	otp, err := generateTOTP()
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to generate TOTP"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// สร้างเนื้อหาอีเมล
 	emailContent := generateEmailContent(otp, req.Reference)
+	emailText := generateEmailText(otp, req.Reference)
 
 	var recordemail string
 	var recordfirebase string
@@ -518,16 +453,16 @@ func Sendemail(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		recordfirebase = "resetpassword"
 	}
 
-	err = sendEmail(req.Email, recordemail, emailContent)
+	err = sendEmail(c.Request.Context(), req.Email, recordemail, emailContent, emailText)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to send email: " + err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// บันทึกข้อมูล TOTP ลงใน Firebase (ไม่ต้องเก็บ OTP code จริง เก็บเฉพาะ metadata)
-	err = saveTOTPRecord(c, firestoreClient, req.Email, req.Reference, recordfirebase)
+	err = saveTOTPRecord(c, fb, req.Email, req.Reference, recordfirebase)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to save TOTP record: " + err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -536,25 +471,25 @@ func Sendemail(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	})
 }
 
-func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func VerifyOTP(c *gin.Context, db *gorm.DB, fb store.Store) {
 	// รับข้อมูลจาก request
 	var verifyRequest dto.VerifyRequest
 
 	if err := c.ShouldBindJSON(&verifyRequest); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	var user model.User
 	result := db.Where("email = ?", verifyRequest.Email).First(&user)
 	if result.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	}
 
 	// ตรวจสอบว่า input ไม่เป็นค่าว่าง
 	if verifyRequest.Record == "" || verifyRequest.Reference == "" || verifyRequest.OTP == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Record, Reference and OTP are required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("record", "required").WithField("ref", "required").WithField("otp", "required"))
 		return
 	}
 
@@ -570,47 +505,34 @@ func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 
 	// ดึงข้อมูล OTP จาก Firebase โดยใช้ reference
 	ctx := c.Request.Context() // ใช้ context จาก request แทนการส่ง c ไปโดยตรง
-	mainDoc := firestoreClient.Collection("OTPRecords").Doc(verifyRequest.Email)
-	subCollection := mainDoc.Collection(fmt.Sprintf("OTPRecords_%s", recordfirebase))
-	docRef := subCollection.Doc(verifyRequest.Reference)
-
-	docSnap, err := docRef.Get(ctx)
+	otpRecord, err := fb.OTPRecords().Get(ctx, verifyRequest.Email, recordfirebase, verifyRequest.Reference)
 
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Invalid reference code"})
+		if errors.Is(err, store.ErrNotFound) {
+			apperror.Respond(c, apperror.OTPReferenceInvalid)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve OTP record"})
-			fmt.Printf("Firestore error: %v", err) // บันทึก error ที่เกิดขึ้นโดยไม่แสดงให้ user เห็น
+			apperror.Respond(c, apperror.Internal)
+			logging.FromContext(c).Error("failed to get OTP record", "error", err) // บันทึก error ที่เกิดขึ้นโดยไม่แสดงให้ user เห็น
 		}
 		return
 	}
 
-	// แปลงข้อมูลจาก Firestore เป็นโครงสร้างข้อมูลที่ใช้งานได้
-	var otpRecord model.OTPRecord
-
-	if err := docSnap.DataTo(&otpRecord); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse OTP record"})
-		fmt.Printf("Data parsing error: %v", err)
-		return
-	}
-
 	// ตรวจสอบว่า OTP ถูกใช้ไปแล้วหรือไม่
 	if otpRecord.Is_used == "1" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "OTP has already been used"})
+		apperror.Respond(c, apperror.OTPAlreadyUsed)
 		return
 	}
 
 	// ตรวจสอบว่า OTP หมดอายุหรือยัง
 	currentTime := time.Now()
 	if currentTime.After(otpRecord.ExpiresAt) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "OTP has expired"})
+		apperror.Respond(c, apperror.OTPExpired)
 		return
 	}
 
 	// ตรวจสอบว่า OTP ตรงกันหรือไม่
 	if !verifyTOTP(verifyRequest.OTP, otpRecord.CreatedAt) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OTP"})
+		apperror.Respond(c, apperror.OTPInvalid)
 		return
 	}
 
@@ -623,20 +545,18 @@ func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	}()
 
 	if err := tx.Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
-		fmt.Printf("Transaction error: %v", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		logging.FromContext(c).Error("failed to start transaction", "error", err)
 		return
 	}
 
 	// อัปเดตสถานะ OTP ว่าถูกใช้แล้ว
-	_, err = docRef.Update(ctx, []firestore.Update{
-		{Path: "is_used", Value: "1"},
-	})
+	err = fb.OTPRecords().MarkUsed(ctx, verifyRequest.Email, recordfirebase, verifyRequest.Reference)
 
 	if err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update OTP status"})
-		fmt.Printf("Firestore update error: %v", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		logging.FromContext(c).Error("failed to update OTP record", "error", err)
 		return
 	}
 
@@ -652,14 +572,14 @@ func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 
 		if result.Error != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user verification status"})
-			fmt.Printf("DB update error: %v", result.Error)
+			apperror.Respond(c, apperror.Internal.Wrap(result.Error))
+			logging.FromContext(c).Error("failed to update user verification status", "error", result.Error)
 			return
 		}
 
 		if result.RowsAffected == 0 {
 			tx.Rollback()
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 			return
 		}
 
@@ -667,14 +587,14 @@ func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		accessToken, err := CreateAccessToken(uint(user.UserID), user.Role)
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create access token"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
 		refreshToken, err := CreateRefreshToken(uint(user.UserID))
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create refresh token"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
@@ -682,13 +602,13 @@ func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		hashedRefreshToken, err := HashRefreshToken(refreshToken)
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash refresh token"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
 		// กำหนดค่าเวลาสำหรับ token
 		now := time.Now()
-		expiresAt := now.Add(7 * 24 * time.Hour).Unix() // เปลี่ยนเป็น 7 วันแทน 2 นาที
+		expiresAt := now.Add(appConfig.JWT.RefreshTTL).Unix()
 		issuedAt := now.Unix()
 
 		// สร้างข้อมูล refresh token
@@ -701,10 +621,9 @@ func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		}
 
 		// บันทึก refresh token ใน Firestore
-		userIDStr := strconv.Itoa(user.UserID)
-		if _, err := firestoreClient.Collection("refreshTokens").Doc(userIDStr).Set(ctx, refreshTokenData); err != nil {
+		if err := fb.RefreshTokens().Save(ctx, user.UserID, refreshTokenData); err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store refresh token"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
@@ -714,19 +633,19 @@ func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		isActive := "1"
 		isVerify := "1"
 		// บันทึกหรืออัปเดตข้อมูลใน Firebase collection "usersLogin"
-		_, err = firestoreClient.Collection("usersLogin").Doc(otpRecord.Email).Set(ctx, map[string]interface{}{
+		err = fb.Logins().Merge(ctx, otpRecord.Email, store.Fields{
 			"email":      otpRecord.Email,
 			"active":     isActive,
 			"verify":     isVerify,
 			"login":      0,
 			"role":       role,
 			"updated_at": time.Now(),
-		}, firestore.MergeAll)
+		})
 
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update Firebase user data"})
-			fmt.Printf("Firestore set error: %v", err)
+			apperror.Respond(c, apperror.Internal.Wrap(err))
+			logging.FromContext(c).Error("failed to update Firestore login data", "error", err)
 			return
 		}
 
@@ -737,8 +656,8 @@ func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 
 	// commit transaction หากทุกอย่างเรียบร้อย
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
-		fmt.Printf("Transaction commit error: %v", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		logging.FromContext(c).Error("failed to commit transaction", "error", err)
 		return
 	}
 
@@ -747,31 +666,31 @@ func VerifyOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 }
 
 func verifyTOTP(inputCode string, createdAt time.Time) bool {
-	secret := os.Getenv("TOTPsecret")
+	secret := appConfig.TOTP.Secret
 	if secret == "" {
 		return false
 	}
 
 	// ตรวจสอบ TOTP code กับเวลาที่สร้าง
 	valid, err := totp.ValidateCustom(inputCode, secret, createdAt, totp.ValidateOpts{
-		Period:    900, // 15 minutes in seconds
-		Skew:      0,   // อนุญาตให้คลาดเคลื่อนได้ 1 period
+		Period:    otpPeriod(),
+		Skew:      0, // อนุญาตให้คลาดเคลื่อนได้ 1 period
 		Digits:    6,
 		Algorithm: otp.AlgorithmSHA256,
 	})
 
 	if err != nil {
-		fmt.Printf("TOTP validation error: %v", err)
+		slog.Warn("TOTP validation failed", "error", err)
 		return false
 	}
 
 	return valid
 }
 
-func ResendOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func ResendOTP(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var req dto.ResendOTPRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -779,7 +698,7 @@ func ResendOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	var user model.User
 	result := db.Where("email = ?", req.Email).First(&user)
 	if result.Error != nil {
-		c.JSON(404, gin.H{"error": "Email not found"})
+		apperror.Respond(c, apperror.EmailNotFound)
 		return
 	}
 
@@ -797,31 +716,31 @@ func ResendOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	}
 
 	// ตรวจสอบว่าอีเมลถูกบล็อกหรือไม่
-	blocked, err := isEmailBlocked(c, firestoreClient, req.Email, recordfirebase)
+	blocked, err := isEmailBlocked(c, fb, req.Email, recordfirebase)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to check email status"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	if blocked {
-		c.JSON(403, gin.H{"error": "Too many OTP requests. Please try again later."})
+		apperror.Respond(c, apperror.OTPRateLimited)
 		return
 	}
 
 	// ตรวจสอบจำนวนครั้งที่ขอ OTP และบล็อกถ้าเกินกำหนด
-	shouldBlock, err := checkAndBlockIfNeeded(c, firestoreClient, req.Email, recordfirebase)
+	shouldBlock, err := checkAndBlockIfNeeded(c, fb, req.Email, recordfirebase)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to check OTP request count"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	if shouldBlock {
-		c.JSON(403, gin.H{"error": "Too many OTP requests. Your email has been blocked temporarily."})
+		apperror.Respond(c, apperror.EmailBlocked)
 		return
 	}
 
 	// สร้าง OTP และ REF ใหม่
 	otp, err := generateTOTP()
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to generate TOTP"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -829,17 +748,18 @@ func ResendOTP(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 
 	// สร้างเนื้อหาอีเมล
 	emailContent := generateEmailContent(otp, ref)
+	emailText := generateEmailText(otp, ref)
 
-	err = sendEmail(req.Email, recordemail, emailContent)
+	err = sendEmail(c.Request.Context(), req.Email, recordemail, emailContent, emailText)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to send email: " + err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// บันทึกข้อมูล TOTP ลงใน Firebase (ไม่ต้องเก็บ OTP code จริง เก็บเฉพาะ metadata)
-	err = saveTOTPRecord(c, firestoreClient, req.Email, ref, recordfirebase)
+	err = saveTOTPRecord(c, fb, req.Email, ref, recordfirebase)
 	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to save TOTP record: " + err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -5,76 +5,82 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/outbox"
+	"mydayplanner/store"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func BoardController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/board", middleware.AccessTokenMiddleware())
+func BoardController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	invite := func(c *gin.Context) { InviteBoardFirebase(c, db, fb) }
+	accept := func(c *gin.Context) { AcceptInvite(c, db, fb) }
+	join := func(c *gin.Context) { Addboard(c, db, fb) }
+	adjust := func(c *gin.Context) { AdjustBoards(c, db, fb) }
+	newToken := func(c *gin.Context) { NewBoardToken(c, db, fb) }
+	removeUser := func(c *gin.Context) { DeleteUserOnboard(c, db, fb) }
+
+	routes := router.Group("/v1/boards/:boardid", middleware.AccessTokenMiddleware())
 	{
-		routes.POST("/invite", func(c *gin.Context) {
-			InviteBoardFirebase(c, db, firestoreClient)
-		})
-		routes.POST("/accept", func(c *gin.Context) {
-			AcceptInvite(c, db, firestoreClient)
-		})
-		routes.POST("/addboard/:boardId", func(c *gin.Context) {
-			Addboard(c, db, firestoreClient)
-		})
-		routes.PUT("/adjust", func(c *gin.Context) {
-			AdjustBoards(c, db, firestoreClient)
-		})
-		routes.PUT("/newtoken/:boardId", func(c *gin.Context) {
-			NewBoardToken(c, db, firestoreClient)
-		})
-		routes.DELETE("/boarduser", func(c *gin.Context) {
-			DeleteUserOnboard(c, db, firestoreClient)
-		})
+		routes.PUT("", adjust)
+		routes.POST("/members", join)
+		routes.DELETE("/members/:userid", removeUser)
+		routes.POST("/invites", invite)
+		routes.PUT("/token", newToken)
 	}
-}
+	router.POST("/v1/invites/:inviteid", middleware.AccessTokenMiddleware(), accept)
 
-type BoardInvite struct {
-	Accept    bool      `firestore:"accept"`
-	BoardID   int       `firestore:"board_id"`
-	CreatedAt time.Time `firestore:"created_at"`
-	InviteID  int       `firestore:"invite_id"`
-	InviterID int       `firestore:"inviter_id"`
-	UpdatedAt time.Time `firestore:"updated_at"`
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	legacy := router.Group("/board")
+	{
+		legacy.POST("/invite", middleware.Deprecated("/v1/boards/:boardid/invites"), middleware.AccessTokenMiddleware(), invite)
+		legacy.POST("/accept", middleware.Deprecated("/v1/invites/:inviteid"), middleware.AccessTokenMiddleware(), accept)
+		legacy.POST("/addboard/:boardid", middleware.Deprecated("/v1/boards/:boardid/members"), middleware.AccessTokenMiddleware(), join)
+		legacy.PUT("/adjust", middleware.Deprecated("/v1/boards/:boardid"), middleware.AccessTokenMiddleware(), adjust)
+		legacy.PUT("/newtoken/:boardid", middleware.Deprecated("/v1/boards/:boardid/token"), middleware.AccessTokenMiddleware(), newToken)
+		legacy.DELETE("/boarduser", middleware.Deprecated("/v1/boards/:boardid/members/:userid"), middleware.AccessTokenMiddleware(), removeUser)
+	}
 }
 
-func AdjustBoards(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+type BoardInvite = store.Invite
+
+func AdjustBoards(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 
 	var adjustData dto.AdjustBoardRequest
 	if err := c.ShouldBindJSON(&adjustData); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
+	// route /v1 ระบุบอร์ดใน path
+	if boardID := c.Param("boardid"); boardID != "" {
+		adjustData.BoardID = boardID
+	}
 
 	// ตรวจสอบค่า input
 	if strings.TrimSpace(adjustData.BoardID) == "" || strings.TrimSpace(adjustData.BoardName) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "BoardID and BoardName are required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("board_id", "required").WithField("board_name", "required"))
 		return
 	}
 	boardName := strings.TrimSpace(adjustData.BoardName)
 	if len(boardName) > 255 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Board name is too long (max 255 characters)"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("board_name", "max=255"))
 		return
 	}
 
 	// ตรวจสอบสิทธิ์ของผู้ใช้
 	var board struct {
-		BoardID  string
+		BoardID  int
 		CreateBy int
 	}
 	if err := db.Table("board").
@@ -83,9 +89,9 @@ func AdjustBoards(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 		First(&board).Error; err != nil {
 
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+			apperror.Respond(c, apperror.BoardNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get board data"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -101,9 +107,9 @@ func AdjustBoards(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 		var boardUser model.BoardUser
 		if err := db.Where("board_id = ? AND user_id = ?", adjustData.BoardID, userID).First(&boardUser).Error; err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: not the board owner or member"})
+				apperror.Respond(c, apperror.NotBoardMember)
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board membership"})
+				apperror.Respond(c, apperror.Internal)
 			}
 			return
 		}
@@ -112,37 +118,33 @@ func AdjustBoards(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	}
 
 	if !canUpdate {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		apperror.Respond(c, apperror.Forbidden)
 		return
 	}
 
 	// Firestore Rollback Variables
-	var firestoreDocRef *firestore.DocumentRef
 	var firestoreOriginalData map[string]interface{}
 	var firestoreUpdated = false
 
 	// อัพเดต Firestore ก่อน (ถ้าเป็นสมาชิก)
 	if shouldUpdateFirestore {
 		ctx := c.Request.Context()
-		firestoreDocRef = firestoreClient.Collection("Boards").Doc(adjustData.BoardID)
 
 		// ดึงข้อมูลเดิมมา backup
-		docSnap, err := firestoreDocRef.Get(ctx)
+		data, err := fb.Boards().Get(ctx, board.BoardID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get Firestore board data"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
-		if docSnap.Exists() {
-			firestoreOriginalData = docSnap.Data()
-		}
+		firestoreOriginalData = data
 
 		// อัปเดต Firestore
-		_, err = firestoreDocRef.Update(ctx, []firestore.Update{
-			{Path: "BoardName", Value: boardName},
-			{Path: "update_at", Value: time.Now()},
+		err = fb.Boards().Update(ctx, board.BoardID, store.Fields{
+			"BoardName": boardName,
+			"update_at": time.Now(),
 		})
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board in Firestore"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 		firestoreUpdated = true
@@ -162,24 +164,24 @@ func AdjustBoards(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 
 	// Rollback Firestore ถ้า SQL fail
 	if err != nil {
-		if firestoreUpdated && firestoreDocRef != nil {
+		if firestoreUpdated {
 			ctx := c.Request.Context()
 			if firestoreOriginalData != nil {
 				if originalBoardName, exists := firestoreOriginalData["BoardName"]; exists {
-					_, rollbackErr := firestoreDocRef.Update(ctx, []firestore.Update{
-						{Path: "BoardName", Value: originalBoardName},
+					rollbackErr := fb.Boards().Update(ctx, board.BoardID, store.Fields{
+						"BoardName": originalBoardName,
 					})
 					if rollbackErr != nil {
-						fmt.Printf("CRITICAL: Firestore rollback failed for board %s: %v\n", adjustData.BoardID, rollbackErr)
+						logging.FromContext(c).Error("Firestore rollback failed", "board_id", adjustData.BoardID, "error", rollbackErr)
 					}
 				}
 			}
 		}
 
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+			apperror.Respond(c, apperror.BoardNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -193,18 +195,21 @@ func AdjustBoards(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	})
 }
 
-func InviteBoardFirebase(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func InviteBoardFirebase(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	var req dto.InviteBoardRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
+	if boardID := c.Param("boardid"); boardID != "" {
+		req.BoardID = boardID
+	}
 
 	// Validate input
 	if req.BoardID == "" || req.UserID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "BoardID and UserID are required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("board_id", "required").WithField("user_id", "required"))
 		return
 	}
 
@@ -212,9 +217,9 @@ func InviteBoardFirebase(c *gin.Context, db *gorm.DB, firestoreClient *firestore
 	var inviterUser model.User
 	if err := db.Where("user_id = ?", userID).First(&inviterUser).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Inviter user not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify inviter user"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -222,13 +227,13 @@ func InviteBoardFirebase(c *gin.Context, db *gorm.DB, firestoreClient *firestore
 	// แปลง BoardID และ UserID จาก string เป็น int
 	boardIDInt, err := strconv.Atoi(req.BoardID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid BoardID format"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("board_id", "invalid"))
 		return
 	}
 
 	inviteeUserIDInt, err := strconv.Atoi(req.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid UserID format"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("user_id", "invalid"))
 		return
 	}
 
@@ -236,9 +241,9 @@ func InviteBoardFirebase(c *gin.Context, db *gorm.DB, firestoreClient *firestore
 	var board model.BoardUser
 	if err := db.Where("board_id = ?", boardIDInt).First(&board).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Board not found"})
+			apperror.Respond(c, apperror.BoardNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get board data"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -247,9 +252,9 @@ func InviteBoardFirebase(c *gin.Context, db *gorm.DB, firestoreClient *firestore
 	var inviteeUser model.User
 	if err := db.Where("user_id = ?", inviteeUserIDInt).First(&inviteeUser).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Invitee user not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get invitee user data"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -257,10 +262,10 @@ func InviteBoardFirebase(c *gin.Context, db *gorm.DB, firestoreClient *firestore
 	// ตรวจสอบว่า User ยังไม่ได้เป็นสมาชิกของ Board นี้
 	var existingBoardUser model.BoardUser
 	if err := db.Where("board_id = ? AND user_id = ?", boardIDInt, inviteeUserIDInt).First(&existingBoardUser).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of this board"})
+		apperror.Respond(c, apperror.AlreadyBoardMember)
 		return
 	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing board membership"})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
@@ -268,16 +273,16 @@ func InviteBoardFirebase(c *gin.Context, db *gorm.DB, firestoreClient *firestore
 	ctx := context.Background()
 
 	// ดึงข้อมูลทั้งหมดจาก BoardInvite collection
-	docs, err := firestoreClient.Collection("BoardInvite").Documents(ctx).GetAll()
+	docIDs, err := fb.Invites().IDs(ctx)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get existing invites: " + err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// สร้าง map เพื่อเก็บ ID ที่ใช้แล้ว
 	usedIDs := make(map[int]bool)
-	for _, doc := range docs {
-		if id, err := strconv.Atoi(doc.Ref.ID); err == nil {
+	for _, docID := range docIDs {
+		if id, err := strconv.Atoi(docID); err == nil {
 			usedIDs[id] = true
 		}
 	}
@@ -290,19 +295,19 @@ func InviteBoardFirebase(c *gin.Context, db *gorm.DB, firestoreClient *firestore
 	inviteID := strconv.Itoa(nextID)
 
 	// สร้างข้อมูลสำหรับบันทึกลง Firebase
-	inviteData := map[string]interface{}{
-		"inviter_id": int(userID),      // ผู้เชิญ
-		"invite_id":  inviteeUserIDInt, // ผู้ถูกเชิญ
-		"board_id":   boardIDInt,
-		"accept":     false, // ค่าเริ่มต้นเป็น false
-		"created_at": time.Now(),
-		"updated_at": time.Now(),
+	inviteData := BoardInvite{
+		InviterID: int(userID),      // ผู้เชิญ
+		InviteID:  inviteeUserIDInt, // ผู้ถูกเชิญ
+		BoardID:   boardIDInt,
+		Accept:    false, // ค่าเริ่มต้นเป็น false
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
 	}
 
 	// บันทึกลง Firebase Firestore
-	_, err = firestoreClient.Collection("BoardInvite").Doc(inviteID).Set(ctx, inviteData)
+	err = fb.Invites().Set(ctx, inviteID, inviteData)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save invite to Firebase: " + err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -313,15 +318,18 @@ func InviteBoardFirebase(c *gin.Context, db *gorm.DB, firestoreClient *firestore
 	})
 }
 
-func AcceptInvite(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func AcceptInvite(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	var req dto.AcceptBoardRequest
 
 	// Bind JSON request
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
+	if inviteID := c.Param("inviteid"); inviteID != "" {
+		req.InviteID = inviteID
+	}
 
 	// Get user data และตรวจสอบว่า user มีอยู่ในระบบหรือไม่
 	var user struct {
@@ -330,39 +338,32 @@ func AcceptInvite(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	}
 	if err := db.Raw("SELECT user_id, email FROM user WHERE user_id = ?", userID).Scan(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
 
 	// ดึงข้อมูลจาก Firebase Firestore
 	ctx := context.Background()
-	docRef := firestoreClient.Collection("BoardInvite").Doc(req.InviteID)
-	docSnapshot, err := docRef.Get(ctx)
+	invite, err := fb.Invites().Get(ctx, req.InviteID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Invite not found"})
-		return
-	}
-
-	var invite BoardInvite
-	if err := docSnapshot.DataTo(&invite); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to parse invite data"})
+		apperror.Respond(c, apperror.InviteNotFound)
 		return
 	}
 
 	// ตรวจสอบว่า invitation นี้เป็นของ user นี้หรือไม่ (optional security check)
 	if invite.InviterID == int(userID) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Cannot accept your own invitation"})
+		apperror.Respond(c, apperror.CannotAcceptOwnInvite)
 		return
 	}
 
 	// ตรวจสอบการตอบรับ
 	if !req.Accept {
 		// หาก Accept เป็น false ให้ลบ document ออกจาก Firestore
-		if _, err := docRef.Delete(ctx); err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete invitation"})
+		if err := fb.Invites().Delete(ctx, req.InviteID); err != nil {
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 		c.JSON(http.StatusOK, gin.H{"message": "Invitation declined and removed"})
@@ -371,11 +372,8 @@ func AcceptInvite(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 
 	// หาก Accept เป็น true
 	// 1. อัปเดต accept เป็น true ใน Firestore
-	if _, err := docRef.Update(ctx, []firestore.Update{
-		{Path: "accept", Value: true},
-		{Path: "updated_at", Value: time.Now()},
-	}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update invitation"})
+	if err := fb.Invites().Accept(ctx, req.InviteID); err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -384,7 +382,7 @@ func AcceptInvite(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	db.Model(&model.BoardUser{}).Where("board_id = ? AND user_id = ?", invite.BoardID, userID).Count(&existingBoardUser)
 
 	if existingBoardUser > 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "User is already a member of this board"})
+		apperror.Respond(c, apperror.AlreadyBoardMember)
 		return
 	}
 
@@ -396,7 +394,7 @@ func AcceptInvite(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	}
 
 	if err := db.Create(&boardUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add user to board"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -407,20 +405,20 @@ func AcceptInvite(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 	})
 }
 
-func NewBoardToken(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
-	BoardID := c.Param("boardId")
+func NewBoardToken(c *gin.Context, db *gorm.DB, fb store.Store) {
+	BoardID := c.Param("boardid")
 
 	// แปลง BoardID จาก string เป็น int
 	boardIDInt, err := strconv.Atoi(BoardID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid BoardID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("board_id", "invalid"))
 		return
 	}
 
 	// เริ่ม transaction
 	tx := db.Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction: " + tx.Error.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(tx.Error))
 		return
 	}
 
@@ -437,14 +435,14 @@ func NewBoardToken(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 
 	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing token: " + result.Error.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	}
 
 	// หากไม่มี token อยู่แล้ว ให้ส่งข้อผิดพลาด
 	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 		tx.Rollback()
-		c.JSON(http.StatusNotFound, gin.H{"error": "No token found for this board"})
+		apperror.Respond(c, apperror.ShareTokenNotFound)
 		return
 	}
 
@@ -452,12 +450,12 @@ func NewBoardToken(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	decodedBytes, err := base64.URLEncoding.DecodeString(existingToken.Token)
 	if err != nil {
 		// หาก decode ไม่ได้ ให้สร้าง token ใหม่เลย
-		fmt.Printf("Warning: Failed to decode existing token: %v\n", err)
+		logging.FromContext(c).Warn("failed to decode existing board token", "error", err)
 	} else {
 		// parse URL parameters
 		decodedParams, err := url.ParseQuery(string(decodedBytes))
 		if err != nil {
-			fmt.Printf("Warning: Failed to parse decoded token parameters: %v\n", err)
+			logging.FromContext(c).Warn("failed to parse decoded board token parameters", "error", err)
 		} else {
 			// ตรวจสอบเวลาหมดอายุ
 			if expireStr := decodedParams.Get("expire"); expireStr != "" {
@@ -503,25 +501,26 @@ func NewBoardToken(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	// บันทึกการอัปเดทลงฐานข้อมูล
 	if err := tx.Save(&existingToken).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update board token: " + err.Error()})
-		return
-	}
-
-	// Commit transaction
-	if err := tx.Commit().Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction: " + err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+	// บันทึก token ใหม่ของ Firestore ลง outbox ใน transaction เดียวกัน
 	data := map[string]interface{}{
 		"ShareToken":     encodedParams,
 		"ShareExpiresAt": expireAt,
 		"updatedAt":      time.Now(),
 	}
-	if err := saveTaskToFirestore(ctx, firestoreClient, boardIDInt, data); err != nil {
-		fmt.Printf("Warning: Failed to save token to Firestore: %v\n", err)
+	if err := saveTaskToFirestore(context.Background(), outbox.Writer(tx), boardIDInt, data); err != nil {
+		tx.Rollback()
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+
+	// Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
 	}
 
 	// ส่งข้อมูล token กลับไป
@@ -536,48 +535,36 @@ func NewBoardToken(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	})
 }
 
-func saveTaskToFirestore(ctx context.Context, client *firestore.Client, boardID int, data map[string]interface{}) error {
-	boardPath := fmt.Sprintf("Boards/%d", boardID)
-
-	// แปลง map[string]interface{} เป็น []firestore.Update
-	var updates []firestore.Update
-	for k, v := range data {
-		updates = append(updates, firestore.Update{
-			Path:  k,
-			Value: v,
-		})
-	}
-
-	_, err := client.Doc(boardPath).Update(ctx, updates)
-	return err
+func saveTaskToFirestore(ctx context.Context, fb store.Store, boardID int, data map[string]interface{}) error {
+	return fb.Boards().Update(ctx, boardID, data)
 }
 
-func Addboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func Addboard(c *gin.Context, db *gorm.DB, fb store.Store) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	// Extract userID safely
 	userIDVal, exists := c.Get("userId")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authorized"})
+		apperror.Respond(c, apperror.TokenClaimsInvalid)
 		return
 	}
 
 	userID, ok := userIDVal.(uint)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("user_id", "invalid"))
 		return
 	}
 
-	boardIDStr := c.Param("boardId")
+	boardIDStr := c.Param("boardid")
 	if boardIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Board ID is required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("board_id", "required"))
 		return
 	}
 
 	boardIDInt, err := strconv.Atoi(boardIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid board ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("board_id", "invalid"))
 		return
 	}
 
@@ -589,7 +576,7 @@ func Addboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		Profile string
 	}
 	if err := db.Raw("SELECT user_id, email, name, profile FROM user WHERE user_id = ?", userID).Scan(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -601,16 +588,14 @@ func Addboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 
 	if err := db.Create(&boardUser).Error; err != nil {
 		if strings.Contains(err.Error(), "Duplicate entry") || strings.Contains(err.Error(), "UNIQUE constraint failed") {
-			c.JSON(http.StatusConflict, gin.H{"error": "User is already added to this board"})
+			apperror.Respond(c, apperror.AlreadyBoardMember)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add user to board"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// Add to Firestore
-	boardDocRef := firestoreClient.Collection("Boards").Doc(strconv.Itoa(boardIDInt)).Collection("BoardUsers").Doc(strconv.Itoa(boardUser.BoardUserID))
-
 	boardUserData := map[string]interface{}{
 		"BoardID":   boardIDInt,
 		"UserID":    user.UserID,
@@ -621,8 +606,8 @@ func Addboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		"updatedAt": time.Now(),
 	}
 
-	if _, err := boardDocRef.Set(ctx, boardUserData); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add user to board in Firestore: " + err.Error()})
+	if err := fb.Boards().SetUser(ctx, boardIDInt, boardUser.BoardUserID, boardUserData); err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -633,24 +618,27 @@ func Addboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	})
 }
 
-func DeleteUserOnboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteUserOnboard(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var req dto.BoarduserRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	if boardID, userID := c.Param("boardid"), c.Param("userid"); boardID != "" && userID != "" {
+		// route /v1 ส่งทั้งสองค่ามาใน path และไม่มี body
+		req = dto.BoarduserRequest{BoardID: boardID, UserID: userID}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// ค้นหา BoardUser จาก SQL
 	var boardUser model.BoardUser
 	if err := db.Where("board_id = ? AND user_id = ?", req.BoardID, req.UserID).First(&boardUser).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "BoardUser not found"})
+		apperror.Respond(c, apperror.BoardMemberNotFound)
 		return
 	}
 
 	// ค้นหา Tasks ทั้งหมดที่อยู่ใน Board นี้
 	var tasks []model.Tasks
 	if err := db.Where("board_id = ?", req.BoardID).Find(&tasks).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -662,18 +650,15 @@ func DeleteUserOnboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 
 	// ลบ BoardUser จาก SQL
 	if err := db.Delete(&boardUser).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete BoardUser from SQL"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// ลบ BoardUser จาก Firestore
 	boardUserDocPath := fmt.Sprintf("Boards/%s/BoardUsers/%d", req.BoardID, boardUser.BoardUserID)
-	_, err := firestoreClient.Doc(boardUserDocPath).Delete(c)
+	err := fb.Boards().DeleteUser(c, boardUser.BoardID, boardUser.BoardUserID)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":         "Deleted BoardUser from SQL, but failed to delete from Firestore",
-			"firestorePath": boardUserDocPath,
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(fmt.Errorf("deleted from SQL but not from Firestore %s: %w", boardUserDocPath, err)))
 		return
 	}
 
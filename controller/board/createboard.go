@@ -4,31 +4,33 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"net/url"
 	"strconv"
 	"sync"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func CreateBoardController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.POST("/board", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		CreateBoard(c, db, firestoreClient)
-	})
+func CreateBoardController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	create := func(c *gin.Context) { CreateBoard(c, db, fb) }
+	router.POST("/v1/boards", middleware.AccessTokenMiddleware(), create)
+	router.POST("/board", middleware.Deprecated("/v1/boards"), middleware.AccessTokenMiddleware(), create)
 }
 
-func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func CreateBoard(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 	var board dto.CreateBoardRequest
 	if err := c.ShouldBindJSON(&board); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -44,7 +46,7 @@ func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		Profile string
 	}
 	if err := db.Table("user").Select("user_id, name, email, profile").Where("user_id = ?", userId).First(&user).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
@@ -61,7 +63,7 @@ func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	// 2. สร้าง board ใน PostgreSQL ก่อน
 	tx := db.Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database transaction error"})
+		apperror.Respond(c, apperror.Internal.Wrap(tx.Error))
 		return
 	}
 	defer func() {
@@ -72,7 +74,7 @@ func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 	if err := tx.Create(&newBoard).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create board: " + err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -88,7 +90,7 @@ func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 		if err := tx.Create(&boardUser).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create board user: " + err.Error()})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
@@ -109,7 +111,7 @@ func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 		if err := tx.Create(&shareToken).Error; err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create share token: " + err.Error()})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
@@ -118,7 +120,7 @@ func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction: " + err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -164,8 +166,7 @@ func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 				"updatedAt":      time.Now(),
 			}
 
-			boardDoc := firestoreClient.Collection("Boards").Doc(strconv.Itoa(newBoard.BoardID))
-			if _, err := boardDoc.Set(ctx, boardDataFirebase); err != nil {
+			if err := fb.Boards().Set(ctx, newBoard.BoardID, boardDataFirebase); err != nil {
 				mu.Lock()
 				firestoreErr = fmt.Errorf("failed to create board in Firestore: %w", err)
 				mu.Unlock()
@@ -193,8 +194,7 @@ func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 				"updatedAt": time.Now(),
 			}
 
-			userDoc := firestoreClient.Collection("Boards").Doc(strconv.Itoa(newBoard.BoardID)).Collection("BoardUsers").Doc(strconv.Itoa(boardUser.BoardUserID))
-			if _, err := userDoc.Set(ctx, boardUserData); err != nil {
+			if err := fb.Boards().SetUser(ctx, newBoard.BoardID, boardUser.BoardUserID, boardUserData); err != nil {
 				mu.Lock()
 				firestoreErr = fmt.Errorf("failed to create board user in Firestore: %w", err)
 				mu.Unlock()
@@ -207,7 +207,7 @@ func CreateBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		// ตรวจสอบ error จาก Firestore
 		if firestoreErr != nil {
 			// Log error แต่ไม่ fail ทั้งหมด เนื่องจาก PostgreSQL สำเร็จแล้ว
-			fmt.Printf("Firestore error (board created in DB): %v\n", firestoreErr)
+			logging.FromContext(c).Error("failed to save board to Firestore (board created in DB)", "error", firestoreErr)
 			response["message"] = "Board created successfully (with Firestore sync issue)"
 			response["warning"] = "Firestore sync failed but board was created"
 			c.JSON(http.StatusCreated, response)
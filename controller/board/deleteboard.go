@@ -3,38 +3,30 @@ package board
 import (
 	"context"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func DeleteBoardController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.DELETE("/board", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		DeleteBoard(c, db, firestoreClient)
-	})
-
-}
-
-type ErrorResponse struct {
-	Error   string            `json:"error"`
-	Details map[string]string `json:"details,omitempty"`
+func DeleteBoardController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	del := func(c *gin.Context) { DeleteBoard(c, db, fb) }
+	router.DELETE("/v1/boards", middleware.AccessTokenMiddleware(), del)
+	router.DELETE("/board", middleware.Deprecated("/v1/boards"), middleware.AccessTokenMiddleware(), del)
 }
 
-func DeleteBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteBoard(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	var boardIDreq dto.DeleteBoardRequest
 	if err := c.ShouldBindJSON(&boardIDreq); err != nil {
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error:   "Invalid request body",
-			Details: map[string]string{"validation": err.Error()},
-		})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -46,20 +38,14 @@ func DeleteBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		// แปลง string เป็น int
 		boardID, err := strconv.Atoi(boardIDStr)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, ErrorResponse{
-				Error:   "Invalid board ID format",
-				Details: map[string]string{"board_id": boardIDStr},
-			})
+			apperror.Respond(c, apperror.InvalidInput.Wrap(err).WithField("board_id", "numeric"))
 			return
 		}
 
 		// ตรวจสอบว่า Board ID นี้มีใน BoardUser หรือไม่
 		var count int64
 		if err := db.Model(&model.BoardUser{}).Where("board_id = ?", boardID).Count(&count).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "Database error",
-				Details: map[string]string{"error": err.Error()},
-			})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
@@ -75,21 +61,15 @@ func DeleteBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 	// เรียกใช้ฟังก์ชันตามประเภท Board
 	if len(groupBoardIDs) > 0 {
-		if err := deleteGroupBoard(db, firestoreClient, groupBoardIDs); err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "Failed to delete group boards",
-				Details: map[string]string{"error": err.Error()},
-			})
+		if err := deleteGroupBoard(db, fb, groupBoardIDs); err != nil {
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 	}
 
 	if len(privateBoardIDs) > 0 {
-		if err := deletePrivateBoard(db, firestoreClient, privateBoardIDs, userID); err != nil {
-			c.JSON(http.StatusInternalServerError, ErrorResponse{
-				Error:   "Failed to delete private boards",
-				Details: map[string]string{"error": err.Error()},
-			})
+		if err := deletePrivateBoard(db, fb, privateBoardIDs, userID); err != nil {
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 	}
@@ -101,7 +81,7 @@ func DeleteBoard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	})
 }
 
-func deleteGroupBoard(db *gorm.DB, firestoreClient *firestore.Client, boardIDs []int) error {
+func deleteGroupBoard(db *gorm.DB, fb store.Store, boardIDs []int) error {
 	tx := db.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -115,12 +95,12 @@ func deleteGroupBoard(db *gorm.DB, firestoreClient *firestore.Client, boardIDs [
 			return err
 		}
 		for _, task := range tasks {
-			if err := deleteSubCollectionByTaskID(db, firestoreClient, task.TaskID); err != nil {
+			if err := deleteSubCollectionByTaskID(db, fb, task.TaskID); err != nil {
 				return err
 			}
 		}
 		// ลบ boarduser
-		if err := deleteMainPathByBoardID(db, firestoreClient, boardID); err != nil {
+		if err := deleteMainPathByBoardID(db, fb, boardID); err != nil {
 			tx.Rollback()
 			return fmt.Errorf("failed to delete main path for board %d: %w", boardID, err)
 		}
@@ -138,7 +118,7 @@ func deleteGroupBoard(db *gorm.DB, firestoreClient *firestore.Client, boardIDs [
 	return nil
 }
 
-func deletePrivateBoard(db *gorm.DB, firestoreClient *firestore.Client, boardIDs []int, userid uint) error {
+func deletePrivateBoard(db *gorm.DB, fb store.Store, boardIDs []int, userid uint) error {
 	var user model.User
 	if err := db.First(&user, userid).Error; err != nil {
 		return err
@@ -158,7 +138,7 @@ func deletePrivateBoard(db *gorm.DB, firestoreClient *firestore.Client, boardIDs
 			return err
 		}
 		for _, task := range tasks {
-			if err := deleteNotificationsByTaskID(db, firestoreClient, task.TaskID, email); err != nil {
+			if err := deleteNotificationsByTaskID(db, fb, task.TaskID, email); err != nil {
 				return err
 			}
 		}
@@ -182,7 +162,7 @@ func queryTaskByBoardID(db *gorm.DB, boardID int) ([]model.Tasks, error) {
 	return tasks, nil
 }
 
-func deleteNotificationsByTaskID(db *gorm.DB, fb *firestore.Client, taskID int, email string) error {
+func deleteNotificationsByTaskID(db *gorm.DB, fb store.Store, taskID int, email string) error {
 	var notifications []model.Notification
 	if err := db.Where("task_id = ?", taskID).Find(&notifications).Error; err != nil {
 		return fmt.Errorf("failed to find notifications: %w", err)
@@ -200,18 +180,15 @@ func deleteNotificationsByTaskID(db *gorm.DB, fb *firestore.Client, taskID int,
 			tx.Rollback()
 		}
 	}()
-	batch := fb.Batch()
 
-	// Add all deletions to batch
+	// รวมทุกรายการแล้วลบใน batch เดียว
+	notificationIDs := make([]int, 0, len(notifications))
 	for _, notification := range notifications {
-		notificationIDStr := strconv.Itoa(notification.NotificationID)
-		firestorePath := fmt.Sprintf("Notifications/%s/Tasks/%s", email, notificationIDStr)
-		docRef := fb.Doc(firestorePath)
-		batch.Delete(docRef)
+		notificationIDs = append(notificationIDs, notification.NotificationID)
 	}
 
 	// Execute Firestore batch operation
-	if _, err := batch.Commit(ctx); err != nil {
+	if err := fb.Notifications().DeleteTasks(ctx, email, notificationIDs...); err != nil {
 		tx.Rollback()
 		return fmt.Errorf("failed to delete notifications from Firestore: %w", err)
 	}
@@ -224,7 +201,7 @@ func deleteNotificationsByTaskID(db *gorm.DB, fb *firestore.Client, taskID int,
 	return nil
 }
 
-func deleteSubCollectionByTaskID(db *gorm.DB, fb *firestore.Client, taskID int) error {
+func deleteSubCollectionByTaskID(db *gorm.DB, fb store.Store, taskID int) error {
 	ctx := context.Background()
 
 	// Delete Notifications
@@ -233,8 +210,7 @@ func deleteSubCollectionByTaskID(db *gorm.DB, fb *firestore.Client, taskID int)
 		return fmt.Errorf("failed to find notifications: %w", err)
 	}
 	for _, notification := range notifications {
-		docPath := fmt.Sprintf("BoardTasks/%d/Notifications/%d", taskID, notification.NotificationID) // หรือ notification.NotificationID ขึ้นอยู่กับ field name
-		if _, err := fb.Doc(docPath).Delete(ctx); err != nil {
+		if err := fb.BoardTasks().DeleteItem(ctx, taskID, store.TaskNotifications, strconv.Itoa(notification.NotificationID)); err != nil {
 			return fmt.Errorf("failed to delete notification from Firestore: %w", err)
 		}
 	}
@@ -245,8 +221,7 @@ func deleteSubCollectionByTaskID(db *gorm.DB, fb *firestore.Client, taskID int)
 		return fmt.Errorf("failed to find attachments: %w", err)
 	}
 	for _, attachment := range attachments {
-		docPath := fmt.Sprintf("BoardTasks/%d/Attachments/%d", taskID, attachment.AttachmentID) // หรือ attachment.AttachmentID
-		if _, err := fb.Doc(docPath).Delete(ctx); err != nil {
+		if err := fb.BoardTasks().DeleteItem(ctx, taskID, store.TaskAttachments, strconv.Itoa(attachment.AttachmentID)); err != nil {
 			return fmt.Errorf("failed to delete attachment from Firestore: %w", err)
 		}
 	}
@@ -257,14 +232,12 @@ func deleteSubCollectionByTaskID(db *gorm.DB, fb *firestore.Client, taskID int)
 		return fmt.Errorf("failed to find checklists: %w", err)
 	}
 	for _, checklist := range checklists {
-		docPath := fmt.Sprintf("BoardTasks/%d/Checklist/%d", taskID, checklist.ChecklistID) // หรือ checklist.ChecklistID
-		if _, err := fb.Doc(docPath).Delete(ctx); err != nil {
+		if err := fb.BoardTasks().DeleteItem(ctx, taskID, store.TaskChecklist, strconv.Itoa(checklist.ChecklistID)); err != nil {
 			return fmt.Errorf("failed to delete checklist from Firestore: %w", err)
 		}
 	}
 
-	docPath := fmt.Sprintf("BoardTasks/%d", taskID)
-	if _, err := fb.Doc(docPath).Delete(ctx); err != nil {
+	if err := fb.BoardTasks().DeleteAll(ctx, taskID); err != nil {
 		return fmt.Errorf("failed to delete boardtask from Firestore: %w", err)
 	}
 
@@ -279,7 +252,7 @@ func queryBoarduserByBoardID(db *gorm.DB, boardID int) ([]model.BoardUser, error
 	return boardUsers, nil
 }
 
-func deleteMainPathByBoardID(db *gorm.DB, fb *firestore.Client, boardID int) error {
+func deleteMainPathByBoardID(db *gorm.DB, fb store.Store, boardID int) error {
 	ctx := context.Background()
 	// หาBoarduser
 	boardUsers, err := queryBoarduserByBoardID(db, boardID)
@@ -288,8 +261,7 @@ func deleteMainPathByBoardID(db *gorm.DB, fb *firestore.Client, boardID int) err
 	}
 	// ลบBoarduser
 	for _, bu := range boardUsers {
-		docPath := fmt.Sprintf("Boards/%d/BoardUsers/%d", boardID, bu.BoardUserID) // หรือ notification.NotificationID ขึ้นอยู่กับ field name
-		if _, err := fb.Doc(docPath).Delete(ctx); err != nil {
+		if err := fb.Boards().DeleteUser(ctx, boardID, bu.BoardUserID); err != nil {
 			return fmt.Errorf("failed to delete notification from Firestore: %w", err)
 		}
 	}
@@ -298,14 +270,12 @@ func deleteMainPathByBoardID(db *gorm.DB, fb *firestore.Client, boardID int) err
 		return fmt.Errorf("failed to get board users for board %d: %w", boardID, err)
 	}
 	for _, task := range taskid {
-		docPath := fmt.Sprintf("Boards/%d/Tasks/%d", boardID, task.TaskID) // หรือ notification.NotificationID ขึ้นอยู่กับ field name
-		if _, err := fb.Doc(docPath).Delete(ctx); err != nil {
+		if err := fb.Boards().DeleteTask(ctx, boardID, task.TaskID); err != nil {
 			return fmt.Errorf("failed to delete notification from Firestore: %w", err)
 		}
 	}
 	// ลบmainboard
-	docPath := fmt.Sprintf("Boards/%d", boardID)
-	if _, err := fb.Doc(docPath).Delete(ctx); err != nil {
+	if err := fb.Boards().Delete(ctx, boardID); err != nil {
 		return fmt.Errorf("failed to delete main path for board %d: %w", boardID, err)
 	}
 	return nil
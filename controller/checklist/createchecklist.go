@@ -3,52 +3,53 @@ package checklist
 import (
 	"context"
 	"errors"
-	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func CreateChecklistController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.POST("/checklist/:taskid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		Checklist(c, db, firestoreClient)
-	})
+func CreateChecklistController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	create := func(c *gin.Context) { Checklist(c, db, fb) }
+	router.POST("/v1/tasks/:taskid/checklists", middleware.AccessTokenMiddleware(), create)
+	router.POST("/checklist/:taskid", middleware.Deprecated("/v1/tasks/:taskid/checklists"), middleware.AccessTokenMiddleware(), create)
 }
 
-func Checklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func Checklist(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 
 	taskIDStr := c.Param("taskid")
 	if taskIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Task ID is required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "required"))
 		return
 	}
 
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	var req dto.CreateChecklistTaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	var user model.User
 	if err := db.Where("user_id = ?", userId).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -64,9 +65,9 @@ func Checklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		Where("task_id = ?", taskID).
 		First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -81,7 +82,7 @@ func Checklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 			hasPermission = true
 			shouldSaveToFirestore = false // Task ส่วนตัว ไม่ sync Firestore
 		} else {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: not the owner of this personal task"})
+			apperror.Respond(c, apperror.NotTaskOwner)
 			return
 		}
 	} else {
@@ -93,9 +94,9 @@ func Checklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 				var board model.Board
 				if err := db.Where("board_id = ? AND create_by = ?", task.BoardID, userId).First(&board).Error; err != nil {
 					if errors.Is(err, gorm.ErrRecordNotFound) {
-						c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: not a board member or board owner"})
+						apperror.Respond(c, apperror.NotBoardMember)
 					} else {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board ownership"})
+						apperror.Respond(c, apperror.Internal)
 					}
 					return
 				}
@@ -103,7 +104,7 @@ func Checklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 				hasPermission = true
 				shouldSaveToFirestore = false
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch board user"})
+				apperror.Respond(c, apperror.Internal)
 				return
 			}
 		} else {
@@ -116,7 +117,7 @@ func Checklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	// เริ่ม transaction
 	tx := db.Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		apperror.Respond(c, apperror.Internal.Wrap(tx.Error))
 		return
 	}
 	defer func() {
@@ -133,14 +134,14 @@ func Checklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 
 	if err := tx.Create(&newChecklist).Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create checklist"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -150,10 +151,9 @@ func Checklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 		defer cancel()
 
 		checklistIDInt := int(newChecklist.ChecklistID)
-		if err := saveTaskToFirestore(ctx, firestoreClient, &newChecklist, checklistIDInt); err != nil {
+		if err := saveTaskToFirestore(ctx, fb, &newChecklist, checklistIDInt); err != nil {
 			// Log error แต่ไม่ return เพราะ database บันทึกสำเร็จแล้ว
-			// ควรใช้ proper logging system
-			fmt.Printf("Warning: Failed to save checklist to Firestore: %v\n", err)
+			logging.FromContext(c).Warn("failed to save checklist to Firestore", "checklist_id", checklistIDInt, "error", err)
 		}
 	}
 
@@ -166,9 +166,7 @@ func Checklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
 	c.JSON(http.StatusCreated, response)
 }
 
-func saveTaskToFirestore(ctx context.Context, client *firestore.Client, checklist *model.Checklist, ChecklistID int) error {
-	taskPath := fmt.Sprintf("BoardTasks/%d/Checklist/%d", checklist.TaskID, ChecklistID)
-
+func saveTaskToFirestore(ctx context.Context, fb store.Store, checklist *model.Checklist, ChecklistID int) error {
 	taskData := map[string]interface{}{
 		"checklist_id":   checklist.ChecklistID,
 		"task_id":        checklist.TaskID,
@@ -177,6 +175,5 @@ func saveTaskToFirestore(ctx context.Context, client *firestore.Client, checklis
 		"updatedAt":      time.Now(),
 	}
 
-	_, err := client.Doc(taskPath).Set(ctx, taskData)
-	return err
+	return fb.BoardTasks().SetItem(ctx, checklist.TaskID, store.TaskChecklist, strconv.Itoa(ChecklistID), taskData)
 }
@@ -4,46 +4,51 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func DeleteChecklistController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.DELETE("/checklist/:taskid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		DeleteChecklist(c, db, firestoreClient)
-	})
-	router.DELETE("/checklist/:taskid/:checklistid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		DeleteSingleChecklist(c, db, firestoreClient)
-	})
+func DeleteChecklistController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	deleteMany := func(c *gin.Context) { DeleteChecklist(c, db, fb) }
+	deleteOne := func(c *gin.Context) { DeleteSingleChecklist(c, db, fb) }
+
+	router.DELETE("/v1/tasks/:taskid/checklists", middleware.AccessTokenMiddleware(), deleteMany)
+	router.DELETE("/v1/tasks/:taskid/checklists/:checklistid", middleware.AccessTokenMiddleware(), deleteOne)
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	router.DELETE("/checklist/:taskid", middleware.Deprecated("/v1/tasks/:taskid/checklists"), middleware.AccessTokenMiddleware(), deleteMany)
+	router.DELETE("/checklist/:taskid/:checklistid", middleware.Deprecated("/v1/tasks/:taskid/checklists/:checklistid"), middleware.AccessTokenMiddleware(), deleteOne)
 }
 
-func DeleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteChecklist(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	taskIDStr := c.Param("taskid")
 
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	var req dto.DeleteChecklistRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	checklistIDs := req.ChecklistIDs
 	if len(checklistIDs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No checklist IDs provided"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("checklist_id", "required"))
 		return
 	}
 
@@ -52,11 +57,11 @@ func DeleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	if err := db.
 		Where("checklist_id IN ? AND task_id = ?", checklistIDs, taskID).
 		Find(&existingChecklists).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checklists"})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 	if len(existingChecklists) != len(checklistIDs) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Some checklists not found or not belong to specified task"})
+		apperror.Respond(c, apperror.ChecklistNotFound)
 		return
 	}
 
@@ -71,9 +76,9 @@ func DeleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 		Where("task_id = ?", taskID).
 		First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -95,16 +100,16 @@ func DeleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 				var board model.Board
 				if err := db.Where("board_id = ? AND create_by = ?", task.BoardID, userID).First(&board).Error; err != nil {
 					if errors.Is(err, gorm.ErrRecordNotFound) {
-						c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: not a board member or owner"})
+						apperror.Respond(c, apperror.NotBoardMember)
 					} else {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board ownership"})
+						apperror.Respond(c, apperror.Internal)
 					}
 					return
 				}
 				hasPermission = true
 				shouldDeleteFromFirestore = false
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch board user"})
+				apperror.Respond(c, apperror.Internal)
 				return
 			}
 		} else {
@@ -114,7 +119,7 @@ func DeleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	}
 
 	if !hasPermission {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		apperror.Respond(c, apperror.Forbidden)
 		return
 	}
 
@@ -131,7 +136,7 @@ func DeleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	})
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete checklists"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -142,9 +147,9 @@ func DeleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 
 		for _, checklist := range existingChecklists {
 			docID := strconv.Itoa(int(checklist.ChecklistID))
-			_, err := firestoreClient.Collection("BoardTasks").Doc(taskIDStr).Collection("Checklist").Doc(docID).Delete(ctx)
+			err := fb.BoardTasks().DeleteItem(ctx, taskID, store.TaskChecklist, docID)
 			if err != nil {
-				fmt.Printf("⚠️ Failed to delete checklist %s from Firestore: %v\n", docID, err)
+				logging.FromContext(c).Warn("failed to delete checklist from Firestore", "checklist_id", docID, "error", err)
 			}
 		}
 	}
@@ -156,20 +161,20 @@ func DeleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	})
 }
 
-func DeleteSingleChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteSingleChecklist(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	taskIDStr := c.Param("taskid")
 	checklistIDStr := c.Param("checklistid")
 
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	checklistID, err := strconv.Atoi(checklistIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("checklist_id", "invalid"))
 		return
 	}
 
@@ -178,9 +183,9 @@ func DeleteSingleChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firesto
 	if err := db.Where("checklist_id = ? AND task_id = ?", checklistID, taskID).
 		First(&checklist).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Checklist not found or not belong to specified task"})
+			apperror.Respond(c, apperror.ChecklistNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checklist"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -197,9 +202,9 @@ func DeleteSingleChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firesto
 		Where("task_id = ?", taskID).
 		First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -220,16 +225,16 @@ func DeleteSingleChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firesto
 				var board model.Board
 				if err := db.Where("board_id = ? AND create_by = ?", task.BoardID, userID).First(&board).Error; err != nil {
 					if errors.Is(err, gorm.ErrRecordNotFound) {
-						c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: not a board member or owner"})
+						apperror.Respond(c, apperror.NotBoardMember)
 					} else {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board ownership"})
+						apperror.Respond(c, apperror.Internal)
 					}
 					return
 				}
 				hasPermission = true
 				shouldDeleteFromFirestore = false
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch board user"})
+				apperror.Respond(c, apperror.Internal)
 				return
 			}
 		} else {
@@ -239,7 +244,7 @@ func DeleteSingleChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firesto
 	}
 
 	if !hasPermission {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		apperror.Respond(c, apperror.Forbidden)
 		return
 	}
 
@@ -255,7 +260,7 @@ func DeleteSingleChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firesto
 		return nil
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete checklist"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -265,9 +270,9 @@ func DeleteSingleChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firesto
 		defer cancel()
 
 		docID := strconv.Itoa(checklistID)
-		_, err := firestoreClient.Collection("BoardTasks").Doc(taskIDStr).Collection("Checklist").Doc(docID).Delete(ctx)
+		err := fb.BoardTasks().DeleteItem(ctx, taskID, store.TaskChecklist, docID)
 		if err != nil {
-			fmt.Printf("⚠️ Failed to delete checklist %s from Firestore: %v\n", docID, err)
+			logging.FromContext(c).Warn("failed to delete checklist from Firestore", "checklist_id", docID, "error", err)
 		}
 	}
 
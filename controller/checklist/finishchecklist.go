@@ -2,41 +2,47 @@ package checklist
 
 import (
 	"context"
-	"fmt"
+	"mydayplanner/apperror"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func FinishChecklistController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.PUT("/checklistfinish/:checklistid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		CompleteChecklist(c, db, firestoreClient)
-	})
+func FinishChecklistController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	toggle := func(c *gin.Context) { CompleteChecklist(c, db, fb) }
+	router.PUT("/v1/tasks/:taskid/checklists/:checklistid/finish", middleware.AccessTokenMiddleware(), toggle)
+	router.PUT("/checklistfinish/:checklistid", middleware.Deprecated("/v1/tasks/:taskid/checklists/:checklistid/finish"), middleware.AccessTokenMiddleware(), toggle)
 }
 
-func CompleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func CompleteChecklist(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	checklistIDStr := c.Param("checklistid")
 
 	checklistID, err := strconv.Atoi(checklistIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("checklist_id", "invalid"))
 		return
 	}
 
 	var currentChecklist model.Checklist
 	if err := db.Where("checklist_id = ?", checklistID).First(&currentChecklist).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Checklist not found"})
+			apperror.Respond(c, apperror.ChecklistNotFound)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get checklist data"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	// route /v1 ระบุ task ใน path ด้วย checklist ต้องอยู่ใน task นั้น
+	if taskIDStr := c.Param("taskid"); taskIDStr != "" && taskIDStr != strconv.Itoa(currentChecklist.TaskID) {
+		apperror.Respond(c, apperror.ChecklistNotFound)
 		return
 	}
 
@@ -50,7 +56,7 @@ func CompleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 		Select("task_id, board_id, create_by").
 		Where("task_id = ?", currentChecklist.TaskID).
 		First(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task for checklist"})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
@@ -76,7 +82,7 @@ func CompleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 
 	// อัปเดตใน database
 	if err := db.Model(&currentChecklist).Update("status", newStatus).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update checklist status"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -85,14 +91,12 @@ func CompleteChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		_, err := firestoreClient.Collection("BoardTasks").Doc(strconv.Itoa(task.TaskID)).Collection("Checklist").
-			Doc(strconv.Itoa(checklistID)).
-			Update(ctx, []firestore.Update{
-				{Path: "status", Value: newStatus},
-				{Path: "updatedAt", Value: time.Now()},
-			})
+		err := fb.BoardTasks().UpdateItem(ctx, task.TaskID, store.TaskChecklist, strconv.Itoa(checklistID), store.Fields{
+			"status":    newStatus,
+			"updatedAt": time.Now(),
+		})
 		if err != nil {
-			fmt.Printf("⚠️ Firestore update failed for checklist %d: %v\n", checklistID, err)
+			logging.FromContext(c).Warn("failed to update checklist in Firestore", "checklist_id", checklistID, "error", err)
 		}
 	}
 
@@ -3,27 +3,28 @@ package checklist
 import (
 	"context"
 	"errors"
-	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func UpdateChecklistController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.PUT("/checklist/:taskid/:checklistid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		UpdateChecklist(c, db, firestoreClient)
-	})
+func UpdateChecklistController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	update := func(c *gin.Context) { UpdateChecklist(c, db, fb) }
+	router.PUT("/v1/tasks/:taskid/checklists/:checklistid", middleware.AccessTokenMiddleware(), update)
+	router.PUT("/checklist/:taskid/:checklistid", middleware.Deprecated("/v1/tasks/:taskid/checklists/:checklistid"), middleware.AccessTokenMiddleware(), update)
 }
 
-func UpdateChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func UpdateChecklist(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 	taskIDStr := c.Param("taskid")
 	checklistIDStr := c.Param("checklistid")
@@ -31,32 +32,32 @@ func UpdateChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	// แปลง taskID เป็น int
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	// แปลง checklistID เป็น int
 	checklistID, err := strconv.Atoi(checklistIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid checklist ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("checklist_id", "invalid"))
 		return
 	}
 
 	var req dto.UpdateChecklistRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// ตรวจสอบความยาวของชื่อ checklist
 	if strings.TrimSpace(req.ChecklistName) == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Checklist name is required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("checklist_name", "required"))
 		return
 	}
 
 	checklistName := strings.TrimSpace(req.ChecklistName)
 	if len(checklistName) > 255 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Checklist name is too long (max 255 characters)"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("checklist_name", "max=255"))
 		return
 	}
 
@@ -72,9 +73,9 @@ func UpdateChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 		Where("checklist_id = ? AND task_id = ?", checklistID, taskID).
 		First(&existingChecklist).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Checklist not found or not belong to specified task"})
+			apperror.Respond(c, apperror.ChecklistNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch checklist"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -91,9 +92,9 @@ func UpdateChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 		Where("task_id = ?", taskID).
 		First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -118,9 +119,9 @@ func UpdateChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 				var board model.Board
 				if err := db.Where("board_id = ? AND create_by = ?", task.BoardID, userId).First(&board).Error; err != nil {
 					if errors.Is(err, gorm.ErrRecordNotFound) {
-						c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: You are not the board owner or member"})
+						apperror.Respond(c, apperror.NotBoardMember)
 					} else {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board ownership"})
+						apperror.Respond(c, apperror.Internal)
 					}
 					return
 				}
@@ -128,7 +129,7 @@ func UpdateChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 				canUpdate = true
 				shouldUpdateFirestore = false
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board membership"})
+				apperror.Respond(c, apperror.Internal)
 				return
 			}
 		} else {
@@ -139,12 +140,12 @@ func UpdateChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	}
 
 	if !canUpdate {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		apperror.Respond(c, apperror.Forbidden)
 		return
 	}
 
 	// Variables สำหรับ rollback
-	var firestoreDocRef *firestore.DocumentRef
+	checklistDocID := strconv.Itoa(checklistID)
 	var firestoreOriginalData map[string]interface{}
 	var firestoreUpdated = false
 
@@ -153,33 +154,23 @@ func UpdateChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
-		firestoreDocRef = firestoreClient.Collection("Checklist").Doc(fmt.Sprintf("%d", checklistID))
-
 		// ดึงข้อมูลเดิมจาก Firestore เพื่อใช้ในการ rollback
-		docSnap, err := firestoreDocRef.Get(ctx)
+		originalData, err := fb.BoardTasks().GetItem(ctx, taskID, store.TaskChecklist, checklistDocID)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get original Firestore data"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
-
-		if docSnap.Exists() {
-			firestoreOriginalData = docSnap.Data()
-		}
+		firestoreOriginalData = originalData
 
 		// อัพเดท Firestore
-		firestoreUpdates := []firestore.Update{
-			{
-				Path:  "checklist_name",
-				Value: checklistName,
-			}, {
-				Path:  "updatedAt",
-				Value: firestore.ServerTimestamp,
-			},
+		firestoreUpdates := store.Fields{
+			"checklist_name": checklistName,
+			"updatedAt":      store.ServerTimestamp,
 		}
 
-		_, err = firestoreDocRef.Update(ctx, firestoreUpdates)
+		err = fb.BoardTasks().UpdateItem(ctx, taskID, store.TaskChecklist, checklistDocID, firestoreUpdates)
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update checklist in Firestore"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 		firestoreUpdated = true
@@ -212,44 +203,41 @@ func UpdateChecklist(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 
 	// Step 3: หาก Database update ล้มเหลว และได้อัพเดท Firestore แล้ว ให้ rollback Firestore
 	if err != nil {
-		if firestoreUpdated && firestoreDocRef != nil {
+		if firestoreUpdated {
 			rollbackCtx, rollbackCancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer rollbackCancel()
 
 			// Rollback Firestore
 			if firestoreOriginalData != nil {
 				if originalChecklistName, exists := firestoreOriginalData["checklist_name"]; exists {
-					rollbackUpdates := []firestore.Update{
-						{
-							Path:  "checklist_name",
-							Value: originalChecklistName,
-						},
+					rollbackUpdates := store.Fields{
+						"checklist_name": originalChecklistName,
 					}
 
-					_, rollbackErr := firestoreDocRef.Update(rollbackCtx, rollbackUpdates)
+					rollbackErr := fb.BoardTasks().UpdateItem(rollbackCtx, taskID, store.TaskChecklist, checklistDocID, rollbackUpdates)
 					if rollbackErr != nil {
 						// Log rollback error but continue with the main error response
-						fmt.Printf("CRITICAL: Failed to rollback Firestore for checklist %d: %v\n", checklistID, rollbackErr)
+						logging.FromContext(c).Error("Firestore rollback failed", "checklist_id", checklistID, "error", rollbackErr)
 					} else {
-						fmt.Printf("INFO: Successfully rolled back Firestore for checklist %d\n", checklistID)
+						logging.FromContext(c).Info("Firestore rolled back", "checklist_id", checklistID)
 					}
 				}
 			} else {
 				// ถ้าไม่มีข้อมูลเดิม ให้ลบ document ออก
-				_, rollbackErr := firestoreDocRef.Delete(rollbackCtx)
+				rollbackErr := fb.BoardTasks().DeleteItem(rollbackCtx, taskID, store.TaskChecklist, checklistDocID)
 				if rollbackErr != nil {
-					fmt.Printf("CRITICAL: Failed to delete Firestore document for checklist %d during rollback: %v\n", checklistID, rollbackErr)
+					logging.FromContext(c).Error("Firestore rollback delete failed", "checklist_id", checklistID, "error", rollbackErr)
 				} else {
-					fmt.Printf("INFO: Successfully deleted Firestore document for checklist %d during rollback\n", checklistID)
+					logging.FromContext(c).Info("Firestore document deleted during rollback", "checklist_id", checklistID)
 				}
 			}
 		}
 
 		// ส่ง error response
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Checklist not found"})
+			apperror.Respond(c, apperror.ChecklistNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update checklist"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -2,13 +2,15 @@ package controller
 
 import (
 	"mydayplanner/controller/user"
+	"mydayplanner/middleware"
+	"mydayplanner/ratelimit"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 func GetemailCTL(router *gin.Engine, db *gorm.DB) {
-	router.POST("/email", func(c *gin.Context) {
-		user.GetEmail(c, db)
-	})
+	lookup := func(c *gin.Context) { user.GetEmail(c, db) }
+	router.POST("/v1/users/lookup", middleware.RateLimit(ratelimit.ClassAuth), lookup)
+	router.POST("/email", middleware.Deprecated("/v1/users/lookup"), middleware.RateLimit(ratelimit.ClassAuth), lookup)
 }
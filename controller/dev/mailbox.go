@@ -0,0 +1,40 @@
+// Package dev route สำหรับพัฒนาบนเครื่องเท่านั้น ลงทะเบียนตาม config จึงไม่มีใน production และไม่อยู่ใน openapi.json
+package dev
+
+import (
+	"mydayplanner/mail"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MailboxController เปิดดูอีเมลที่ mail.Memory เก็บไว้ ใช้แทนกล่องจดหมายจริงตอนทดสอบ OTP
+// ไม่ต้อง login จึงลงทะเบียนเฉพาะเมื่อ DEV_MAILBOX=true ซึ่ง config รับเฉพาะ APP_ENV=development และ MAIL_SENDER=memory
+func MailboxController(router *gin.Engine, box *mail.Memory) {
+	routes := router.Group("/v1/dev/mailbox")
+	{
+		routes.GET("", func(c *gin.Context) { ListMailbox(c, box) })
+		routes.DELETE("", func(c *gin.Context) { ClearMailbox(c, box) })
+	}
+}
+
+// ListMailbox คืนอีเมลจากเก่าไปใหม่ กรองด้วย ?to=
+func ListMailbox(c *gin.Context, box *mail.Memory) {
+	messages := []gin.H{}
+	for _, m := range box.Messages(c.Query("to")) {
+		messages = append(messages, gin.H{
+			"from":    m.From,
+			"to":      m.To,
+			"subject": m.Subject,
+			"text":    m.Text,
+			"html":    m.HTML,
+			"sent_at": m.SentAt,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
+
+func ClearMailbox(c *gin.Context, box *mail.Memory) {
+	box.Reset()
+	c.Status(http.StatusNoContent)
+}
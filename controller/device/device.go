@@ -0,0 +1,82 @@
+package device
+
+import (
+	"errors"
+	"mydayplanner/apperror"
+	"mydayplanner/dto"
+	"mydayplanner/middleware"
+	"mydayplanner/model"
+	"mydayplanner/pushtoken"
+	"mydayplanner/store"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DeviceController ทะเบียนเครื่องที่รับ push ของผู้ใช้ แอปเรียก POST ทุกครั้งที่เปิดหรือได้ token ใหม่
+// และ DELETE ตอน sign out ผู้ใช้หนึ่งคนมีได้หลายเครื่อง
+func DeviceController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	list := func(c *gin.Context) { ListDevices(c, db) }
+	register := func(c *gin.Context) { RegisterDevice(c, db) }
+	unregister := func(c *gin.Context) { UnregisterDevice(c, db, fb) }
+
+	routes := router.Group("/v1/devices", middleware.AccessTokenMiddleware())
+	{
+		routes.GET("", list)
+		routes.POST("", register)
+		routes.DELETE("", unregister)
+	}
+}
+
+func ListDevices(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+
+	devices, err := pushtoken.Devices(c.Request.Context(), db, int(userId))
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	c.JSON(200, gin.H{"devices": devices})
+}
+
+func RegisterDevice(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+	var req dto.RegisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+
+	device, err := pushtoken.Register(c.Request.Context(), db, int(userId), req.Token, req.Platform, req.AppVersion, time.Now())
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	c.JSON(200, device)
+}
+
+func UnregisterDevice(c *gin.Context, db *gorm.DB, fb store.Store) {
+	userId := c.MustGet("userId").(uint)
+	var req dto.UnregisterDeviceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+
+	var user model.User
+	if err := db.First(&user, userId).Error; err != nil {
+		apperror.Respond(c, apperror.UserNotFound)
+		return
+	}
+	err := pushtoken.Unregister(c.Request.Context(), db, fb, user, req.Token)
+	if errors.Is(err, pushtoken.ErrUnknownDevice) {
+		apperror.Respond(c, apperror.DeviceNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	c.JSON(200, gin.H{"message": "Device unregistered successfully"})
+}
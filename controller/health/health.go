@@ -0,0 +1,157 @@
+package health
+
+import (
+	"context"
+	"mydayplanner/leader"
+	"mydayplanner/store"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// checkTimeout เวลาสูงสุดของแต่ละการตรวจใน /readyz
+const checkTimeout = 2 * time.Second
+
+// JobTracker เก็บเวลารันล่าสุดของ cron job ให้ /readyz ตรวจว่า job ยังทำงานอยู่
+type JobTracker struct {
+	mu          sync.RWMutex
+	startedAt   time.Time
+	lastRun     time.Time
+	lastSuccess time.Time
+	lastError   string
+}
+
+// NewJobTracker เริ่มนับ lag จากเวลาที่สร้าง จนกว่า job จะสำเร็จครั้งแรก
+func NewJobTracker() *JobTracker {
+	return &JobTracker{startedAt: time.Now()}
+}
+
+// Record บันทึกผลของการรันหนึ่งรอบ
+func (t *JobTracker) Record(at time.Time, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.lastRun = at
+	if err != nil {
+		t.lastError = err.Error()
+		return
+	}
+	t.lastSuccess = at
+	t.lastError = ""
+}
+
+// JobStatus สถานะของ job ณ เวลาหนึ่ง
+type JobStatus struct {
+	LastRun     *time.Time `json:"last_run,omitempty"`
+	LastSuccess *time.Time `json:"last_success,omitempty"`
+	LastError   string     `json:"last_error,omitempty"`
+	LagSeconds  float64    `json:"lag_seconds"`
+}
+
+// Status คืนสถานะ โดย lag คือเวลานับจากครั้งล่าสุดที่สำเร็จ (หรือจากตอนเริ่ม ถ้ายังไม่เคยสำเร็จ)
+func (t *JobTracker) Status(now time.Time) JobStatus {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var st JobStatus
+	since := t.startedAt
+	if !t.lastRun.IsZero() {
+		lastRun := t.lastRun
+		st.LastRun = &lastRun
+	}
+	if !t.lastSuccess.IsZero() {
+		lastSuccess := t.lastSuccess
+		st.LastSuccess = &lastSuccess
+		since = lastSuccess
+	}
+	st.LastError = t.lastError
+	st.LagSeconds = now.Sub(since).Seconds()
+	return st
+}
+
+type check struct {
+	Status    string         `json:"status"`
+	Error     string         `json:"error,omitempty"`
+	Scheduler *JobStatus     `json:"scheduler,omitempty"`
+	Leader    *leader.Status `json:"leader,omitempty"`
+}
+
+// HealthController /healthz ตอบว่า process ยังมีชีวิต ส่วน /readyz ตรวจ MySQL, Firestore และ cron
+// maxLag คือเวลาที่ยอมให้ SendNotificationJob ไม่สำเร็จได้ก่อนถือว่า instance ไม่พร้อม
+// elector เป็น nil เมื่อไม่มีการเลือก leader ถือว่า instance นี้รัน scheduler เสมอ
+func HealthController(router *gin.Engine, db *gorm.DB, fb store.Store, job *JobTracker, elector *leader.Elector, maxLag time.Duration) {
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/readyz", func(c *gin.Context) {
+		Readiness(c, db, fb, job, elector, maxLag)
+	})
+}
+
+func Readiness(c *gin.Context, db *gorm.DB, fb store.Store, job *JobTracker, elector *leader.Elector, maxLag time.Duration) {
+	checks := map[string]check{
+		"mysql":     checkMySQL(c.Request.Context(), db),
+		"firestore": checkFirestore(c.Request.Context(), fb),
+		"scheduler": checkScheduler(job, elector, maxLag),
+	}
+
+	ready := true
+	for _, ch := range checks {
+		if ch.Status != "ok" && ch.Status != "standby" {
+			ready = false
+		}
+	}
+
+	if !ready {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "unavailable", "checks": checks})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "checks": checks})
+}
+
+func checkMySQL(ctx context.Context, db *gorm.DB) check {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	sqlDB, err := db.DB()
+	if err != nil {
+		return check{Status: "error", Error: err.Error()}
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return check{Status: "error", Error: err.Error()}
+	}
+	return check{Status: "ok"}
+}
+
+func checkFirestore(ctx context.Context, fb store.Store) check {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	if err := fb.Ping(ctx); err != nil {
+		return check{Status: "error", Error: err.Error()}
+	}
+	return check{Status: "ok"}
+}
+
+// checkScheduler instance ที่ไม่ได้เป็น leader ไม่รัน job จึงไม่ตรวจ lag
+// ส่วน leader นับ lag ไม่เกินเวลาที่ได้เป็น leader เพื่อไม่ให้ instance ที่เพิ่งรับช่วงต่อถูกนับว่าค้าง
+func checkScheduler(job *JobTracker, elector *leader.Elector, maxLag time.Duration) check {
+	now := time.Now()
+	st := job.Status(now)
+	ch := check{Status: "ok", Scheduler: &st}
+	if elector != nil {
+		ls := elector.Status()
+		ch.Leader = &ls
+		if !ls.Leader {
+			ch.Status = "standby"
+			return ch
+		}
+		if held := now.Sub(*ls.Since).Seconds(); held < st.LagSeconds {
+			st.LagSeconds = held
+		}
+	}
+	if st.LagSeconds > maxLag.Seconds() {
+		ch.Status = "stale"
+		ch.Error = "notification job has not succeeded within " + maxLag.String()
+	}
+	return ch
+}
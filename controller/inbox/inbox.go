@@ -0,0 +1,126 @@
+package inbox
+
+import (
+	"errors"
+	"mydayplanner/apperror"
+	"mydayplanner/dto"
+	"mydayplanner/inbox"
+	"mydayplanner/middleware"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// InboxController กล่องแจ้งเตือนในแอปของผู้ใช้ที่ sign in อยู่
+func InboxController(router *gin.Engine, db *gorm.DB) {
+	list := func(c *gin.Context) { ListInbox(c, db) }
+	unread := func(c *gin.Context) { UnreadCount(c, db) }
+	readAll := func(c *gin.Context) { MarkAllRead(c, db) }
+	read := func(c *gin.Context) { MarkRead(c, db) }
+	dismiss := func(c *gin.Context) { Dismiss(c, db) }
+
+	routes := router.Group("/v1/inbox", middleware.AccessTokenMiddleware())
+	{
+		routes.GET("", list)
+		routes.GET("/unread-count", unread)
+		routes.PUT("/read", readAll)
+		routes.PUT("/:itemid/read", read)
+		routes.DELETE("/:itemid", dismiss)
+	}
+}
+
+// ListInbox next_before ใช้เป็น before ของหน้าถัดไป (null เมื่อหมดแล้ว)
+func ListInbox(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+	var req dto.InboxQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+	limit := req.Limit
+	if limit == 0 {
+		limit = inbox.DefaultLimit
+	}
+
+	items, err := inbox.List(c.Request.Context(), db, int(userId), req.Unread, req.Before, limit)
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	var next *int64
+	if len(items) == limit {
+		next = &items[len(items)-1].ItemID
+	}
+	c.JSON(200, gin.H{"items": items, "next_before": next})
+}
+
+func UnreadCount(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+
+	n, err := inbox.Unread(c.Request.Context(), db, int(userId))
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	c.JSON(200, gin.H{"unread": n})
+}
+
+func MarkRead(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+	itemID, ok := itemParam(c)
+	if !ok {
+		return
+	}
+
+	err := inbox.MarkRead(c.Request.Context(), db, int(userId), itemID, time.Now())
+	if errors.Is(err, inbox.ErrNotFound) {
+		apperror.Respond(c, apperror.InboxItemNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	c.JSON(200, gin.H{"message": "Inbox item marked as read"})
+}
+
+func MarkAllRead(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+
+	n, err := inbox.MarkAllRead(c.Request.Context(), db, int(userId), time.Now())
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	c.JSON(200, gin.H{"message": "Inbox marked as read", "updated": n})
+}
+
+func Dismiss(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+	itemID, ok := itemParam(c)
+	if !ok {
+		return
+	}
+
+	err := inbox.Dismiss(c.Request.Context(), db, int(userId), itemID, time.Now())
+	if errors.Is(err, inbox.ErrNotFound) {
+		apperror.Respond(c, apperror.InboxItemNotFound)
+		return
+	}
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	c.JSON(200, gin.H{"message": "Inbox item dismissed"})
+}
+
+func itemParam(c *gin.Context) (int64, bool) {
+	itemID, err := strconv.ParseInt(c.Param("itemid"), 10, 64)
+	if err != nil {
+		apperror.Respond(c, apperror.InvalidInput.WithField("item_id", "invalid"))
+		return 0, false
+	}
+	return itemID, true
+}
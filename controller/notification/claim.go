@@ -0,0 +1,210 @@
+package notification
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mydayplanner/model"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// claimLease เวลาที่ worker ถือ notification ไว้ระหว่างส่ง ถ้า process ตายกลางทาง worker อื่นรับต่อได้หลังจากนี้
+	claimLease = 5 * time.Minute
+	// maxAttempts จำนวนครั้งที่ลองส่ง transition เดียวกันก่อนเลิกและเลื่อนสถานะไปเลย
+	// กันไม่ให้ reminder ที่ส่งไม่ได้ถูกลองใหม่ทุกนาทีไปตลอด
+	maxAttempts = 5
+)
+
+// transition การส่งแจ้งเตือนหนึ่งครั้งที่พา is_send จาก From ไป To
+// At คือเวลาที่ transition ถึงกำหนด ใช้แยกรอบเมื่อผู้ใช้ตั้งเวลาใหม่หรือ recurring ขึ้นวันใหม่
+type transition struct {
+	Kind string // before, due, snooze, recurring ใช้เป็น type ใน push data และ label ของ metrics
+	From string
+	To   string
+	At   time.Time
+}
+
+// nextTransition transition ที่ถึงกำหนดแล้วของ notification คืน false ถ้ายังไม่มี
+func nextTransition(n model.Notification, now time.Time) (transition, bool) {
+	reached := func(t *time.Time) bool { return t != nil && !t.After(now) }
+	switch n.IsSend {
+	case model.NotifyPending:
+		if n.BeforeDueDate != nil {
+			if reached(n.BeforeDueDate) {
+				return transition{Kind: "before", From: model.NotifyPending, To: model.NotifyBeforeSent, At: *n.BeforeDueDate}, true
+			}
+		} else if reached(n.DueDate) {
+			return transition{Kind: "due", From: model.NotifyPending, To: model.NotifyDueSent, At: *n.DueDate}, true
+		}
+	case model.NotifyBeforeSent:
+		if reached(n.DueDate) {
+			return transition{Kind: "due", From: model.NotifyBeforeSent, To: model.NotifyDueSent, At: *n.DueDate}, true
+		}
+	case model.NotifySnoozed:
+		if reached(n.Snooze) {
+			return transition{Kind: "snooze", From: model.NotifySnoozed, To: model.NotifySnoozeSent, At: *n.Snooze}, true
+		}
+	}
+	return transition{}, false
+}
+
+// recurringTransition แจ้งเตือนงานเลยกำหนดวันละครั้งโดยไม่เปลี่ยน is_send
+// At เป็นเวลารอบของวันนั้น รอบเดียวกันจึงส่งได้ครั้งเดียวแม้ job จะรันหลายครั้งในช่วงเวลานั้น
+func recurringTransition(n model.Notification, at time.Time) transition {
+	return transition{Kind: "recurring", From: n.IsSend, To: n.IsSend, At: at}
+}
+
+var (
+	// errNotClaimed worker อื่นถือ notification อยู่ หรือ is_send เปลี่ยนไปแล้วตั้งแต่ query
+	errNotClaimed = errors.New("notification is claimed elsewhere or has moved on")
+	// errSettled transition นี้เคยเริ่มส่งไปแล้วหรือลองครบ maxAttempts แล้ว
+	// claim เลื่อน is_send ให้โดยไม่ส่งซ้ำ
+	errSettled = errors.New("transition already attempted")
+	// errLostClaim ส่งแล้วแต่ claim หมดอายุหรือ is_send ถูกเปลี่ยนระหว่างส่ง จึงไม่เลื่อนสถานะทับ
+	errLostClaim = errors.New("claim lost before the state was updated")
+)
+
+// attempt การส่งหนึ่งครั้งที่ worker นี้ claim notification ไว้แล้ว ต้องปิดด้วย finish หรือ fail เสมอ
+type attempt struct {
+	db     *gorm.DB
+	owner  string
+	n      model.Notification
+	tr     transition
+	record model.NotificationAttempt
+}
+
+// claim จอง notification สำหรับ tr ด้วย UPDATE แบบมีเงื่อนไข แล้วบันทึก attempt สถานะ sending ก่อนส่ง
+// worker ที่ UPDATE ไม่โดนแถวไหนได้ errNotClaimed จึงไม่มีสอง worker ส่ง transition เดียวกันพร้อมกัน
+// ถ้ารอบก่อนเริ่มส่งไปแล้วโดยไม่รู้ผล (process ตายระหว่างส่ง) จะไม่ส่งซ้ำ ยอมพลาดดีกว่าส่งสองครั้ง
+func claim(ctx context.Context, db *gorm.DB, n model.Notification, tr transition, owner string, now time.Time) (*attempt, error) {
+	db = db.WithContext(ctx)
+	now = now.UTC()
+	res := db.Model(&model.Notification{}).
+		Where("notification_id = ? AND is_send = ? AND (claim_expires_at IS NULL OR claim_expires_at <= ?)", n.NotificationID, tr.From, now).
+		Updates(map[string]any{"claimed_by": owner, "claim_expires_at": now.Add(claimLease)})
+	if res.Error != nil {
+		return nil, fmt.Errorf("claim notification: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return nil, errNotClaimed
+	}
+
+	a := &attempt{db: db, owner: owner, n: n, tr: tr}
+	var prev model.NotificationAttempt
+	err := db.Where("notification_id = ? AND transition = ?", n.NotificationID, tr.Kind).
+		Order("attempt_id DESC").First(&prev).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		a.release()
+		return nil, fmt.Errorf("load previous attempt: %w", err)
+	}
+	number := 1
+	if err == nil && prev.ScheduledFor.Equal(tr.At) {
+		switch {
+		case prev.Status == model.AttemptSending:
+			// worker ก่อนหน้าหายไประหว่างส่ง อาจส่งถึงผู้ใช้แล้ว
+			if err := db.Model(&prev).Updates(map[string]any{"status": model.AttemptInterrupted, "finished_at": now}).Error; err != nil {
+				a.release()
+				return nil, fmt.Errorf("mark attempt interrupted: %w", err)
+			}
+			return nil, a.settle()
+		case prev.Status != model.AttemptFailed, prev.Attempt >= maxAttempts:
+			return nil, a.settle()
+		}
+		number = prev.Attempt + 1
+	}
+
+	a.record = model.NotificationAttempt{
+		NotificationID: n.NotificationID,
+		Transition:     tr.Kind,
+		FromState:      tr.From,
+		ToState:        tr.To,
+		ScheduledFor:   tr.At,
+		Attempt:        number,
+		ClaimedBy:      owner,
+		Status:         model.AttemptSending,
+		StartedAt:      now,
+	}
+	if err := db.Create(&a.record).Error; err != nil {
+		a.release()
+		return nil, fmt.Errorf("record attempt: %w", err)
+	}
+	return a, nil
+}
+
+// settle เลื่อน is_send ไป tr.To โดยไม่ส่ง แล้วคืน errSettled
+func (a *attempt) settle() error {
+	if err := a.advance(a.db); err != nil {
+		a.release()
+		return err
+	}
+	return errSettled
+}
+
+// finish บันทึกผลที่ส่งแล้ว (sent, skipped หรือ deferred) push ที่เลื่อนไว้ และเลื่อน is_send ใน transaction เดียวกัน
+// เมื่อ claim หายไประหว่างส่ง ผลการส่งยังถูกบันทึกแต่คืน errLostClaim
+func (a *attempt) finish(status string, tokens, failed int, deferred []model.DeferredPush) error {
+	now := time.Now().UTC()
+	lost := false
+	err := a.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&a.record).Updates(map[string]any{
+			"status": status, "tokens": tokens, "failed_tokens": failed, "finished_at": now,
+		}).Error; err != nil {
+			return fmt.Errorf("record attempt result: %w", err)
+		}
+		if len(deferred) > 0 {
+			if err := tx.Create(&deferred).Error; err != nil {
+				return fmt.Errorf("record deferred pushes: %w", err)
+			}
+		}
+		err := a.advance(tx)
+		if errors.Is(err, errLostClaim) {
+			lost = true
+			return nil
+		}
+		return err
+	})
+	if err == nil && lost {
+		return errLostClaim
+	}
+	return err
+}
+
+// fail บันทึกว่าส่งไม่สำเร็จและคืน claim โดยไม่เปลี่ยน is_send รอบถัดไปจึงลองใหม่
+func (a *attempt) fail(tokens int, sendErr error) error {
+	msg := sendErr.Error()
+	if err := a.db.Model(&a.record).Updates(map[string]any{
+		"status": model.AttemptFailed, "tokens": tokens, "failed_tokens": tokens, "error": msg, "finished_at": time.Now().UTC(),
+	}).Error; err != nil {
+		a.release()
+		return fmt.Errorf("record attempt failure: %w", err)
+	}
+	return a.release()
+}
+
+// advance เลื่อน is_send และคืน claim ถ้ายังเป็นของเราและ is_send ยังเป็น From
+// ถ้าผู้ใช้ snooze หรือแก้ notification ระหว่างส่ง จะไม่เขียนทับ
+func (a *attempt) advance(tx *gorm.DB) error {
+	res := tx.Model(&model.Notification{}).
+		Where("notification_id = ? AND claimed_by = ? AND is_send = ?", a.n.NotificationID, a.owner, a.tr.From).
+		Updates(map[string]any{"is_send": a.tr.To, "claimed_by": nil, "claim_expires_at": nil})
+	if res.Error != nil {
+		return fmt.Errorf("advance is_send: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return errLostClaim
+	}
+	return nil
+}
+
+// release คืน claim โดยไม่เปลี่ยน is_send
+func (a *attempt) release() error {
+	if err := a.db.Model(&model.Notification{}).
+		Where("notification_id = ? AND claimed_by = ?", a.n.NotificationID, a.owner).
+		Updates(map[string]any{"claimed_by": nil, "claim_expires_at": nil}).Error; err != nil {
+		return fmt.Errorf("release claim: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package notification
+
+import (
+	"errors"
+	"mydayplanner/apperror"
+	"mydayplanner/delivery"
+	"mydayplanner/dto"
+	"mydayplanner/middleware"
+	"mydayplanner/model"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// DeliveryLogController ประวัติการส่ง push ผู้ใช้ดูของตัวเอง admin ดูของผู้ใช้คนใดก็ได้
+func DeliveryLogController(router *gin.Engine, db *gorm.DB) {
+	mine := func(c *gin.Context) { MyDeliveries(c, db) }
+	ofUser := func(c *gin.Context) { UserDeliveries(c, db) }
+
+	router.GET("/v1/users/me/deliveries", middleware.AccessTokenMiddleware(), mine)
+	router.GET("/v1/admin/users/:id/deliveries", middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), ofUser)
+}
+
+func MyDeliveries(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+	listDeliveries(c, db, int(userId))
+}
+
+func UserDeliveries(c *gin.Context, db *gorm.DB) {
+	userID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		apperror.Respond(c, apperror.InvalidInput.WithField("id", "invalid"))
+		return
+	}
+	var user model.User
+	if err := db.First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.Respond(c, apperror.UserNotFound)
+			return
+		}
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	listDeliveries(c, db, userID)
+}
+
+// listDeliveries ตอบหน้าหนึ่งของประวัติ next_before ใช้เป็น before ของหน้าถัดไป (null เมื่อหมดแล้ว)
+func listDeliveries(c *gin.Context, db *gorm.DB, userID int) {
+	var req dto.DeliveryQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+
+	q := delivery.Query{
+		UserID:         userID,
+		TaskID:         req.TaskID,
+		NotificationID: req.NotificationID,
+		Type:           req.Type,
+		Before:         req.Before,
+		Limit:          req.Limit,
+	}
+	if q.Limit == 0 {
+		q.Limit = delivery.DefaultLimit
+	}
+	deliveries, err := delivery.List(c.Request.Context(), db, q)
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+
+	var next *int64
+	if len(deliveries) == q.Limit {
+		next = &deliveries[len(deliveries)-1].DeliveryID
+	}
+	c.JSON(200, gin.H{"deliveries": deliveries, "next_before": next})
+}
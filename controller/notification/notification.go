@@ -4,42 +4,41 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
+	"mydayplanner/timezone"
 	"net/http"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func NotificationTaskController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/notification", middleware.AccessTokenMiddleware())
-	{
-		routes.PUT("/update/:taskid", func(c *gin.Context) {
-			UpdateNotificationDynamic(c, db, firestoreClient)
-		})
-
-	}
+func NotificationTaskController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	update := func(c *gin.Context) { UpdateNotificationDynamic(c, db, fb) }
+	router.PUT("/v1/tasks/:taskid/reminder", middleware.AccessTokenMiddleware(), update)
+	router.PUT("/notification/update/:taskid", middleware.Deprecated("/v1/tasks/:taskid/reminder"), middleware.AccessTokenMiddleware(), update)
 }
 
-func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 	taskID := c.Param("taskid")
 
 	// Convert taskID to integer for validation
 	taskIDInt, err := strconv.Atoi(taskID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	var req dto.UpdateNotificationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -47,9 +46,9 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 	var user model.User
 	if err := db.Where("user_id = ?", userId).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -66,9 +65,9 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 		Where("task_id = ?", taskIDInt).
 		First(&task).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -80,7 +79,7 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 	if task.BoardID == nil {
 		// Today task - ตรวจสอบว่าเป็นเจ้าของ task
 		if task.CreateBy == nil || uint(*task.CreateBy) != userId {
-			c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: not the owner of this personal task"})
+			apperror.Respond(c, apperror.NotTaskOwner)
 			return
 		}
 		shouldSaveToFirestore = false
@@ -94,16 +93,16 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 				var board model.Board
 				if err := db.Where("board_id = ? AND create_by = ?", task.BoardID, userId).First(&board).Error; err != nil {
 					if errors.Is(err, gorm.ErrRecordNotFound) {
-						c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: not a board member or board owner"})
+						apperror.Respond(c, apperror.NotBoardMember)
 					} else {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board ownership"})
+						apperror.Respond(c, apperror.Internal)
 					}
 					return
 				}
 				shouldSaveToFirestore = true // Board owner
 				boardmember = false
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch board user"})
+				apperror.Respond(c, apperror.Internal)
 				return
 			}
 		} else {
@@ -127,7 +126,7 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 				CreatedAt:        time.Now(),
 			}
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification"})
+			apperror.Respond(c, apperror.Internal)
 			return
 		}
 	}
@@ -135,17 +134,17 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 	// Create update map for dynamic updates
 	updates := make(map[string]interface{})
 
-	onlyDueDate := req.DueDate != nil && req.BeforeDueDate == nil && req.RecurringPattern == nil && req.IsSend == nil
+	onlyDueDate := req.DueDate != nil && req.BeforeDueDate == nil && req.RecurringPattern == nil && req.IsSend == nil && req.Critical == nil
+
+	// วันที่ที่ไม่มี offset เป็นเวลาท้องถิ่นของผู้ใช้ที่แก้
+	loc := timezone.Of(user)
 
 	// Parse and update due date if provided
 	if req.DueDate != nil {
-		parsedDate, err := time.Parse(time.RFC3339, *req.DueDate)
+		parsedDate, err := timezone.ParseDateTime(*req.DueDate, loc)
 		if err != nil {
-			parsedDate, err = time.Parse("2006-01-02T15:04:05Z07:00", *req.DueDate)
-			if err != nil {
-				c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid due date format. Use RFC3339 format"})
-				return
-			}
+			apperror.Respond(c, apperror.InvalidDate.WithField("due_date", "rfc3339"))
+			return
 		}
 		updates["due_date"] = &parsedDate
 		notification.DueDate = &parsedDate
@@ -165,13 +164,10 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 			updates["beforedue_date"] = nil
 			notification.BeforeDueDate = nil
 		} else {
-			parsedBeforeDate, err := time.Parse(time.RFC3339, *req.BeforeDueDate)
+			parsedBeforeDate, err := timezone.ParseDateTime(*req.BeforeDueDate, loc)
 			if err != nil {
-				parsedBeforeDate, err = time.Parse("2006-01-02T15:04:05Z07:00", *req.BeforeDueDate)
-				if err != nil {
-					c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid before due date format. Use RFC3339 format"})
-					return
-				}
+				apperror.Respond(c, apperror.InvalidDate.WithField("before_due_date", "rfc3339"))
+				return
 			}
 			updates["beforedue_date"] = &parsedBeforeDate
 			notification.BeforeDueDate = &parsedBeforeDate
@@ -190,12 +186,17 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 		notification.IsSend = *req.IsSend
 	}
 
+	if req.Critical != nil {
+		updates["critical"] = *req.Critical
+		notification.Critical = *req.Critical
+	}
+
 	updates["snooze"] = nil
 	notification.Snooze = nil
 
 	// If no updates provided for existing notification, return error
 	if !isNewNotification && len(updates) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		apperror.Respond(c, apperror.NoFieldsToUpdate)
 		return
 	}
 
@@ -203,14 +204,14 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 	if isNewNotification {
 		// Create new notification
 		if err := db.Create(&notification).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
 		// Create Firebase document with all notification data
-		if err := createFirebaseNotification(firestoreClient, user, notification, shouldSaveToFirestore, boardmember); err != nil {
+		if err := createFirebaseNotification(fb, user, notification, shouldSaveToFirestore, boardmember); err != nil {
 			// Log the error but don't fail the request since DB create succeeded
-			fmt.Printf("Warning: Failed to create Firebase notification: %v\n", err)
+			logging.FromContext(c).Warn("failed to create Firestore notification", "error", err)
 		}
 
 		c.JSON(http.StatusCreated, gin.H{
@@ -222,14 +223,14 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 		// Update existing notification
 
 		if err := db.Model(&notification).Updates(updates).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 
 		// Update in Firebase with only the modified fields
-		if err := updateFirebaseNotification(firestoreClient, user, notification, updates, shouldSaveToFirestore, boardmember); err != nil {
+		if err := updateFirebaseNotification(fb, user, notification, updates, shouldSaveToFirestore, boardmember); err != nil {
 			// Log the error but don't fail the request since DB update succeeded
-			fmt.Printf("Warning: Failed to update Firebase notification: %v\n", err)
+			logging.FromContext(c).Warn("failed to update Firestore notification", "error", err)
 		}
 
 		c.JSON(http.StatusOK, gin.H{
@@ -241,23 +242,12 @@ func UpdateNotificationDynamic(c *gin.Context, db *gorm.DB, firestoreClient *fir
 }
 
 // Helper function to create new Firebase notification document
-func createFirebaseNotification(firestoreClient *firestore.Client, user model.User, notification model.Notification, shouldSaveToFirestore bool, boardmember bool) error {
+func createFirebaseNotification(fb store.Store, user model.User, notification model.Notification, shouldSaveToFirestore bool, boardmember bool) error {
 	ctx := context.Background()
 
-	// สร้าง path ตาม shouldSaveToFirestore และ boardmember
-	var docPath string
-	if shouldSaveToFirestore {
-		if boardmember {
-			// สำหรับ board tasks ที่ user เป็น board member
-			docPath = fmt.Sprintf("BoardTasks/%d/Notifications/%d", notification.TaskID, notification.NotificationID)
-		} else {
-			// สำหรับ board tasks ที่ user เป็น board owner
-			docPath = fmt.Sprintf("Notifications/%s/Tasks/%d", user.Email, notification.NotificationID)
-		}
-	} else {
-		// สำหรับ today tasks (personal tasks)
-		docPath = fmt.Sprintf("Notifications/%s/Tasks/%d", user.Email, notification.NotificationID)
-	}
+	// เลือก path ตาม shouldSaveToFirestore และ boardmember
+	// board member ใช้ BoardTasks/{task}/Notifications ส่วน board owner และ today tasks ใช้ Notifications/{email}/Tasks
+	isBoardDoc := shouldSaveToFirestore && boardmember
 
 	// Create complete Firebase document data
 	firebaseData := map[string]interface{}{
@@ -281,7 +271,12 @@ func createFirebaseNotification(firestoreClient *firestore.Client, user model.Us
 	}
 
 	// Create document in Firebase
-	_, err := firestoreClient.Doc(docPath).Set(ctx, firebaseData)
+	var err error
+	if isBoardDoc {
+		err = fb.BoardTasks().SetItem(ctx, notification.TaskID, store.TaskNotifications, strconv.Itoa(notification.NotificationID), firebaseData)
+	} else {
+		err = fb.Notifications().SetTask(ctx, user.Email, notification.NotificationID, firebaseData)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create Firebase document: %v", err)
 	}
@@ -296,6 +291,7 @@ func prepareNotificationResponse(notification model.Notification) map[string]int
 		"task_id":           notification.TaskID,
 		"recurring_pattern": notification.RecurringPattern,
 		"is_send":           notification.IsSend,
+		"critical":          notification.Critical,
 		"created_at":        notification.CreatedAt,
 	}
 
@@ -321,23 +317,12 @@ func prepareNotificationResponse(notification model.Notification) map[string]int
 	return responseData
 }
 
-func updateFirebaseNotification(firestoreClient *firestore.Client, user model.User, notification model.Notification, updatedFields map[string]interface{}, shouldSaveToFirestore bool, boardmember bool) error {
+func updateFirebaseNotification(fb store.Store, user model.User, notification model.Notification, updatedFields map[string]interface{}, shouldSaveToFirestore bool, boardmember bool) error {
 	ctx := context.Background()
 
-	// สร้าง path ตาม shouldSaveToFirestore และ boardmember
-	var docPath string
-	if shouldSaveToFirestore {
-		if boardmember {
-			// สำหรับ board tasks ที่ user เป็น board member
-			docPath = fmt.Sprintf("BoardTasks/%d/Notifications/%d", notification.TaskID, notification.NotificationID)
-		} else {
-			// สำหรับ board tasks ที่ user เป็น board owner
-			docPath = fmt.Sprintf("Notifications/%s/Tasks/%d", user.Email, notification.NotificationID)
-		}
-	} else {
-		// สำหรับ today tasks (personal tasks)
-		docPath = fmt.Sprintf("Notifications/%s/Tasks/%d", user.Email, notification.NotificationID)
-	}
+	// เลือก path ตาม shouldSaveToFirestore และ boardmember
+	// board member ใช้ BoardTasks/{task}/Notifications ส่วน board owner และ today tasks ใช้ Notifications/{email}/Tasks
+	isBoardDoc := shouldSaveToFirestore && boardmember
 
 	// Create Firebase document data only for updated fields
 	firebaseData := make(map[string]interface{})
@@ -361,14 +346,13 @@ func updateFirebaseNotification(firestoreClient *firestore.Client, user model.Us
 	// Always update the updatedAt timestamp when any field is updated
 	firebaseData["updatedAt"] = time.Now()
 
-	// Convert firebaseData map to []firestore.Update
-	var updates []firestore.Update
-	for k, v := range firebaseData {
-		updates = append(updates, firestore.Update{Path: k, Value: v})
-	}
-
 	// Update only the modified fields in Firebase
-	_, err := firestoreClient.Doc(docPath).Update(ctx, updates)
+	var err error
+	if isBoardDoc {
+		err = fb.BoardTasks().UpdateItem(ctx, notification.TaskID, store.TaskNotifications, strconv.Itoa(notification.NotificationID), firebaseData)
+	} else {
+		err = fb.Notifications().UpdateTask(ctx, user.Email, notification.NotificationID, firebaseData)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update Firebase document: %v", err)
 	}
@@ -2,100 +2,70 @@ package notification
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"mydayplanner/apperror"
+	"mydayplanner/delivery"
 	"mydayplanner/dto"
+	"mydayplanner/inbox"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/push"
+	"mydayplanner/pushtoken"
 	"mydayplanner/services"
+	"mydayplanner/store"
 	"net/http"
-	"os"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
-	firebase "firebase.google.com/go/v4"
-	"firebase.google.com/go/v4/messaging"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
 	"gorm.io/gorm"
 )
 
-func RemindNotificationTaskController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.POST("/inviteboardNotify", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		InviteBoardNotify(c, db, firestoreClient)
-	})
-	router.POST("/acceptinviteboardNotify/:boardid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		AcceptInviteNotify(c, db, firestoreClient)
-	})
-	router.POST("/assignedtaskNotify", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		AssignedTaskNotify(c, db, firestoreClient)
-	})
-	router.POST("/unassignedtaskNotify", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		UnAssignedTaskNotify(c, db, firestoreClient)
-	})
-	router.PUT("/snoozeNotify/:taskid", func(c *gin.Context) {
-		SnoozeNotification(c, db, firestoreClient)
-	})
-
+func RemindNotificationTaskController(router *gin.Engine, db *gorm.DB, fb store.Store, pusher push.Provider) {
+	invite := func(c *gin.Context) { InviteBoardNotify(c, db, fb, pusher) }
+	accepted := func(c *gin.Context) { AcceptInviteNotify(c, db, fb, pusher) }
+	assigned := func(c *gin.Context) { AssignedTaskNotify(c, db, fb, pusher) }
+	unassigned := func(c *gin.Context) { UnAssignedTaskNotify(c, db, fb, pusher) }
+	snooze := func(c *gin.Context) { SnoozeNotification(c, db, fb) }
+
+	routes := router.Group("/v1/push", middleware.AccessTokenMiddleware())
+	{
+		routes.POST("/board-invites", invite)
+		routes.POST("/boards/:boardid/accepted", accepted)
+		routes.POST("/assignments", assigned)
+		routes.POST("/unassignments", unassigned)
+	}
+	// snooze ถูกเรียกจาก action ของ notification บนเครื่องซึ่งไม่มี token
+	router.PUT("/v1/tasks/:taskid/reminder/snooze", snooze)
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	router.POST("/inviteboardNotify", middleware.Deprecated("/v1/push/board-invites"), middleware.AccessTokenMiddleware(), invite)
+	router.POST("/acceptinviteboardNotify/:boardid", middleware.Deprecated("/v1/push/boards/:boardid/accepted"), middleware.AccessTokenMiddleware(), accepted)
+	router.POST("/assignedtaskNotify", middleware.Deprecated("/v1/push/assignments"), middleware.AccessTokenMiddleware(), assigned)
+	router.POST("/unassignedtaskNotify", middleware.Deprecated("/v1/push/unassignments"), middleware.AccessTokenMiddleware(), unassigned)
+	router.PUT("/snoozeNotify/:taskid", middleware.Deprecated("/v1/tasks/:taskid/reminder/snooze"), snooze)
 }
 
-func InviteBoardNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func InviteBoardNotify(c *gin.Context, db *gorm.DB, fb store.Store, pusher push.Provider) {
 	var req dto.InviteNotify
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// Query board information using req.BoardID
 	var board model.Board
 	if err := db.Where("board_id = ?", req.BoardID).First(&board).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Board not found"})
-		return
-	}
-
-	// Get FCM token from Firestore
-	doc, err := firestoreClient.Collection("usersLogin").Doc(req.RecieveEmail).Get(context.Background())
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to get user token from Firestore: " + err.Error()})
+		apperror.Respond(c, apperror.BoardNotFound)
 		return
 	}
 
-	// Check if document exists
-	if !doc.Exists() {
-		c.JSON(404, gin.H{"error": "User login data not found"})
-		return
-	}
-
-	// Extract FCM token
-	data := doc.Data()
-	fcmTokenInterface, exists := data["FMCToken"]
-	if !exists {
-		c.JSON(404, gin.H{"error": "FCM token not found for user"})
-		return
-	}
-
-	fcmToken, ok := fcmTokenInterface.(string)
-	if !ok || fcmToken == "" {
-		c.JSON(400, gin.H{"error": "Invalid or empty FCM token"})
-		return
-	}
-
-	// Load environment variables (consider moving this to initialization)
-	err = godotenv.Load()
-	if err != nil {
-		fmt.Println("Warning: No .env file found or failed to load")
-	}
-
-	serviceAccountKeyPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_1")
-	if serviceAccountKeyPath == "" {
-		c.JSON(500, gin.H{"error": "Firebase credentials not configured"})
-		return
-	}
-
-	// Initialize Firebase app
-	app, err := services.InitializeFirebaseApp(serviceAccountKeyPath)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to initialize Firebase app: " + err.Error()})
+	var recieveUSER model.User
+	if err := db.Where("email = ?", req.RecieveEmail).First(&recieveUSER).Error; err != nil {
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
@@ -106,45 +76,38 @@ func InviteBoardNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 		"payload": "notification",
 	}
 
-	err = sendPushNotification(app, fcmToken, title, body, datasend)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to send notification: " + err.Error()})
+	if _, err := notifyUsers(c.Request.Context(), db, fb, pusher, []model.User{recieveUSER}, delivery.Meta{Type: "invite", BoardID: &board.BoardID}, push.Message{Title: title, Body: body, Data: datasend}); err != nil {
+		respondPushError(c, err)
 		return
 	}
 
-	updateFirestoreInviteNotification(firestoreClient, req.RecieveEmail, req.SendingEmail, req.BoardID)
+	updateFirestoreInviteNotification(fb, req.RecieveEmail, req.SendingEmail, req.BoardID)
 
 	c.JSON(200, gin.H{
 		"message": "Notification sent successfully",
 	})
 }
 
-func AcceptInviteNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func AcceptInviteNotify(c *gin.Context, db *gorm.DB, fb store.Store, pusher push.Provider) {
 	userId := c.MustGet("userId").(uint)
 	boardID := c.Param("boardid")
 
 	user, err := services.GetUserdata(db, fmt.Sprintf("%d", userId))
 	if err != nil {
-		c.JSON(404, gin.H{
-			"error": "User not found",
-		})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
 	var board model.Board
 	if err := db.Where("board_id = ?", boardID).First(&board).Error; err != nil {
-		c.JSON(404, gin.H{
-			"error": "Board not found",
-		})
+		apperror.Respond(c, apperror.BoardNotFound)
 		return
 	}
 
 	// 1. ค้นหา UserID ทุกคนใน BoardUser จาก BoardID
 	var boardUsers []model.BoardUser
 	if err := db.Where("board_id = ?", boardID).Find(&boardUsers).Error; err != nil {
-		c.JSON(500, gin.H{
-			"error": "Failed to fetch board users",
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -167,114 +130,54 @@ func AcceptInviteNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestore.
 	// 3. ค้นหา Email จาก User model
 	var users []model.User
 	if err := db.Where("user_id IN ?", userIDs).Find(&users).Error; err != nil {
-		c.JSON(500, gin.H{
-			"error": "Failed to fetch users",
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
-	// 4. ดึง FCM Token จาก Firestore
-	ctx := context.Background()
-	var fcmTokens []string
-
-	for _, user := range users {
-		// เข้าถึง document ใน path /usersLogin/{email}
-		doc, err := firestoreClient.Collection("usersLogin").Doc(user.Email).Get(ctx)
-		if err != nil {
-			fmt.Printf("Error getting document for email %s: %v", user.Email, err)
-			continue
-		}
-
-		// ดึงข้อมูล FMC_Token
-		data := doc.Data()
-		if fmcToken, exists := data["FMCToken"]; exists {
-			if tokenStr, ok := fmcToken.(string); ok && tokenStr != "" {
-				fcmTokens = append(fcmTokens, tokenStr)
-			}
-		}
+	// 4. ส่ง notification ไปทุกเครื่องของสมาชิกแต่ละคน
+	title := "เข้าร่วมกลุ่มงานแล้ว"
+	body := fmt.Sprintf("ผู้ใช้ %s เข้าร่วมกลุ่มงาน %s แล้ว", user.Name, board.BoardName)
+	data := map[string]string{
+		"payload": "notification",
 	}
 
-	if len(fcmTokens) == 0 {
+	sent, err := notifyUsers(c.Request.Context(), db, fb, pusher, users, delivery.Meta{Type: "accepted", BoardID: &board.BoardID}, push.Message{Title: title, Body: body, Data: data})
+	if errors.Is(err, push.ErrNoTokens) {
 		c.JSON(200, gin.H{
 			"message": "No FCM tokens found",
 		})
 		return
 	}
-
-	// 5. สร้าง Firebase App (สมมติว่ามี app instance อยู่แล้ว)
-	// Load environment variables (consider moving this to initialization)
-	err = godotenv.Load()
 	if err != nil {
-		fmt.Println("Warning: No .env file found or failed to load")
-	}
-
-	serviceAccountKeyPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_1")
-	if serviceAccountKeyPath == "" {
-		c.JSON(500, gin.H{"error": "Firebase credentials not configured"})
-		return
-	}
-
-	// Initialize Firebase app
-	app, err := services.InitializeFirebaseApp(serviceAccountKeyPath)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to initialize Firebase app: " + err.Error()})
-		return
-	}
-
-	// 6. ส่ง notification
-	title := "เข้าร่วมกลุ่มงานแล้ว"
-	body := fmt.Sprintf("ผู้ใช้ %s เข้าร่วมกลุ่มงาน %s แล้ว", user.Name, board.BoardName)
-	data := map[string]string{
-		"payload": "notification",
-	}
-
-	// หมายเหตุ: คุณต้องส่ง firebase.App เป็น parameter หรือสร้างที่นี่
-	if err := services.SendMulticastNotification(app, fcmTokens, title, body, data); err != nil {
-		fmt.Printf("Error sending notification: %v", err)
-		c.JSON(500, gin.H{
-			"error": "Failed to send notification",
-		})
+		logging.FromContext(c).Error("failed to send notification", "error", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	c.JSON(200, gin.H{
 		"message":      "Notification sent successfully",
-		"tokens_count": len(fcmTokens),
+		"tokens_count": sent,
 	})
 }
 
-func AssignedTaskNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func AssignedTaskNotify(c *gin.Context, db *gorm.DB, fb store.Store, pusher push.Provider) {
 	// userID := c.MustGet("userId").(uint)
 	var req dto.AssignedNotify
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// Query task information using req.TaskID
 	task, err := services.GetTaskData(db, req.TaskID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "Task not found"})
+		apperror.Respond(c, apperror.TaskNotFound)
 		return
 	}
 
 	recieveUSER, err := services.GetUserdata(db, req.RecieveID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "User not found"})
-		return
-	}
-
-	// Get FCM token from Firestore
-	fcmToken, err := services.GetFMCTokenData(firestoreClient, recieveUSER.Email)
-	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
-		return
-	}
-
-	// สร้าง Firebase app
-	app, err := services.GetFirebaseApp()
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to initialize Firebase app: " + err.Error()})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
@@ -285,9 +188,8 @@ func AssignedTaskNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestore.
 		"payload": "notification",
 	}
 
-	err = sendPushNotification(app, fcmToken, title, body, datasend)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to send notification: " + err.Error()})
+	if _, err := notifyUsers(c.Request.Context(), db, fb, pusher, []model.User{*recieveUSER}, delivery.Meta{Type: "assigned", TaskID: &task.TaskID, BoardID: task.BoardID}, push.Message{Title: title, Body: body, Data: datasend}); err != nil {
+		respondPushError(c, err)
 		return
 	}
 
@@ -296,30 +198,16 @@ func AssignedTaskNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestore.
 	})
 }
 
-func UnAssignedTaskNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func UnAssignedTaskNotify(c *gin.Context, db *gorm.DB, fb store.Store, pusher push.Provider) {
 	var req dto.UnAssignedNotify
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	recieveUSER, err := services.GetUserdata(db, req.RecieveID)
 	if err != nil {
-		c.JSON(404, gin.H{"error": "User not found"})
-		return
-	}
-
-	// Get FCM token from Firestore
-	fcmToken, err := services.GetFMCTokenData(firestoreClient, recieveUSER.Email)
-	if err != nil {
-		c.JSON(400, gin.H{"error": err.Error()})
-		return
-	}
-
-	// Initialize Firebase app
-	app, err := services.GetFirebaseApp()
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to initialize Firebase app: " + err.Error()})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
@@ -330,9 +218,8 @@ func UnAssignedTaskNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestor
 		"payload": "notification",
 	}
 
-	err = sendPushNotification(app, fcmToken, title, body, datasend)
-	if err != nil {
-		c.JSON(500, gin.H{"error": "Failed to send notification: " + err.Error()})
+	if _, err := notifyUsers(c.Request.Context(), db, fb, pusher, []model.User{*recieveUSER}, delivery.Meta{Type: "unassigned"}, push.Message{Title: title, Body: body, Data: datasend}); err != nil {
+		respondPushError(c, err)
 		return
 	}
 
@@ -341,62 +228,67 @@ func UnAssignedTaskNotify(c *gin.Context, db *gorm.DB, firestoreClient *firestor
 	})
 }
 
-func sendPushNotification(app *firebase.App, token, title, body string, data map[string]string) error {
-	ctx := context.Background()
+// notifyUsers เพิ่มรายการใน inbox ของผู้ใช้ทุกคน แล้วส่ง msg ไปทุกเครื่องที่ยังใช้งานและบันทึกผลลง push_deliveries
+// คืนจำนวน token ที่ส่ง ล้มบางเครื่องแค่ log ไว้ คืน push.ErrNoTokens เมื่อไม่มีใครมีเครื่องที่รับได้
+func notifyUsers(ctx context.Context, db *gorm.DB, fb store.Store, pusher push.Provider, users []model.User, meta delivery.Meta, msg push.Message) (int, error) {
+	userIDs := make([]int, len(users))
+	for i, u := range users {
+		userIDs[i] = u.UserID
+	}
+	item := model.InboxItem{Type: meta.Type, Title: msg.Title, Body: msg.Body, TaskID: meta.TaskID, BoardID: meta.BoardID}
+	if err := inbox.Add(ctx, db, inbox.ForUsers(userIDs, item)); err != nil {
+		logging.FromContext(ctx).Error("failed to add to inbox", "type", meta.Type, "error", err)
+	}
 
-	client, err := app.Messaging(ctx)
+	byUser, err := pushtoken.Tokens(ctx, db, fb, users, time.Now())
 	if err != nil {
-		return fmt.Errorf("error getting Messaging client: %v", err)
+		return 0, err
 	}
-
-	message := &messaging.Message{
-		Data: data,
-		Notification: &messaging.Notification{
-			Title: title,
-			Body:  body,
-		},
-		Token: token,
+	recipients := make([]delivery.Recipient, 0, len(users))
+	for _, u := range users {
+		recipients = append(recipients, delivery.Recipient{UserID: u.UserID, Tokens: byUser[u.UserID]})
 	}
-
-	response, err := client.Send(ctx, message)
+	results, err := delivery.Send(ctx, db, pusher, recipients, meta, msg)
 	if err != nil {
-		return fmt.Errorf("error sending message: %v", err)
+		return len(results), err
+	}
+	if failed := push.Failed(results); failed > 0 {
+		logging.FromContext(ctx).Warn("push failed for some tokens", "failed", failed, "total", len(results))
 	}
+	return len(results), nil
+}
 
-	fmt.Printf("Successfully sent message: %s", response)
-	return nil
+// respondPushError ผู้รับที่ไม่มีเครื่องรับ push ได้ FCMTokenNotFound นอกนั้นเป็น Internal
+func respondPushError(c *gin.Context, err error) {
+	if errors.Is(err, push.ErrNoTokens) {
+		apperror.Respond(c, apperror.FCMTokenNotFound)
+		return
+	}
+	apperror.Respond(c, apperror.Internal.Wrap(err))
 }
 
-func updateFirestoreInviteNotification(firestoreClient *firestore.Client, RecieveEmail string, Sendingemail string, boardid string) {
+func updateFirestoreInviteNotification(fb store.Store, RecieveEmail string, Sendingemail string, boardid string) {
 	ctx := context.Background()
-	var docPath string
-
 	docname := fmt.Sprintf("%sfrom-%s", boardid, Sendingemail)
-	docPath = fmt.Sprintf("Notifications/%s/InviteJoin/%s", RecieveEmail, docname)
 
 	updateData := map[string]interface{}{
 		"notiCount": false,
-		"updatedAt": firestore.ServerTimestamp,
+		"updatedAt": store.ServerTimestamp,
 	}
 
-	_, err := firestoreClient.Doc(docPath).Set(ctx, updateData, firestore.MergeAll)
+	err := fb.Notifications().MergeInvite(ctx, RecieveEmail, docname, updateData)
 	if err != nil {
-		fmt.Printf("Error updating Firestore document: %v\n", err)
-	} else {
-		fmt.Println("Firestore document updated successfully")
+		slog.Error("failed to update Firestore invite notification", "board_id", boardid, "error", err)
 	}
 }
 
-func SnoozeNotification(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func SnoozeNotification(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var taskid = c.Param("taskid")
 
 	// แปลง taskid เป็น int
 	taskID, err := strconv.Atoi(taskid)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid task ID",
-			"message": "Task ID must be a number",
-		})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
@@ -404,23 +296,17 @@ func SnoozeNotification(c *gin.Context, db *gorm.DB, firestoreClient *firestore.
 	var task model.Tasks
 	if err := db.Where("task_id = ?", taskID).First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "Task not found",
-				"message": "Task with given ID does not exist",
-			})
+			apperror.Respond(c, apperror.TaskNotFound)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Database error",
-			"message": err.Error(),
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// ตรวจสอบว่า task มี BoardID หรือไม่
 	if task.BoardID == nil {
 		// หาก BoardID เป็น null ให้ดำเนินการแบบ private
-		snoozePrivate(c, db, firestoreClient, taskID)
+		snoozePrivate(c, db, fb, taskID)
 		return
 	}
 
@@ -431,47 +317,35 @@ func SnoozeNotification(c *gin.Context, db *gorm.DB, firestoreClient *firestore.
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			// ไม่พบใน BoardUser ให้ดำเนินการแบบ private
-			snoozePrivate(c, db, firestoreClient, taskID)
+			snoozePrivate(c, db, fb, taskID)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Database error",
-			"message": err.Error(),
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// พบใน BoardUser ให้ดำเนินการแบบ group
-	snoozeGroup(c, db, firestoreClient, taskID, *task.BoardID)
+	snoozeGroup(c, db, fb, taskID, *task.BoardID)
 }
 
-func snoozePrivate(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client, taskID int) {
+func snoozePrivate(c *gin.Context, db *gorm.DB, fb store.Store, taskID int) {
 	// ค้นหา task เพื่อเอา CreateBy
 	var task model.Tasks
 	if err := db.Where("task_id = ?", taskID).Preload("Creator").First(&task).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Task not found",
-			"message": err.Error(),
-		})
+		apperror.Respond(c, apperror.TaskNotFound)
 		return
 	}
 
 	// ตรวจสอบว่ามี CreateBy หรือไม่
 	if task.CreateBy == nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Task creator not found",
-			"message": "Cannot snooze notification without task creator",
-		})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
 	// ดึง email ของผู้สร้าง task จาก user_id (task.CreateBy)
 	var creator model.User
 	if err := db.Where("user_id = ?", *task.CreateBy).First(&creator).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Task creator not found",
-			"message": err.Error(),
-		})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 	task.Creator = &creator
@@ -480,16 +354,10 @@ func snoozePrivate(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	var notification model.Notification
 	if err := db.Where("task_id = ?", taskID).First(&notification).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "Notification not found",
-				"message": "No notification found for this task",
-			})
+			apperror.Respond(c, apperror.NotificationNotFound)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Database error",
-			"message": err.Error(),
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -500,18 +368,15 @@ func snoozePrivate(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	// อัปเดทข้อมูลในฐานข้อมูล - บันทึกใน snooze field
 	if err := db.Model(&notification).Updates(map[string]interface{}{
 		"snooze":  &newSnooze,
-		"is_send": "3", // รีเซ็ตสถานะการส่ง
+		"is_send": model.NotifySnoozed, // รีเซ็ตสถานะการส่ง
 	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Update failed",
-			"message": err.Error(),
-		})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
 	// อัปเดท Firestore: /Notifications/{email}/Tasks/{notificationid}
 	if task.Creator != nil && task.Creator.Email != "" {
-		err := updatePrivateFirestore(firestoreClient, task.Creator.Email, notification.NotificationID, &newSnooze)
+		err := updatePrivateFirestore(fb, task.Creator.Email, notification.NotificationID, &newSnooze)
 		if err != nil {
 			c.JSON(http.StatusOK, gin.H{
 				"message":     "Private task notification snoozed successfully (Firestore update failed)",
@@ -532,21 +397,15 @@ func snoozePrivate(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	})
 }
 
-func snoozeGroup(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client, taskID int, boardID int) {
+func snoozeGroup(c *gin.Context, db *gorm.DB, fb store.Store, taskID int, boardID int) {
 	// ค้นหา notification ของ task นี้
 	var notification model.Notification
 	if err := db.Where("task_id = ?", taskID).First(&notification).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{
-				"error":   "Notification not found",
-				"message": "No notification found for this task",
-			})
+			apperror.Respond(c, apperror.NotificationNotFound)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Database error",
-			"message": err.Error(),
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -557,17 +416,14 @@ func snoozeGroup(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client,
 	// อัปเดทข้อมูลในฐานข้อมูล - บันทึกใน snooze field
 	if err := db.Model(&notification).Updates(map[string]interface{}{
 		"snooze":  &newSnooze,
-		"is_send": "3", // รีเซ็ตสถานะการส่ง
+		"is_send": model.NotifySnoozed, // รีเซ็ตสถานะการส่ง
 	}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Update failed",
-			"message": err.Error(),
-		})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
 	// อัปเดท Firestore: /BoardTasks/{taskid}/Notifications/{notificationid}
-	err := updateGroupFirestore(firestoreClient, taskID, notification.NotificationID, &newSnooze)
+	err := updateGroupFirestore(fb, taskID, notification.NotificationID, &newSnooze)
 	if err != nil {
 		c.JSON(http.StatusOK, gin.H{
 			"message":     "Group task notification snoozed successfully (Firestore update failed)",
@@ -590,12 +446,9 @@ func snoozeGroup(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client,
 }
 
 // ฟังก์ชันอัปเดท Firestore สำหรับ Private Task
-func updatePrivateFirestore(firestoreClient *firestore.Client, email string, notificationID int, newSnooze *time.Time) error {
+func updatePrivateFirestore(fb store.Store, email string, notificationID int, newSnooze *time.Time) error {
 	ctx := context.Background()
 
-	// สร้าง document reference: /Notifications/{email}/Tasks/{notificationid}
-	docRef := firestoreClient.Collection("Notifications").Doc(email).Collection("Tasks").Doc(strconv.Itoa(notificationID))
-
 	// เตรียมข้อมูลที่จะอัปเดท
 	updateData := map[string]interface{}{
 		"isSend": "3",
@@ -608,22 +461,14 @@ func updatePrivateFirestore(firestoreClient *firestore.Client, email string, not
 		updateData["snooze"] = nil
 	}
 
-	// อัปเดทข้อมูลใน Firestore
-	_, err := docRef.Update(ctx, []firestore.Update{
-		{Path: "snooze", Value: updateData["snooze"]},
-		{Path: "isSend", Value: updateData["isSend"]},
-	})
-
-	return err
+	// อัปเดทข้อมูลใน Firestore: /Notifications/{email}/Tasks/{notificationid}
+	return fb.Notifications().UpdateTask(ctx, email, notificationID, updateData)
 }
 
 // ฟังก์ชันอัปเดท Firestore สำหรับ Group Task
-func updateGroupFirestore(firestoreClient *firestore.Client, taskID int, notificationID int, newSnooze *time.Time) error {
+func updateGroupFirestore(fb store.Store, taskID int, notificationID int, newSnooze *time.Time) error {
 	ctx := context.Background()
 
-	// สร้าง document reference: /BoardTasks/{taskid}/Notifications/{notificationid}
-	docRef := firestoreClient.Collection("BoardTasks").Doc(strconv.Itoa(taskID)).Collection("Notifications").Doc(strconv.Itoa(notificationID))
-
 	// เตรียมข้อมูลที่จะอัปเดท
 	updateData := map[string]interface{}{
 		"isSend": "3",
@@ -636,11 +481,6 @@ func updateGroupFirestore(firestoreClient *firestore.Client, taskID int, notific
 		updateData["snooze"] = nil
 	}
 
-	// อัปเดทข้อมูลใน Firestore
-	_, err := docRef.Update(ctx, []firestore.Update{
-		{Path: "snooze", Value: updateData["snooze"]},
-		{Path: "isSend", Value: updateData["isSend"]},
-	})
-
-	return err
+	// อัปเดทข้อมูลใน Firestore: /BoardTasks/{taskid}/Notifications/{notificationid}
+	return fb.BoardTasks().UpdateItem(ctx, taskID, store.TaskNotifications, strconv.Itoa(notificationID), updateData)
 }
@@ -0,0 +1,199 @@
+package notification
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"mydayplanner/delivery"
+	"mydayplanner/logging"
+	"mydayplanner/metrics"
+	"mydayplanner/model"
+	"mydayplanner/push"
+	"mydayplanner/pushtoken"
+	"mydayplanner/quiethours"
+	"mydayplanner/store"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// deferredBatch จำนวน push ที่เลื่อนไว้ซึ่งส่งได้ต่อรอบ ที่เหลือรอรอบถัดไป
+const deferredBatch = 500
+
+// deferrable แจ้งเตือนที่เลื่อนไปหลัง quiet hours ได้ แจ้งเตือน due ของ notification ที่ตั้ง critical ส่งทันทีเสมอ
+func deferrable(n model.Notification, tr transition) bool {
+	switch tr.Kind {
+	case "before", "snooze", "recurring":
+		return true
+	case "due":
+		return !n.Critical
+	}
+	return false
+}
+
+// splitQuiet แยกผู้รับที่ส่งได้ทันทีออกจากผู้รับที่อยู่ใน quiet hours
+// ผู้รับที่ต้องรอได้ DeferredPush ที่มีแค่ UserID และ DeliverAt ผู้เรียกเติมส่วนที่เหลือเอง
+func (p *NotificationProcessor) splitQuiet(n model.Notification, tr transition, recipients []delivery.Recipient, now time.Time) ([]delivery.Recipient, []model.DeferredPush) {
+	if !deferrable(n, tr) {
+		return recipients, nil
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var immediate []delivery.Recipient
+	var deferred []model.DeferredPush
+	for _, r := range recipients {
+		if until, quiet := p.quietCache[r.UserID].Until(now); quiet {
+			deferred = append(deferred, model.DeferredPush{UserID: r.UserID, DeliverAt: until.UTC()})
+			continue
+		}
+		immediate = append(immediate, r)
+	}
+	return immediate, deferred
+}
+
+// preloadQuietHours โหลด quiet hours ของผู้ใช้ทุกคนในครั้งเดียว ถ้าโหลดไม่ได้ส่งทันทีทุกคน
+func (p *NotificationProcessor) preloadQuietHours(userIDs []int) {
+	schedules, err := quiethours.Load(p.ctx, p.db, userIDs)
+	if err != nil {
+		p.log.Error("failed to load quiet hours", "error", err)
+		return
+	}
+	p.mu.Lock()
+	for userID, s := range schedules {
+		p.quietCache[userID] = s
+	}
+	p.mu.Unlock()
+}
+
+// deferPushes เติมเนื้อหาของ push ที่เลื่อนไว้ให้ครบ
+func deferPushes(deferred []model.DeferredPush, n model.Notification, tr transition, a *attempt, body func(userID int) string, data map[string]string, now time.Time) ([]model.DeferredPush, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("encode push data: %w", err)
+	}
+	for i := range deferred {
+		deferred[i].NotificationID = n.NotificationID
+		deferred[i].AttemptID = &a.record.AttemptID
+		deferred[i].Type = tr.Kind
+		deferred[i].Title = reminderTitle
+		deferred[i].Body = body(deferred[i].UserID)
+		deferred[i].Data = string(encoded)
+		deferred[i].CreatedAt = now.UTC()
+	}
+	return deferred, nil
+}
+
+// ProcessDeferredPushes ส่ง push ที่เลื่อนไว้ระหว่าง quiet hours ซึ่งถึงเวลาแล้ว
+// แต่ละแถวถูก claim ด้วย DELETE ก่อนส่ง จึงส่งได้ครั้งเดียวแม้หลาย instance รันพร้อมกัน และไม่ลองใหม่ถ้าส่งไม่สำเร็จ
+// ผลการส่งอยู่ใน push_deliveries ผู้ใช้ที่แก้ quiet hours จนยังเงียบอยู่ถูกเลื่อนต่อ และงานที่เสร็จแล้วไม่ถูกแจ้ง
+func ProcessDeferredPushes(ctx context.Context, db *gorm.DB, fb store.Store, pusher push.Provider) (*NotificationResult, error) {
+	log := logging.FromContext(ctx)
+	db = db.WithContext(ctx)
+	now := time.Now().UTC()
+	result := &NotificationResult{Message: "Deferred pushes processed successfully", CurrentTime: now.Format(time.RFC3339)}
+
+	var rows []model.DeferredPush
+	if err := db.Where("deliver_at <= ?", now).Order("push_id").Limit(deferredBatch).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("Failed to fetch deferred pushes: %v", err)
+	}
+	result.TotalCount = len(rows)
+	if len(rows) == 0 {
+		return result, nil
+	}
+
+	seenUsers := make(map[int]bool)
+	var userIDs, notificationIDs []int
+	for _, r := range rows {
+		if !seenUsers[r.UserID] {
+			seenUsers[r.UserID] = true
+			userIDs = append(userIDs, r.UserID)
+		}
+		notificationIDs = append(notificationIDs, r.NotificationID)
+	}
+	var users []model.User
+	if err := db.Where("user_id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("Failed to fetch users: %v", err)
+	}
+	tokens, err := pushtoken.Tokens(ctx, db, fb, users, now)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to load push devices: %v", err)
+	}
+	schedules, err := quiethours.Load(ctx, db, userIDs)
+	if err != nil {
+		return nil, err
+	}
+	var notifications []model.Notification
+	if err := db.Preload("Task").Where("notification_id IN ?", notificationIDs).Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("Failed to fetch notifications: %v", err)
+	}
+	byID := make(map[int]model.Notification, len(notifications))
+	for _, n := range notifications {
+		byID[n.NotificationID] = n
+	}
+
+	for _, row := range rows {
+		rowLog := log.With("push_id", row.PushID, "notification_id", row.NotificationID, "user_id", row.UserID, "message_type", row.Type)
+		outcome := sendDeferred(logging.WithContext(ctx, rowLog), db, pusher, row, byID[row.NotificationID], schedules[row.UserID], tokens[row.UserID], now)
+		metrics.RecordNotification(row.Type, outcome)
+		switch outcome {
+		case "success":
+			result.SuccessCount++
+		case "error":
+			result.ErrorCount++
+		case "deferred":
+			result.DeferredCount++
+		default:
+			result.SkippedCount++
+		}
+	}
+	return result, nil
+}
+
+// sendDeferred ส่ง push ที่เลื่อนไว้หนึ่งแถว คืน success, skipped, deferred หรือ error
+func sendDeferred(ctx context.Context, db *gorm.DB, pusher push.Provider, row model.DeferredPush, n model.Notification, schedule quiethours.Schedule, tokens []string, now time.Time) string {
+	log := logging.FromContext(ctx)
+	if until, quiet := schedule.Until(now); quiet {
+		if err := db.Model(&row).Update("deliver_at", until.UTC()).Error; err != nil {
+			log.Error("failed to reschedule deferred push", "error", err)
+			return "error"
+		}
+		return "deferred"
+	}
+
+	res := db.Delete(&model.DeferredPush{}, row.PushID)
+	if res.Error != nil {
+		log.Error("failed to claim deferred push", "error", res.Error)
+		return "error"
+	}
+	if res.RowsAffected == 0 {
+		return "skipped"
+	}
+	if n.Task.Status == "2" {
+		log.Info("dropping deferred push: task is finished")
+		return "skipped"
+	}
+	if len(tokens) == 0 {
+		log.Info("dropping deferred push: user has no push devices")
+		return "skipped"
+	}
+
+	var data map[string]string
+	if err := json.Unmarshal([]byte(row.Data), &data); err != nil {
+		log.Error("failed to decode deferred push data", "error", err)
+		return "error"
+	}
+	meta := delivery.Meta{
+		Type:           row.Type,
+		NotificationID: &row.NotificationID,
+		AttemptID:      row.AttemptID,
+		TaskID:         &n.TaskID,
+		BoardID:        n.Task.BoardID,
+	}
+	msg := push.Message{Title: row.Title, Body: row.Body, Data: data}
+	if _, err := delivery.Send(ctx, db, pusher, []delivery.Recipient{{UserID: row.UserID, Tokens: tokens}}, meta, msg); err != nil {
+		log.Error("failed to send deferred push", "error", err)
+		return "error"
+	}
+	log.Info("deferred push sent", "tokens", len(tokens))
+	return "success"
+}
@@ -2,19 +2,27 @@ package notification
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"mydayplanner/apperror"
+	"mydayplanner/delivery"
+	"mydayplanner/inbox"
+	"mydayplanner/logging"
+	"mydayplanner/metrics"
+	"mydayplanner/middleware"
 	"mydayplanner/model"
-	"os"
+	"mydayplanner/push"
+	"mydayplanner/pushtoken"
+	"mydayplanner/quiethours"
+	"mydayplanner/ratelimit"
+	"mydayplanner/store"
+	"mydayplanner/timezone"
+	"strconv"
 	"sync"
 	"time"
 
-	"cloud.google.com/go/firestore"
-	firebase "firebase.google.com/go/v4"
-	"firebase.google.com/go/v4/messaging"
 	"github.com/gin-gonic/gin"
-	"github.com/joho/godotenv"
-	"google.golang.org/api/option"
 	"gorm.io/gorm"
 )
 
@@ -28,11 +36,11 @@ type MulticastRequest struct {
 
 // TaskInfo เก็บข้อมูลที่ต้องใช้ซ้ำๆ
 type TaskInfo struct {
-	Task    model.Tasks
-	Users   []model.User
-	Tokens  []string
-	IsGroup bool
-	BoardID interface{}
+	Task       model.Tasks
+	Users      []model.User
+	Recipients []delivery.Recipient // token ของทุกเครื่องแยกตามผู้รับ
+	IsGroup    bool
+	BoardID    interface{}
 }
 
 // UserTokenInfo เก็บ cache ของ user tokens
@@ -41,117 +49,115 @@ type UserTokenInfo struct {
 	Token string
 }
 
+// reminderTitle หัวข้อของแจ้งเตือนงานทั้ง push และรายการใน inbox
+const reminderTitle = "แจ้งเตือนงาน"
+
 // NotificationProcessor จัดการการประมวลผล notification
 type NotificationProcessor struct {
-	db              *gorm.DB
-	firestoreClient *firestore.Client
-	app             *firebase.App
-	taskCache       map[int]*TaskInfo         // cache ข้อมูล task
-	userTokenCache  map[string]string         // cache FCM tokens โดยใช้ email เป็น key
-	boardUserCache  map[int][]model.BoardUser // cache board users
-	userCache       map[int]model.User        // cache users
-	mu              sync.RWMutex              // mutex สำหรับ thread safety
-}
-
-// NotificationBatch สำหรับประมวลผลแบบ batch
-type NotificationBatch struct {
-	Notification model.Notification
-	UpdateIsSend string
-	ShouldSend   bool
-	MessageType  string // "before", "due", "snooze", "recurring"
+	ctx            context.Context // มี logger ของรอบนี้ (run_id หรือ request_id)
+	log            *slog.Logger
+	db             *gorm.DB
+	fb             store.Store
+	push           push.Provider
+	owner          string                      // ชื่อที่ใช้ claim notification ต่างกันทุกรอบ
+	taskCache      map[int]*TaskInfo           // cache ข้อมูล task
+	userTokenCache map[int][]string            // cache token ของทุกเครื่องโดยใช้ user_id เป็น key
+	boardUserCache map[int][]model.BoardUser   // cache board users
+	userCache      map[int]model.User          // cache users
+	quietCache     map[int]quiethours.Schedule // quiet hours ของผู้รับ ผู้ใช้ที่ไม่ได้ตั้งไม่อยู่ใน map
+	mu             sync.RWMutex                // mutex สำหรับ thread safety
 }
 
 // NotificationResult สำหรับ return ผลลัพธ์
 type NotificationResult struct {
-	Message      string `json:"message"`
-	CurrentTime  string `json:"current_time"`
-	TotalCount   int    `json:"total_count"`
-	SuccessCount int    `json:"success_count"`
-	ErrorCount   int    `json:"error_count"`
-	SkippedCount int    `json:"skipped_count"` // เพิ่ม field สำหรับนับงานที่ข้าม
+	Message       string `json:"message"`
+	CurrentTime   string `json:"current_time"`
+	TotalCount    int    `json:"total_count"`
+	SuccessCount  int    `json:"success_count"`
+	ErrorCount    int    `json:"error_count"`
+	SkippedCount  int    `json:"skipped_count"`  // เพิ่ม field สำหรับนับงานที่ข้าม
+	DeferredCount int    `json:"deferred_count"` // ผู้รับทุกคนอยู่ใน quiet hours
 }
 
 // API Controller - เดิม
-func SendNotificationTaskController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.POST("/send_notification", func(c *gin.Context) {
-		SendNotification(c, db, firestoreClient)
-	})
+func SendNotificationTaskController(router *gin.Engine, db *gorm.DB, fb store.Store, pusher push.Provider) {
+	run := func(c *gin.Context) { SendNotification(c, db, fb, pusher) }
+	router.POST("/v1/jobs/notifications", middleware.RateLimit(ratelimit.ClassJobs), run)
+	router.POST("/send_notification", middleware.Deprecated("/v1/jobs/notifications"), middleware.RateLimit(ratelimit.ClassJobs), run)
 }
 
 // API Handler - เรียกใช้ business logic
-func SendNotification(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
-	result, err := ProcessNotifications(db, firestoreClient)
+func SendNotification(c *gin.Context, db *gorm.DB, fb store.Store, pusher push.Provider) {
+	result, err := ProcessNotifications(c.Request.Context(), db, fb, pusher)
 	if err != nil {
-		log.Printf("API Error: %v", err)
-		c.JSON(500, gin.H{
-			"error": err.Error(),
-		})
+		logging.FromContext(c).Error("failed to process notifications", "error", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	c.JSON(200, gin.H{
-		"message":       result.Message,
-		"current_time":  result.CurrentTime,
-		"total_count":   result.TotalCount,
-		"success_count": result.SuccessCount,
-		"error_count":   result.ErrorCount,
-		"skipped_count": result.SkippedCount, // เพิ่ม skipped_count ใน response
+		"message":        result.Message,
+		"current_time":   result.CurrentTime,
+		"total_count":    result.TotalCount,
+		"success_count":  result.SuccessCount,
+		"error_count":    result.ErrorCount,
+		"skipped_count":  result.SkippedCount, // เพิ่ม skipped_count ใน response
+		"deferred_count": result.DeferredCount,
 	})
 }
 
 // Cron Job Function - Enhanced version
-func SendNotificationJob(db *gorm.DB, firestoreClient *firestore.Client) {
-	log.Println("🔔 Starting notification cron job...")
+// คืน error ของรอบหลักเท่านั้น (recurring เป็นแค่ warning) เพื่อให้ scheduler บันทึกสถานะสำหรับ /readyz
+func SendNotificationJob(ctx context.Context, db *gorm.DB, fb store.Store, pusher push.Provider) error {
+	logger := logging.FromContext(ctx)
+	logger.Info("notification job started")
 
 	// 1. Process all notifications (รวม snooze แล้ว)
-	result, err := ProcessNotifications(db, firestoreClient)
-	if err != nil {
-		log.Printf("❌ Notification job error: %v", err)
+	result, jobErr := ProcessNotifications(ctx, db, fb, pusher)
+	if jobErr != nil {
+		logger.Error("notification job failed", "error", jobErr)
 	} else {
-		log.Printf("✅ All notifications completed - Success: %d, Error: %d, Skipped: %d, Total: %d",
-			result.SuccessCount, result.ErrorCount, result.SkippedCount, result.TotalCount)
+		logger.Info("notifications processed",
+			"success", result.SuccessCount, "error", result.ErrorCount, "skipped", result.SkippedCount, "deferred", result.DeferredCount, "total", result.TotalCount)
+	}
+
+	// push ที่เลื่อนไว้ระหว่าง quiet hours ซึ่งถึงเวลาส่งแล้ว
+	deferredResult, err := ProcessDeferredPushes(ctx, db, fb, pusher)
+	if err != nil {
+		logger.Warn("deferred pushes failed", "error", err)
+	} else if deferredResult.TotalCount > 0 {
+		logger.Info("deferred pushes processed",
+			"success", deferredResult.SuccessCount, "error", deferredResult.ErrorCount, "deferred", deferredResult.DeferredCount, "total", deferredResult.TotalCount)
 	}
 
 	time.Sleep(1 * time.Second)
 
 	// 2. Process recurring notifications เท่านั้น (daily at 7:00 AM Thailand time)
-	log.Println("🔄 Processing recurring notifications...")
-	recurringResult, err := ProcessRecurringNotifications(db, firestoreClient)
+	recurringResult, err := ProcessRecurringNotifications(ctx, db, fb, pusher)
 	if err != nil {
-		log.Printf("⚠️ Warning: Recurring notification error: %v", err)
+		logger.Warn("recurring notifications failed", "error", err)
 	} else {
-		log.Printf("✅ Recurring notifications completed - Success: %d, Error: %d, Skipped: %d, Total: %d",
-			recurringResult.SuccessCount, recurringResult.ErrorCount, recurringResult.SkippedCount, recurringResult.TotalCount)
+		logger.Info("recurring notifications processed",
+			"success", recurringResult.SuccessCount, "error", recurringResult.ErrorCount, "skipped", recurringResult.SkippedCount, "total", recurringResult.TotalCount)
 	}
-}
 
-func ProcessNotifications(db *gorm.DB, firestoreClient *firestore.Client) (*NotificationResult, error) {
-	// โหลด environment variables
-	if err := godotenv.Load(); err != nil {
-		fmt.Println("Warning: No .env file found or failed to load")
-	}
+	return jobErr
+}
 
+func ProcessNotifications(ctx context.Context, db *gorm.DB, fb store.Store, pusher push.Provider) (*NotificationResult, error) {
+	defer metrics.ObserveJob(metrics.JobProcessNotifications, time.Now())
 	now := time.Now().UTC()
 
-	// เริ่ม Firebase app
-	serviceAccountKeyPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_1")
-	if serviceAccountKeyPath == "" {
-		return nil, fmt.Errorf("Firebase credentials not configured")
-	}
-
-	app, err := initializeFirebaseApp(serviceAccountKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to initialize Firebase app: %s", err.Error())
-	}
-
 	var notifications []model.Notification
 
-	// แก้ไข Query ให้รวม snooze notifications ด้วย
+	// แถวที่ถึงกำหนดของแต่ละสถานะ แถวที่ worker อื่น claim อยู่จะถูกข้ามตอน claim
 	query := db.Preload("Task").Where(
-		"(is_send = '0' AND ((beforedue_date IS NOT NULL AND beforedue_date <= ?) OR (beforedue_date IS NULL AND due_date <= ?))) OR "+
-			"(is_send = '1' AND due_date <= ?) OR "+
-			"(is_send = '3' AND snooze IS NOT NULL AND snooze <= ?)", // เพิ่ม condition สำหรับ snooze
-		now, now, now, now,
+		"(is_send = ? AND ((beforedue_date IS NOT NULL AND beforedue_date <= ?) OR (beforedue_date IS NULL AND due_date <= ?))) OR "+
+			"(is_send = ? AND due_date <= ?) OR "+
+			"(is_send = ? AND snooze IS NOT NULL AND snooze <= ?)",
+		model.NotifyPending, now, now,
+		model.NotifyBeforeSent, now,
+		model.NotifySnoozed, now,
 	)
 
 	if err := query.Find(&notifications).Error; err != nil {
@@ -166,215 +172,147 @@ func ProcessNotifications(db *gorm.DB, firestoreClient *firestore.Client) (*Noti
 		}
 	}
 
-	// สร้าง processor พร้อม cache
-	processor := &NotificationProcessor{
-		db:              db,
-		firestoreClient: firestoreClient,
-		app:             app,
-		taskCache:       make(map[int]*TaskInfo),
-		userTokenCache:  make(map[string]string),
-		boardUserCache:  make(map[int][]model.BoardUser),
-		userCache:       make(map[int]model.User),
-	}
+	processor := newProcessor(ctx, db, fb, pusher)
 
 	// Preload ข้อมูลที่จำเป็น
 	processor.preloadData(filteredNotifications)
 
-	successCount := 0
-	errorCount := 0
-	skippedCount := 0
-
-	// สร้าง batches สำหรับการประมวลผล (รวม snooze)
-	batches := processor.prepareBatchesWithSnooze(filteredNotifications, now)
-
-	// ประมวลผลแบบ concurrent
-	const maxWorkers = 10
-	semaphore := make(chan struct{}, maxWorkers)
-	var wg sync.WaitGroup
-	var resultMu sync.Mutex
-
-	for _, batch := range batches {
-		if batch.ShouldSend {
-			wg.Add(1)
-			go func(b NotificationBatch) {
-				defer wg.Done()
-				semaphore <- struct{}{}
-				defer func() { <-semaphore }()
-
-				var result string
-				// ตรวจสอบประเภทของ notification
-				if b.MessageType == "snooze" {
-					result = processor.processSnoozeNotification(b.Notification, now, db)
-				} else {
-					result = processor.processNotificationConcurrent(b.Notification, b.UpdateIsSend, b.MessageType, now, db)
-				}
-
-				resultMu.Lock()
-				switch result {
-				case "success":
-					successCount++
-				case "skipped":
-					skippedCount++
-				case "error":
-					errorCount++
-				}
-				resultMu.Unlock()
-			}(batch)
+	var transitions []pending
+	for _, noti := range filteredNotifications {
+		if tr, ok := nextTransition(noti, now); ok {
+			transitions = append(transitions, pending{noti, tr, nil})
 		}
 	}
 
-	wg.Wait()
-
-	return &NotificationResult{
-		Message:      "Notifications processed successfully",
-		CurrentTime:  now.Format(time.RFC3339),
-		TotalCount:   len(filteredNotifications),
-		SuccessCount: successCount,
-		ErrorCount:   errorCount,
-		SkippedCount: skippedCount,
-	}, nil
+	result := processor.deliverAll(transitions, now, db)
+	result.Message = "Notifications processed successfully"
+	result.TotalCount = len(filteredNotifications)
+	return result, nil
 }
 
-func (p *NotificationProcessor) prepareBatchesWithSnooze(notifications []model.Notification, now time.Time) []NotificationBatch {
-	batches := make([]NotificationBatch, 0, len(notifications))
-
-	for _, notification := range notifications {
-		// ตรวจสอบ snooze notification ก่อน
-		if notification.IsSend == "3" && notification.Snooze != nil &&
-			(notification.Snooze.Before(now) || notification.Snooze.Equal(now)) {
-			batches = append(batches, NotificationBatch{
-				Notification: notification,
-				UpdateIsSend: "3", // ยังคงเป็น 3 แต่จะอัปเดต snooze time
-				ShouldSend:   true,
-				MessageType:  "snooze",
-			})
-		} else {
-			// ใช้ logic เดิมสำหรับ notification ประเภทอื่น
-			shouldSend, updateIsSend, messageType := p.shouldSendNotification(notification, now)
-			batches = append(batches, NotificationBatch{
-				Notification: notification,
-				UpdateIsSend: updateIsSend,
-				ShouldSend:   shouldSend,
-				MessageType:  messageType,
-			})
-		}
-	}
-
-	return batches
+// pending notification หนึ่งรายการกับ transition ที่จะส่ง
+type pending struct {
+	Notification model.Notification
+	Transition   transition
+	Users        map[int]bool // ผู้รับของรอบนี้ nil คือทุกคนของงาน
 }
 
-// ProcessSnoozeNotifications จัดการการแจ้งเตือน snooze
-func ProcessSnoozeNotifications(db *gorm.DB, firestoreClient *firestore.Client) (*NotificationResult, error) {
-	if err := godotenv.Load(); err != nil {
-		fmt.Println("Warning: No .env file found or failed to load")
-	}
-
-	now := time.Now().UTC()
-
-	serviceAccountKeyPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_1")
-	if serviceAccountKeyPath == "" {
-		return nil, fmt.Errorf("Firebase credentials not configured")
-	}
-
-	app, err := initializeFirebaseApp(serviceAccountKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to initialize Firebase app: %s", err.Error())
-	}
-
-	var notifications []model.Notification
-
-	// Query สำหรับ snooze notifications (is_send = '3' และถึงเวลา snooze แล้ว)
-	query := db.Preload("Task").Where(
-		"is_send = '3' AND snooze IS NOT NULL AND snooze <= ?",
-		now,
-	)
-
-	if err := query.Find(&notifications).Error; err != nil {
-		return nil, fmt.Errorf("Failed to fetch snooze notifications: %v", err)
+// newProcessor สร้าง processor ของรอบหนึ่ง owner ต่างกันทุกรอบ claim จึงไม่ปนกันแม้รอบจะซ้อนกันใน process เดียว
+func newProcessor(ctx context.Context, db *gorm.DB, fb store.Store, pusher push.Provider) *NotificationProcessor {
+	return &NotificationProcessor{
+		ctx:            ctx,
+		log:            logging.FromContext(ctx),
+		db:             db,
+		fb:             fb,
+		push:           pusher,
+		owner:          logging.NewID(),
+		taskCache:      make(map[int]*TaskInfo),
+		userTokenCache: make(map[int][]string),
+		boardUserCache: make(map[int][]model.BoardUser),
+		userCache:      make(map[int]model.User),
+		quietCache:     make(map[int]quiethours.Schedule),
 	}
+}
 
-	// กรอง notifications ที่งานยังไม่เสร็จ
-	filteredNotifications := []model.Notification{}
-	for _, noti := range notifications {
-		if noti.Task.Status != "2" {
-			filteredNotifications = append(filteredNotifications, noti)
-		}
-	}
-
-	processor := &NotificationProcessor{
-		db:              db,
-		firestoreClient: firestoreClient,
-		app:             app,
-		taskCache:       make(map[int]*TaskInfo),
-		userTokenCache:  make(map[string]string),
-		boardUserCache:  make(map[int][]model.BoardUser),
-		userCache:       make(map[int]model.User),
-	}
-
-	processor.preloadData(filteredNotifications)
-
+// deliverAll ส่งทุก transition แบบ concurrent แล้วนับผล
+func (p *NotificationProcessor) deliverAll(transitions []pending, now time.Time, db *gorm.DB) *NotificationResult {
 	successCount := 0
 	errorCount := 0
 	skippedCount := 0
+	deferredCount := 0
 
 	const maxWorkers = 10
 	semaphore := make(chan struct{}, maxWorkers)
 	var wg sync.WaitGroup
 	var resultMu sync.Mutex
 
-	for _, notification := range filteredNotifications {
+	for _, item := range transitions {
 		wg.Add(1)
-		go func(noti model.Notification) {
+		go func(item pending) {
 			defer wg.Done()
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			result := processor.processSnoozeNotification(noti, now, db)
+			result := p.deliver(item, now, db)
+			metrics.RecordNotification(item.Transition.Kind, result)
+
 			resultMu.Lock()
 			switch result {
 			case "success":
 				successCount++
 			case "skipped":
 				skippedCount++
+			case "deferred":
+				deferredCount++
 			case "error":
 				errorCount++
 			}
 			resultMu.Unlock()
-		}(notification)
+		}(item)
 	}
 
 	wg.Wait()
 
 	return &NotificationResult{
-		Message:      "Snooze notifications processed successfully",
-		CurrentTime:  now.Format(time.RFC3339),
-		TotalCount:   len(filteredNotifications),
-		SuccessCount: successCount,
-		ErrorCount:   errorCount,
-		SkippedCount: skippedCount,
-	}, nil
+		CurrentTime:   now.Format(time.RFC3339),
+		TotalCount:    len(transitions),
+		SuccessCount:  successCount,
+		ErrorCount:    errorCount,
+		SkippedCount:  skippedCount,
+		DeferredCount: deferredCount,
+	}
 }
 
-// ProcessRecurringNotifications จัดการการแจ้งเตือน recurring
-func ProcessRecurringNotifications(db *gorm.DB, firestoreClient *firestore.Client) (*NotificationResult, error) {
-	if err := godotenv.Load(); err != nil {
-		fmt.Println("Warning: No .env file found or failed to load")
+// ProcessSnoozeNotifications จัดการการแจ้งเตือน snooze
+func ProcessSnoozeNotifications(ctx context.Context, db *gorm.DB, fb store.Store, pusher push.Provider) (*NotificationResult, error) {
+	now := time.Now().UTC()
+
+	var notifications []model.Notification
+
+	// Query สำหรับ snooze notifications (is_send = '3' และถึงเวลา snooze แล้ว)
+	query := db.Preload("Task").Where(
+		"is_send = ? AND snooze IS NOT NULL AND snooze <= ?",
+		model.NotifySnoozed, now,
+	)
+
+	if err := query.Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("Failed to fetch snooze notifications: %v", err)
 	}
 
-	// ใช้ Thailand timezone (GMT+7)
-	thailandTZ, err := time.LoadLocation("Asia/Bangkok")
-	if err != nil {
-		return nil, fmt.Errorf("Failed to load Thailand timezone: %v", err)
+	// กรอง notifications ที่งานยังไม่เสร็จ
+	filteredNotifications := []model.Notification{}
+	var transitions []pending
+	for _, noti := range notifications {
+		if noti.Task.Status == "2" {
+			continue
+		}
+		if tr, ok := nextTransition(noti, now); ok {
+			filteredNotifications = append(filteredNotifications, noti)
+			transitions = append(transitions, pending{noti, tr, nil})
+		}
 	}
 
-	now := time.Now().In(thailandTZ)
+	processor := newProcessor(ctx, db, fb, pusher)
+	processor.preloadData(filteredNotifications)
+
+	result := processor.deliverAll(transitions, now, db)
+	result.Message = "Snooze notifications processed successfully"
+	return result, nil
+}
 
-	// ตรวจสอบว่าเป็นเวลา 7:00 AM หรือไม่ (ให้ tolerance 1 นาที)
-	if now.Hour() != 7 || now.Minute() > 1 {
-		log.Println("⏰ Not time for recurring notifications (should be 7:00 AM Thailand time)")
+// ProcessRecurringNotifications จัดการการแจ้งเตือน recurring
+// ส่งวันละครั้งตอน 07:00 ตามเวลาท้องถิ่นของผู้รับแต่ละคน ผู้รับที่ 07:00 ตรงกันเป็นรอบเดียวกัน
+// รอบหนึ่งส่งได้ครั้งเดียวแม้ job จะรันทั้งตอน 07:00 และ 07:01
+func ProcessRecurringNotifications(ctx context.Context, db *gorm.DB, fb store.Store, pusher push.Provider) (*NotificationResult, error) {
+	defer metrics.ObserveJob(metrics.JobProcessRecurringNotifications, time.Now())
+	nowUTC := time.Now().UTC()
+
+	// offset ของทุกเขตเวลาลงตัวที่ 15 นาที นาทีอื่นจึงไม่มีเขตไหนอยู่ที่ 07:00 หรือ 07:01
+	if nowUTC.Minute()%15 >= int(recurringTolerance/time.Minute) {
+		logging.FromContext(ctx).Debug("not time for recurring notifications in any time zone")
 		return &NotificationResult{
 			Message:      "Not time for recurring notifications",
-			CurrentTime:  now.Format(time.RFC3339),
+			CurrentTime:  nowUTC.Format(time.RFC3339),
 			TotalCount:   0,
 			SuccessCount: 0,
 			ErrorCount:   0,
@@ -382,16 +320,6 @@ func ProcessRecurringNotifications(db *gorm.DB, firestoreClient *firestore.Clien
 		}, nil
 	}
 
-	serviceAccountKeyPath := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS_1")
-	if serviceAccountKeyPath == "" {
-		return nil, fmt.Errorf("Firebase credentials not configured")
-	}
-
-	app, err := initializeFirebaseApp(serviceAccountKeyPath)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to initialize Firebase app: %s", err.Error())
-	}
-
 	var notifications []model.Notification
 
 	// Query สำหรับ recurring notifications ที่ไม่ใช่ "onetime" และงานยังไม่เสร็จ
@@ -405,7 +333,6 @@ func ProcessRecurringNotifications(db *gorm.DB, firestoreClient *firestore.Clien
 
 	// กรอง notifications ที่งานยังไม่เสร็จและเลยกำหนดมาแล้ว
 	filteredNotifications := []model.Notification{}
-	nowUTC := time.Now().UTC()
 
 	for _, noti := range notifications {
 		if noti.Task.Status != "2" && noti.DueDate != nil && noti.DueDate.Before(nowUTC) {
@@ -413,155 +340,237 @@ func ProcessRecurringNotifications(db *gorm.DB, firestoreClient *firestore.Clien
 		}
 	}
 
-	processor := &NotificationProcessor{
-		db:              db,
-		firestoreClient: firestoreClient,
-		app:             app,
-		taskCache:       make(map[int]*TaskInfo),
-		userTokenCache:  make(map[string]string),
-		boardUserCache:  make(map[int][]model.BoardUser),
-		userCache:       make(map[int]model.User),
-	}
-
+	processor := newProcessor(ctx, db, fb, pusher)
 	processor.preloadData(filteredNotifications)
 
-	successCount := 0
-	errorCount := 0
-	skippedCount := 0
+	var transitions []pending
+	for _, noti := range filteredNotifications {
+		taskInfo, err := processor.getTaskInfoOptimized(noti.TaskID)
+		if err != nil {
+			processor.log.Error("failed to get task info", "notification_id", noti.NotificationID, "error", err)
+			continue
+		}
+		for round, users := range processor.recurringRounds(taskInfo.Users, nowUTC) {
+			transitions = append(transitions, pending{noti, recurringTransition(noti, round), users})
+		}
+	}
 
-	const maxWorkers = 10
-	semaphore := make(chan struct{}, maxWorkers)
-	var wg sync.WaitGroup
-	var resultMu sync.Mutex
+	result := processor.deliverAll(transitions, nowUTC, db)
+	result.Message = "Recurring notifications processed successfully"
+	return result, nil
+}
 
-	for _, notification := range filteredNotifications {
-		wg.Add(1)
-		go func(noti model.Notification) {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
+const (
+	// recurringHour เวลาท้องถิ่นที่ส่งแจ้งเตือนงาน recurring ที่เลยกำหนด
+	recurringHour = 7
+	// recurringTolerance ช่วงหลัง recurringHour ที่ job ยังส่งรอบของวันนั้น
+	recurringTolerance = 2 * time.Minute
+)
 
-			result := processor.processRecurringNotification(noti, nowUTC, db)
-			resultMu.Lock()
-			switch result {
-			case "success":
-				successCount++
-			case "skipped":
-				skippedCount++
-			case "error":
-				errorCount++
-			}
-			resultMu.Unlock()
-		}(notification)
+// recurringRounds จัดกลุ่มผู้รับที่ตอนนี้เป็นเวลา 07:00 ตามเขตเวลาของตัวเองตามเวลารอบ (UTC)
+func (p *NotificationProcessor) recurringRounds(users []model.User, now time.Time) map[time.Time]map[int]bool {
+	rounds := make(map[time.Time]map[int]bool)
+	for _, u := range users {
+		round, ok := timezone.DailyRound(now, timezone.Of(u), recurringHour, recurringTolerance)
+		if !ok {
+			continue
+		}
+		if rounds[round] == nil {
+			rounds[round] = make(map[int]bool)
+		}
+		rounds[round][u.UserID] = true
 	}
-
-	wg.Wait()
-
-	return &NotificationResult{
-		Message:      "Recurring notifications processed successfully",
-		CurrentTime:  nowUTC.Format(time.RFC3339),
-		TotalCount:   len(filteredNotifications),
-		SuccessCount: successCount,
-		ErrorCount:   errorCount,
-		SkippedCount: skippedCount,
-	}, nil
+	return rounds
 }
 
-// processSnoozeNotification ประมวลผล snooze notification - แก้ไขให้ return string
-func (p *NotificationProcessor) processSnoozeNotification(notification model.Notification, now time.Time, db *gorm.DB) string {
-	fmt.Printf("Processing snooze notification for Task ID: %d\n", notification.TaskID)
+// deliver claim notification แล้วส่ง transition หนึ่งครั้ง คืน success, skipped, deferred หรือ error
+// is_send เปลี่ยนพร้อมกับบันทึกผลใน notification_attempts เมื่อส่งสำเร็จ เลื่อนไว้ หรือไม่มี token เท่านั้น
+// ผู้รับที่อยู่ใน quiet hours ได้รายการใน inbox ทันทีแต่ push ถูกเลื่อนไป deferred_pushes
+// ข้อความของผู้รับแต่ละคนแสดงเวลาตามเขตเวลาของผู้รับ
+func (p *NotificationProcessor) deliver(item pending, now time.Time, db *gorm.DB) string {
+	notification, tr := item.Notification, item.Transition
+	ctx, log := p.workerContext(notification, tr.Kind)
+	log.Debug("processing notification")
 
 	taskInfo, err := p.getTaskInfoOptimized(notification.TaskID)
 	if err != nil {
-		log.Printf("Failed to get task info for snooze Task ID %d: %v", notification.TaskID, err)
+		log.Error("failed to get task info", "error", err)
 		return "error"
 	}
+	taskInfo = taskInfo.only(item.Users)
 
-	if len(taskInfo.Tokens) == 0 {
-		log.Printf("⏭️ Skipping snooze notification for Task ID %d - no FCM tokens (user disabled notifications)", notification.TaskID)
-		// // ยังคงอัปเดต database แม้ไม่ส่งแจ้งเตือน
+	// recurring ไม่เปลี่ยน is_send แต่ Firestore บันทึกเวลาที่แจ้งล่าสุด
+	mirrorStatus := tr.To
+	if tr.Kind == "recurring" {
+		mirrorStatus = "recurring"
+	}
 
-		if err := p.db.Model(&notification).Updates(map[string]interface{}{
-			"is_send": "4",
-		}).Error; err != nil {
-			log.Printf("Failed to update snooze for notification %d: %v", notification.NotificationID, err)
-			return "error"
+	a, err := claim(ctx, db, notification, tr, p.owner, time.Now())
+	switch {
+	case errors.Is(err, errNotClaimed):
+		log.Debug("notification claimed by another worker or already moved on")
+		return "skipped"
+	case errors.Is(err, errSettled):
+		log.Warn("transition already attempted, advancing without sending", "is_send", tr.To)
+		if tr.From != tr.To {
+			p.mirror(ctx, notification, taskInfo.IsGroup, mirrorStatus, db)
 		}
-		updateFirestoreNotification(p.firestoreClient, notification, taskInfo.IsGroup, "4", db)
 		return "skipped"
+	case err != nil:
+		log.Error("failed to claim notification", "error", err)
+		return "error"
 	}
+	log = log.With("attempt", a.record.Attempt)
+	ctx = logging.WithContext(ctx, log)
 
-	// สร้างข้อความสำหรับ snooze
-	message := fmt.Sprintf("⏰ งานของคุณเลยเวลาที่กำหนดมาแล้ว: %s", taskInfo.Task.TaskName)
+	body := func(userID int) string {
+		return pushBody(notification, tr, taskInfo, now, p.location(userID))
+	}
+	data := pushData(notification, tr, taskInfo, now)
+	p.addToInbox(ctx, notification, tr, taskInfo, body)
 
-	data := map[string]string{
-		"taskid":    fmt.Sprintf("%d", notification.TaskID),
-		"timestamp": now.Format(time.RFC3339),
-		"boardid":   fmt.Sprintf("%v", taskInfo.BoardID),
-		"type":      "snooze",
+	tokens := delivery.Count(taskInfo.Recipients)
+	if tokens == 0 {
+		log.Info("skipping notification: no FCM tokens (user disabled notifications)")
+		// ยังคงเลื่อนสถานะแม้ไม่ส่งแจ้งเตือน
+		if !p.complete(ctx, a, model.AttemptSkipped, 0, 0, nil) {
+			return "error"
+		}
+		p.mirror(ctx, notification, taskInfo.IsGroup, mirrorStatus, db)
+		return "skipped"
 	}
 
-	err = sendMulticastNotification(p.app, taskInfo.Tokens, "แจ้งเตือนงาน", message, data)
+	meta := delivery.Meta{
+		Type:           tr.Kind,
+		NotificationID: &notification.NotificationID,
+		AttemptID:      &a.record.AttemptID,
+		TaskID:         &notification.TaskID,
+		BoardID:        taskInfo.Task.BoardID,
+	}
+	immediate, deferred := p.splitQuiet(notification, tr, taskInfo.Recipients, now)
+	deferred, err = deferPushes(deferred, notification, tr, a, body, data, now)
 	if err != nil {
-		log.Printf("Failed to send snooze notification for Task ID %d: %v", notification.TaskID, err)
+		log.Error("failed to defer notification", "error", err)
+		if err := a.fail(tokens, err); err != nil {
+			log.Error("failed to record notification attempt", "error", err)
+		}
 		return "error"
 	}
 
-	// // อัปเดต snooze เป็นเวลาถัดไป (บวก 10 นาที) และรีเซ็ต is_send เป็น 4
-	if err := p.db.Model(&notification).Updates(map[string]interface{}{
-		"is_send": "4",
-	}).Error; err != nil {
-		log.Printf("Failed to update snooze for notification %d: %v", notification.NotificationID, err)
+	tokens = delivery.Count(immediate)
+	if tokens == 0 {
+		if !p.complete(ctx, a, model.AttemptDeferred, 0, 0, deferred) {
+			return "error"
+		}
+		p.mirror(ctx, notification, taskInfo.IsGroup, mirrorStatus, db)
+		log.Info("notification deferred for quiet hours", "users", len(deferred))
+		return "deferred"
+	}
+
+	failed, err := p.send(ctx, immediate, meta, body, data)
+	if err != nil {
+		log.Error("failed to send notification", "error", err)
+		if err := a.fail(tokens, err); err != nil {
+			log.Error("failed to record notification attempt", "error", err)
+		}
 		return "error"
 	}
 
-	updateFirestoreNotification(p.firestoreClient, notification, taskInfo.IsGroup, "4", db)
-	fmt.Printf("✅ Successfully sent snooze notification for Task ID: %d\n", notification.TaskID)
+	if !p.complete(ctx, a, model.AttemptSent, tokens, failed, deferred) {
+		return "error"
+	}
+	p.mirror(ctx, notification, taskInfo.IsGroup, mirrorStatus, db)
+	log.Info("notification sent", "tokens", tokens, "deferred_users", len(deferred))
 	return "success"
 }
 
-// processRecurringNotification ประมวลผล recurring notification - แก้ไขให้ return string
-func (p *NotificationProcessor) processRecurringNotification(notification model.Notification, now time.Time, db *gorm.DB) string {
-	fmt.Printf("Processing recurring notification for Task ID: %d\n", notification.TaskID)
-
-	taskInfo, err := p.getTaskInfoOptimized(notification.TaskID)
-	if err != nil {
-		log.Printf("Failed to get task info for recurring Task ID %d: %v", notification.TaskID, err)
-		return "error"
+// addToInbox เพิ่มรายการในกล่องของผู้รับทุกคน รวมคนที่ไม่มีเครื่องรับ push
+// dedup_key ผูกกับรอบของ transition การลองส่งใหม่จึงไม่สร้างรายการซ้ำ
+func (p *NotificationProcessor) addToInbox(ctx context.Context, notification model.Notification, tr transition, taskInfo *TaskInfo, body func(userID int) string) {
+	key := fmt.Sprintf("notification:%d:%s:%d", notification.NotificationID, tr.Kind, tr.At.Unix())
+	items := make([]model.InboxItem, len(taskInfo.Users))
+	for i, u := range taskInfo.Users {
+		items[i] = model.InboxItem{
+			UserID:         u.UserID,
+			Type:           tr.Kind,
+			Title:          reminderTitle,
+			Body:           body(u.UserID),
+			TaskID:         &notification.TaskID,
+			BoardID:        taskInfo.Task.BoardID,
+			NotificationID: &notification.NotificationID,
+			DedupKey:       &key,
+		}
 	}
-
-	if len(taskInfo.Tokens) == 0 {
-		log.Printf("⏭️ Skipping recurring notification for Task ID %d - no FCM tokens (user disabled notifications)", notification.TaskID)
-		// ยังคงอัปเดต Firestore แม้ไม่ส่งแจ้งเตือน
-		updateFirestoreNotification(p.firestoreClient, notification, taskInfo.IsGroup, "recurring", db)
-		return "skipped"
+	if err := inbox.Add(ctx, p.db, items); err != nil {
+		logging.FromContext(ctx).Error("failed to add reminder to inbox", "error", err)
 	}
+}
 
-	// สร้างข้อความสำหรับ recurring
-	daysPassed := int(now.Sub(*notification.DueDate).Hours() / 24)
-	var message string
-	if daysPassed == 1 {
-		message = fmt.Sprintf("📅 งานเลยกำหนด 1 วันแล้ว: %s", taskInfo.Task.TaskName)
-	} else {
-		message = fmt.Sprintf("📅 งานเลยกำหนด %d วันแล้ว: %s", daysPassed, taskInfo.Task.TaskName)
+// complete ปิด attempt ที่ส่งแล้ว คืน false ถ้าไม่ควร mirror สถานะใหม่ลง Firestore
+// ถ้าบันทึกไม่ได้ attempt จะค้างเป็น sending และรอบถัดไปจะเลื่อนสถานะโดยไม่ส่งซ้ำ
+func (p *NotificationProcessor) complete(ctx context.Context, a *attempt, status string, tokens, failed int, deferred []model.DeferredPush) bool {
+	err := a.finish(status, tokens, failed, deferred)
+	switch {
+	case errors.Is(err, errLostClaim):
+		logging.FromContext(ctx).Warn("notification changed while sending, is_send left as is")
+		return false
+	case err != nil:
+		logging.FromContext(ctx).Error("failed to record notification attempt", "error", err)
+		return false
+	}
+	return true
+}
+
+// pushBody ข้อความของ push ตามประเภท transition เวลาและจำนวนวันแสดงตามเขตเวลา loc ของผู้รับ
+func pushBody(notification model.Notification, tr transition, taskInfo *TaskInfo, now time.Time, loc *time.Location) string {
+	if tr.Kind == "recurring" {
+		daysPassed := timezone.DaysBetween(*notification.DueDate, now, loc)
+		if daysPassed == 1 {
+			return fmt.Sprintf("📅 งานเลยกำหนด 1 วันแล้ว: %s", taskInfo.Task.TaskName)
+		}
+		return fmt.Sprintf("📅 งานเลยกำหนด %d วันแล้ว: %s", daysPassed, taskInfo.Task.TaskName)
 	}
+	return buildNotificationMessage(notification, tr.Kind, loc)
+}
 
-	data := map[string]string{
+// pushData data ของ push ซึ่งเหมือนกันทุกผู้รับ timestamp เป็น RFC3339 ให้แอปแสดงตามเวลาของเครื่องเอง
+func pushData(notification model.Notification, tr transition, taskInfo *TaskInfo, now time.Time) map[string]string {
+	timestamp := now.Format(time.RFC3339)
+	if tr.Kind == "before" || tr.Kind == "due" {
+		// before และ due ใช้เวลาที่ผู้ใช้ตั้งไว้
+		timestamp = tr.At.Format(time.RFC3339)
+	}
+	return map[string]string{
 		"taskid":    fmt.Sprintf("%d", notification.TaskID),
-		"timestamp": now.Format(time.RFC3339),
+		"timestamp": timestamp,
 		"boardid":   fmt.Sprintf("%v", taskInfo.BoardID),
-		"type":      "recurring",
+		"type":      tr.Kind,
 	}
+}
 
-	err = sendMulticastNotification(p.app, taskInfo.Tokens, "แจ้งเตือนงาน", message, data)
-	if err != nil {
-		log.Printf("Failed to send recurring notification for Task ID %d: %v", notification.TaskID, err)
-		return "error"
-	}
+// location เขตเวลาของผู้ใช้จาก cache ที่ preload แล้ว
+func (p *NotificationProcessor) location(userID int) *time.Location {
+	return timezone.Of(p.userCache[userID])
+}
 
-	updateFirestoreNotification(p.firestoreClient, notification, taskInfo.IsGroup, "recurring", db)
-	fmt.Printf("✅ Successfully sent recurring notification for Task ID: %d\n", notification.TaskID)
-	return "success"
+// only สำเนาของ TaskInfo ที่มีเฉพาะผู้รับใน users ถ้า users เป็น nil คืนตัวเดิม
+func (t *TaskInfo) only(users map[int]bool) *TaskInfo {
+	if users == nil {
+		return t
+	}
+	out := *t
+	out.Users, out.Recipients = nil, nil
+	for _, u := range t.Users {
+		if users[u.UserID] {
+			out.Users = append(out.Users, u)
+		}
+	}
+	for _, r := range t.Recipients {
+		if users[r.UserID] {
+			out.Recipients = append(out.Recipients, r)
+		}
+	}
+	return &out
 }
 
 // preloadData โหลดข้อมูลที่จำเป็นล่วงหน้าเพื่อลด database queries
@@ -621,105 +630,36 @@ func (p *NotificationProcessor) preloadData(notifications []model.Notification)
 
 		// Preload FCM tokens
 		p.preloadTokens(users)
+		p.preloadQuietHours(userIDList)
 	}
 }
 
-// preloadTokens โหลด FCM tokens จาก Firestore แบบ batch
+// preloadTokens โหลด token ของทุกเครื่องที่ยังใช้งานของผู้ใช้ทุกคนในครั้งเดียว
 func (p *NotificationProcessor) preloadTokens(users []model.User) {
-	ctx := context.Background()
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, 5) // จำกัด concurrent Firestore calls
-
-	for _, user := range users {
-		wg.Add(1)
-		go func(u model.User) {
-			defer wg.Done()
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			doc, err := p.firestoreClient.Collection("usersLogin").Doc(u.Email).Get(ctx)
-			if err == nil && doc.Exists() {
-				data := doc.Data()
-				if fcmToken, ok := data["FMCToken"].(string); ok && fcmToken != "" {
-					p.mu.Lock()
-					p.userTokenCache[u.Email] = fcmToken
-					p.mu.Unlock()
-				}
-			}
-		}(user)
-	}
-	wg.Wait()
-}
-
-// shouldSendNotification ตรวจสอบว่าควรส่ง notification หรือไม่
-func (p *NotificationProcessor) shouldSendNotification(notification model.Notification, now time.Time) (bool, string, string) {
-	if notification.IsSend == "0" {
-		if notification.BeforeDueDate != nil && (notification.BeforeDueDate.Before(now) || notification.BeforeDueDate.Equal(now)) {
-			return true, "1", "before"
-		} else if notification.BeforeDueDate == nil && (notification.DueDate.Before(now) || notification.DueDate.Equal(now)) {
-			return true, "2", "due"
-		}
-	} else if notification.IsSend == "1" {
-		if notification.DueDate.Before(now) || notification.DueDate.Equal(now) {
-			return true, "2", "due"
-		}
-	}
-	return false, "", ""
-}
-
-// processNotificationConcurrent ประมวลผล notification แบบ concurrent-safe - แก้ไขให้ return string
-func (p *NotificationProcessor) processNotificationConcurrent(notification model.Notification, updateIsSend string, messageType string, now time.Time, db *gorm.DB) string {
-	fmt.Printf("Processing %s notification for Task ID: %d\n", messageType, notification.TaskID)
-
-	message := buildNotificationMessage(notification, messageType)
-	if message == "" {
-		return "error"
-	}
-
-	taskInfo, err := p.getTaskInfoOptimized(notification.TaskID)
+	tokens, err := pushtoken.Tokens(p.ctx, p.db, p.fb, users, time.Now())
 	if err != nil {
-		log.Printf("Failed to get task info for Task ID %d: %v", notification.TaskID, err)
-		return "error"
-	}
-
-	if len(taskInfo.Tokens) == 0 {
-		log.Printf("⏭️ Skipping notification for Task ID %d - no FCM tokens (user disabled notifications)", notification.TaskID)
-		// ยังคงอัปเดต database status แม้ไม่ส่งแจ้งเตือน
-		if err := p.db.Model(&notification).Update("is_send", updateIsSend).Error; err != nil {
-			log.Printf("Failed to update notification %d: %v", notification.NotificationID, err)
-			return "error"
-		}
-		updateFirestoreNotification(p.firestoreClient, notification, taskInfo.IsGroup, updateIsSend, db)
-		return "skipped"
-	}
-
-	timestamp, err := p.getTimeInfo(updateIsSend, notification)
-	if err != nil {
-		log.Printf("Failed to get time info for Task ID %d: %v", notification.TaskID, err)
-		return "error"
+		p.log.Error("failed to load push devices", "error", err)
+		return
 	}
-
-	data := map[string]string{
-		"taskid":    fmt.Sprintf("%d", notification.TaskID),
-		"timestamp": timestamp,
-		"boardid":   fmt.Sprintf("%v", taskInfo.BoardID),
-		"type":      messageType,
+	p.mu.Lock()
+	for userID, t := range tokens {
+		p.userTokenCache[userID] = t
 	}
+	p.mu.Unlock()
+}
 
-	err = sendMulticastNotification(p.app, taskInfo.Tokens, "แจ้งเตือนงาน", message, data)
-	if err != nil {
-		log.Printf("Failed to send notification for Task ID %d: %v", notification.TaskID, err)
-		return "error"
-	}
+// workerContext คืน ctx และ logger ของ notification หนึ่งรายการ ให้ค้น log ของ task เดียวได้ทุก component
+func (p *NotificationProcessor) workerContext(notification model.Notification, messageType string) (context.Context, *slog.Logger) {
+	log := p.log.With("notification_id", notification.NotificationID, "task_id", notification.TaskID, "message_type", messageType)
+	return logging.WithContext(p.ctx, log), log
+}
 
-	if err := p.db.Model(&notification).Update("is_send", updateIsSend).Error; err != nil {
-		log.Printf("Failed to update notification %d: %v", notification.NotificationID, err)
-		return "error"
+// mirror อัปเดต Firestore หลัง MySQL แล้ว ถ้าล้มเหลวแค่ log ไว้ เพราะ is_send ใน MySQL เปลี่ยนไปแล้ว
+func (p *NotificationProcessor) mirror(ctx context.Context, notification model.Notification, isGroup bool, newStatus string, db *gorm.DB) {
+	if err := updateFirestoreNotification(ctx, p.fb, notification, isGroup, newStatus, db); err != nil {
+		metrics.FirestoreMirrorFailures.Inc()
+		logging.FromContext(ctx).Error("failed to update Firestore mirror", "status", newStatus, "error", err)
 	}
-
-	updateFirestoreNotification(p.firestoreClient, notification, taskInfo.IsGroup, updateIsSend, db)
-	fmt.Printf("✅ Successfully sent %s notification for Task ID: %d\n", messageType, notification.TaskID)
-	return "success"
 }
 
 // getTaskInfoOptimized ดึงข้อมูล task โดยใช้ cache ที่ preload แล้ว
@@ -741,7 +681,7 @@ func (p *NotificationProcessor) getTaskInfoOptimized(taskID int) (*TaskInfo, err
 		return nil, fmt.Errorf("error checking if task is group: %v", err)
 	}
 
-	users, tokens, err := p.getUsersAndTokensOptimized(task)
+	users, recipients, err := p.getUsersAndTokensOptimized(task)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get users and tokens: %v", err)
 	}
@@ -752,11 +692,11 @@ func (p *NotificationProcessor) getTaskInfoOptimized(taskID int) (*TaskInfo, err
 	}
 
 	taskInfo := &TaskInfo{
-		Task:    task,
-		Users:   users,
-		Tokens:  tokens,
-		IsGroup: isGroup,
-		BoardID: boardID,
+		Task:       task,
+		Users:      users,
+		Recipients: recipients,
+		IsGroup:    isGroup,
+		BoardID:    boardID,
 	}
 
 	p.mu.Lock()
@@ -767,9 +707,15 @@ func (p *NotificationProcessor) getTaskInfoOptimized(taskID int) (*TaskInfo, err
 }
 
 // getUsersAndTokensOptimized ใช้ cache แทนการ query database
-func (p *NotificationProcessor) getUsersAndTokensOptimized(task model.Tasks) ([]model.User, []string, error) {
+func (p *NotificationProcessor) getUsersAndTokensOptimized(task model.Tasks) ([]model.User, []delivery.Recipient, error) {
 	var users []model.User
-	var tokens []string
+	var recipients []delivery.Recipient
+	add := func(user model.User) {
+		users = append(users, user)
+		if tokens := p.userTokenCache[user.UserID]; len(tokens) > 0 {
+			recipients = append(recipients, delivery.Recipient{UserID: user.UserID, Tokens: tokens})
+		}
+	}
 
 	if task.BoardID != nil {
 		// ใช้ cached board users
@@ -780,10 +726,7 @@ func (p *NotificationProcessor) getUsersAndTokensOptimized(task model.Tasks) ([]
 		if exists && len(boardUsers) > 0 {
 			for _, boardUser := range boardUsers {
 				if user, userExists := p.userCache[boardUser.UserID]; userExists {
-					users = append(users, user)
-					if token, tokenExists := p.userTokenCache[user.Email]; tokenExists {
-						tokens = append(tokens, token)
-					}
+					add(user)
 				}
 			}
 		} else {
@@ -794,25 +737,19 @@ func (p *NotificationProcessor) getUsersAndTokensOptimized(task model.Tasks) ([]
 			}
 
 			if user, exists := p.userCache[board.CreatedBy]; exists {
-				users = append(users, user)
-				if token, tokenExists := p.userTokenCache[user.Email]; tokenExists {
-					tokens = append(tokens, token)
-				}
+				add(user)
 			}
 		}
 	} else {
 		// ไม่มี board_id ให้ใช้ CreateBy ใน Tasks
 		if task.CreateBy != nil {
 			if user, exists := p.userCache[*task.CreateBy]; exists {
-				users = append(users, user)
-				if token, tokenExists := p.userTokenCache[user.Email]; tokenExists {
-					tokens = append(tokens, token)
-				}
+				add(user)
 			}
 		}
 	}
 
-	return users, tokens, nil
+	return users, recipients, nil
 }
 
 // isGroupTaskOptimized ใช้ cached data
@@ -828,35 +765,20 @@ func (p *NotificationProcessor) isGroupTaskOptimized(task model.Tasks) (bool, er
 	return exists && len(boardUsers) > 0, nil
 }
 
-func (p *NotificationProcessor) getTimeInfo(updateIsSend string, notification model.Notification) (string, error) {
-	var timestamp string
-	if updateIsSend == "1" {
-		timestamp = notification.BeforeDueDate.Format(time.RFC3339)
-	} else if updateIsSend == "2" {
-		timestamp = notification.DueDate.Format(time.RFC3339)
-	} else {
-		return "", fmt.Errorf("invalid update status: %s", updateIsSend)
-	}
-	return timestamp, nil
-}
-
-func initializeFirebaseApp(serviceAccountKeyPath string) (*firebase.App, error) {
-	ctx := context.Background()
-	opt := option.WithCredentialsFile(serviceAccountKeyPath)
-	app, err := firebase.NewApp(ctx, nil, opt)
-	if err != nil {
-		return nil, fmt.Errorf("error initializing app: %v", err)
-	}
-	return app, nil
-}
-
-func buildNotificationMessage(noti model.Notification, messageType string) string {
+// buildNotificationMessage เวลาครบกำหนดในข้อความแสดงตามเวลาท้องถิ่นใน loc
+func buildNotificationMessage(noti model.Notification, messageType string, loc *time.Location) string {
 	taskName := noti.Task.TaskName
 
 	switch messageType {
 	case "before":
+		if noti.DueDate != nil {
+			return fmt.Sprintf("⏰ ใกล้ถึงเวลา: %s (ครบกำหนด %s)", taskName, timezone.Format(*noti.DueDate, loc))
+		}
 		return fmt.Sprintf("⏰ ใกล้ถึงเวลา: %s", taskName)
 	case "due":
+		if noti.DueDate != nil {
+			return fmt.Sprintf("📌 ถึงกำหนดแล้ว: %s (%s)", taskName, timezone.Format(*noti.DueDate, loc))
+		}
 		return fmt.Sprintf("📌 ถึงกำหนดแล้ว: %s", taskName)
 	case "snooze":
 		return fmt.Sprintf("⏰ งานของคุณเลยเวลาที่กำหนดมาแล้ว: %s", taskName)
@@ -864,9 +786,9 @@ func buildNotificationMessage(noti model.Notification, messageType string) strin
 		return fmt.Sprintf("📅 งานยังไม่เสร็จ: %s", taskName)
 	default:
 		// Fallback to original logic
-		if noti.BeforeDueDate != nil && noti.IsSend == "0" {
+		if noti.BeforeDueDate != nil && noti.IsSend == model.NotifyPending {
 			return fmt.Sprintf("⏰ ใกล้ถึงเวลา: %s", taskName)
-		} else if noti.IsSend == "1" || (noti.BeforeDueDate == nil && noti.IsSend == "0") {
+		} else if noti.IsSend == model.NotifyBeforeSent || (noti.BeforeDueDate == nil && noti.IsSend == model.NotifyPending) {
 			return fmt.Sprintf("📌 ถึงกำหนดแล้ว: %s", taskName)
 		}
 	}
@@ -874,57 +796,42 @@ func buildNotificationMessage(noti model.Notification, messageType string) strin
 	return ""
 }
 
-func sendMulticastNotification(app *firebase.App, tokens []string, title, body string, data map[string]string) error {
-	ctx := context.Background()
-
-	client, err := app.Messaging(ctx)
-	if err != nil {
-		return fmt.Errorf("error getting Messaging client: %v", err)
-	}
-
-	// แบ่ง tokens เป็น batches (FCM จำกัดที่ 500 tokens ต่อ request)
-	const batchSize = 500
-	for i := 0; i < len(tokens); i += batchSize {
-		end := i + batchSize
-		if end > len(tokens) {
-			end = len(tokens)
-		}
-
-		batch := tokens[i:end]
-		message := &messaging.MulticastMessage{
-			Data: data,
-			Notification: &messaging.Notification{
-				Title: title,
-				Body:  body,
-			},
-			Tokens: batch,
+// send ส่งแจ้งเตือนงานไปทุกเครื่องของผู้รับและบันทึกผลลง push_deliveries คืนจำนวน token ที่ล้ม
+// ผู้รับที่ได้ข้อความเดียวกัน (เขตเวลาเดียวกัน) ถูกส่งใน request เดียว
+// token ที่ล้มบางส่วนแค่ log ไว้ ไม่ถือว่ารอบนี้ล้ม แต่ถ้าล้มทุก token ถือว่าส่งไม่สำเร็จให้ลองใหม่รอบถัดไป
+func (p *NotificationProcessor) send(ctx context.Context, recipients []delivery.Recipient, meta delivery.Meta, body func(userID int) string, data map[string]string) (int, error) {
+	var bodies []string
+	groups := make(map[string][]delivery.Recipient)
+	for _, r := range recipients {
+		b := body(r.UserID)
+		if _, ok := groups[b]; !ok {
+			bodies = append(bodies, b)
 		}
+		groups[b] = append(groups[b], r)
+	}
 
-		response, err := client.SendEachForMulticast(ctx, message)
+	failed, total, errs := 0, 0, 0
+	var lastErr error
+	for _, b := range bodies {
+		results, err := delivery.Send(ctx, p.db, p.push, groups[b], meta, push.Message{Title: reminderTitle, Body: b, Data: data})
+		failed += push.Failed(results)
+		total += len(results)
 		if err != nil {
-			log.Printf("Error sending batch %d-%d: %v", i, end-1, err)
-			continue
-		}
-
-		log.Printf("Batch %d-%d: Success: %d, Failure: %d",
-			i, end-1, response.SuccessCount, response.FailureCount)
-
-		if response.FailureCount > 0 {
-			for idx, resp := range response.Responses {
-				if !resp.Success {
-					log.Printf("Failed to send to token %s: %v", batch[idx], resp.Error)
-				}
-			}
+			errs++
+			lastErr = err
 		}
 	}
-
-	return nil
+	if errs == len(bodies) {
+		return failed, lastErr
+	}
+	if failed > 0 {
+		logging.FromContext(ctx).Warn("push failed for some tokens", "failed", failed, "total", total)
+	}
+	return failed, nil
 }
 
-func updateFirestoreNotification(client *firestore.Client, notification model.Notification, isGroup bool, newStatus string, db *gorm.DB) error {
-	ctx := context.Background()
-
-	var docPath string
+func updateFirestoreNotification(ctx context.Context, fb store.Store, notification model.Notification, isGroup bool, newStatus string, db *gorm.DB) error {
+	var docPath, email string
 	updateData := map[string]interface{}{
 		"isSend": newStatus,
 	}
@@ -950,8 +857,8 @@ func updateFirestoreNotification(client *firestore.Client, notification model.No
 
 			updateData["isNotiRemind"] = true
 			updateData["isNotiRemindShow"] = true
-			updateData["dueDateOld"] = firestore.Delete
-			updateData["remindMeBeforeOld"] = firestore.Delete
+			updateData["dueDateOld"] = store.DeleteField
+			updateData["remindMeBeforeOld"] = store.DeleteField
 			updateData["updatedAt"] = time.Now().UTC()
 
 			userNotifications := make(map[string]interface{})
@@ -983,8 +890,8 @@ func updateFirestoreNotification(client *firestore.Client, notification model.No
 
 				updateData["isShow"] = true
 				updateData["updatedAt"] = time.Now().UTC()
-				updateData["dueDateOld"] = firestore.Delete
-				updateData["remindMeBeforeOld"] = firestore.Delete
+				updateData["dueDateOld"] = store.DeleteField
+				updateData["remindMeBeforeOld"] = store.DeleteField
 
 				userNotifications := make(map[string]interface{})
 				for _, boardUser := range boardUsers {
@@ -1018,12 +925,11 @@ func updateFirestoreNotification(client *firestore.Client, notification model.No
 				updateData["isShow"] = false
 				updateData["isNotiRemind"] = false
 
-				if notification.BeforeDueDate != nil {
-					nextRemindMeBefore := notification.BeforeDueDate.AddDate(0, 0, 1)
-					updateData["remindMeBefore"] = nextRemindMeBefore
-				} else {
-					updateData["remindMeBefore"] = nil
+				loc := timezone.Load(timezone.Default)
+				if owner, err := getTaskOwner(db, notification.TaskID); err == nil {
+					loc = timezone.Of(owner)
 				}
+				updateData["remindMeBefore"] = nextRemindMeBefore(notification, loc)
 
 				userNotifications := make(map[string]interface{})
 				for _, boardUser := range boardUsers {
@@ -1047,24 +953,25 @@ func updateFirestoreNotification(client *firestore.Client, notification model.No
 			updateData["lastRecurringNotification"] = time.Now().UTC()
 		}
 	} else {
-		email, err := getTaskOwnerEmail(db, notification.TaskID)
+		owner, err := getTaskOwner(db, notification.TaskID)
 		if err != nil {
-			return fmt.Errorf("failed to get task owner email: %v", err)
+			return fmt.Errorf("failed to get task owner: %v", err)
 		}
+		email = owner.Email
 		docPath = fmt.Sprintf("Notifications/%s/Tasks/%d", email, notification.NotificationID)
 
 		if newStatus == "1" {
 			updateData["isNotiRemind"] = true
 			updateData["isNotiRemindShow"] = true
 			updateData["updatedAt"] = time.Now().UTC()
-			updateData["dueDateOld"] = firestore.Delete
-			updateData["remindMeBeforeOld"] = firestore.Delete
+			updateData["dueDateOld"] = store.DeleteField
+			updateData["remindMeBeforeOld"] = store.DeleteField
 		} else if newStatus == "2" {
 			if notification.RecurringPattern == "onetime" {
 				updateData["isShow"] = true
 				updateData["updatedAt"] = time.Now().UTC()
-				updateData["dueDateOld"] = firestore.Delete
-				updateData["remindMeBeforeOld"] = firestore.Delete
+				updateData["dueDateOld"] = store.DeleteField
+				updateData["remindMeBeforeOld"] = store.DeleteField
 			} else {
 				// Recurring task
 
@@ -1074,12 +981,7 @@ func updateFirestoreNotification(client *firestore.Client, notification model.No
 				updateData["isShow"] = false
 				updateData["isNotiRemind"] = false
 
-				if notification.BeforeDueDate != nil {
-					nextRemindMeBefore := notification.BeforeDueDate.AddDate(0, 0, 1)
-					updateData["remindMeBefore"] = nextRemindMeBefore
-				} else {
-					updateData["remindMeBefore"] = nil
-				}
+				updateData["remindMeBefore"] = nextRemindMeBefore(notification, timezone.Of(owner))
 			}
 		} else if newStatus == "3" {
 			// Snooze notification
@@ -1091,29 +993,43 @@ func updateFirestoreNotification(client *firestore.Client, notification model.No
 		}
 	}
 
-	_, err := client.Doc(docPath).Set(ctx, updateData, firestore.MergeAll)
+	var err error
+	if isGroup {
+		err = fb.BoardTasks().MergeItem(ctx, notification.TaskID, store.TaskNotifications, strconv.Itoa(notification.NotificationID), updateData)
+	} else {
+		err = fb.Notifications().MergeTask(ctx, email, notification.NotificationID, updateData)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to update Firestore document at %s: %v", docPath, err)
 	}
 
-	log.Printf("Successfully updated Firestore notification at path: %s", docPath)
+	logging.FromContext(ctx).Debug("updated Firestore notification", "path", docPath)
 	return nil
 }
 
-func getTaskOwnerEmail(db *gorm.DB, taskID int) (string, error) {
+// nextRemindMeBefore เวลาเตือนล่วงหน้าของรอบถัดไปของงาน recurring เลื่อนหนึ่งวันตามปฏิทินในเขตเวลาของเจ้าของงาน
+// เวลาท้องถิ่นจึงคงเดิมแม้ข้ามวันที่เปลี่ยน daylight saving คืน nil ถ้าไม่ได้ตั้งเตือนล่วงหน้า
+func nextRemindMeBefore(notification model.Notification, loc *time.Location) interface{} {
+	if notification.BeforeDueDate == nil {
+		return nil
+	}
+	return timezone.AddDate(*notification.BeforeDueDate, loc, 0, 0, 1)
+}
+
+func getTaskOwner(db *gorm.DB, taskID int) (model.User, error) {
 	var task model.Tasks
 	if err := db.First(&task, "task_id = ?", taskID).Error; err != nil {
-		return "", fmt.Errorf("failed to find task: %v", err)
+		return model.User{}, fmt.Errorf("failed to find task: %v", err)
 	}
 
 	if task.CreateBy == nil {
-		return "", fmt.Errorf("task has no creator (create_by is nil)")
+		return model.User{}, fmt.Errorf("task has no creator (create_by is nil)")
 	}
 
 	var user model.User
 	if err := db.First(&user, "user_id = ?", *task.CreateBy).Error; err != nil {
-		return "", fmt.Errorf("failed to find user: %v", err)
+		return model.User{}, fmt.Errorf("failed to find user: %v", err)
 	}
 
-	return user.Email, nil
+	return user, nil
 }
@@ -2,33 +2,40 @@ package report
 
 import (
 	"context"
-	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/ratelimit"
+	"mydayplanner/store"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func ReportController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/report")
+func ReportController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	readAll := func(c *gin.Context) { ReadAllReport(c, db, fb) }
+	readCategory := func(c *gin.Context) { ReadCategoryReport(c, db, fb) }
+	send := func(c *gin.Context) { ReportSending(c, db, fb) }
+	del := func(c *gin.Context) { DeleteReport(c, db, fb) }
+
+	routes := router.Group("/v1/reports", middleware.AccessTokenMiddleware())
 	{
-		routes.GET("/allreport", middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), func(c *gin.Context) {
-			ReadAllReport(c, db, firestoreClient)
-		})
-		routes.GET("/category/:categoryid", middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), func(c *gin.Context) {
-			ReadCategoryReport(c, db, firestoreClient)
-		})
-		routes.POST("/send", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-			ReportSending(c, db, firestoreClient)
-		})
-		routes.DELETE("/delete/:rid", middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), func(c *gin.Context) {
-			DeleteReport(c, db, firestoreClient)
-		})
+		routes.GET("", middleware.AdminMiddleware(), readAll)
+		routes.GET("/categories/:categoryid", middleware.AdminMiddleware(), readCategory)
+		routes.POST("", middleware.RateLimit(ratelimit.ClassReport), send)
+		routes.DELETE("/:rid", middleware.AdminMiddleware(), del)
+	}
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	legacy := router.Group("/report")
+	{
+		legacy.GET("/allreport", middleware.Deprecated("/v1/reports"), middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), readAll)
+		legacy.GET("/category/:categoryid", middleware.Deprecated("/v1/reports/categories/:categoryid"), middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), readCategory)
+		legacy.POST("/send", middleware.Deprecated("/v1/reports"), middleware.AccessTokenMiddleware(), middleware.RateLimit(ratelimit.ClassReport), send)
+		legacy.DELETE("/delete/:rid", middleware.Deprecated("/v1/reports/:rid"), middleware.AccessTokenMiddleware(), middleware.AdminMiddleware(), del)
 	}
 }
 
@@ -67,20 +74,20 @@ func GenarateColor(category string) string {
 	}
 }
 
-func ReportSending(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func ReportSending(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 	var reportdata dto.ReportdataRequest
 
 	// ใช้ ShouldBindJSON เพราะไม่ต้องการอ่าน body หลายครั้ง
 	if err := c.ShouldBindJSON(&reportdata); err != nil {
-		c.JSON(400, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// ตรวจสอบความถูกต้องของ CategoryID ก่อนที่จะทำการค้นหาผู้ใช้
 	category, valid := getCategoryName(reportdata.CategoryID)
 	if !valid {
-		c.JSON(400, gin.H{"error": "Invalid report ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("report_id", "invalid"))
 		return
 	}
 
@@ -101,7 +108,7 @@ func ReportSending(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	}()
 
 	if err := tx.Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to start transaction", "details": err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -109,14 +116,14 @@ func ReportSending(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	var user model.User
 	if err := tx.Select("user_id", "email").Where("user_id = ?", userId).First(&user).Error; err != nil {
 		tx.Rollback()
-		c.JSON(500, gin.H{"error": "User not found", "details": err.Error()})
+		apperror.Respond(c, apperror.UserNotFound.Wrap(err))
 		return
 	}
 
 	// บันทึก report
 	if err := tx.Create(&report).Error; err != nil {
 		tx.Rollback()
-		c.JSON(500, gin.H{"error": "Failed to save report", "details": err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -129,48 +136,45 @@ func ReportSending(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	}
 
 	ctx := context.Background()
-	subCollection := firestoreClient.Collection("Reports").Doc(user.Email).Collection(category)
-	docRef := subCollection.Doc(fmt.Sprintf("report_%d", report.ReportID))
-
-	if _, err := docRef.Set(ctx, reportdatafirebase); err != nil {
+	if err := fb.Reports().Set(ctx, user.Email, category, report.ReportID, reportdatafirebase); err != nil {
 		tx.Rollback()
-		c.JSON(500, gin.H{"error": "Failed to save report to Firestore", "details": err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to commit transaction", "details": err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Report sent successfully!"})
 }
 
-func DeleteReport(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteReport(c *gin.Context, db *gorm.DB, fb store.Store) {
 	reportId := c.Param("rid")
 	var report model.Report
 
 	// ตรวจสอบว่าผู้ใช้มีสิทธิ์ในการลบรายงาน
 	if err := db.Where("report_id = ?", reportId).First(&report).Error; err != nil {
-		c.JSON(404, gin.H{"error": "Report not found"})
+		apperror.Respond(c, apperror.ReportNotFound)
 		return
 	}
 
 	// ลบรายงานจากฐานข้อมูล
 	if err := db.Delete(&report).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Failed to delete report", "details": err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	c.JSON(200, gin.H{"message": "Report deleted successfully!"})
 }
 
-func ReadAllReport(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func ReadAllReport(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var reports []model.Report
 
 	// ใช้ Preload เพื่อดึงข้อมูลผู้ใช้ที่เกี่ยวข้องในคำสั่งเดียว
 	if err := db.Preload("User").Find(&reports).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Database error", "details": err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -191,16 +195,16 @@ func ReadAllReport(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clien
 	c.JSON(200, gin.H{"reports": reportList})
 }
 
-func ReadCategoryReport(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func ReadCategoryReport(c *gin.Context, db *gorm.DB, fb store.Store) {
 	category := c.Param("categoryid")
 	categoryID, err := strconv.Atoi(category)
 	if err != nil {
-		c.JSON(400, gin.H{"error": "Invalid category ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("category_id", "invalid"))
 		return
 	}
 	category, valid := getCategoryName(categoryID)
 	if !valid {
-		c.JSON(400, gin.H{"error": "Invalid category"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("category", "invalid"))
 		return
 	}
 
@@ -208,7 +212,7 @@ func ReadCategoryReport(c *gin.Context, db *gorm.DB, firestoreClient *firestore.
 
 	// ใช้ Preload เพื่อดึงข้อมูลผู้ใช้ที่เกี่ยวข้องในคำสั่งเดียว
 	if err := db.Preload("User").Where("category = ?", category).Find(&reports).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Database error", "details": err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
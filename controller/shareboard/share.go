@@ -4,24 +4,22 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/hex"
+	"mydayplanner/apperror"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/url"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func ShareboardController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/shareboard")
-	{
-		routes.POST("/create/:boardid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-			CreateShareboard(c, db, firestoreClient)
-		})
-	}
+func ShareboardController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	create := func(c *gin.Context) { CreateShareboard(c, db, fb) }
+	router.POST("/v1/boards/:boardid/share", middleware.AccessTokenMiddleware(), create)
+	router.POST("/shareboard/create/:boardid", middleware.Deprecated("/v1/boards/:boardid/share"), middleware.AccessTokenMiddleware(), create)
 }
 
 // ShareToken struct สำหรับเก็บข้อมูล share token
@@ -34,16 +32,14 @@ type ShareToken struct {
 	CreatedBy uint      `json:"created_by"`
 }
 
-func CreateShareboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func CreateShareboard(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 	boardID := c.Param("boardid")
 
 	// แปลง boardID จาก string เป็น int
 	boardIDInt, err := strconv.Atoi(boardID)
 	if err != nil {
-		c.JSON(400, gin.H{
-			"error": "Invalid board ID format",
-		})
+		apperror.Respond(c, apperror.InvalidInput.WithField("boardid", "invalid"))
 		return
 	}
 
@@ -51,14 +47,10 @@ func CreateShareboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	var board model.Board
 	if err := db.Where("board_id = ? AND create_by = ?", boardIDInt, userId).First(&board).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(404, gin.H{
-				"error": "Board not found or access denied",
-			})
+			apperror.Respond(c, apperror.BoardNotFound)
 			return
 		}
-		c.JSON(500, gin.H{
-			"error": "Database error",
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -88,9 +80,7 @@ func CreateShareboard(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	}
 
 	if err := db.Create(&shareToken).Error; err != nil {
-		c.JSON(500, gin.H{
-			"error": "Failed to create share token",
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -111,9 +101,7 @@ func JoinSharedBoard(c *gin.Context, db *gorm.DB) {
 	boardIDStr := c.Query("boardId")
 
 	if token == "" || boardIDStr == "" {
-		c.JSON(400, gin.H{
-			"error": "Missing required parameters",
-		})
+		apperror.Respond(c, apperror.InvalidInput)
 		return
 	}
 
@@ -121,40 +109,30 @@ func JoinSharedBoard(c *gin.Context, db *gorm.DB) {
 	var shareToken ShareToken
 	if err := db.Where("token = ?", token).First(&shareToken).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(404, gin.H{
-				"error": "Invalid share link",
-			})
+			apperror.Respond(c, apperror.ShareTokenNotFound)
 			return
 		}
-		c.JSON(500, gin.H{
-			"error": "Database error",
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// ตรวจสอบว่า token หมดอายุหรือยัง
 	if time.Now().After(shareToken.ExpireAt) {
-		c.JSON(410, gin.H{
-			"error": "Share link has expired",
-		})
+		apperror.Respond(c, apperror.ShareLinkExpired)
 		return
 	}
 
 	// ตรวจสอบว่า boardID ตรงกับ token หรือไม่
 	boardID, _ := strconv.Atoi(boardIDStr)
 	if shareToken.BoardID != uint(boardID) {
-		c.JSON(400, gin.H{
-			"error": "Invalid board ID for this token",
-		})
+		apperror.Respond(c, apperror.ShareTokenInvalid)
 		return
 	}
 
 	// ดึงข้อมูล board
 	var board model.Board
 	if err := db.Where("board_id = ?", boardID).First(&board).Error; err != nil {
-		c.JSON(404, gin.H{
-			"error": "Board not found",
-		})
+		apperror.Respond(c, apperror.BoardNotFound)
 		return
 	}
 
@@ -180,18 +158,14 @@ func ValidateShareToken(c *gin.Context, db *gorm.DB) {
 	token := c.Query("token")
 
 	if token == "" {
-		c.JSON(401, gin.H{
-			"error": "Access token required",
-		})
+		apperror.Respond(c, apperror.TokenMissing)
 		return
 	}
 
 	// แปลง boardID
 	boardIDInt, err := strconv.Atoi(boardID)
 	if err != nil {
-		c.JSON(400, gin.H{
-			"error": "Invalid board ID format",
-		})
+		apperror.Respond(c, apperror.InvalidInput.WithField("boardid", "invalid"))
 		return
 	}
 
@@ -200,23 +174,17 @@ func ValidateShareToken(c *gin.Context, db *gorm.DB) {
 	if err := db.Where("board_id = ? AND token = ? AND expires_at > ?",
 		boardIDInt, token, time.Now()).First(&boardToken).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(401, gin.H{
-				"error": "Invalid or expired token",
-			})
+			apperror.Respond(c, apperror.ShareTokenInvalid)
 			return
 		}
-		c.JSON(500, gin.H{
-			"error": "Database error",
-		})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
 	// ดึงข้อมูล board
 	var board model.Board
 	if err := db.Where("board_id = ?", boardIDInt).First(&board).Error; err != nil {
-		c.JSON(404, gin.H{
-			"error": "Board not found",
-		})
+		apperror.Respond(c, apperror.BoardNotFound)
 		return
 	}
 
@@ -235,26 +203,20 @@ func RevokeShareToken(c *gin.Context, db *gorm.DB) {
 
 	boardIDInt, err := strconv.Atoi(boardID)
 	if err != nil {
-		c.JSON(400, gin.H{
-			"error": "Invalid board ID format",
-		})
+		apperror.Respond(c, apperror.InvalidInput.WithField("boardid", "invalid"))
 		return
 	}
 
 	// ตรวจสอบว่า user เป็นเจ้าของ board
 	var board model.Board
 	if err := db.Where("board_id = ? AND user_id = ?", boardIDInt, userId).First(&board).Error; err != nil {
-		c.JSON(404, gin.H{
-			"error": "Board not found or access denied",
-		})
+		apperror.Respond(c, apperror.BoardNotFound)
 		return
 	}
 
 	// ลบ token
 	if err := db.Where("board_id = ? AND token = ?", boardIDInt, token).Delete(&model.BoardToken{}).Error; err != nil {
-		c.JSON(500, gin.H{
-			"error": "Failed to revoke token",
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
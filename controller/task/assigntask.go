@@ -3,45 +3,60 @@ package task
 import (
 	"context"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
-func AssignedController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.POST("/assigned", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		AddAssignedTask(c, db, firestoreClient)
-	})
-	router.DELETE("/assigned/:taskid/:assignid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		DelAssignedTask(c, db, firestoreClient)
-	})
+func AssignedController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	assign := func(c *gin.Context) { AddAssignedTask(c, db, fb) }
+	unassign := func(c *gin.Context) { DelAssignedTask(c, db, fb) }
+
+	routes := router.Group("/v1/tasks/:taskid/assignees", middleware.AccessTokenMiddleware())
+	{
+		routes.POST("", assign)
+		routes.DELETE("/:assignid", unassign)
+	}
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	router.POST("/assigned", middleware.Deprecated("/v1/tasks/:taskid/assignees"), middleware.AccessTokenMiddleware(), assign)
+	router.DELETE("/assigned/:taskid/:assignid", middleware.Deprecated("/v1/tasks/:taskid/assignees/:assignid"), middleware.AccessTokenMiddleware(), unassign)
 }
 
-func AddAssignedTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func AddAssignedTask(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var assignedTask dto.AssignedTaskRequest
-	if err := c.ShouldBindJSON(&assignedTask); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request data"})
+	if taskIDStr := c.Param("taskid"); taskIDStr != "" {
+		var req dto.AssigneeRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apperror.Respond(c, apperror.Validation(err))
+			return
+		}
+		assignedTask = dto.AssignedTaskRequest{TaskID: taskIDStr, UserID: req.UserID}
+	} else if err := c.ShouldBindJSON(&assignedTask); err != nil {
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// Convert string IDs to integers
 	taskID, err := strconv.Atoi(assignedTask.TaskID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID format"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	userID, err := strconv.Atoi(assignedTask.UserID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid user ID format"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("user_id", "invalid"))
 		return
 	}
 
@@ -49,9 +64,9 @@ func AddAssignedTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	var task model.Tasks
 	if err := db.Where("task_id = ?", taskID).First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -60,9 +75,9 @@ func AddAssignedTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	var user model.User
 	if err := db.Where("user_id = ?", userID).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate user"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -77,9 +92,9 @@ func AddAssignedTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	}
 
 	// Add to Firebase
-	if err := createFirebaseAssignment(firestoreClient, newAssignment, task, user); err != nil {
+	if err := createFirebaseAssignment(fb, newAssignment, task, user); err != nil {
 		// Log the error but don't fail the request since DB creation succeeded
-		fmt.Printf("Warning: Failed to create Firebase assignment: %v\n", err)
+		logging.FromContext(c).Warn("failed to create Firestore assignment", "error", err)
 	}
 
 	// Return success response
@@ -93,12 +108,9 @@ func AddAssignedTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	})
 }
 
-func createFirebaseAssignment(firestoreClient *firestore.Client, assignment model.Assignment, task model.Tasks, user model.User) error {
+func createFirebaseAssignment(fb store.Store, assignment model.Assignment, task model.Tasks, user model.User) error {
 	ctx := context.Background()
 
-	// Construct the Firebase path: /Assigned/{newAssignedID}
-	docPath := fmt.Sprintf("BoardTasks/%d/Assigned/%d", task.TaskID, assignment.AssID)
-
 	// Create Firebase document data
 	firebaseData := map[string]interface{}{
 		"assId":     assignment.AssID,
@@ -111,8 +123,8 @@ func createFirebaseAssignment(firestoreClient *firestore.Client, assignment mode
 		"updatedat": time.Now(),    // Add update timestamp
 	}
 
-	// Create the document in Firebase
-	_, err := firestoreClient.Doc(docPath).Set(ctx, firebaseData)
+	// Create the document in Firebase: BoardTasks/{taskID}/Assigned/{assID}
+	err := fb.BoardTasks().SetItem(ctx, task.TaskID, store.TaskAssigned, assignment.AssID, firebaseData)
 	if err != nil {
 		return fmt.Errorf("failed to create Firebase document: %v", err)
 	}
@@ -120,12 +132,12 @@ func createFirebaseAssignment(firestoreClient *firestore.Client, assignment mode
 	return nil
 }
 
-func DelAssignedTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DelAssignedTask(c *gin.Context, db *gorm.DB, fb store.Store) {
 	taskIDStr := c.Param("taskid")
 	assignIDStr := c.Param("assignid")
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid assignment ID format"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("assign_id", "invalid"))
 		return
 	}
 
@@ -136,9 +148,9 @@ func DelAssignedTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	}
 
 	// Delete from Firebase
-	if err := deleteFirebaseAssignment(firestoreClient, assignment); err != nil {
+	if err := deleteFirebaseAssignment(fb, assignment); err != nil {
 		// Log the error but don't fail the request since DB deletion succeeded
-		fmt.Printf("Warning: Failed to delete Firebase assignment: %v\n", err)
+		logging.FromContext(c).Warn("failed to delete Firestore assignment", "error", err)
 	}
 
 	// Return success response
@@ -151,14 +163,11 @@ func DelAssignedTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cli
 	})
 }
 
-func deleteFirebaseAssignment(firestoreClient *firestore.Client, assign model.Assignment) error {
+func deleteFirebaseAssignment(fb store.Store, assign model.Assignment) error {
 	ctx := context.Background()
 
-	// Construct the Firebase path: /Assigned/{assignID}
-	docPath := fmt.Sprintf("BoardTasks/%d/Assigned/%d", assign.TaskID, assign.AssID)
-
-	// Delete the document from Firebase
-	_, err := firestoreClient.Doc(docPath).Delete(ctx)
+	// Delete the document from Firebase: BoardTasks/{taskID}/Assigned/{assID}
+	err := fb.BoardTasks().DeleteItem(ctx, assign.TaskID, store.TaskAssigned, assign.AssID)
 	if err != nil {
 		return fmt.Errorf("failed to delete Firebase document: %v", err)
 	}
@@ -4,44 +4,50 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/outbox"
+	"mydayplanner/store"
+	"mydayplanner/timezone"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 // TaskService encapsulates task-related business logic
 type TaskService struct {
-	db              *gorm.DB
-	firestoreClient *firestore.Client
+	db *gorm.DB
+	fb store.Store
 }
 
 // NewTaskService creates a new TaskService instance
-func NewTaskService(db *gorm.DB, firestoreClient *firestore.Client) *TaskService {
+func NewTaskService(db *gorm.DB, fb store.Store) *TaskService {
 	return &TaskService{
-		db:              db,
-		firestoreClient: firestoreClient,
+		db: db,
+		fb: fb,
 	}
 }
 
-func CreateTaskController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	service := NewTaskService(db, firestoreClient)
-	router.POST("/task", middleware.AccessTokenMiddleware(), service.CreateTaskHandler)
+func CreateTaskController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	service := NewTaskService(db, fb)
+	router.POST("/v1/boards/:boardid/tasks", middleware.AccessTokenMiddleware(), service.CreateTaskHandler)
+	router.POST("/task", middleware.Deprecated("/v1/boards/:boardid/tasks"), middleware.AccessTokenMiddleware(), service.CreateTaskHandler)
 }
 
-func TodayTaskController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	service := NewTaskService(db, firestoreClient)
-	routes := router.Group("/todaytasks", middleware.AccessTokenMiddleware())
-	{
-		routes.POST("/create", service.CreateTodayTaskHandler)
-	}
+func TodayTaskController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	service := NewTaskService(db, fb)
+	today := func(c *gin.Context) { ListTodayTasks(c, db) }
+	// งานของวันนี้ไม่มีบอร์ด จึงสร้างที่ /v1/tasks โดยตรง
+	router.POST("/v1/tasks", middleware.AccessTokenMiddleware(), service.CreateTodayTaskHandler)
+	// งานที่ครบกำหนดวันนี้ตามเขตเวลาของผู้ใช้
+	router.GET("/v1/tasks/today", middleware.AccessTokenMiddleware(), today)
+	router.POST("/todaytasks/create", middleware.Deprecated("/v1/tasks"), middleware.AccessTokenMiddleware(), service.CreateTodayTaskHandler)
 }
 
 // CreateTask with board
@@ -49,8 +55,28 @@ func (s *TaskService) CreateTaskHandler(c *gin.Context) {
 	userId := c.MustGet("userId").(uint)
 
 	var taskReq dto.CreateTaskRequest
-	if err := c.ShouldBindJSON(&taskReq); err != nil {
-		respondWithError(c, http.StatusBadRequest, "Invalid input", err)
+	if boardIDStr := c.Param("boardid"); boardIDStr != "" {
+		// route /v1 ระบุบอร์ดใน path body เหมือนงานของวันนี้
+		boardID, err := strconv.Atoi(boardIDStr)
+		if err != nil {
+			apperror.Respond(c, apperror.InvalidInput.WithField("board_id", "invalid"))
+			return
+		}
+		var req dto.CreateTodayTaskRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apperror.Respond(c, apperror.Validation(err))
+			return
+		}
+		taskReq = dto.CreateTaskRequest{
+			BoardID:     boardID,
+			TaskName:    req.TaskName,
+			Description: req.Description,
+			Status:      req.Status,
+			Reminder:    req.Reminder,
+			Priority:    req.Priority,
+		}
+	} else if err := c.ShouldBindJSON(&taskReq); err != nil {
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -58,9 +84,9 @@ func (s *TaskService) CreateTaskHandler(c *gin.Context) {
 	user, err := s.getUserByID(userId)
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			respondWithError(c, http.StatusNotFound, "User not found", nil)
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			respondWithError(c, http.StatusInternalServerError, "Failed to fetch user", err)
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 		}
 		return
 	}
@@ -68,20 +94,17 @@ func (s *TaskService) CreateTaskHandler(c *gin.Context) {
 	// ตรวจสอบว่าอยู่บอร์ดกลุ่มหรือไม่
 	shouldSaveToFirestore, err := s.validateBoardAccess(taskReq.BoardID, userId)
 	if err != nil {
-		respondWithError(c, http.StatusForbidden, "Access denied: not a board member or board owner", err)
+		apperror.Respond(c, apperror.NotBoardMember.Wrap(err))
 		return
 	}
 
-	// สร้างงาน
-	task, notification, err := s.createTaskWithTransaction(&taskReq, user)
+	// สร้างงาน และบันทึกการเขียน Firestore ลง outbox ใน transaction เดียวกัน
+	task, notification, err := s.createTaskWithTransaction(&taskReq, user, shouldSaveToFirestore)
 	if err != nil {
-		respondWithError(c, http.StatusInternalServerError, "Failed to create task", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
-	// สร้างการแจ้งเตือนใน Firestore
-	go s.handleFirestoreOperations(task, notification, user.Email, shouldSaveToFirestore)
-
 	// Prepare response
 	response := gin.H{
 		"message": "Task created successfully",
@@ -101,30 +124,25 @@ func (s *TaskService) CreateTodayTaskHandler(c *gin.Context) {
 
 	var taskReq dto.CreateTodayTaskRequest
 	if err := c.ShouldBindJSON(&taskReq); err != nil {
-		respondWithError(c, http.StatusBadRequest, "Invalid input", err)
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// Get user information
 	user, err := s.getUserByID(userId)
 	if err != nil {
-		respondWithError(c, http.StatusNotFound, "User not found", err)
+		apperror.Respond(c, apperror.UserNotFound.Wrap(err))
 		return
 	}
 
 	// Create today task with transaction
+	// งานของวันนี้ไม่มีบอร์ด การแจ้งเตือนจึงไปที่ Notifications/{email} ผ่าน outbox
 	task, notification, err := s.createTodayTaskWithTransaction(&taskReq, user)
 	if err != nil {
-		respondWithError(c, http.StatusInternalServerError, "Failed to create task", err)
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
-	// Handle Firestore operations (non-blocking)
-	// For today tasks, shouldSaveToFirestore is false (board-related)
-	if notification != nil {
-		go s.saveNotificationToFirestore(notification, user.Email, false)
-	}
-
 	// Prepare response
 	response := gin.H{
 		"message": "Task created successfully",
@@ -169,7 +187,7 @@ func (s *TaskService) validateBoardAccess(boardID int, userID uint) (bool, error
 }
 
 // สร้างงานใน sql
-func (s *TaskService) createTaskWithTransaction(taskReq *dto.CreateTaskRequest, user *model.User) (*model.Tasks, *model.Notification, error) {
+func (s *TaskService) createTaskWithTransaction(taskReq *dto.CreateTaskRequest, user *model.User, shouldSaveToFirestore bool) (*model.Tasks, *model.Notification, error) {
 	tx := s.db.Begin()
 	if tx.Error != nil {
 		return nil, nil, tx.Error
@@ -194,13 +212,17 @@ func (s *TaskService) createTaskWithTransaction(taskReq *dto.CreateTaskRequest,
 	// Handle notification if provided and DueDate is not empty
 	var notification *model.Notification
 	if taskReq.Reminder != nil && isValidDueDate(taskReq.Reminder.DueDate) {
-		notif, err := s.createNotificationInTx(tx, uint(task.TaskID), taskReq.Reminder)
+		notif, err := s.createNotificationInTx(tx, uint(task.TaskID), taskReq.Reminder, timezone.Of(*user))
 		if err != nil {
 			return nil, nil, err
 		}
 		notification = notif
 	}
 
+	if err := s.enqueueFirestoreWrites(outbox.Writer(tx), task, notification, user.Email, shouldSaveToFirestore); err != nil {
+		return nil, nil, err
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
@@ -235,13 +257,19 @@ func (s *TaskService) createTodayTaskWithTransaction(taskReq *dto.CreateTodayTas
 	// Handle notification only if reminder exists and DueDate is valid
 	var notification *model.Notification
 	if taskReq.Reminder != nil && isValidDueDate(taskReq.Reminder.DueDate) {
-		notif, err := s.createNotificationInTx(tx, uint(task.TaskID), taskReq.Reminder)
+		notif, err := s.createNotificationInTx(tx, uint(task.TaskID), taskReq.Reminder, timezone.Of(*user))
 		if err != nil {
 			return nil, nil, err
 		}
 		notification = notif
 	}
 
+	if notification != nil {
+		if err := s.saveNotificationToFirestore(context.Background(), outbox.Writer(tx), notification, user.Email, false); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		return nil, nil, fmt.Errorf("failed to commit transaction: %w", err)
@@ -250,16 +278,16 @@ func (s *TaskService) createTodayTaskWithTransaction(taskReq *dto.CreateTodayTas
 	return task, notification, nil
 }
 
-// สร้างการแจ้งเตือนใน sql
-func (s *TaskService) createNotificationInTx(tx *gorm.DB, taskID uint, reminder *dto.Reminder) (*model.Notification, error) {
-	parsedDueDate, err := parseDateTime(*reminder.DueDate) // dereference pointer
+// สร้างการแจ้งเตือนใน sql วันที่ใน reminder ที่ไม่มี offset เป็นเวลาท้องถิ่นใน loc ของผู้สร้าง
+func (s *TaskService) createNotificationInTx(tx *gorm.DB, taskID uint, reminder *dto.Reminder, loc *time.Location) (*model.Notification, error) {
+	parsedDueDate, err := timezone.ParseDateTime(*reminder.DueDate, loc)
 	if err != nil {
 		return nil, fmt.Errorf("invalid DueDate format: %w", err)
 	}
 
 	var parsedBeforeDueDate *time.Time
 	if isValidDueDate(reminder.BeforeDueDate) {
-		beforeDue, err := parseDateTime(*reminder.BeforeDueDate) // dereference pointer
+		beforeDue, err := timezone.ParseDateTime(*reminder.BeforeDueDate, loc)
 		if err != nil {
 			return nil, fmt.Errorf("invalid BeforeDueDate format: %w", err)
 		}
@@ -271,6 +299,7 @@ func (s *TaskService) createNotificationInTx(tx *gorm.DB, taskID uint, reminder
 		DueDate:          &parsedDueDate, // แปลงเป็น pointer
 		BeforeDueDate:    parsedBeforeDueDate,
 		RecurringPattern: reminder.RecurringPattern,
+		Critical:         reminder.Critical,
 		IsSend: func() string {
 			if parsedDueDate.Before(time.Now()) {
 				return "2"
@@ -287,35 +316,32 @@ func (s *TaskService) createNotificationInTx(tx *gorm.DB, taskID uint, reminder
 	return notification, nil
 }
 
-// ตรวจสอบและบันทึกการดำเนินการ Firestore
-func (s *TaskService) handleFirestoreOperations(task *model.Tasks, notification *model.Notification, userEmail string, shouldSaveToFirestore bool) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// บันทึกการเขียน Firestore ของงานใหม่ลง outbox fb ต้องมาจาก outbox.Writer ของ transaction ที่สร้างงาน
+func (s *TaskService) enqueueFirestoreWrites(fb store.Store, task *model.Tasks, notification *model.Notification, userEmail string, shouldSaveToFirestore bool) error {
+	ctx := context.Background()
 
 	// Save task to Firestore if needed
 	if shouldSaveToFirestore && task.BoardID != nil {
-		if err := s.saveTaskToFirestore(ctx, task, int(*task.BoardID)); err != nil {
-			log.Printf("Warning: Failed to save task to Firestore: %v", err)
+		if err := s.saveTaskToFirestore(ctx, fb, task, int(*task.BoardID)); err != nil {
+			return fmt.Errorf("failed to enqueue task %d for Firestore: %w", task.TaskID, err)
 		}
 	}
 
 	// Save notification to Firestore if exists
 	if notification != nil {
-		if err := s.saveNotificationToFirestore(notification, userEmail, shouldSaveToFirestore); err != nil {
-			log.Printf("Warning: Failed to save notification to Firestore: %v", err)
+		if err := s.saveNotificationToFirestore(ctx, fb, notification, userEmail, shouldSaveToFirestore); err != nil {
+			return fmt.Errorf("failed to enqueue notification of task %d for Firestore: %w", task.TaskID, err)
 		}
 	}
+	return nil
 }
 
 // บันทึกงานใน Firestore
-func (s *TaskService) saveTaskToFirestore(ctx context.Context, task *model.Tasks, boardID int) error {
-	taskPath := fmt.Sprintf("Boards/%d/Tasks/%d", boardID, task.TaskID)
-	boardPath := fmt.Sprintf("BoardTasks/%d", task.TaskID)
-
+func (s *TaskService) saveTaskToFirestore(ctx context.Context, fb store.Store, task *model.Tasks, boardID int) error {
 	boardData := map[string]interface{}{
 		"createAt": task.CreateAt,
 	}
-	if _, err := s.firestoreClient.Doc(boardPath).Set(ctx, boardData, firestore.MergeAll); err != nil {
+	if err := fb.BoardTasks().Merge(ctx, task.TaskID, boardData); err != nil {
 		return err
 	}
 
@@ -342,26 +368,11 @@ func (s *TaskService) saveTaskToFirestore(ctx context.Context, task *model.Tasks
 		taskData["createBy"] = *task.CreateBy
 	}
 
-	_, err := s.firestoreClient.Doc(taskPath).Set(ctx, taskData)
-	return err
+	return fb.Boards().SetTask(ctx, boardID, task.TaskID, taskData)
 }
 
 // บันทึกการแจ้งเตือนใน Firestore
-func (s *TaskService) saveNotificationToFirestore(notification *model.Notification, email string, shouldSaveToFirestore bool) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	var notificationPath string
-
-	// แยก path ตาม shouldSaveToFirestore
-	if shouldSaveToFirestore {
-		// สำหรับ board tasks ที่ user เป็น board member
-		notificationPath = fmt.Sprintf("BoardTasks/%d/Notifications/%d", notification.TaskID, notification.NotificationID)
-	} else {
-		// สำหรับ today tasks หรือ board tasks ที่ user เป็น board owner
-		notificationPath = fmt.Sprintf("Notifications/%s/Tasks/%d", email, notification.NotificationID)
-	}
-
+func (s *TaskService) saveNotificationToFirestore(ctx context.Context, fb store.Store, notification *model.Notification, email string, shouldSaveToFirestore bool) error {
 	notificationData := map[string]interface{}{
 		"notificationID": notification.NotificationID,
 		"taskID":         notification.TaskID,
@@ -380,8 +391,13 @@ func (s *TaskService) saveNotificationToFirestore(notification *model.Notificati
 		notificationData["beforeDueDate"] = notification.BeforeDueDate
 	}
 
-	_, err := s.firestoreClient.Doc(notificationPath).Set(ctx, notificationData)
-	return err
+	// แยก path ตาม shouldSaveToFirestore
+	if shouldSaveToFirestore {
+		// สำหรับ board tasks ที่ user เป็น board member
+		return fb.BoardTasks().SetItem(ctx, notification.TaskID, store.TaskNotifications, strconv.Itoa(notification.NotificationID), notificationData)
+	}
+	// สำหรับ today tasks หรือ board tasks ที่ user เป็น board owner
+	return fb.Notifications().SetTask(ctx, email, notification.NotificationID, notificationData)
 }
 
 // Utility functions
@@ -399,53 +415,3 @@ func intToPtr(i int) *int {
 func isValidDueDate(datePtr *string) bool {
 	return datePtr != nil && strings.TrimSpace(*datePtr) != ""
 }
-
-// แก้ไขฟังก์ชัน parseDateTime ให้รองรับมากขึ้น
-func parseDateTime(dateStr string) (time.Time, error) {
-	// ตรวจสอบ empty string ก่อน
-	if dateStr == "" {
-		return time.Time{}, fmt.Errorf("due date is required")
-	}
-
-	// ตัด whitespace ออก
-	dateStr = strings.TrimSpace(dateStr)
-	if dateStr == "" {
-		return time.Time{}, fmt.Errorf("due date cannot be empty")
-	}
-
-	formats := []string{
-		"2006-01-02 15:04:05.999999",
-		"2006-01-02 15:04:05.000000",
-		"2006-01-02 15:04:05",
-		"2006-01-02T15:04:05.999999Z07:00",
-		"2006-01-02T15:04:05.000000Z07:00",
-		"2006-01-02T15:04:05Z07:00",
-		"2006-01-02T15:04:05.999999Z",
-		"2006-01-02T15:04:05.000000Z",
-		"2006-01-02T15:04:05Z",
-		time.RFC3339,
-		time.RFC3339Nano,
-		"2006-01-02",
-	}
-
-	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
-			return t, nil
-		}
-	}
-
-	return time.Time{}, fmt.Errorf("unsupported date format: %s", dateStr)
-}
-
-func respondWithError(c *gin.Context, statusCode int, message string, err error) {
-	response := gin.H{"error": message}
-
-	if err != nil {
-		// In development, you might want to include error details
-		// In production, log the error and return generic message
-		log.Printf("Error: %v", err)
-		response["details"] = err.Error()
-	}
-
-	c.JSON(statusCode, response)
-}
@@ -3,38 +3,43 @@ package task
 import (
 	"context"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func DeleteTaskController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.DELETE("/deltask", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		DeleteTask(c, db, firestoreClient)
-	})
-	router.DELETE("/deltask/:taskid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		DeleteSingleTask(c, db, firestoreClient)
-	})
+func DeleteTaskController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	deleteMany := func(c *gin.Context) { DeleteTask(c, db, fb) }
+	deleteOne := func(c *gin.Context) { DeleteSingleTask(c, db, fb) }
+
+	router.DELETE("/v1/tasks", middleware.AccessTokenMiddleware(), deleteMany)
+	router.DELETE("/v1/tasks/:taskid", middleware.AccessTokenMiddleware(), deleteOne)
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	router.DELETE("/deltask", middleware.Deprecated("/v1/tasks"), middleware.AccessTokenMiddleware(), deleteMany)
+	router.DELETE("/deltask/:taskid", middleware.Deprecated("/v1/tasks/:taskid"), middleware.AccessTokenMiddleware(), deleteOne)
 }
 
-func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteTask(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 
 	var req dto.DeletetaskRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 	taskIDs := req.TaskID
 	if len(taskIDs) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No task IDs provided"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "required"))
 		return
 	}
 
@@ -44,7 +49,7 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		Select("email").
 		Where("user_id = ?", userID).
 		Scan(&userEmail).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user email"})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
@@ -58,13 +63,13 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		Select("task_id, board_id, create_by").
 		Where("task_id IN ?", taskIDs).
 		Find(&existingTasks).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tasks"})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
 	// ตรวจสอบว่าพบ tasks ครบหรือไม่
 	if len(existingTasks) != len(taskIDs) {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Some tasks not found"})
+		apperror.Respond(c, apperror.TaskNotFound)
 		return
 	}
 
@@ -93,7 +98,7 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 				) AS authorized
 			`
 			if err := db.Raw(query, *t.BoardID, userID, *t.BoardID, userID).Count(&count).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board access"})
+				apperror.Respond(c, apperror.Internal.Wrap(err))
 				return
 			}
 			if count > 0 {
@@ -102,7 +107,7 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 				if err := db.Table("board_user").
 					Where("board_id = ?", *t.BoardID).
 					Count(&memberCount).Error; err != nil {
-					c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check board members"})
+					apperror.Respond(c, apperror.Internal)
 					return
 				}
 				taskType := "private"
@@ -119,14 +124,11 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	}
 
 	if len(unauthorizedTasks) > 0 {
-		c.JSON(http.StatusForbidden, gin.H{
-			"error":             "Access denied for some tasks",
-			"unauthorizedTasks": unauthorizedTasks,
-		})
+		apperror.Respond(c, apperror.NotTaskOwner)
 		return
 	}
 	if len(deletableTasks) == 0 {
-		c.JSON(http.StatusForbidden, gin.H{"error": "No tasks can be deleted"})
+		apperror.Respond(c, apperror.NoDeletableTasks)
 		return
 	}
 
@@ -139,7 +141,7 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		Select("notification_id, task_id").
 		Where("task_id IN ?", deletableTasks).
 		Find(&relatedNotifications).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
@@ -165,10 +167,7 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		}
 		return nil
 	}); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete tasks and notifications",
-			"details": err.Error(),
-		})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
@@ -183,13 +182,11 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 			if notif.TaskID == taskID {
 				if taskType == "today" || taskType == "private" {
 					// Personal notification
-					notifDoc := firestoreClient.Collection("Notifications").Doc(userEmail).
-						Collection("Tasks").Doc(fmt.Sprintf("%d", notif.NotificationID))
 					ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
-					_, err := notifDoc.Delete(ctx)
+					err := fb.Notifications().DeleteTasks(ctx, userEmail, notif.NotificationID)
 					cancel()
 					if err != nil {
-						fmt.Printf("Failed to delete personal notification %d: %v\n", notif.NotificationID, err)
+						logging.FromContext(c).Warn("failed to delete notification from Firestore", "notification_id", notif.NotificationID, "error", err)
 					}
 				}
 			}
@@ -197,43 +194,19 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 		// ลบ Tasks
 		if taskType == "group" {
-			// ลบ Subcollections
-			subCollections := []string{"Notifications", "Assigned", "Attachments", "Checklist"}
-			for _, sub := range subCollections {
-				subColRef := firestoreClient.Collection("BoardTasks").
-					Doc(fmt.Sprintf("%d", taskID)).Collection(sub)
-				ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
-				docs, err := subColRef.Documents(ctx).GetAll()
-				cancel()
-				if err != nil {
-					fmt.Printf("Failed to list %s for task %d: %v\n", sub, taskID, err)
-					continue
-				}
-				for _, doc := range docs {
-					ctxDel, cancelDel := context.WithTimeout(context.Background(), ctxTimeout)
-					_, err := doc.Ref.Delete(ctxDel)
-					cancelDel()
-					if err != nil {
-						fmt.Printf("Failed to delete %s/%s: %v\n", sub, doc.Ref.ID, err)
-					}
-				}
-			}
-			// ลบ BoardTasks/{taskID}
+			// ลบ Subcollections และ BoardTasks/{taskID}
 			ctxBT, cancelBT := context.WithTimeout(context.Background(), ctxTimeout)
-			_, errBT := firestoreClient.Collection("BoardTasks").
-				Doc(fmt.Sprintf("%d", taskID)).Delete(ctxBT)
+			errBT := fb.BoardTasks().DeleteAll(ctxBT, taskID)
 			cancelBT()
 			if errBT != nil {
-				fmt.Printf("Failed to delete BoardTasks/%d: %v\n", taskID, errBT)
+				logging.FromContext(c).Warn("failed to delete BoardTasks from Firestore", "task_id", taskID, "error", errBT)
 			}
 			// ลบ Boards/{boardID}/Tasks/{taskID}
 			ctxBoard, cancelBoard := context.WithTimeout(context.Background(), ctxTimeout)
-			_, errBoard := firestoreClient.Collection("Boards").
-				Doc(fmt.Sprintf("%d", boardID)).Collection("Tasks").
-				Doc(fmt.Sprintf("%d", taskID)).Delete(ctxBoard)
+			errBoard := fb.Boards().DeleteTask(ctxBoard, boardID, taskID)
 			cancelBoard()
 			if errBoard != nil {
-				fmt.Printf("Failed to delete Boards/%d/Tasks/%d: %v\n", boardID, taskID, errBoard)
+				logging.FromContext(c).Warn("failed to delete board task from Firestore", "board_id", boardID, "task_id", taskID, "error", errBoard)
 			}
 		}
 	}
@@ -247,26 +220,26 @@ func DeleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 }
 
 // DeleteSingleTask - ฟังก์ชันสำหรับลบงานเดี่ยว
-func DeleteSingleTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteSingleTask(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 
 	// รับ task_id จาก URL
 	taskIDStr := c.Param("taskid")
 	if taskIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Task ID is required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "required"))
 		return
 	}
 
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	// ดึง email ของผู้ใช้
 	var userEmail string
 	if err := db.Table("user").Select("email").Where("user_id = ?", userID).Scan(&userEmail).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch user email"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -281,9 +254,9 @@ func DeleteSingleTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		Where("task_id = ?", taskID).
 		First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -309,7 +282,7 @@ func DeleteSingleTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 			) AS authorized
 		`
 		if err := db.Raw(query, *task.BoardID, userID, *task.BoardID, userID).Count(&authorizedCount).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board access"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 		if authorizedCount > 0 {
@@ -321,7 +294,7 @@ func DeleteSingleTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		if err := db.Table("board_user").
 			Where("board_id = ?", *task.BoardID).
 			Count(&boardUserCount).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board_user membership"})
+			apperror.Respond(c, apperror.Internal)
 			return
 		}
 		if boardUserCount > 0 {
@@ -332,7 +305,7 @@ func DeleteSingleTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	}
 
 	if !canDelete {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		apperror.Respond(c, apperror.Forbidden)
 		return
 	}
 
@@ -345,7 +318,7 @@ func DeleteSingleTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		Select("notification_id, task_id").
 		Where("task_id = ?", taskID).
 		Find(&relatedNotifications).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch related notifications"})
+		apperror.Respond(c, apperror.Internal)
 		return
 	}
 
@@ -371,10 +344,7 @@ func DeleteSingleTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		return nil
 	})
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to delete task and notifications",
-			"details": err.Error(),
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -383,55 +353,31 @@ func DeleteSingleTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	// Firestore ลบ Notifications: Today และ Private เท่านั้น
 	if (isToday || isPrivate) && len(relatedNotifications) > 0 {
 		for _, notification := range relatedNotifications {
-			docRef := firestoreClient.Collection("Notifications").Doc(userEmail).
-				Collection("Tasks").Doc(fmt.Sprintf("%d", notification.NotificationID))
 			ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
-			_, err := docRef.Delete(ctx)
+			err := fb.Notifications().DeleteTasks(ctx, userEmail, notification.NotificationID)
 			cancel()
 			if err != nil {
-				fmt.Printf("Firestore delete notification %d failed: %v\n", notification.NotificationID, err)
+				logging.FromContext(c).Warn("failed to delete notification from Firestore", "notification_id", notification.NotificationID, "error", err)
 			}
 		}
 	}
 
 	// Firestore ลบ Group Task (ถ้ามี)
 	if isGroup {
-		// ลบ Subcollections
-		subCollections := []string{"Notifications", "Assigned", "Attachments", "Checklist"}
-		for _, subCol := range subCollections {
-			subColRef := firestoreClient.Collection("BoardTasks").Doc(fmt.Sprintf("%d", taskID)).Collection(subCol)
-			ctx, cancel := context.WithTimeout(context.Background(), ctxTimeout)
-			docs, err := subColRef.Documents(ctx).GetAll()
-			cancel()
-			if err != nil {
-				fmt.Printf("Failed to list documents in %s: %v\n", subCol, err)
-				continue
-			}
-			for _, doc := range docs {
-				ctxDel, cancelDel := context.WithTimeout(context.Background(), ctxTimeout)
-				_, errDel := doc.Ref.Delete(ctxDel)
-				cancelDel()
-				if errDel != nil {
-					fmt.Printf("Failed to delete %s/%s: %v\n", subCol, doc.Ref.ID, errDel)
-				}
-			}
-		}
-
-		// ลบ BoardTasks/{taskID}
+		// ลบ Subcollections และ BoardTasks/{taskID}
 		ctxBT, cancelBT := context.WithTimeout(context.Background(), ctxTimeout)
-		_, errBT := firestoreClient.Collection("BoardTasks").Doc(fmt.Sprintf("%d", taskID)).Delete(ctxBT)
+		errBT := fb.BoardTasks().DeleteAll(ctxBT, taskID)
 		cancelBT()
 		if errBT != nil {
-			fmt.Printf("Failed to delete BoardTasks/%d: %v\n", taskID, errBT)
+			logging.FromContext(c).Warn("failed to delete BoardTasks from Firestore", "task_id", taskID, "error", errBT)
 		}
 
 		// ลบ Boards/{boardID}/Tasks/{taskID}
 		ctxBoard, cancelBoard := context.WithTimeout(context.Background(), ctxTimeout)
-		_, errBoard := firestoreClient.Collection("Boards").Doc(fmt.Sprintf("%d", *task.BoardID)).
-			Collection("Tasks").Doc(fmt.Sprintf("%d", taskID)).Delete(ctxBoard)
+		errBoard := fb.Boards().DeleteTask(ctxBoard, *task.BoardID, taskID)
 		cancelBoard()
 		if errBoard != nil {
-			fmt.Printf("Failed to delete Boards/%d/Tasks/%d: %v\n", *task.BoardID, taskID, errBoard)
+			logging.FromContext(c).Warn("failed to delete board task from Firestore", "board_id", *task.BoardID, "task_id", taskID, "error", errBoard)
 		}
 	}
 
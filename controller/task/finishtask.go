@@ -2,48 +2,56 @@ package task
 
 import (
 	"context"
-	"fmt"
-	"log"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/outbox"
+	"mydayplanner/store"
 	"net/http"
+	"strconv"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func FinishTaskController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.PUT("/taskfinish/:taskid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		CompleteTask(c, db, firestoreClient)
-	})
-	router.PUT("/updatestatus/:taskid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		UpdateTaskStatus(c, db, firestoreClient)
-	})
-	router.PUT("/markasdoneTask/:taskid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		MarkAsdoneTaskStatus(c, db, firestoreClient)
-	})
+func FinishTaskController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	toggle := func(c *gin.Context) { CompleteTask(c, db, fb) }
+	status := func(c *gin.Context) { UpdateTaskStatus(c, db, fb) }
+	done := func(c *gin.Context) { MarkAsdoneTaskStatus(c, db, fb) }
+
+	routes := router.Group("/v1/tasks/:taskid", middleware.AccessTokenMiddleware())
+	{
+		routes.PUT("/finish", toggle)
+		routes.PUT("/status", status)
+		routes.PUT("/done", done)
+	}
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	router.PUT("/taskfinish/:taskid", middleware.Deprecated("/v1/tasks/:taskid/finish"), middleware.AccessTokenMiddleware(), toggle)
+	router.PUT("/updatestatus/:taskid", middleware.Deprecated("/v1/tasks/:taskid/status"), middleware.AccessTokenMiddleware(), status)
+	router.PUT("/markasdoneTask/:taskid", middleware.Deprecated("/v1/tasks/:taskid/done"), middleware.AccessTokenMiddleware(), done)
 }
 
 // ฟังก์ชั่นสำหรับเปลี่ยน status ของ task เป็น complete (2)
-func CompleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func CompleteTask(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	taskID := c.Param("taskid")
 
 	var email string
 	if err := db.Raw("SELECT email FROM user WHERE user_id = ?", userID).Scan(&email).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user email"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	var currentTask model.Tasks
 	if err := db.Where("task_id = ?", taskID).First(&currentTask).Error; err != nil {
-		status := http.StatusInternalServerError
 		if err == gorm.ErrRecordNotFound {
-			status = http.StatusNotFound
+			apperror.Respond(c, apperror.TaskNotFound)
+			return
 		}
-		c.JSON(status, gin.H{"error": "Task not found"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -57,7 +65,7 @@ func CompleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 		if err == gorm.ErrRecordNotFound {
 			notiExists = false
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to query notification"})
+			apperror.Respond(c, apperror.Internal)
 			return
 		}
 	}
@@ -72,80 +80,62 @@ func CompleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 		message = "Task completed successfully"
 	}
 
-	// update SQL task status
-	if err := db.Model(&currentTask).Update("status", newStatus).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task status"})
-		return
-	}
+	// อัปเดต SQL และบันทึกการเขียน Firestore ลง outbox ใน transaction เดียวกัน
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// update SQL task status
+		if err := tx.Model(&currentTask).Update("status", newStatus).Error; err != nil {
+			return err
+		}
 
-	// update notification only if exists
-	if notiExists {
-		if newStatus == "0" {
-			if err := db.Model(&notification).Updates(map[string]interface{}{
-				"is_send":        "0",
-				"due_date":       nil,
-				"beforedue_date": nil,
-				"snooze":         nil,
-			}).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification in SQL"})
-				return
-			}
-		} else {
-			if err := db.Model(&notification).Update("is_send", "2").Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update is_send in SQL"})
-				return
+		// update notification only if exists
+		if notiExists {
+			if newStatus == "0" {
+				if err := tx.Model(&notification).Updates(map[string]interface{}{
+					"is_send":        model.NotifyPending,
+					"due_date":       nil,
+					"beforedue_date": nil,
+					"snooze":         nil,
+				}).Error; err != nil {
+					return err
+				}
+			} else {
+				if err := tx.Model(&notification).Update("is_send", model.NotifyDueSent).Error; err != nil {
+					return err
+				}
 			}
 		}
-	}
 
-	ctx := context.Background()
-
-	if boardgroupExists {
-		// Firestore: /Boards/{boardID}/Tasks/{taskID}
-		boardTaskRef := firestoreClient.
-			Collection("Boards").
-			Doc(fmt.Sprint(*currentTask.BoardID)).
-			Collection("Tasks").
-			Doc(fmt.Sprint(currentTask.TaskID))
+		ctx := context.Background()
+		fb := outbox.Writer(tx)
 
-		_, err := boardTaskRef.Update(ctx, []firestore.Update{
-			{Path: "status", Value: newStatus},
-		})
-		if err != nil {
-			log.Printf("Failed to update status in Firestore (Boards/Tasks): %v", err)
-		}
+		if boardgroupExists {
+			// Firestore: /Boards/{boardID}/Tasks/{taskID}
+			if err := fb.Boards().UpdateTask(ctx, *currentTask.BoardID, currentTask.TaskID, store.Fields{
+				"status": newStatus,
+			}); err != nil {
+				return err
+			}
 
-		// update notification in firestore only if exists
-		if notiExists {
-			notiRef := firestoreClient.
-				Collection("BoardTasks").
-				Doc(fmt.Sprint(currentTask.TaskID)).
-				Collection("Notifications").
-				Doc(fmt.Sprint(notification.NotificationID))
-
-			_, err = notiRef.Update(ctx, []firestore.Update{
-				{Path: "isSend", Value: "2"},
-			})
-			if err != nil {
-				log.Printf("Failed to update isSend in Firestore (BoardTasks/Notifications): %v", err)
+			// update notification in firestore only if exists
+			if notiExists {
+				return fb.BoardTasks().UpdateItem(ctx, currentTask.TaskID, store.TaskNotifications, strconv.Itoa(notification.NotificationID), store.Fields{
+					"isSend": "2",
+				})
 			}
+			return nil
 		}
-	} else {
+
 		// update notification in firestore only if exists
 		if notiExists {
-			notiRef := firestoreClient.
-				Collection("Notifications").
-				Doc(email).
-				Collection("Tasks").
-				Doc(fmt.Sprint(notification.NotificationID))
-
-			_, err := notiRef.Update(ctx, []firestore.Update{
-				{Path: "isSend", Value: "2"},
+			return fb.Notifications().UpdateTask(ctx, email, notification.NotificationID, store.Fields{
+				"isSend": "2",
 			})
-			if err != nil {
-				log.Printf("Failed to update isSend in Firestore (Notifications/Tasks): %v", err)
-			}
 		}
+		return nil
+	})
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -155,45 +145,45 @@ func CompleteTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client
 }
 
 // ฟังก์ชั่นสำหรับเปลี่ยน status แบบทั่วไป (ถ้าต้องการความยืดหยุ่น)
-func UpdateTaskStatus(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func UpdateTaskStatus(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	taskID := c.Param("taskid")
 
 	var req dto.StatusRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	if req.Status != "0" && req.Status != "1" && req.Status != "2" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status. Must be 0, 1, or 2"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("status", "oneof=0 1 2"))
 		return
 	}
 
 	var email string
 	if err := db.Raw("SELECT email FROM user WHERE user_id = ?", userID).Scan(&email).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	var currentTask model.Tasks
 	if err := db.Where("task_id = ?", taskID).First(&currentTask).Error; err != nil {
-		status := http.StatusInternalServerError
 		if err == gorm.ErrRecordNotFound {
-			status = http.StatusNotFound
+			apperror.Respond(c, apperror.TaskNotFound)
+			return
 		}
-		c.JSON(status, gin.H{"error": "Task not found"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	if currentTask.Status == req.Status {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Task is already in this status"})
+		apperror.Respond(c, apperror.TaskStatusUnchanged)
 		return
 	}
 
 	// อัปเดต status ใน SQL
 	if err := db.Model(&currentTask).Update("status", req.Status).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task status"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -211,17 +201,11 @@ func UpdateTaskStatus(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 
 	// === Firestore: update status เสมอ ===
 	if currentTask.BoardID != nil {
-		boardTaskRef := firestoreClient.
-			Collection("Boards").
-			Doc(fmt.Sprint(*currentTask.BoardID)).
-			Collection("Tasks").
-			Doc(fmt.Sprint(currentTask.TaskID))
-
-		_, err := boardTaskRef.Update(ctx, []firestore.Update{
-			{Path: "status", Value: req.Status},
+		err := fb.Boards().UpdateTask(ctx, *currentTask.BoardID, currentTask.TaskID, store.Fields{
+			"status": req.Status,
 		})
 		if err != nil {
-			log.Printf("Failed to update Firestore (Boards/Tasks): %v", err)
+			logging.FromContext(c).Warn("failed to update task status in Firestore (Boards/Tasks)", "error", err)
 		}
 	}
 
@@ -233,44 +217,32 @@ func UpdateTaskStatus(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 			if err == gorm.ErrRecordNotFound {
 				notiExists = false
 			} else {
-				log.Printf("Failed to fetch notification: %v", err)
+				logging.FromContext(c).Error("failed to fetch notification", "error", err)
 			}
 		}
 
 		if notiExists {
 			// SQL: update is_send
-			if err := db.Model(&notification).Update("is_send", "2").Error; err != nil {
-				log.Printf("Failed to update is_send in SQL: %v", err)
+			if err := db.Model(&notification).Update("is_send", model.NotifyDueSent).Error; err != nil {
+				logging.FromContext(c).Error("failed to update is_send", "error", err)
 			}
 
 			// Firestore update
 			if isGroupBoard && currentTask.BoardID != nil {
 				// Firestore: /BoardTasks/{taskID}/Notifications/{notificationID}
-				notiRef := firestoreClient.
-					Collection("BoardTasks").
-					Doc(fmt.Sprint(currentTask.TaskID)).
-					Collection("Notifications").
-					Doc(fmt.Sprint(notification.NotificationID))
-
-				_, err := notiRef.Update(ctx, []firestore.Update{
-					{Path: "isSend", Value: "2"},
+				err := fb.BoardTasks().UpdateItem(ctx, currentTask.TaskID, store.TaskNotifications, strconv.Itoa(notification.NotificationID), store.Fields{
+					"isSend": "2",
 				})
 				if err != nil {
-					log.Printf("Failed to update isSend in Firestore (BoardTasks/Notifications): %v", err)
+					logging.FromContext(c).Warn("failed to update isSend in Firestore (BoardTasks/Notifications)", "error", err)
 				}
 			} else {
 				// Firestore: /Notifications/{email}/Tasks/{notificationID}
-				notiRef := firestoreClient.
-					Collection("Notifications").
-					Doc(email).
-					Collection("Tasks").
-					Doc(fmt.Sprint(notification.NotificationID))
-
-				_, err := notiRef.Update(ctx, []firestore.Update{
-					{Path: "isSend", Value: "2"},
+				err := fb.Notifications().UpdateTask(ctx, email, notification.NotificationID, store.Fields{
+					"isSend": "2",
 				})
 				if err != nil {
-					log.Printf("Failed to update isSend in Firestore (Notifications/Tasks): %v", err)
+					logging.FromContext(c).Warn("failed to update isSend in Firestore (Notifications/Tasks)", "error", err)
 				}
 			}
 		}
@@ -293,23 +265,23 @@ func UpdateTaskStatus(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	})
 }
 
-func MarkAsdoneTaskStatus(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func MarkAsdoneTaskStatus(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userID := c.MustGet("userId").(uint)
 	taskID := c.Param("taskid")
 
 	var email string
 	if err := db.Raw("SELECT email FROM user WHERE user_id = ?", userID).Scan(&email).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get user data"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	var currentTask model.Tasks
 	if err := db.Where("task_id = ?", taskID).First(&currentTask).Error; err != nil {
-		status := http.StatusInternalServerError
 		if err == gorm.ErrRecordNotFound {
-			status = http.StatusNotFound
+			apperror.Respond(c, apperror.TaskNotFound)
+			return
 		}
-		c.JSON(status, gin.H{"error": "Task not found"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	var statusTask string
@@ -325,7 +297,7 @@ func MarkAsdoneTaskStatus(c *gin.Context, db *gorm.DB, firestoreClient *firestor
 
 	// อัปเดต status ใน SQL
 	if err := db.Model(&currentTask).Update("status", req.Status).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task status"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -342,17 +314,11 @@ func MarkAsdoneTaskStatus(c *gin.Context, db *gorm.DB, firestoreClient *firestor
 
 	// === 🔥 Firestore: update status เสมอ ===
 	if currentTask.BoardID != nil {
-		boardTaskRef := firestoreClient.
-			Collection("Boards").
-			Doc(fmt.Sprint(*currentTask.BoardID)).
-			Collection("Tasks").
-			Doc(fmt.Sprint(currentTask.TaskID))
-
-		_, err := boardTaskRef.Update(ctx, []firestore.Update{
-			{Path: "status", Value: req.Status},
+		err := fb.Boards().UpdateTask(ctx, *currentTask.BoardID, currentTask.TaskID, store.Fields{
+			"status": req.Status,
 		})
 		if err != nil {
-			log.Printf("Failed to update Firestore (Boards/Tasks): %v", err)
+			logging.FromContext(c).Warn("failed to update task status in Firestore (Boards/Tasks)", "error", err)
 		}
 	}
 
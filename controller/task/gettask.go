@@ -1,22 +1,20 @@
 package task
 
 import (
+	"mydayplanner/apperror"
 	"mydayplanner/middleware"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func TaskController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/task", middleware.AccessTokenMiddleware())
-	{
-		routes.GET("/user/:boardid", func(c *gin.Context) {
-			Getboardusertask(c, db, firestoreClient)
-		})
-	}
+func TaskController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	list := func(c *gin.Context) { Getboardusertask(c, db, fb) }
+	router.GET("/v1/boards/:boardid/tasks", middleware.AccessTokenMiddleware(), list)
+	router.GET("/task/user/:boardid", middleware.Deprecated("/v1/boards/:boardid/tasks"), middleware.AccessTokenMiddleware(), list)
 }
 
 // UserResponse represents the response structure for user data
@@ -34,7 +32,7 @@ type BoardUserResponse struct {
 	Users   []UserResponse `json:"users"`
 }
 
-func Getboardusertask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func Getboardusertask(c *gin.Context, db *gorm.DB, fb store.Store) {
 	// Get userId from middleware (for potential authorization checks)
 	userId := c.MustGet("userId").(uint)
 
@@ -42,9 +40,7 @@ func Getboardusertask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 	boardIdStr := c.Param("boardid")
 	boardId, err := strconv.Atoi(boardIdStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Invalid board ID",
-		})
+		apperror.Respond(c, apperror.InvalidInput.WithField("board_id", "invalid"))
 		return
 	}
 
@@ -59,9 +55,7 @@ func Getboardusertask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		Find(&userResponses).Error
 
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to fetch board users",
-		})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -73,16 +67,12 @@ func Getboardusertask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Cl
 		if err := db.Table("board_user").
 			Where("board_id = ? AND user_id = ?", boardId, userId).
 			Count(&accessCheck).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{
-				"error": "Database error",
-			})
+			apperror.Respond(c, apperror.Internal)
 			return
 		}
 
 		if accessCheck == 0 {
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Access denied to this board",
-			})
+			apperror.Respond(c, apperror.NotBoardMember)
 			return
 		}
 	}
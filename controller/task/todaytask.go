@@ -0,0 +1,97 @@
+package task
+
+import (
+	"errors"
+	"mydayplanner/apperror"
+	"mydayplanner/dto"
+	"mydayplanner/model"
+	"mydayplanner/timezone"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// TodayTaskResponse งานที่ครบกำหนดในวันที่ขอ due_date แสดงตามเขตเวลาของผู้ใช้
+type TodayTaskResponse struct {
+	TaskID   int     `json:"task_id"`
+	BoardID  *int    `json:"board_id"`
+	TaskName string  `json:"task_name"`
+	Status   string  `json:"status"`
+	Priority *string `json:"priority"`
+	DueDate  string  `json:"due_date"`
+}
+
+// ListTodayTasks งานที่ผู้ใช้เห็นได้ (งานไม่มีบอร์ดของตัวเองและงานในบอร์ดที่สร้างหรือเป็นสมาชิก)
+// ซึ่งครบกำหนดระหว่างเที่ยงคืนถึงเที่ยงคืนถัดไปตามเขตเวลาของผู้ใช้
+func ListTodayTasks(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+
+	var req dto.TodayQuery
+	if err := c.ShouldBindQuery(&req); err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+
+	var user model.User
+	if err := db.Select("user_id", "timezone").First(&user, userId).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			apperror.Respond(c, apperror.UserNotFound)
+			return
+		}
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	loc := timezone.Of(user)
+
+	day := time.Now()
+	if req.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.Date, loc)
+		if err != nil {
+			apperror.Respond(c, apperror.InvalidInput.WithField("date", "date"))
+			return
+		}
+		day = parsed
+	}
+	start, end := timezone.Day(day, loc)
+
+	var rows []struct {
+		TaskID   int
+		BoardID  *int
+		TaskName string
+		Status   string
+		Priority *string
+		DueDate  time.Time
+	}
+	err := db.Table("tasks t").
+		Select("t.task_id, t.board_id, t.task_name, t.status, t.priority, n.due_date").
+		Joins("INNER JOIN notification n ON n.task_id = t.task_id").
+		Where("n.due_date >= ? AND n.due_date < ?", start.UTC(), end.UTC()).
+		Where("(t.board_id IS NULL AND t.create_by = ?) OR t.board_id IN (SELECT board_id FROM board WHERE create_by = ?) OR t.board_id IN (SELECT board_id FROM board_user WHERE user_id = ?)",
+			userId, userId, userId).
+		Order("n.due_date, t.task_id").
+		Scan(&rows).Error
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+
+	tasks := make([]TodayTaskResponse, len(rows))
+	for i, r := range rows {
+		tasks[i] = TodayTaskResponse{
+			TaskID:   r.TaskID,
+			BoardID:  r.BoardID,
+			TaskName: r.TaskName,
+			Status:   r.Status,
+			Priority: r.Priority,
+			DueDate:  r.DueDate.In(loc).Format(time.RFC3339),
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"date":      start.Format("2006-01-02"),
+		"time_zone": loc.String(),
+		"tasks":     tasks,
+	})
+}
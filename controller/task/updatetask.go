@@ -2,44 +2,45 @@ package task
 
 import (
 	"errors"
-	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"strconv"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
-func UpdateTaskController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	router.PUT("/updatetask/:taskid", middleware.AccessTokenMiddleware(), func(c *gin.Context) {
-		AdjustTask(c, db, firestoreClient)
-	})
+func UpdateTaskController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	update := func(c *gin.Context) { AdjustTask(c, db, fb) }
+	router.PUT("/v1/tasks/:taskid", middleware.AccessTokenMiddleware(), update)
+	router.PUT("/updatetask/:taskid", middleware.Deprecated("/v1/tasks/:taskid"), middleware.AccessTokenMiddleware(), update)
 }
 
-func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func AdjustTask(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 	taskIDStr := c.Param("taskid")
 	if taskIDStr == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Task ID is required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "required"))
 		return
 	}
 
 	// แปลง string ID เป็น int
 	taskID, err := strconv.Atoi(taskIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid task ID"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("task_id", "invalid"))
 		return
 	}
 
 	var taskreq dto.AdjustTaskRequest
 	if err := c.ShouldBindJSON(&taskreq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input", "details": err.Error()})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -55,9 +56,9 @@ func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		Where("task_id = ?", taskID).
 		First(&task).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -81,9 +82,9 @@ func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 				var board model.Board
 				if err := db.Where("board_id = ? AND create_by = ?", task.BoardID, userId).First(&board).Error; err != nil {
 					if errors.Is(err, gorm.ErrRecordNotFound) {
-						c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: You are not the board owner or member"})
+						apperror.Respond(c, apperror.NotBoardMember)
 					} else {
-						c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board ownership"})
+						apperror.Respond(c, apperror.Internal)
 					}
 					return
 				}
@@ -91,7 +92,7 @@ func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 				canUpdate = true
 				isBoardUser = false
 			} else {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify board membership"})
+				apperror.Respond(c, apperror.Internal)
 				return
 			}
 		} else {
@@ -102,7 +103,7 @@ func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	}
 
 	if !canUpdate {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied: You don't have permission to update this task"})
+		apperror.Respond(c, apperror.NotTaskOwner)
 		return
 	}
 
@@ -113,7 +114,7 @@ func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	if strings.TrimSpace(taskreq.TaskName) != "" {
 		taskName := strings.TrimSpace(taskreq.TaskName)
 		if len(taskName) > 255 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Task name is too long (max 255 characters)"})
+			apperror.Respond(c, apperror.InvalidInput.WithField("task_name", "max=255"))
 			return
 		}
 		updates["task_name"] = taskName
@@ -122,7 +123,7 @@ func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	if strings.TrimSpace(taskreq.Description) != "" {
 		description := strings.TrimSpace(taskreq.Description)
 		if len(description) > 2000 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Description is too long (max 2000 characters)"})
+			apperror.Respond(c, apperror.InvalidInput.WithField("description", "max=2000"))
 			return
 		}
 		updates["description"] = description
@@ -136,62 +137,45 @@ func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 			priority, _ := strconv.Atoi(priorityStr)
 			updates["priority"] = priority
 		} else {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Priority must be 1, 2, or 3"})
+			apperror.Respond(c, apperror.InvalidInput.WithField("priority", "oneof=1 2 3"))
 			return
 		}
 	}
 
 	// ตรวจสอบว่ามีฟิลด์ที่จะอัปเดทหรือไม่
 	if len(updates) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No valid fields to update"})
+		apperror.Respond(c, apperror.NoFieldsToUpdate)
 		return
 	}
 
 	// สำหรับ Firestore rollback
-	var firestoreDocRef *firestore.DocumentRef
 	var firestoreOriginalData map[string]interface{}
 	var firestoreUpdated bool = false
 
 	// อัปเดท Firestore ก่อน (เฉพาะเมื่อเป็น board_user และมี board_id)
 	if task.BoardID != nil && isBoardUser {
 		// เตรียมข้อมูลสำหรับ Firestore
-		firestoreUpdates := []firestore.Update{}
+		firestoreUpdates := store.Fields{}
 		for key, value := range updates {
 			// แปลงชื่อฟิลด์สำหรับ Firestore
-			firestoreFieldName := convertToFirestoreFieldName(key)
-			firestoreUpdates = append(firestoreUpdates, firestore.Update{
-				Path:  firestoreFieldName,
-				Value: value,
-			})
+			firestoreUpdates[convertToFirestoreFieldName(key)] = value
 		}
+		firestoreUpdates["updatedAt"] = time.Now().Format(time.RFC3339)
 
-		firestoreUpdates = append(firestoreUpdates, firestore.Update{
-			Path:  "updatedAt",
-			Value: time.Now().Format(time.RFC3339),
-		})
-
-		if len(firestoreUpdates) > 0 {
-			firestoreDocRef = firestoreClient.Collection("Boards").Doc(fmt.Sprintf("%d", *task.BoardID)).
-				Collection("Tasks").Doc(fmt.Sprintf("%d", taskID))
-
-			// ดึงข้อมูลเดิมจาก Firestore เพื่อใช้ในการ rollback
-			docSnap, err := firestoreDocRef.Get(c)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get original Firestore data"})
-				return
-			}
-			if docSnap.Exists() {
-				firestoreOriginalData = docSnap.Data()
-			}
+		// ดึงข้อมูลเดิมจาก Firestore เพื่อใช้ในการ rollback
+		originalData, err := fb.Boards().GetTask(c, *task.BoardID, taskID)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			apperror.Respond(c, apperror.Internal)
+			return
+		}
+		firestoreOriginalData = originalData
 
-			// อัปเดท Firestore
-			_, err = firestoreDocRef.Update(c, firestoreUpdates)
-			if err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task in Firestore"})
-				return
-			}
-			firestoreUpdated = true
+		// อัปเดท Firestore
+		if err := fb.Boards().UpdateTask(c, *task.BoardID, taskID, firestoreUpdates); err != nil {
+			apperror.Respond(c, apperror.Internal.Wrap(err))
+			return
 		}
+		firestoreUpdated = true
 	}
 
 	// อัปเดทข้อมูลใน Database ด้วย Transaction
@@ -213,25 +197,22 @@ func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 	// หาก Database update ล้มเหลว และได้อัปเดท Firestore แล้ว ให้ rollback Firestore
 	if err != nil {
-		if firestoreUpdated && firestoreDocRef != nil {
+		if firestoreUpdated {
 			// Rollback Firestore
 			if firestoreOriginalData != nil {
 				// สร้าง updates สำหรับ rollback
-				rollbackUpdates := []firestore.Update{}
+				rollbackUpdates := store.Fields{}
 				for key := range updates {
 					if originalValue, exists := firestoreOriginalData[key]; exists {
-						rollbackUpdates = append(rollbackUpdates, firestore.Update{
-							Path:  key,
-							Value: originalValue,
-						})
+						rollbackUpdates[key] = originalValue
 					}
 				}
 
 				if len(rollbackUpdates) > 0 {
-					_, rollbackErr := firestoreDocRef.Update(c, rollbackUpdates)
+					rollbackErr := fb.Boards().UpdateTask(c, *task.BoardID, taskID, rollbackUpdates)
 					if rollbackErr != nil {
 						// Log rollback error
-						fmt.Printf("Failed to rollback Firestore for task %d: %v", taskID, rollbackErr)
+						logging.FromContext(c).Error("Firestore rollback failed", "task_id", taskID, "error", rollbackErr)
 					}
 				}
 			}
@@ -239,9 +220,9 @@ func AdjustTask(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 		// ส่ง error response
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Task not found or no changes made"})
+			apperror.Respond(c, apperror.TaskNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update task"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -2,12 +2,14 @@ package user
 
 import (
 	"fmt"
+	"mydayplanner/apperror"
+	"mydayplanner/logging"
 	"mydayplanner/model"
+	"mydayplanner/store"
 	"net/http"
 	"sync"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -23,7 +25,7 @@ type TaskRelatedData struct {
 	Notifications []model.Notification
 }
 
-func AllDataUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func AllDataUser(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 
 	// Channel สำหรับรับผลลัพธ์จาก goroutines
@@ -86,7 +88,7 @@ func AllDataUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	// ตรวจสอบ error
 	select {
 	case err := <-errorChan:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	default:
 	}
@@ -98,7 +100,7 @@ func AllDataUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 	// 4. Fetch tasks data (ต้องรอ board data ก่อน)
 	allBoardIDs := extractBoardIDs(boardData, boardGroupData)
-	fmt.Printf("Debug: Found %d board IDs: %v\n", len(allBoardIDs), allBoardIDs) // Debug log
+	logging.FromContext(c).Debug("loaded boards", "board_ids", allBoardIDs)
 
 	var wg2 sync.WaitGroup
 
@@ -113,7 +115,7 @@ func AllDataUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 			}
 			return
 		}
-		fmt.Printf("Debug: Found %d tasks\n", len(tasksData)) // Debug log
+		logging.FromContext(c).Debug("loaded tasks", "count", len(tasksData))
 		tasksChan <- tasksData
 	}()
 
@@ -122,7 +124,7 @@ func AllDataUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	// ตรวจสอบ error จาก task fetching
 	select {
 	case err := <-errorChan:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	default:
 	}
@@ -139,7 +141,7 @@ func AllDataUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 
 func fetchUserData(db *gorm.DB, userId uint) (map[string]interface{}, error) {
 	var user model.User
-	if err := db.Raw("SELECT user_id, email, name, role, profile, is_verify, is_active, create_at FROM user WHERE user_id = ?", userId).Scan(&user).Error; err != nil {
+	if err := db.Raw("SELECT user_id, email, name, role, profile, is_verify, is_active, timezone, create_at FROM user WHERE user_id = ?", userId).Scan(&user).Error; err != nil {
 		return nil, err
 	}
 
@@ -151,6 +153,7 @@ func fetchUserData(db *gorm.DB, userId uint) (map[string]interface{}, error) {
 		"Role":      user.Role,
 		"IsVerify":  user.IsVerify,
 		"IsActive":  user.IsActive,
+		"Timezone":  user.Timezone,
 		"CreatedAt": user.CreatedAt,
 	}, nil
 }
@@ -0,0 +1,93 @@
+package user
+
+import (
+	"fmt"
+	"mydayplanner/apperror"
+	"mydayplanner/dto"
+	"mydayplanner/middleware"
+	"mydayplanner/quiethours"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// QuietHoursController ช่วงเวลาที่ผู้ใช้ไม่ต้องการรับแจ้งเตือนงานที่ไม่เร่งด่วน
+func QuietHoursController(router *gin.Engine, db *gorm.DB) {
+	get := func(c *gin.Context) { GetQuietHours(c, db) }
+	put := func(c *gin.Context) { PutQuietHours(c, db) }
+
+	routes := router.Group("/v1/users/me/quiet-hours", middleware.AccessTokenMiddleware())
+	{
+		routes.GET("", get)
+		routes.PUT("", put)
+	}
+}
+
+func GetQuietHours(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+	s, err := quiethours.Get(c.Request.Context(), db, int(userId))
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	c.JSON(200, quietHoursResponse(s))
+}
+
+// PutQuietHours แทนที่ทุกช่วงของผู้ใช้ แจ้งเตือนที่เลื่อนไว้แล้วถูกตรวจกับค่าใหม่อีกครั้งตอนถึงเวลาส่ง
+func PutQuietHours(c *gin.Context, db *gorm.DB) {
+	userId := c.MustGet("userId").(uint)
+	var req dto.QuietHoursRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		apperror.Respond(c, apperror.Validation(err))
+		return
+	}
+	windows := make([]quiethours.Window, len(req.Windows))
+	for i, w := range req.Windows {
+		field := fmt.Sprintf("windows[%d]", i)
+		days, err := quiethours.ParseDays(w.Days)
+		if err != nil {
+			apperror.Respond(c, apperror.InvalidInput.WithField(field+".days", "oneof"))
+			return
+		}
+		start, err := quiethours.ParseClock(w.Start)
+		if err != nil {
+			apperror.Respond(c, apperror.InvalidInput.WithField(field+".start", "time"))
+			return
+		}
+		end, err := quiethours.ParseClock(w.End)
+		if err != nil {
+			apperror.Respond(c, apperror.InvalidInput.WithField(field+".end", "time"))
+			return
+		}
+		windows[i] = quiethours.Window{Days: days, Start: start, End: end}
+		if err := windows[i].Validate(); err != nil {
+			apperror.Respond(c, apperror.InvalidInput.WithField(field+".start", "time"))
+			return
+		}
+	}
+
+	ctx := c.Request.Context()
+	if err := quiethours.Save(ctx, db, int(userId), windows); err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	s, err := quiethours.Get(ctx, db, int(userId))
+	if err != nil {
+		apperror.Respond(c, apperror.Internal.Wrap(err))
+		return
+	}
+	c.JSON(200, quietHoursResponse(s))
+}
+
+// quietHoursResponse time_zone เป็นเขตเวลาของผู้ใช้ที่ใช้ตีความทุกช่วง แสดงไว้ให้ client อ่านอย่างเดียว
+func quietHoursResponse(s quiethours.Schedule) gin.H {
+	windows := []dto.QuietHourWindow{}
+	for _, w := range s.Windows {
+		windows = append(windows, dto.QuietHourWindow{
+			Days:  w.Days.Names(),
+			Start: quiethours.FormatClock(w.Start),
+			End:   quiethours.FormatClock(w.End),
+		})
+	}
+	return gin.H{"time_zone": s.Location.String(), "windows": windows}
+}
@@ -4,47 +4,57 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"mydayplanner/apperror"
 	"mydayplanner/dto"
+	"mydayplanner/logging"
 	"mydayplanner/middleware"
 	"mydayplanner/model"
+	"mydayplanner/ratelimit"
+	"mydayplanner/store"
+	"mydayplanner/timezone"
 	"net/http"
 	"strings"
 	"time"
 
-	"cloud.google.com/go/firestore"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-func UserController(router *gin.Engine, db *gorm.DB, firestoreClient *firestore.Client) {
-	routes := router.Group("/user", middleware.AccessTokenMiddleware())
+func UserController(router *gin.Engine, db *gorm.DB, fb store.Store) {
+	data := func(c *gin.Context) { AllDataUser(c, db, fb) }
+	all := func(c *gin.Context) { GetAllUser(c, db, fb) }
+	search := func(c *gin.Context) { SearchUser(c, db) }
+	profile := func(c *gin.Context) { UpdateProfileUser(c, db, fb) }
+	password := func(c *gin.Context) { RemovePassword(c, db, fb) }
+	deleteAccount := func(c *gin.Context) { DeleteUser(c, db, fb) }
+
+	routes := router.Group("/v1/users", middleware.AccessTokenMiddleware())
 	{
-		routes.GET("/data", func(c *gin.Context) {
-			AllDataUser(c, db, firestoreClient)
-		})
-		routes.GET("/alluser", func(c *gin.Context) {
-			GetAllUser(c, db, firestoreClient)
-		})
-		routes.POST("/search", func(c *gin.Context) {
-			SearchUser(c, db)
-		})
-		routes.PUT("/profile", func(c *gin.Context) {
-			UpdateProfileUser(c, db, firestoreClient)
-		})
-		routes.PUT("/removepassword", func(c *gin.Context) {
-			RemovePassword(c, db, firestoreClient)
-		})
-		routes.DELETE("/account", func(c *gin.Context) {
-			DeleteUser(c, db, firestoreClient)
-		})
+		routes.GET("", all)
+		routes.POST("/search", middleware.RateLimit(ratelimit.ClassSearch), search)
+		routes.GET("/me", data)
+		routes.PUT("/me/profile", profile)
+		routes.PUT("/me/password", password)
+		routes.DELETE("/me", deleteAccount)
+	}
+
+	// route เดิม คงไว้ให้ client ที่ยังไม่ย้ายไป /v1
+	legacy := router.Group("/user")
+	{
+		legacy.GET("/data", middleware.Deprecated("/v1/users/me"), middleware.AccessTokenMiddleware(), data)
+		legacy.GET("/alluser", middleware.Deprecated("/v1/users"), middleware.AccessTokenMiddleware(), all)
+		legacy.POST("/search", middleware.Deprecated("/v1/users/search"), middleware.AccessTokenMiddleware(), middleware.RateLimit(ratelimit.ClassSearch), search)
+		legacy.PUT("/profile", middleware.Deprecated("/v1/users/me/profile"), middleware.AccessTokenMiddleware(), profile)
+		legacy.PUT("/removepassword", middleware.Deprecated("/v1/users/me/password"), middleware.AccessTokenMiddleware(), password)
+		legacy.DELETE("/account", middleware.Deprecated("/v1/users/me"), middleware.AccessTokenMiddleware(), deleteAccount)
 	}
 }
 
-func GetAllUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func GetAllUser(c *gin.Context, db *gorm.DB, fb store.Store) {
 	var users []map[string]interface{}
 	if err := db.Table("user").Select("user_id, email, name, role, profile, is_active, is_verify, create_at").Scan(&users).Error; err != nil {
-		c.JSON(500, gin.H{"error": "Database error"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 	c.JSON(200, users)
@@ -53,7 +63,7 @@ func GetAllUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 func SearchUser(c *gin.Context, db *gorm.DB) {
 	var emailReq dto.EmailText
 	if err := c.ShouldBindJSON(&emailReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -61,12 +71,12 @@ func SearchUser(c *gin.Context, db *gorm.DB) {
 
 	var users []model.User
 	if err := db.Where("email LIKE ? AND is_verify != ? AND is_active = ?", searchPattern, "0", "1").Find(&users).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	if len(users) == 0 {
-		c.JSON(http.StatusNotFound, gin.H{"message": "No matching users found"})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
@@ -98,18 +108,18 @@ func SearchUser(c *gin.Context, db *gorm.DB) {
 	c.JSON(http.StatusOK, userResponses)
 }
 
-func UpdateProfileUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func UpdateProfileUser(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 
 	var updateProfile dto.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&updateProfile); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// Validate if there's anything to update
-	if updateProfile.Name == "" && updateProfile.HashedPassword == "" && updateProfile.Profile == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "No data to update"})
+	if updateProfile.Name == "" && updateProfile.HashedPassword == "" && updateProfile.Profile == "" && updateProfile.Timezone == "" {
+		apperror.Respond(c, apperror.NoFieldsToUpdate)
 		return
 	}
 
@@ -117,7 +127,7 @@ func UpdateProfileUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 	if updateProfile.Name != "" {
 		updateProfile.Name = strings.TrimSpace(updateProfile.Name)
 		if len(updateProfile.Name) < 2 || len(updateProfile.Name) > 100 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Name must be between 2 and 100 characters"})
+			apperror.Respond(c, apperror.InvalidInput.WithField("name", "min=2,max=100"))
 			return
 		}
 	}
@@ -125,15 +135,20 @@ func UpdateProfileUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 	if updateProfile.Profile != "" {
 		updateProfile.Profile = strings.TrimSpace(updateProfile.Profile)
 		if len(updateProfile.Profile) > 500 {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Profile description must not exceed 500 characters"})
+			apperror.Respond(c, apperror.InvalidInput.WithField("profile", "max=500"))
 			return
 		}
 	}
 
+	if updateProfile.Timezone != "" && !timezone.Valid(updateProfile.Timezone) {
+		apperror.Respond(c, apperror.InvalidInput.WithField("timezone", "timezone"))
+		return
+	}
+
 	// Start database transaction
 	tx := db.Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		apperror.Respond(c, apperror.Internal.Wrap(tx.Error))
 		return
 	}
 
@@ -141,7 +156,7 @@ func UpdateProfileUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			apperror.Respond(c, apperror.Internal.Wrap(fmt.Errorf("panic: %v", r)))
 		}
 	}()
 
@@ -150,9 +165,9 @@ func UpdateProfileUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 	if err := tx.Table("user").Select("user_id, name").Where("user_id = ?", userId).First(&existingUser).Error; err != nil {
 		tx.Rollback()
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -167,6 +182,9 @@ func UpdateProfileUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 	if updateProfile.Profile != "" {
 		updateMap["profile"] = updateProfile.Profile
 	}
+	if updateProfile.Timezone != "" {
+		updateMap["timezone"] = updateProfile.Timezone
+	}
 
 	// Handle password hashing if password is provided
 	if updateProfile.HashedPassword != "" {
@@ -174,7 +192,7 @@ func UpdateProfileUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(updateProfile.HashedPassword), bcrypt.DefaultCost)
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 		updateMap["hashed_password"] = string(hashedPassword)
@@ -184,21 +202,21 @@ func UpdateProfileUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 	result := tx.Model(&model.User{}).Where("user_id = ?", userId).Updates(updateMap)
 	if result.Error != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user profile"})
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	}
 
 	// Check if any rows were affected
 	if result.RowsAffected == 0 {
 		tx.Rollback()
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found or no changes made"})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save changes"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
@@ -210,7 +228,7 @@ func UpdateProfileUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.C
 	c.JSON(http.StatusOK, responseData)
 }
 
-func DeleteUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func DeleteUser(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 
 	// Use channels for concurrent checking
@@ -244,7 +262,7 @@ func DeleteUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	// Get result from goroutine
 	result := <-checkChan
 	if result.err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check user associations"})
+		apperror.Respond(c, apperror.Internal.Wrap(result.err))
 		return
 	}
 
@@ -253,12 +271,12 @@ func DeleteUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		// Deactivate user
 		updateResult := db.Model(&model.User{}).Where("user_id = ?", userId).Update("is_active", "2")
 		if updateResult.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to deactivate user"})
+			apperror.Respond(c, apperror.Internal.Wrap(updateResult.Error))
 			return
 		}
 
 		if updateResult.RowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 			return
 		}
 
@@ -267,12 +285,12 @@ func DeleteUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 		// Delete user
 		deleteResult := db.Where("user_id = ?", userId).Delete(&model.User{})
 		if deleteResult.Error != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete user"})
+			apperror.Respond(c, apperror.Internal.Wrap(deleteResult.Error))
 			return
 		}
 
 		if deleteResult.RowsAffected == 0 {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 			return
 		}
 
@@ -280,25 +298,25 @@ func DeleteUser(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client)
 	}
 }
 
-func RemovePassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Client) {
+func RemovePassword(c *gin.Context, db *gorm.DB, fb store.Store) {
 	userId := c.MustGet("userId").(uint)
 
 	var passwordReq dto.PasswordRequest
 	if err := c.ShouldBindJSON(&passwordReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
 	// Validate input
 	if passwordReq.OldPassword == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Old password is required"})
+		apperror.Respond(c, apperror.InvalidInput.WithField("oldpassword", "required"))
 		return
 	}
 
 	// Start database transaction
 	tx := db.Begin()
 	if tx.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start transaction"})
+		apperror.Respond(c, apperror.Internal.Wrap(tx.Error))
 		return
 	}
 
@@ -306,7 +324,7 @@ func RemovePassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clie
 	defer func() {
 		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+			apperror.Respond(c, apperror.Internal.Wrap(fmt.Errorf("panic: %v", r)))
 		}
 	}()
 
@@ -315,9 +333,9 @@ func RemovePassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clie
 	if err := tx.Table("user").Where("user_id = ?", userId).First(&user).Error; err != nil {
 		tx.Rollback()
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+			apperror.Respond(c, apperror.Internal)
 		}
 		return
 	}
@@ -325,7 +343,7 @@ func RemovePassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clie
 	// Validate old password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(passwordReq.OldPassword)); err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		apperror.Respond(c, apperror.CurrentPasswordIncorrect)
 		return
 	}
 
@@ -340,14 +358,14 @@ func RemovePassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clie
 		// Validate new password is provided
 		if passwordReq.NewPassword == "" {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "New password is required"})
+			apperror.Respond(c, apperror.InvalidInput.WithField("newpassword", "required"))
 			return
 		}
 
 		// Check if new password is same as old password
 		if err := bcrypt.CompareHashAndPassword([]byte(user.HashedPassword), []byte(passwordReq.NewPassword)); err == nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "New password must be different from current password"})
+			apperror.Respond(c, apperror.PasswordUnchanged)
 			return
 		}
 
@@ -355,7 +373,7 @@ func RemovePassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clie
 		hashedPassword, err = bcrypt.GenerateFromPassword([]byte(passwordReq.NewPassword), bcrypt.DefaultCost)
 		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process new password"})
+			apperror.Respond(c, apperror.Internal.Wrap(err))
 			return
 		}
 	}
@@ -364,32 +382,32 @@ func RemovePassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clie
 	result := tx.Model(&model.User{}).Where("user_id = ?", userId).Update("hashed_password", string(hashedPassword))
 	if result.Error != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update password"})
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	}
 
 	if result.RowsAffected == 0 {
 		tx.Rollback()
-		c.JSON(http.StatusNotFound, gin.H{"error": "User not found or password unchanged"})
+		apperror.Respond(c, apperror.UserNotFound)
 		return
 	}
 
 	// Commit transaction
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save changes"})
+		apperror.Respond(c, apperror.Internal.Wrap(err))
 		return
 	}
 
 	// Update Firestore if client is available
-	if firestoreClient != nil {
+	if fb != nil {
 		ctx := context.Background()
-		_, err := firestoreClient.Collection("usersLogin").Doc(fmt.Sprintf("%s", user.Email)).Update(ctx, []firestore.Update{
-			{Path: "passwordChangedAt", Value: time.Now()},
+		err := fb.Logins().Update(ctx, user.Email, store.Fields{
+			"passwordChangedAt": time.Now(),
 		})
 		if err != nil {
 			// Log error but don't fail the request since DB is already updated
-			fmt.Printf("Failed to update Firestore for user %d: %v\n", userId, err)
+			logging.FromContext(c).Error("failed to update Firestore user", "user_id", userId, "error", err)
 		}
 	}
 
@@ -408,7 +426,7 @@ func RemovePassword(c *gin.Context, db *gorm.DB, firestoreClient *firestore.Clie
 func GetEmail(c *gin.Context, db *gorm.DB) {
 	var emailReq dto.EmailRequest
 	if err := c.ShouldBindJSON(&emailReq); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		apperror.Respond(c, apperror.Validation(err))
 		return
 	}
 
@@ -419,11 +437,11 @@ func GetEmail(c *gin.Context, db *gorm.DB) {
 	// ตรวจสอบว่าพบข้อมูลหรือไม่
 	if result.Error != nil {
 		if result.Error == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			apperror.Respond(c, apperror.UserNotFound)
 			return
 		}
 		// Error อื่นๆ จากฐานข้อมูล
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database error"})
+		apperror.Respond(c, apperror.Internal.Wrap(result.Error))
 		return
 	}
 
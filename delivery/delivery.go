@@ -0,0 +1,136 @@
+// Package delivery ส่ง push ไปยังทุกเครื่องของผู้รับและบันทึกผลราย token ลงตาราง push_deliveries
+// ให้ตอบได้ว่าผู้ใช้ได้รับแจ้งเตือนของงานไหน ที่เครื่องใด เมื่อไร และ provider ตอบ message id หรือ error อะไร
+// scheduler และ handler ใน notify.go ส่งผ่าน Send เท่านั้น การบันทึกที่ล้มแค่ log ไว้และไม่ทำให้การส่งล้ม
+package delivery
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/logging"
+	"mydayplanner/model"
+	"mydayplanner/push"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// DefaultLimit จำนวนรายการต่อหน้าเมื่อไม่ระบุ limit
+	DefaultLimit = 50
+	// MaxLimit จำนวนรายการต่อหน้าสูงสุด
+	MaxLimit = 200
+)
+
+// Recipient ผู้รับหนึ่งคนกับ token ของทุกเครื่อง
+type Recipient struct {
+	UserID int
+	Tokens []string
+}
+
+// Count จำนวน token ของผู้รับทั้งหมด
+func Count(recipients []Recipient) int {
+	n := 0
+	for _, r := range recipients {
+		n += len(r.Tokens)
+	}
+	return n
+}
+
+// Meta ที่มาของ push บันทึกไว้กับทุกแถว
+type Meta struct {
+	Type           string // before, due, snooze, recurring, invite, accepted, assigned, unassigned
+	NotificationID *int
+	AttemptID      *int64
+	TaskID         *int
+	BoardID        *int
+}
+
+// Send ส่ง msg ไปยังทุก token ของผู้รับผ่าน push.SendAll แล้วบันทึกผลราย token
+// token ซ้ำ (เช่นสองบัญชีบนเครื่องเดียวกัน) ถูกส่งครั้งเดียวและบันทึกเป็นของผู้รับคนแรก
+func Send(ctx context.Context, db *gorm.DB, pusher push.Provider, recipients []Recipient, meta Meta, msg push.Message) ([]push.Result, error) {
+	owner := make(map[string]int)
+	var tokens []string
+	for _, r := range recipients {
+		for _, token := range r.Tokens {
+			if _, dup := owner[token]; dup {
+				continue
+			}
+			owner[token] = r.UserID
+			tokens = append(tokens, token)
+		}
+	}
+	results, err := push.SendAll(ctx, pusher, tokens, msg)
+	if len(results) > 0 {
+		record(ctx, db, owner, meta, results)
+	}
+	return results, err
+}
+
+func record(ctx context.Context, db *gorm.DB, owner map[string]int, meta Meta, results []push.Result) {
+	now := time.Now().UTC()
+	rows := make([]model.PushDelivery, 0, len(results))
+	for _, r := range results {
+		row := model.PushDelivery{
+			UserID:         owner[r.Token],
+			Type:           meta.Type,
+			NotificationID: meta.NotificationID,
+			AttemptID:      meta.AttemptID,
+			TaskID:         meta.TaskID,
+			BoardID:        meta.BoardID,
+			Token:          r.Token,
+			Status:         model.DeliverySent,
+			SentAt:         now,
+		}
+		if r.MessageID != "" {
+			id := r.MessageID
+			row.MessageID = &id
+		}
+		if r.Err != nil {
+			reason, msg := string(push.Classify(r.Err)), r.Err.Error()
+			row.Status, row.Reason, row.Error = model.DeliveryFailed, &reason, &msg
+		}
+		rows = append(rows, row)
+	}
+	if err := db.WithContext(ctx).CreateInBatches(rows, 500).Error; err != nil {
+		logging.FromContext(ctx).Error("failed to record push deliveries", "type", meta.Type, "rows", len(rows), "error", err)
+	}
+}
+
+// Query เงื่อนไขการค้นประวัติของผู้ใช้หนึ่งคน ค่าศูนย์หรือ nil คือไม่กรอง
+// Before คือ delivery_id ของรายการสุดท้ายในหน้าก่อน
+type Query struct {
+	UserID         int
+	TaskID         *int
+	NotificationID *int
+	Type           string
+	Before         int64
+	Limit          int
+}
+
+// List ประวัติการส่งของผู้ใช้จากใหม่ไปเก่า
+func List(ctx context.Context, db *gorm.DB, q Query) ([]model.PushDelivery, error) {
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	tx := db.WithContext(ctx).Where("user_id = ?", q.UserID)
+	if q.TaskID != nil {
+		tx = tx.Where("task_id = ?", *q.TaskID)
+	}
+	if q.NotificationID != nil {
+		tx = tx.Where("notification_id = ?", *q.NotificationID)
+	}
+	if q.Type != "" {
+		tx = tx.Where("type = ?", q.Type)
+	}
+	if q.Before > 0 {
+		tx = tx.Where("delivery_id < ?", q.Before)
+	}
+	deliveries := []model.PushDelivery{}
+	if err := tx.Order("delivery_id DESC").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("list push deliveries: %w", err)
+	}
+	return deliveries, nil
+}
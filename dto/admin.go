@@ -4,3 +4,10 @@ type AdminRequest struct {
 	Email          string `json:"email"`
 	HashedPassword string `json:"password"`
 }
+
+// ReconcileRequest ขอบเขตของการตรวจ Firestore mirror ระบุ board_id หรือ user_id อย่างใดอย่างหนึ่ง หรือไม่ระบุเพื่อตรวจทั้งหมด
+type ReconcileRequest struct {
+	BoardID int  `json:"board_id"`
+	UserID  int  `json:"user_id"`
+	Apply   bool `json:"apply"`
+}
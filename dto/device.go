@@ -0,0 +1,11 @@
+package dto
+
+type RegisterDeviceRequest struct {
+	Token      string  `json:"token" binding:"required,max=512"`
+	Platform   string  `json:"platform" binding:"required,oneof=android ios web"`
+	AppVersion *string `json:"app_version" binding:"omitempty,max=64"`
+}
+
+type UnregisterDeviceRequest struct {
+	Token string `json:"token" binding:"required"`
+}
@@ -0,0 +1,7 @@
+package dto
+
+type InboxQuery struct {
+	Unread bool  `form:"unread"`
+	Before int64 `form:"before" binding:"omitempty,min=1"`
+	Limit  int   `form:"limit" binding:"omitempty,min=1,max=200"`
+}
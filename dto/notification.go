@@ -11,6 +11,7 @@ type UpdateNotificationRequest struct {
 	BeforeDueDate    *string `json:"before_due_date"`
 	RecurringPattern *string `json:"recurring_pattern"`
 	IsSend           *string `json:"is_send"`
+	Critical         *bool   `json:"critical"`
 }
 
 type InviteNotify struct {
@@ -28,3 +29,11 @@ type UnAssignedNotify struct {
 	RecieveID string `json:"recieveID" binding:"required"`
 	TaskName  string `json:"task_name" binding:"required"`
 }
+
+type DeliveryQuery struct {
+	TaskID         *int   `form:"task_id"`
+	NotificationID *int   `form:"notification_id"`
+	Type           string `form:"type"`
+	Before         int64  `form:"before" binding:"omitempty,min=1"`
+	Limit          int    `form:"limit" binding:"omitempty,min=1,max=200"`
+}
@@ -0,0 +1,14 @@
+package dto
+
+// QuietHoursRequest แทนที่ quiet hours ทั้งหมดของผู้ใช้ windows ว่างคือปิด quiet hours
+// start และ end เป็น HH:MM ตามเขตเวลาของผู้ใช้ (ตั้งที่ /v1/users/me/profile) ช่วงที่ end ไม่ถึง start ข้ามเที่ยงคืน
+// และ start เท่ากับ end คือทั้งวัน
+type QuietHoursRequest struct {
+	Windows []QuietHourWindow `json:"windows" binding:"max=14,dive"`
+}
+
+type QuietHourWindow struct {
+	Days  []string `json:"days" binding:"required,min=1,dive,oneof=sun mon tue wed thu fri sat"`
+	Start string   `json:"start" binding:"required,len=5"`
+	End   string   `json:"end" binding:"required,len=5"`
+}
@@ -21,6 +21,7 @@ type Reminder struct {
 	DueDate          *string `json:"due_date"`
 	BeforeDueDate    *string `json:"before_due_date"`
 	RecurringPattern string  `json:"recurring_pattern,omitempty"`
+	Critical         bool    `json:"critical,omitempty"` // แจ้งเตือน due ส่งแม้อยู่ใน quiet hours
 }
 
 type DeletetaskRequest struct {
@@ -41,3 +42,13 @@ type AssignedTaskRequest struct {
 	TaskID string `json:"task_id" binding:"required"`
 	UserID string `json:"user_id" binding:"required"`
 }
+
+// AssigneeRequest body ของ POST /v1/tasks/:taskid/assignees ซึ่งระบุ task ใน path
+type AssigneeRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// TodayQuery วันที่ในรูปแบบ YYYY-MM-DD ตามเขตเวลาของผู้ใช้ ค่าว่างคือวันนี้
+type TodayQuery struct {
+	Date string `form:"date"`
+}
@@ -4,6 +4,7 @@ type UpdateProfileRequest struct {
 	Name           string `json:"name"`
 	HashedPassword string `json:"password"`
 	Profile        string `json:"profile"`
+	Timezone       string `json:"timezone"` // ชื่อเขตเวลา IANA เช่น Europe/Berlin
 }
 type EmailRequest struct {
 	Email string `json:"email" binding:"required,email"`
@@ -0,0 +1,126 @@
+// Package inbox กล่องแจ้งเตือนในแอปที่ server เป็นเจ้าของ ตาราง inbox_items
+// scheduler และ handler ใน notify.go เพิ่มรายการพร้อมกับส่ง push ผู้ใช้มีรายการแม้ไม่มีเครื่องที่รับ push ได้
+// รายการที่ dismiss แล้วไม่ถูกนับและไม่แสดงอีก
+package inbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mydayplanner/model"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// DefaultLimit จำนวนรายการต่อหน้าเมื่อไม่ระบุ limit
+	DefaultLimit = 50
+	// MaxLimit จำนวนรายการต่อหน้าสูงสุด
+	MaxLimit = 200
+)
+
+// ErrNotFound ไม่มีรายการนี้ในกล่องของผู้ใช้ หรือถูก dismiss ไปแล้ว
+var ErrNotFound = errors.New("inbox item not found")
+
+// Add เพิ่มรายการ รายการที่ DedupKey ซ้ำกับที่มีอยู่ของผู้ใช้คนเดียวกันถูกข้าม
+func Add(ctx context.Context, db *gorm.DB, items []model.InboxItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	now := time.Now().UTC()
+	for i := range items {
+		if items[i].CreatedAt.IsZero() {
+			items[i].CreatedAt = now
+		}
+	}
+	if err := db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&items).Error; err != nil {
+		return fmt.Errorf("add inbox items: %w", err)
+	}
+	return nil
+}
+
+// ForUsers รายการเดียวกันสำหรับผู้ใช้หลายคน
+func ForUsers(userIDs []int, item model.InboxItem) []model.InboxItem {
+	items := make([]model.InboxItem, len(userIDs))
+	for i, id := range userIDs {
+		items[i] = item
+		items[i].UserID = id
+	}
+	return items
+}
+
+// List รายการที่ยังไม่ dismiss จากใหม่ไปเก่า before คือ item_id ของรายการสุดท้ายในหน้าก่อน
+func List(ctx context.Context, db *gorm.DB, userID int, unreadOnly bool, before int64, limit int) ([]model.InboxItem, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	limit = min(limit, MaxLimit)
+
+	tx := visible(db.WithContext(ctx), userID)
+	if unreadOnly {
+		tx = tx.Where("read_at IS NULL")
+	}
+	if before > 0 {
+		tx = tx.Where("item_id < ?", before)
+	}
+	items := []model.InboxItem{}
+	if err := tx.Order("item_id DESC").Limit(limit).Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("list inbox items: %w", err)
+	}
+	return items, nil
+}
+
+// Unread จำนวนรายการที่ยังไม่อ่าน
+func Unread(ctx context.Context, db *gorm.DB, userID int) (int64, error) {
+	var n int64
+	if err := visible(db.WithContext(ctx), userID).Where("read_at IS NULL").Count(&n).Error; err != nil {
+		return 0, fmt.Errorf("count unread inbox items: %w", err)
+	}
+	return n, nil
+}
+
+// MarkRead ทำเครื่องหมายว่าอ่านแล้ว รายการที่อ่านแล้วคงเวลาที่อ่านครั้งแรกไว้
+func MarkRead(ctx context.Context, db *gorm.DB, userID int, itemID int64, now time.Time) error {
+	var item model.InboxItem
+	err := visible(db.WithContext(ctx), userID).Where("item_id = ?", itemID).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("load inbox item: %w", err)
+	}
+	if item.ReadAt != nil {
+		return nil
+	}
+	if err := db.WithContext(ctx).Model(&item).Update("read_at", now.UTC()).Error; err != nil {
+		return fmt.Errorf("mark inbox item read: %w", err)
+	}
+	return nil
+}
+
+// MarkAllRead ทำเครื่องหมายทุกรายการที่ยังไม่อ่าน คืนจำนวนที่เปลี่ยน
+func MarkAllRead(ctx context.Context, db *gorm.DB, userID int, now time.Time) (int64, error) {
+	res := visible(db.WithContext(ctx), userID).Where("read_at IS NULL").Update("read_at", now.UTC())
+	if res.Error != nil {
+		return 0, fmt.Errorf("mark inbox read: %w", res.Error)
+	}
+	return res.RowsAffected, nil
+}
+
+// Dismiss ซ่อนรายการออกจากกล่อง
+func Dismiss(ctx context.Context, db *gorm.DB, userID int, itemID int64, now time.Time) error {
+	res := visible(db.WithContext(ctx), userID).Where("item_id = ?", itemID).Update("dismissed_at", now.UTC())
+	if res.Error != nil {
+		return fmt.Errorf("dismiss inbox item: %w", res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func visible(db *gorm.DB, userID int) *gorm.DB {
+	return db.Model(&model.InboxItem{}).Where("user_id = ? AND dismissed_at IS NULL", userID)
+}
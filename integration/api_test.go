@@ -0,0 +1,224 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/controller/notification"
+	"mydayplanner/model"
+	"mydayplanner/store"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSignupOTPVerificationAndSignin(t *testing.T) {
+	h := newHarness(t)
+	const email, password = "alice@example.test", "P@ssw0rd-123"
+
+	created := h.expect(http.StatusOK, http.MethodPost, "/v1/auth/signup", "", map[string]string{
+		"name": "Alice", "email": email, "password": password,
+	})
+	if got := created["user"].(map[string]any)["email"]; got != email {
+		t.Fatalf("signup returned email %v, want %s", got, email)
+	}
+	h.expectError(http.StatusConflict, "EMAIL_EXISTS", http.MethodPost, "/v1/auth/signup", "", map[string]string{
+		"name": "Alice", "email": email, "password": password,
+	})
+
+	// ยังไม่ยืนยันอีเมลจึง sign in ไม่ได้
+	signin := map[string]string{"email": email, "password": password}
+	h.expectError(http.StatusForbidden, "ACCOUNT_NOT_VERIFIED", http.MethodPost, "/v1/auth/signin", "", signin)
+
+	ref := h.expect(http.StatusOK, http.MethodPost, "/v1/auth/otp/identity", "", map[string]string{"email": email})["ref"].(string)
+	h.expect(http.StatusOK, http.MethodPost, "/v1/auth/otp/email", "", map[string]string{
+		"email": email, "reference": ref, "record": "1",
+	})
+	mails := h.smtp.Mails()
+	if len(mails) != 1 || mails[0].To[0] != email {
+		t.Fatalf("want one OTP mail to %s, got %+v", email, mails)
+	}
+
+	h.expectError(http.StatusBadRequest, "OTP_INVALID", http.MethodPut, "/v1/auth/otp/verify", "", map[string]string{
+		"email": email, "ref": ref, "otp": "000000", "record": "1",
+	})
+	verified := h.expect(http.StatusOK, http.MethodPut, "/v1/auth/otp/verify", "", map[string]string{
+		"email": email, "ref": ref, "otp": h.lastOTP(email), "record": "1",
+	})
+	if verified["accessToken"] == nil || verified["refreshToken"] == nil {
+		t.Fatalf("verify did not return tokens: %v", verified)
+	}
+	h.expectError(http.StatusBadRequest, "OTP_ALREADY_USED", http.MethodPut, "/v1/auth/otp/verify", "", map[string]string{
+		"email": email, "ref": ref, "otp": h.lastOTP(email), "record": "1",
+	})
+
+	h.expectError(http.StatusUnauthorized, "INVALID_CREDENTIALS", http.MethodPost, "/v1/auth/signin", "", map[string]string{
+		"email": email, "password": "wrong",
+	})
+	tokens := h.expect(http.StatusOK, http.MethodPost, "/v1/auth/signin", "", signin)["token"].(map[string]any)
+
+	// access token ใช้กับ route ที่ต้อง login ได้ และ refresh token ขอ access token ใหม่ได้
+	me := h.expect(http.StatusOK, http.MethodGet, "/v1/users/me", tokens["accessToken"].(string), nil)
+	if me["user"] == nil {
+		t.Fatalf("GET /v1/users/me: %v", me)
+	}
+	refreshed := h.expect(http.StatusOK, http.MethodPost, "/v1/auth/token", tokens["refreshToken"].(string), nil)
+	if refreshed["accessToken"] == nil {
+		t.Fatalf("POST /v1/auth/token: %v", refreshed)
+	}
+
+	login, ok := h.fb.Doc("usersLogin/" + email)
+	if !ok || login["login"] != 1 {
+		t.Fatalf("usersLogin/%s = %v, want login=1", email, login)
+	}
+}
+
+func TestGroupBoardInviteAndAccept(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+
+	created := h.expect(http.StatusCreated, http.MethodPost, "/v1/boards", owner.AccessToken, map[string]string{
+		"board_name": "Release", "is_group": "1",
+	})
+	boardID := int(created["boardID"].(float64))
+	if created["deep_link"] == nil {
+		t.Fatalf("group board has no share link: %v", created)
+	}
+	if _, ok := h.fb.Doc(fmt.Sprintf("Boards/%d", boardID)); !ok {
+		t.Fatalf("board %d was not mirrored to Firestore", boardID)
+	}
+
+	boardPath := fmt.Sprintf("/v1/boards/%d", boardID)
+	invite := h.expect(http.StatusOK, http.MethodPost, boardPath+"/invites", owner.AccessToken, map[string]string{
+		"user_id": strconv.Itoa(member.ID),
+	})
+	invitePath := fmt.Sprintf("/v1/invites/%d", int(invite["invite_id"].(float64)))
+
+	h.expectError(http.StatusBadRequest, "CANNOT_ACCEPT_OWN_INVITE", http.MethodPost, invitePath, owner.AccessToken, map[string]bool{"accept": true})
+	accepted := h.expect(http.StatusOK, http.MethodPost, invitePath, member.AccessToken, map[string]bool{"accept": true})
+	if int(accepted["board_id"].(float64)) != boardID {
+		t.Fatalf("accepted invite for board %v, want %d", accepted["board_id"], boardID)
+	}
+
+	var members int64
+	if err := h.deps.DB.Model(&model.BoardUser{}).Where("board_id = ?", boardID).Count(&members).Error; err != nil {
+		t.Fatal(err)
+	}
+	if members != 2 {
+		t.Fatalf("board %d has %d members, want 2", boardID, members)
+	}
+
+	h.expectError(http.StatusConflict, "ALREADY_BOARD_MEMBER", http.MethodPost, boardPath+"/invites", owner.AccessToken, map[string]string{
+		"user_id": strconv.Itoa(member.ID),
+	})
+}
+
+func TestTaskReminderIsPushedByNotificationJob(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	h.registerDevice(owner, "owner-device")
+	h.registerDevice(member, "member-device")
+	boardID := h.groupBoard(owner, member)
+
+	// แจ้งเตือนล่วงหน้าถึงเวลาแล้ว แต่ยังไม่ถึงกำหนดส่ง
+	now := time.Now().UTC()
+	created := h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/v1/boards/%d/tasks", boardID), owner.AccessToken, map[string]any{
+		"task_name": "Ship v1",
+		"status":    "0",
+		"reminder": map[string]string{
+			"due_date":        now.Add(time.Hour).Format(time.RFC3339),
+			"before_due_date": now.Add(-time.Minute).Format(time.RFC3339),
+		},
+	})
+	taskID := int(created["taskID"].(float64))
+	notificationID := int(created["notificationID"].(float64))
+	notificationPath := fmt.Sprintf("BoardTasks/%d/%s/%d", taskID, store.TaskNotifications, notificationID)
+	h.waitFor("notification mirror in Firestore", func() bool {
+		_, ok := h.fb.Doc(notificationPath)
+		return ok
+	})
+
+	if err := notification.SendNotificationJob(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := h.fcm.Messages()
+	got := map[string]fcmMessage{}
+	for _, m := range messages {
+		got[m.Token] = m
+	}
+	if len(messages) != 2 || got["owner-device"].Token == "" || got["member-device"].Token == "" {
+		t.Fatalf("want one push to each board member, got %+v", messages)
+	}
+	for _, m := range messages {
+		if m.Data["taskid"] != strconv.Itoa(taskID) || m.Data["type"] != "before" {
+			t.Errorf("push data = %v, want taskid=%d type=before", m.Data, taskID)
+		}
+	}
+
+	var sent model.Notification
+	if err := h.deps.DB.First(&sent, notificationID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if sent.IsSend != "1" {
+		t.Fatalf("notification is_send = %q after before-due push, want 1", sent.IsSend)
+	}
+	if mirror, _ := h.fb.Doc(notificationPath); mirror["isSend"] != "1" {
+		t.Fatalf("Firestore notification = %v, want isSend=1", mirror)
+	}
+
+	// รอบถัดไปไม่ส่งซ้ำจนกว่าจะถึงกำหนด
+	if err := notification.SendNotificationJob(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(h.fcm.Messages()); n != 2 {
+		t.Fatalf("second run sent %d more pushes, want 0", n-2)
+	}
+}
+
+func TestDeleteTaskAndBoard(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	boardID := h.groupBoard(owner, member)
+
+	createTask := func(name string) int {
+		created := h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/v1/boards/%d/tasks", boardID), owner.AccessToken, map[string]any{
+			"task_name": name, "status": "0",
+		})
+		taskID := int(created["taskID"].(float64))
+		h.waitFor("task mirror in Firestore", func() bool {
+			_, ok := h.fb.Doc(fmt.Sprintf("Boards/%d/Tasks/%d", boardID, taskID))
+			return ok
+		})
+		return taskID
+	}
+	first, second := createTask("Write notes"), createTask("Tag release")
+
+	h.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/v1/tasks/%d", first), member.AccessToken, nil)
+	h.expectError(http.StatusNotFound, "TASK_NOT_FOUND", http.MethodDelete, "/v1/tasks", owner.AccessToken, map[string][]string{
+		"task_id": ids(first),
+	})
+	if _, ok := h.fb.Doc(fmt.Sprintf("Boards/%d/Tasks/%d", boardID, first)); ok {
+		t.Fatalf("task %d is still in Firestore", first)
+	}
+
+	h.expect(http.StatusOK, http.MethodDelete, "/v1/boards", owner.AccessToken, map[string][]string{
+		"board_id": ids(boardID),
+	})
+	for table, id := range map[string]int{"board": boardID, "tasks": second} {
+		var n int64
+		column := map[string]string{"board": "board_id", "tasks": "task_id"}[table]
+		if err := h.deps.DB.Table(table).Where(column+" = ?", id).Count(&n).Error; err != nil {
+			t.Fatal(err)
+		}
+		if n != 0 {
+			t.Errorf("%s %d still exists after deleting the board", table, id)
+		}
+	}
+	if paths := h.fb.Paths(fmt.Sprintf("Boards/%d", boardID)); len(paths) != 0 {
+		t.Errorf("Firestore still has %v", paths)
+	}
+}
@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/controller/notification"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestDeliveryLogRecordsEachDevice(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	support := h.admin("Support", "support@example.test")
+	h.registerDevice(owner, "owner-device")
+	h.registerDevice(member, "member-device")
+	h.expect(http.StatusOK, http.MethodPost, "/v1/devices", member.AccessToken, map[string]string{"token": "member-tablet", "platform": "ios"})
+	boardID := h.groupBoard(owner, member)
+	notificationID := h.dueReminder(owner, boardID)
+	h.fcm.Unregister("member-tablet")
+
+	if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+
+	// แต่ละคนเห็นเฉพาะของตัวเอง หนึ่งแถวต่อเครื่อง
+	mine := h.expect(http.StatusOK, http.MethodGet, "/v1/users/me/deliveries", owner.AccessToken, nil)["deliveries"].([]any)
+	if len(mine) != 1 {
+		t.Fatalf("owner deliveries = %v, want one", mine)
+	}
+	row := mine[0].(map[string]any)
+	if row["type"] != "before" || row["status"] != "sent" || row["token"] != "owner-device" ||
+		int(row["notification_id"].(float64)) != notificationID || row["attempt_id"] == nil ||
+		!strings.HasPrefix(fmt.Sprint(row["message_id"]), "projects/") {
+		t.Fatalf("owner delivery = %v", row)
+	}
+
+	byToken := map[string]map[string]any{}
+	for _, d := range h.expect(http.StatusOK, http.MethodGet, "/v1/users/me/deliveries", member.AccessToken, nil)["deliveries"].([]any) {
+		byToken[d.(map[string]any)["token"].(string)] = d.(map[string]any)
+	}
+	if len(byToken) != 2 || byToken["member-device"]["status"] != "sent" ||
+		byToken["member-tablet"]["status"] != "failed" || byToken["member-tablet"]["reason"] != "unregistered" ||
+		byToken["member-tablet"]["error"] == nil || byToken["member-tablet"]["message_id"] != nil {
+		t.Fatalf("member deliveries = %v, want one sent and one unregistered", byToken)
+	}
+
+	// push จาก handler ก็ถูกบันทึก และกรองตาม task ได้
+	created := h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/v1/boards/%d/tasks", boardID), owner.AccessToken, map[string]any{
+		"task_name": "Review", "status": "0",
+	})
+	taskID := strconv.Itoa(int(created["taskID"].(float64)))
+	h.expect(http.StatusOK, http.MethodPost, "/v1/push/assignments", member.AccessToken, map[string]string{
+		"recieveID": strconv.Itoa(owner.ID), "task_id": taskID,
+	})
+	assigned := h.expect(http.StatusOK, http.MethodGet, "/v1/users/me/deliveries?task_id="+taskID, owner.AccessToken, nil)["deliveries"].([]any)
+	if len(assigned) != 1 || assigned[0].(map[string]any)["type"] != "assigned" {
+		t.Fatalf("deliveries for task %s = %v, want the assignment", taskID, assigned)
+	}
+
+	// หน้าละหนึ่งรายการจากใหม่ไปเก่า
+	page := h.expect(http.StatusOK, http.MethodGet, "/v1/users/me/deliveries?limit=1", owner.AccessToken, nil)
+	next := page["next_before"]
+	if next == nil || page["deliveries"].([]any)[0].(map[string]any)["type"] != "assigned" {
+		t.Fatalf("first page = %v", page)
+	}
+	page = h.expect(http.StatusOK, http.MethodGet, fmt.Sprintf("/v1/users/me/deliveries?limit=1&before=%d", int64(next.(float64))), owner.AccessToken, nil)
+	if page["deliveries"].([]any)[0].(map[string]any)["type"] != "before" {
+		t.Fatalf("second page = %v", page)
+	}
+
+	// admin ดูของผู้ใช้คนอื่นได้ ผู้ใช้ทั่วไปไม่ได้
+	path := fmt.Sprintf("/v1/admin/users/%d/deliveries", member.ID)
+	if got := h.expect(http.StatusOK, http.MethodGet, path, support.AccessToken, nil)["deliveries"].([]any); len(got) != 2 {
+		t.Fatalf("admin view = %v, want the member's two deliveries", got)
+	}
+	h.expectError(http.StatusForbidden, "ADMIN_REQUIRED", http.MethodGet, path, owner.AccessToken, nil)
+	h.expectError(http.StatusNotFound, "USER_NOT_FOUND", http.MethodGet, "/v1/admin/users/9999/deliveries", support.AccessToken, nil)
+	h.expectError(http.StatusBadRequest, "INVALID_INPUT", http.MethodGet, "/v1/users/me/deliveries?limit=500", owner.AccessToken, nil)
+}
@@ -0,0 +1,95 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/controller/notification"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func (h *harness) pushedTo() []string {
+	h.t.Helper()
+	var tokens []string
+	for _, m := range h.fcm.Messages() {
+		tokens = append(tokens, m.Token)
+	}
+	slices.Sort(tokens)
+	return tokens
+}
+
+func TestRegisteredDevicesAllReceivePushes(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	h.registerDevice(owner, "owner-phone") // แอปรุ่นเก่าที่ยังเขียน token ลง usersLogin
+	h.expect(http.StatusOK, http.MethodPost, "/v1/devices", owner.AccessToken, map[string]string{
+		"token": "owner-phone", "platform": "android", "app_version": "3.0.0",
+	})
+	h.expect(http.StatusOK, http.MethodPost, "/v1/devices", owner.AccessToken, map[string]string{
+		"token": "owner-tablet", "platform": "ios",
+	})
+	h.expectError(http.StatusBadRequest, "INVALID_INPUT", http.MethodPost, "/v1/devices", owner.AccessToken, map[string]string{
+		"token": "owner-tv", "platform": "tizen",
+	})
+
+	listed := h.expect(http.StatusOK, http.MethodGet, "/v1/devices", owner.AccessToken, nil)
+	if devices := listed["devices"].([]any); len(devices) != 2 {
+		t.Fatalf("devices = %v, want phone and tablet", devices)
+	}
+
+	// reminder ของ board ส่งถึงทุกเครื่องของเจ้าของ ส่วนสมาชิกไม่มีเครื่องก็ไม่ได้รับ
+	boardID := h.groupBoard(owner, member)
+	h.dueReminder(owner, boardID)
+	if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.pushedTo(); !slices.Equal(got, []string{"owner-phone", "owner-tablet"}) {
+		t.Fatalf("reminder pushed to %v, want each of the owner's devices once", got)
+	}
+
+	// ถอดเครื่องแล้วการมอบหมายงานส่งถึงเฉพาะเครื่องที่เหลือ
+	h.expect(http.StatusOK, http.MethodDelete, "/v1/devices", owner.AccessToken, map[string]string{"token": "owner-phone"})
+	h.expectError(http.StatusNotFound, "DEVICE_NOT_FOUND", http.MethodDelete, "/v1/devices", owner.AccessToken, map[string]string{"token": "owner-phone"})
+	if login, _ := h.fb.Doc("usersLogin/" + owner.Email); login["FMCToken"] != nil {
+		t.Fatalf("owner login = %v, want the unregistered legacy token removed", login)
+	}
+
+	created := h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/v1/boards/%d/tasks", boardID), member.AccessToken, map[string]any{
+		"task_name": "Review", "status": "0",
+	})
+	before := len(h.fcm.Messages())
+	h.expect(http.StatusOK, http.MethodPost, "/v1/push/assignments", member.AccessToken, map[string]string{
+		"recieveID": strconv.Itoa(owner.ID), "task_id": strconv.Itoa(int(created["taskID"].(float64))),
+	})
+	if messages := h.fcm.Messages()[before:]; len(messages) != 1 || messages[0].Token != "owner-tablet" {
+		t.Fatalf("assignment pushed %+v, want only the remaining tablet", messages)
+	}
+
+	// สมาชิกไม่มีเครื่องเลย
+	h.expectError(http.StatusNotFound, "FCM_TOKEN_NOT_FOUND", http.MethodPost, "/v1/push/assignments", owner.AccessToken, map[string]string{
+		"recieveID": strconv.Itoa(member.ID), "task_id": strconv.Itoa(int(created["taskID"].(float64))),
+	})
+}
+
+func TestUnregisteredDeviceIsRemovedFromRegistry(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	for _, token := range []string{"owner-phone", "owner-old-phone"} {
+		h.expect(http.StatusOK, http.MethodPost, "/v1/devices", owner.AccessToken, map[string]string{"token": token, "platform": "android"})
+	}
+	h.dueReminder(owner, h.groupBoard(owner, member))
+	h.fcm.Unregister("owner-old-phone")
+
+	if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+	listed := h.expect(http.StatusOK, http.MethodGet, "/v1/devices", owner.AccessToken, nil)
+	devices := listed["devices"].([]any)
+	if len(devices) != 1 || devices[0].(map[string]any)["token"] != "owner-phone" {
+		t.Fatalf("devices = %v, want only the phone that still receives pushes", devices)
+	}
+}
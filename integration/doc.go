@@ -0,0 +1,20 @@
+// Package integration ทดสอบ HTTP API แบบ end-to-end โดยเปิด server จริงผ่าน connection.Serve
+//
+// ทุก dependency ภายนอกถูกแทนด้วยของในเครื่อง: MySQL เป็น SQLite ในไดเรกทอรีชั่วคราวที่สร้างจาก
+// migrations เดียวกับ production, Firestore เป็น store.Memory, FCM และ SMTP เป็น server ปลอมบน 127.0.0.1
+// จึงรันด้วย go test ./integration ได้บนเครื่องที่ไม่มีเครือข่าย (ต้องเปิด cgo สำหรับ go-sqlite3)
+//
+// SQL ที่ผ่านบน SQLite ไม่ได้แปลว่าผ่านบน MySQL ส่วนที่ SQLite ไม่ได้ทดสอบจริงคือ
+//   - migrations: DDL ถูกแปลงด้วย sqliteDDL ไม่ได้รันผ่าน migrations.Migrator จึงไม่ผ่าน GET_LOCK
+//     MODIFY COLUMN ถูกข้าม และไม่มีการรัน Down
+//   - ratelimit.MySQL: INSERT IGNORE และ SELECT ... FOR UPDATE (harness ใช้ backend memory)
+//   - leader lease: upsert ด้วย ON CONFLICT DO NOTHING ที่ gorm แปลงต่างกันตาม dialect
+//   - RowsAffected: MySQL นับเฉพาะแถวที่ค่าเปลี่ยนจริง ส่วน SQLite นับทุกแถวที่ตรงเงื่อนไข
+//     การ claim notification, lease และการ claim deferred_pushes ด้วย DELETE อาศัยค่านี้
+//   - locking และ isolation: SQLite ล็อกทั้งไฟล์ การแย่งแถวเดียวกันจึงไม่เหมือน InnoDB
+//
+// ตั้ง INTEGRATION_MYSQL_DSN (เช่น root:secret@tcp(127.0.0.1:3306)/) เพื่อรันทั้งชุดบน MySQL จริง
+// แต่ละ test สร้างฐานข้อมูลใหม่ รัน migrations ผ่าน Migrator และลบทิ้งเมื่อจบ rate limiter ใช้ backend mysql
+// และ test ใน mysql_test.go ทดสอบส่วนข้างบนโดยตรง ถ้าไม่ได้ตั้ง test เหล่านั้นถูก skip
+// CI รันโหมดนี้กับ MySQL 8.0 ใน job integration-mysql ของ .github/workflows/test.yml
+package integration
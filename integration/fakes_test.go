@@ -0,0 +1,224 @@
+package integration
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fcmMessage ข้อความหนึ่งรายการที่ messaging.Client ส่งมายัง /projects/{project}/messages:send
+type fcmMessage struct {
+	Token        string            `json:"token"`
+	Data         map[string]string `json:"data"`
+	Notification struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	} `json:"notification"`
+}
+
+// fakeFCM HTTP v1 API ของ FCM แบบย่อ ตอบสำเร็จทุกข้อความและเก็บไว้ให้ test ตรวจ
+// ยกเว้น token ที่ Unregister ไว้ซึ่งตอบ 404 UNREGISTERED แบบเดียวกับเครื่องที่ถอนแอปแล้ว
+type fakeFCM struct {
+	srv *httptest.Server
+
+	mu           sync.Mutex
+	messages     []fcmMessage
+	unregistered map[string]bool
+}
+
+func newFakeFCM(t *testing.T, project string) *fakeFCM {
+	t.Helper()
+	f := &fakeFCM{unregistered: make(map[string]bool)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /projects/"+project+"/messages:send", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Message fcmMessage `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		if f.unregistered[req.Message.Token] {
+			f.mu.Unlock()
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"code":404,"message":"Requested entity was not found.","status":"NOT_FOUND",`+
+				`"details":[{"@type":"type.googleapis.com/google.firebase.fcm.v1.FcmError","errorCode":"UNREGISTERED"}]}}`)
+			return
+		}
+		f.messages = append(f.messages, req.Message)
+		id := len(f.messages)
+		f.mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"name":"projects/%s/messages/%d"}`, project, id)
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeFCM) URL() string { return f.srv.URL }
+
+// Unregister ให้การส่งไปยัง token นี้ตอบ UNREGISTERED ตั้งแต่ครั้งถัดไป
+func (f *fakeFCM) Unregister(token string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.unregistered[token] = true
+}
+
+// Messages สำเนาของข้อความที่ได้รับทั้งหมดตามลำดับที่มาถึง
+func (f *fakeFCM) Messages() []fcmMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fcmMessage(nil), f.messages...)
+}
+
+// smtpMail อีเมลหนึ่งฉบับที่ fakeSMTP ได้รับ Data เป็นข้อความดิบตาม RFC 5322
+type smtpMail struct {
+	From string
+	To   []string
+	Data string
+}
+
+// fakeSMTP SMTP server ที่รับทุกคำสั่งและเก็บเนื้อหาไว้
+// ประกาศ AUTH PLAIN แต่ไม่ประกาศ STARTTLS ซึ่ง net/smtp ยอมส่งรหัสผ่านแบบไม่เข้ารหัสเมื่อ host เป็น 127.0.0.1
+type fakeSMTP struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	mails []smtpMail
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTP{ln: ln}
+	var wg sync.WaitGroup
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.serve(conn)
+			}()
+		}
+	}()
+	t.Cleanup(func() {
+		ln.Close()
+		wg.Wait()
+	})
+	return s
+}
+
+// Addr คืน host และ port แยกกันตามรูปแบบของ config.SMTPConfig
+func (s *fakeSMTP) Addr() (host, port string) {
+	host, port, _ = net.SplitHostPort(s.ln.Addr().String())
+	return host, port
+}
+
+// Mails สำเนาของอีเมลที่ได้รับทั้งหมดตามลำดับที่มาถึง
+func (s *fakeSMTP) Mails() []smtpMail {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]smtpMail(nil), s.mails...)
+}
+
+// Text เนื้อหาส่วน text/plain ที่ถอด quoted-printable แล้ว
+func (m smtpMail) Text() (string, error) {
+	msg, err := netmail.ReadMessage(strings.NewReader(m.Data))
+	if err != nil {
+		return "", err
+	}
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		body, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+		return string(body), err
+	}
+	parts := multipart.NewReader(msg.Body, params["boundary"])
+	for {
+		// NextPart ถอด quoted-printable ให้เอง
+		part, err := parts.NextPart()
+		if err != nil {
+			return "", err
+		}
+		if strings.HasPrefix(part.Header.Get("Content-Type"), "text/plain") {
+			body, err := io.ReadAll(part)
+			return string(body), err
+		}
+	}
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	tp := textproto.NewConn(conn)
+	reply := func(format string, args ...any) bool {
+		return tp.PrintfLine(format, args...) == nil
+	}
+
+	var cur smtpMail
+	if !reply("220 localhost ESMTP fake") {
+		return
+	}
+	for {
+		line, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			reply("250-localhost\r\n250 AUTH PLAIN")
+		case "AUTH":
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			cur = smtpMail{From: strings.Trim(strings.TrimPrefix(arg, "FROM:"), "<>")}
+			reply("250 OK")
+		case "RCPT":
+			cur.To = append(cur.To, strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			if !reply("354 End data with <CR><LF>.<CR><LF>") {
+				return
+			}
+			data, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			cur.Data = string(data)
+			s.mu.Lock()
+			s.mails = append(s.mails, cur)
+			s.mu.Unlock()
+			reply("250 OK")
+		case "RSET", "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
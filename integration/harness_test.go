@@ -0,0 +1,462 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"mydayplanner/config"
+	"mydayplanner/connection"
+	"mydayplanner/controller/auth"
+	"mydayplanner/controller/health"
+	"mydayplanner/logging"
+	"mydayplanner/migrations"
+	"mydayplanner/model"
+	"mydayplanner/outbox"
+	"mydayplanner/push"
+	"mydayplanner/pushtoken"
+	"mydayplanner/store"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	firebase "firebase.google.com/go/v4"
+	"github.com/gin-gonic/gin"
+	"google.golang.org/api/option"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+const fcmProject = "mydayplanner-test"
+
+// password รหัสผ่านของทุกผู้ใช้ที่ signupVerified สร้าง
+const password = "P@ssw0rd-123"
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	// SQLite เก็บเวลาเป็นข้อความ การเปรียบเทียบ due_date กับเวลาปัจจุบันจะถูกต้องก็ต่อเมื่อทุกค่าอยู่ใน UTC
+	time.Local = time.UTC
+	gin.SetMode(gin.TestMode)
+	level := "error"
+	if testing.Verbose() {
+		level = "debug"
+	}
+	slog.SetDefault(logging.New(os.Stderr, level))
+
+	// สมัครสมาชิกตรวจ MX record ของโดเมนอีเมล ซึ่งต้องใช้ DNS
+	auth.LookupMX = func(string) ([]*net.MX, error) {
+		return []*net.MX{{Host: "mx.example.test", Pref: 10}}, nil
+	}
+
+	os.Exit(m.Run())
+}
+
+// harness API หนึ่งชุดที่มีฐานข้อมูล, store และ server ปลอมของตัวเอง test แต่ละตัวสร้างใหม่จึงไม่กระทบกัน
+type harness struct {
+	t       *testing.T
+	baseURL string
+	deps    *connection.Deps
+	fb      *store.Memory
+	fcm     *fakeFCM
+	smtp    *fakeSMTP
+}
+
+// newHarness เปิด server ใหม่ options ใช้ปรับ config ก่อนเปิด เช่นลดโควตาของ rate limiter
+func newHarness(t *testing.T, options ...func(*config.Config)) *harness {
+	t.Helper()
+
+	fcm := newFakeFCM(t, fcmProject)
+	smtpServer := newFakeSMTP(t)
+	smtpHost, smtpPort := smtpServer.Addr()
+
+	cfg := &config.Config{
+		Server: config.ServerConfig{ShutdownTimeout: 5 * time.Second},
+		JWT: config.JWTConfig{
+			AccessSecret:  "integration-access-secret",
+			RefreshSecret: "integration-refresh-secret",
+			AccessTTL:     time.Hour,
+			RefreshTTL:    24 * time.Hour,
+		},
+		SMTP: config.SMTPConfig{
+			Host:     smtpHost,
+			Port:     smtpPort,
+			Username: "noreply@mydayplanner.test",
+			Password: "secret",
+		},
+		Mail: config.MailConfig{
+			Sender: "smtp",
+			From:   "Myday-Planner <noreply@mydayplanner.test>",
+		},
+		TOTP: config.TOTPConfig{Secret: "JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"},
+		OTP: config.OTPConfig{
+			TTL:         15 * time.Minute,
+			MaxActive:   3,
+			BlockWindow: 10 * time.Minute,
+		},
+		Scheduler: config.SchedulerConfig{MaxLag: 5 * time.Minute},
+		RateLimit: config.RateLimitConfig{
+			Backend: "memory",
+			Auth:    config.Rate{Limit: 10, Per: time.Minute},
+			Search:  config.Rate{Limit: 30, Per: time.Minute},
+			Report:  config.Rate{Limit: 5, Per: 10 * time.Minute},
+			Jobs:    config.Rate{Limit: 6, Per: time.Minute},
+		},
+		Outbox: config.OutboxConfig{
+			PollInterval: 20 * time.Millisecond,
+			MaxAttempts:  10,
+			MaxBackoff:   time.Minute,
+		},
+		Push: config.PushConfig{
+			Provider:           "fcm",
+			PruneAfterFailures: 5,
+			PruneMinAge:        72 * time.Hour,
+		},
+	}
+	if mysqlDSN() != "" {
+		cfg.RateLimit.Backend = "mysql"
+	}
+	for _, option := range options {
+		option(cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	app, err := firebase.NewApp(ctx, &firebase.Config{ProjectID: fcmProject},
+		option.WithoutAuthentication(), option.WithEndpoint(fcm.URL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := app.Messaging(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := openDB(t)
+	fb := store.NewMemory()
+	deps := &connection.Deps{
+		Config:          cfg,
+		DB:              db,
+		FB:              fb,
+		Push:            pushtoken.New(push.NewFCM(msg), db, fb, cfg.Push),
+		Mail:            connection.NewMailSender(cfg.Mail, cfg.SMTP),
+		NotificationJob: health.NewJobTracker(),
+		RateLimiter:     connection.NewRateLimiter(cfg.RateLimit, db),
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- connection.Serve(ctx, deps, ln) }()
+	// relay ของ outbox รันใน scheduler ตอน production ที่นี่รันคู่กับ server แทน
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		outbox.NewRelay(db, fb, cfg.Outbox).Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-relayDone
+		if err := <-done; err != nil {
+			t.Errorf("server stopped with error: %v", err)
+		}
+	})
+
+	return &harness{
+		t:       t,
+		baseURL: "http://" + ln.Addr().String(),
+		deps:    deps,
+		fb:      fb,
+		fcm:     fcm,
+		smtp:    smtpServer,
+	}
+}
+
+// openDB สร้างฐานข้อมูล SQLite ในไดเรกทอรีชั่วคราวด้วย schema จาก migrations.All()
+// ใช้ไฟล์แทน :memory: เพราะ gorm เปิดหลาย connection และทุก connection ต้องเห็นข้อมูลเดียวกัน
+// ถ้าตั้ง INTEGRATION_MYSQL_DSN ใช้ MySQL จริงแทน (ดู openMySQL)
+func openDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	if dsn := mysqlDSN(); dsn != "" {
+		return openMySQL(t, dsn)
+	}
+	dsn := "file:" + filepath.Join(t.TempDir(), "mydayplanner.db") +
+		"?_foreign_keys=on&_journal_mode=WAL&_busy_timeout=5000"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations.All() {
+		for _, up := range m.Up {
+			for _, stmt := range sqliteDDL(up) {
+				if err := db.Exec(stmt).Error; err != nil {
+					t.Fatalf("migration %d %s: %v\n%s", m.Version, m.Name, err, stmt)
+				}
+			}
+		}
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+var (
+	autoIncrement = regexp.MustCompile("(`\\w+`) (?:BIG)?INT NOT NULL AUTO_INCREMENT")
+	primaryKey    = regexp.MustCompile(",\\s*PRIMARY KEY \\(`\\w+`\\)")
+	enumType      = regexp.MustCompile(`ENUM\([^)]*\)`)
+	datetimeType  = regexp.MustCompile(`DATETIME\(\d\)`)
+	uniqueKey     = regexp.MustCompile("UNIQUE KEY `\\w+` ")
+	tableOptions  = regexp.MustCompile(`\)\s*ENGINE=.*$`)
+	tableName     = regexp.MustCompile("^CREATE TABLE (`\\w+`)")
+	indexKey      = regexp.MustCompile(",\\s*KEY (`\\w+`) (\\([^)]*\\))")
+	alterTable    = regexp.MustCompile("^ALTER TABLE `\\w+` ")
+	addColumn     = regexp.MustCompile(",\\s*ADD COLUMN ")
+)
+
+// sqliteDDL แปลง CREATE TABLE ของ MySQL ที่ migrations ใช้ให้ SQLite รันได้
+// index ที่ประกาศใน CREATE TABLE ถูกแยกเป็น CREATE INDEX เพราะ SQLite ไม่รองรับ
+// ALTER TABLE ที่เพิ่มหลายคอลัมน์ถูกแยกเป็นหนึ่งคำสั่งต่อคอลัมน์ เพราะ SQLite เพิ่มได้ทีละคอลัมน์
+// MODIFY COLUMN ถูกข้าม ใน SQLite ENUM เป็น TEXT อยู่แล้วจึงไม่ต้องขยายค่าที่รับได้
+// ครอบคลุมเฉพาะรูปแบบที่มีใน migrations/versions.go ถ้า migration ใหม่ใช้รูปแบบอื่นให้เพิ่มที่นี่
+// ส่วนที่การแปลงนี้ไม่ได้ทดสอบแทน MySQL อยู่ใน doc.go
+func sqliteDDL(stmt string) []string {
+	if prefix := alterTable.FindString(stmt); prefix != "" {
+		if strings.HasPrefix(strings.TrimPrefix(stmt, prefix), "MODIFY COLUMN ") {
+			return nil
+		}
+		var stmts []string
+		for _, col := range addColumn.Split(strings.TrimPrefix(stmt, prefix), -1) {
+			if !strings.HasPrefix(col, "ADD COLUMN ") {
+				col = "ADD COLUMN " + col
+			}
+			stmts = append(stmts, datetimeType.ReplaceAllString(prefix+col, "DATETIME"))
+		}
+		return stmts
+	}
+
+	var indexes []string
+	if table := tableName.FindStringSubmatch(stmt); table != nil {
+		for _, m := range indexKey.FindAllStringSubmatch(stmt, -1) {
+			indexes = append(indexes, "CREATE INDEX "+m[1]+" ON "+table[1]+" "+m[2])
+		}
+		stmt = indexKey.ReplaceAllString(stmt, "")
+	}
+
+	if autoIncrement.MatchString(stmt) {
+		stmt = autoIncrement.ReplaceAllString(stmt, "$1 INTEGER PRIMARY KEY AUTOINCREMENT")
+		stmt = primaryKey.ReplaceAllString(stmt, "")
+	}
+	stmt = enumType.ReplaceAllString(stmt, "TEXT")
+	stmt = datetimeType.ReplaceAllString(stmt, "DATETIME")
+	stmt = uniqueKey.ReplaceAllString(stmt, "UNIQUE ")
+	stmt = tableOptions.ReplaceAllString(stmt, ")")
+	return append([]string{stmt}, indexes...)
+}
+
+// response ผลของ request หนึ่งครั้ง body ถูกอ่านไว้แล้ว
+type response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// JSON แปลง body เป็น map ใช้กับ response ที่เป็น JSON object
+func (r *response) JSON(t *testing.T) map[string]any {
+	t.Helper()
+	var out map[string]any
+	if err := json.Unmarshal(r.Body, &out); err != nil {
+		t.Fatalf("response is not a JSON object: %v\n%s", err, r.Body)
+	}
+	return out
+}
+
+// do ส่ง request ไปยัง server โดย body ถูกแปลงเป็น JSON และ token ว่างหมายถึงไม่ส่ง Authorization
+func (h *harness) do(method, path, token string, body any) *response {
+	h.t.Helper()
+	return h.doWithHeader(method, path, token, body, nil)
+}
+
+// doWithHeader เหมือน do แต่ส่ง header เพิ่มด้วย
+func (h *harness) doWithHeader(method, path, token string, body any, header http.Header) *response {
+	h.t.Helper()
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			h.t.Fatal(err)
+		}
+		reader = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, h.baseURL+path, reader)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return &response{Status: res.StatusCode, Header: res.Header, Body: b}
+}
+
+// expect เรียก do แล้วหยุด test ถ้า status ไม่ตรง
+func (h *harness) expect(status int, method, path, token string, body any) map[string]any {
+	h.t.Helper()
+	res := h.do(method, path, token, body)
+	if res.Status != status {
+		h.t.Fatalf("%s %s: status %d, want %d\n%s", method, path, res.Status, status, res.Body)
+	}
+	return res.JSON(h.t)
+}
+
+// expectError ตรวจว่า request ล้มด้วย status และ code ที่คาดไว้
+func (h *harness) expectError(status int, code, method, path, token string, body any) {
+	h.t.Helper()
+	got := h.expect(status, method, path, token, body)
+	if got["code"] != code {
+		h.t.Fatalf("%s %s: code %v, want %s\n%v", method, path, got["code"], code, got)
+	}
+}
+
+var otpInMail = regexp.MustCompile(`OTP : (\d{6})`)
+
+// lastOTP อ่านรหัส OTP จากอีเมลฉบับล่าสุดที่ส่งถึง email
+func (h *harness) lastOTP(email string) string {
+	h.t.Helper()
+	mails := h.smtp.Mails()
+	for i := len(mails) - 1; i >= 0; i-- {
+		if len(mails[i].To) == 0 || mails[i].To[0] != email {
+			continue
+		}
+		text, err := mails[i].Text()
+		if err != nil {
+			h.t.Fatalf("mail to %s has no readable text part: %v\n%s", email, err, mails[i].Data)
+		}
+		if m := otpInMail.FindStringSubmatch(text); m != nil {
+			return m[1]
+		}
+		h.t.Fatalf("mail to %s has no OTP:\n%s", email, text)
+	}
+	h.t.Fatalf("no mail sent to %s", email)
+	return ""
+}
+
+// user บัญชีที่สมัครและยืนยันอีเมลแล้ว
+type user struct {
+	ID           int
+	Email        string
+	AccessToken  string
+	RefreshToken string
+}
+
+// signupVerified สมัครสมาชิก ยืนยันอีเมลด้วย OTP ที่ได้จากอีเมล แล้ว sign in
+func (h *harness) signupVerified(name, email string) user {
+	h.t.Helper()
+
+	created := h.expect(http.StatusOK, http.MethodPost, "/v1/auth/signup", "", map[string]string{
+		"name": name, "email": email, "password": password,
+	})
+	id := int(created["user"].(map[string]any)["userId"].(float64))
+
+	ref := h.expect(http.StatusOK, http.MethodPost, "/v1/auth/otp/identity", "", map[string]string{"email": email})["ref"].(string)
+	h.expect(http.StatusOK, http.MethodPost, "/v1/auth/otp/email", "", map[string]string{
+		"email": email, "reference": ref, "record": "1",
+	})
+	h.expect(http.StatusOK, http.MethodPut, "/v1/auth/otp/verify", "", map[string]string{
+		"email": email, "ref": ref, "otp": h.lastOTP(email), "record": "1",
+	})
+
+	signin := h.expect(http.StatusOK, http.MethodPost, "/v1/auth/signin", "", map[string]string{
+		"email": email, "password": password,
+	})
+	tokens := signin["token"].(map[string]any)
+	return user{
+		ID:           id,
+		Email:        email,
+		AccessToken:  tokens["accessToken"].(string),
+		RefreshToken: tokens["refreshToken"].(string),
+	}
+}
+
+// admin สมัครผู้ใช้ใหม่ ตั้ง role เป็น admin แล้ว sign in ใหม่ให้ token มี role นั้น
+func (h *harness) admin(name, email string) user {
+	h.t.Helper()
+	u := h.signupVerified(name, email)
+	if err := h.deps.DB.Model(&model.User{}).Where("user_id = ?", u.ID).Update("role", "admin").Error; err != nil {
+		h.t.Fatal(err)
+	}
+	signin := h.expect(http.StatusOK, http.MethodPost, "/v1/auth/signin", "", map[string]string{
+		"email": email, "password": password,
+	})
+	u.AccessToken = signin["token"].(map[string]any)["accessToken"].(string)
+	return u
+}
+
+// groupBoard สร้างบอร์ดกลุ่มของ owner แล้วให้ member ตอบรับคำเชิญ
+func (h *harness) groupBoard(owner, member user) int {
+	h.t.Helper()
+	created := h.expect(http.StatusCreated, http.MethodPost, "/v1/boards", owner.AccessToken, map[string]string{
+		"board_name": "Team", "is_group": "1",
+	})
+	boardID := int(created["boardID"].(float64))
+	invite := h.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/v1/boards/%d/invites", boardID), owner.AccessToken, map[string]string{
+		"user_id": strconv.Itoa(member.ID),
+	})
+	h.expect(http.StatusOK, http.MethodPost, fmt.Sprintf("/v1/invites/%d", int(invite["invite_id"].(float64))), member.AccessToken, map[string]bool{"accept": true})
+	return boardID
+}
+
+// registerDevice จำลองแอปมือถือที่เขียน FCM token ของเครื่องลง usersLogin หลัง sign in
+func (h *harness) registerDevice(u user, token string) {
+	h.t.Helper()
+	if err := h.fb.Logins().Merge(context.Background(), u.Email, store.Fields{"FMCToken": token}); err != nil {
+		h.t.Fatal(err)
+	}
+}
+
+// waitFor รอจน cond เป็นจริง ใช้กับงานที่ handler ทำต่อใน goroutine หลังตอบ client แล้ว
+func (h *harness) waitFor(what string, cond func() bool) {
+	h.t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// ids แปลงเลข id เป็น string ตามที่ DTO แบบ bulk รับ
+func ids(values ...int) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strconv.Itoa(v)
+	}
+	return out
+}
@@ -0,0 +1,101 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mydayplanner/controller/notification"
+	"mydayplanner/push"
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+func (h *harness) inbox(u user, query string) []map[string]any {
+	h.t.Helper()
+	var items []map[string]any
+	for _, item := range h.expect(http.StatusOK, http.MethodGet, "/v1/inbox"+query, u.AccessToken, nil)["items"].([]any) {
+		items = append(items, item.(map[string]any))
+	}
+	return items
+}
+
+func (h *harness) unread(u user) int {
+	h.t.Helper()
+	return int(h.expect(http.StatusOK, http.MethodGet, "/v1/inbox/unread-count", u.AccessToken, nil)["unread"].(float64))
+}
+
+func TestInboxCollectsRemindersAndAssignments(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	h.registerDevice(owner, "owner-device")
+	boardID := h.groupBoard(owner, member)
+	notificationID := h.dueReminder(owner, boardID)
+
+	// รอบแรกส่งไม่สำเร็จ รอบถัดไปลองใหม่ แต่ inbox มีรายการเดียว
+	recorder := push.NewRecorder()
+	recorder.Fail("owner-device", errors.New("unavailable"))
+	for range 2 {
+		if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, recorder); err != nil {
+			t.Fatal(err)
+		}
+		recorder.Fail("owner-device", nil)
+	}
+	if n := len(recorder.Sent()); n != 1 {
+		t.Fatalf("sent %d pushes, want 1", n)
+	}
+
+	// สมาชิกไม่มีเครื่องรับ push แต่ยังได้รายการใน inbox
+	for _, u := range []user{owner, member} {
+		items := h.inbox(u, "")
+		if len(items) != 1 || items[0]["type"] != "before" || items[0]["read_at"] != nil ||
+			int(items[0]["notification_id"].(float64)) != notificationID || int(items[0]["board_id"].(float64)) != boardID {
+			t.Fatalf("%s inbox = %v, want one unread before reminder", u.Email, items)
+		}
+		if n := h.unread(u); n != 1 {
+			t.Fatalf("%s unread = %d, want 1", u.Email, n)
+		}
+	}
+
+	created := h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/v1/boards/%d/tasks", boardID), owner.AccessToken, map[string]any{
+		"task_name": "Review", "status": "0",
+	})
+	taskID := int(created["taskID"].(float64))
+	h.expectError(http.StatusNotFound, "FCM_TOKEN_NOT_FOUND", http.MethodPost, "/v1/push/assignments", owner.AccessToken, map[string]string{
+		"recieveID": strconv.Itoa(member.ID), "task_id": strconv.Itoa(taskID),
+	})
+	items := h.inbox(member, "")
+	if len(items) != 2 || items[0]["type"] != "assigned" || int(items[0]["task_id"].(float64)) != taskID {
+		t.Fatalf("member inbox = %v, want the assignment first", items)
+	}
+	assignedID := int64(items[0]["item_id"].(float64))
+	reminderID := int64(items[1]["item_id"].(float64))
+
+	// อ่าน ทีละรายการและทั้งหมด
+	h.expect(http.StatusOK, http.MethodPut, fmt.Sprintf("/v1/inbox/%d/read", assignedID), member.AccessToken, nil)
+	if unread := h.inbox(member, "?unread=true"); len(unread) != 1 || int64(unread[0]["item_id"].(float64)) != reminderID {
+		t.Fatalf("unread items = %v, want only the reminder", unread)
+	}
+	h.expectError(http.StatusNotFound, "INBOX_ITEM_NOT_FOUND", http.MethodPut, fmt.Sprintf("/v1/inbox/%d/read", assignedID), owner.AccessToken, nil)
+	if got := h.expect(http.StatusOK, http.MethodPut, "/v1/inbox/read", member.AccessToken, nil)["updated"]; got != float64(1) {
+		t.Fatalf("mark all read updated %v, want 1", got)
+	}
+	if n := h.unread(member); n != 0 {
+		t.Fatalf("unread = %d after mark all read", n)
+	}
+	if n := h.unread(owner); n != 1 {
+		t.Fatalf("owner unread = %d, want 1 (mark all read is per user)", n)
+	}
+
+	// dismiss ซ่อนรายการ และแบ่งหน้าตาม item_id
+	h.expect(http.StatusOK, http.MethodDelete, fmt.Sprintf("/v1/inbox/%d", assignedID), member.AccessToken, nil)
+	h.expectError(http.StatusNotFound, "INBOX_ITEM_NOT_FOUND", http.MethodDelete, fmt.Sprintf("/v1/inbox/%d", assignedID), member.AccessToken, nil)
+	page := h.expect(http.StatusOK, http.MethodGet, "/v1/inbox?limit=1", member.AccessToken, nil)
+	if items := page["items"].([]any); len(items) != 1 || int64(items[0].(map[string]any)["item_id"].(float64)) != reminderID || page["next_before"] == nil {
+		t.Fatalf("page = %v, want only the reminder", page)
+	}
+	if rest := h.inbox(member, fmt.Sprintf("?before=%d", reminderID)); len(rest) != 0 {
+		t.Fatalf("next page = %v, want empty", rest)
+	}
+}
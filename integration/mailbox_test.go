@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"mydayplanner/config"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDevMailboxHoldsOTPMail(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.Env, cfg.Mail.Sender, cfg.Mail.DevMailbox = "development", "memory", true
+	})
+	const email = "dev@example.test"
+
+	h.expect(http.StatusOK, http.MethodPost, "/v1/auth/signup", "", map[string]string{
+		"name": "Dev", "email": email, "password": password,
+	})
+	ref := h.expect(http.StatusOK, http.MethodPost, "/v1/auth/otp/identity", "", map[string]string{"email": email})["ref"].(string)
+	h.expect(http.StatusOK, http.MethodPost, "/v1/auth/otp/email", "", map[string]string{
+		"email": email, "reference": ref, "record": "1",
+	})
+	if got := h.smtp.Mails(); len(got) != 0 {
+		t.Fatalf("memory sender still used SMTP: %+v", got)
+	}
+
+	box := h.expect(http.StatusOK, http.MethodGet, "/v1/dev/mailbox?to="+email, "", nil)
+	messages := box["messages"].([]any)
+	if len(messages) != 1 {
+		t.Fatalf("mailbox = %v, want one message", box)
+	}
+	m := messages[0].(map[string]any)
+	otp := regexp.MustCompile(`OTP : (\d{6})`).FindStringSubmatch(m["text"].(string))
+	if otp == nil || !strings.Contains(m["html"].(string), otp[1]) || !strings.Contains(m["from"].(string), "noreply@mydayplanner.test") {
+		t.Fatalf("message = %v, want OTP in text and html", m)
+	}
+	h.expect(http.StatusOK, http.MethodPut, "/v1/auth/otp/verify", "", map[string]string{
+		"email": email, "ref": ref, "otp": otp[1], "record": "1",
+	})
+
+	if resp := h.do(http.MethodDelete, "/v1/dev/mailbox", "", nil); resp.Status != http.StatusNoContent {
+		t.Fatalf("clear mailbox = %d", resp.Status)
+	}
+	if box := h.expect(http.StatusOK, http.MethodGet, "/v1/dev/mailbox", "", nil); len(box["messages"].([]any)) != 0 {
+		t.Fatalf("mailbox after clear = %v", box)
+	}
+}
+
+func TestDevMailboxIsNotRoutedWithSMTP(t *testing.T) {
+	h := newHarness(t)
+	if resp := h.do(http.MethodGet, "/v1/dev/mailbox", "", nil); resp.Status != http.StatusNotFound {
+		t.Fatalf("GET /v1/dev/mailbox = %d, want 404 when MAIL_SENDER is smtp", resp.Status)
+	}
+}
+
+func TestDevMailboxNeedsExplicitFlag(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) { cfg.Env, cfg.Mail.Sender = "development", "memory" })
+	if resp := h.do(http.MethodGet, "/v1/dev/mailbox", "", nil); resp.Status != http.StatusNotFound {
+		t.Fatalf("GET /v1/dev/mailbox = %d, want 404 without DEV_MAILBOX", resp.Status)
+	}
+}
@@ -0,0 +1,172 @@
+package integration
+
+import (
+	"context"
+	"mydayplanner/config"
+	"mydayplanner/leader"
+	"mydayplanner/logging"
+	"mydayplanner/migrations"
+	"mydayplanner/ratelimit"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// mysqlDSN server MySQL ที่ใช้แทน SQLite ค่าว่างคือรันบน SQLite ตามปกติ
+func mysqlDSN() string {
+	return os.Getenv("INTEGRATION_MYSQL_DSN")
+}
+
+// requireMySQL skip test ที่ทดสอบ SQL เฉพาะของ MySQL เมื่อไม่ได้ตั้ง INTEGRATION_MYSQL_DSN
+func requireMySQL(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := mysqlDSN()
+	if dsn == "" {
+		t.Skip("INTEGRATION_MYSQL_DSN is not set")
+	}
+	return openMySQL(t, dsn)
+}
+
+// openMySQL สร้างฐานข้อมูลชื่อไม่ซ้ำบน server ของ dsn แล้วรัน migrations ผ่าน Migrator เหมือน production
+// ฐานข้อมูลถูกลบเมื่อ test จบ user ใน dsn จึงต้องมีสิทธิ์ CREATE และ DROP DATABASE
+func openMySQL(t *testing.T, dsn string) *gorm.DB {
+	t.Helper()
+	cfg, err := mysqldriver.ParseDSN(dsn)
+	if err != nil {
+		t.Fatalf("INTEGRATION_MYSQL_DSN: %v", err)
+	}
+	cfg.ParseTime = true
+	cfg.Loc = time.UTC
+	cfg.DBName = ""
+	server, err := gorm.Open(mysql.Open(cfg.FormatDSN()), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := "mydayplanner_it_" + strings.ReplaceAll(logging.NewID(), "-", "")[:16]
+	if err := server.Exec("CREATE DATABASE `" + name + "` CHARACTER SET utf8mb4").Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := server.Exec("DROP DATABASE `" + name + "`").Error; err != nil {
+			t.Errorf("drop %s: %v", name, err)
+		}
+		if sqlDB, err := server.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	cfg.DBName = name
+	db, err := gorm.Open(mysql.Open(cfg.FormatDSN()), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// ปิดก่อน DROP DATABASE เพราะ cleanup รันย้อนลำดับ
+	t.Cleanup(func() { sqlDB.Close() })
+	if _, err := migrations.New(db).Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestMySQLMigrationsRoundTripUnderLock(t *testing.T) {
+	db := requireMySQL(t)
+	ctx := context.Background()
+	all := migrations.All()
+
+	// ทุก Down ต้องรันได้บน MySQL จริง
+	if down, err := migrations.New(db).Down(ctx, len(all)); err != nil || len(down) != len(all) {
+		t.Fatalf("down = %d migrations, %v; want all %d", len(down), err, len(all))
+	}
+
+	// สอง process migrate พร้อมกัน GET_LOCK ให้ตัวหนึ่งรันทั้งหมดและอีกตัวไม่เหลืออะไรให้รัน
+	var wg sync.WaitGroup
+	var ran atomic.Int64
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := migrations.New(db).Up(ctx)
+			if err != nil {
+				t.Error(err)
+			}
+			ran.Add(int64(len(done)))
+		}()
+	}
+	wg.Wait()
+	if got := ran.Load(); got != int64(len(all)) {
+		t.Fatalf("ran %d migrations across both processes, want each of the %d once", got, len(all))
+	}
+}
+
+func TestMySQLRateLimitBucketIsShared(t *testing.T) {
+	db := requireMySQL(t)
+	// สอง backend เหมือนสอง instance ที่นับ bucket เดียวกัน
+	backends := []ratelimit.Backend{ratelimit.NewMySQL(db), ratelimit.NewMySQL(db)}
+	policy := ratelimit.Policy{Limit: 5, Per: time.Hour}
+	now := time.Now()
+
+	var wg sync.WaitGroup
+	var allowed atomic.Int64
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res, err := backends[i%2].Take(context.Background(), "auth:198.51.100.7", policy, now)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if res.Allowed {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := allowed.Load(); got != int64(policy.Limit) {
+		t.Fatalf("allowed %d concurrent requests, want exactly %d", got, policy.Limit)
+	}
+}
+
+func TestMySQLLeaderLeaseHasOneHolder(t *testing.T) {
+	db := requireMySQL(t)
+	cfg := config.LeaderConfig{LeaseTTL: 15 * time.Second, RenewInterval: 5 * time.Second}
+	electors := make([]*leader.Elector, 4)
+	for i := range electors {
+		electors[i] = leader.New(db, leader.SchedulerLease, cfg)
+	}
+
+	var wg sync.WaitGroup
+	var leaders atomic.Int64
+	for _, e := range electors {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if e.Tick(context.Background()) {
+				leaders.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := leaders.Load(); got != 1 {
+		t.Fatalf("%d instances took the lease, want 1", got)
+	}
+
+	// leader ต่อ lease ได้ RowsAffected ต้องเป็น 1 แม้ holder เป็นค่าเดิม
+	for _, e := range electors {
+		if e.IsLeader() && !e.Tick(context.Background()) {
+			t.Fatal("leader failed to renew its own lease")
+		}
+	}
+}
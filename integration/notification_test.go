@@ -0,0 +1,188 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mydayplanner/controller/notification"
+	"mydayplanner/model"
+	"mydayplanner/push"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// dueReminder สร้างงานใน board กลุ่มที่แจ้งเตือนล่วงหน้าถึงเวลาแล้ว คืน notification_id
+func (h *harness) dueReminder(owner user, boardID int) int {
+	h.t.Helper()
+	now := time.Now().UTC()
+	created := h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/v1/boards/%d/tasks", boardID), owner.AccessToken, map[string]any{
+		"task_name": "Ship v1",
+		"status":    "0",
+		"reminder": map[string]string{
+			"due_date":        now.Add(time.Hour).Format(time.RFC3339),
+			"before_due_date": now.Add(-time.Minute).Format(time.RFC3339),
+		},
+	})
+	return int(created["notificationID"].(float64))
+}
+
+func (h *harness) attempts(notificationID int) []model.NotificationAttempt {
+	h.t.Helper()
+	var out []model.NotificationAttempt
+	if err := h.deps.DB.Where("notification_id = ?", notificationID).Order("attempt_id").Find(&out).Error; err != nil {
+		h.t.Fatal(err)
+	}
+	return out
+}
+
+func (h *harness) isSend(notificationID int) string {
+	h.t.Helper()
+	var n model.Notification
+	if err := h.deps.DB.First(&n, notificationID).Error; err != nil {
+		h.t.Fatal(err)
+	}
+	return n.IsSend
+}
+
+func TestConcurrentRunsSendEachTransitionOnce(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	h.registerDevice(owner, "owner-device")
+	h.registerDevice(member, "member-device")
+	notificationID := h.dueReminder(owner, h.groupBoard(owner, member))
+
+	// หลายรอบที่รันพร้อมกัน (เช่นสอง instance ตอนสลับ leader) ต้องส่งได้แค่ครั้งเดียว
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := len(h.fcm.Messages()); n != 2 {
+		t.Fatalf("sent %d pushes, want one per board member", n)
+	}
+	if got := h.isSend(notificationID); got != model.NotifyBeforeSent {
+		t.Fatalf("is_send = %q, want %q", got, model.NotifyBeforeSent)
+	}
+	attempts := h.attempts(notificationID)
+	if len(attempts) != 1 || attempts[0].Status != model.AttemptSent || attempts[0].Transition != "before" ||
+		attempts[0].Attempt != 1 || attempts[0].Tokens != 2 || attempts[0].FinishedAt == nil {
+		t.Fatalf("attempts = %+v, want one sent before attempt", attempts)
+	}
+}
+
+func TestInterruptedAttemptIsNotResent(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	h.registerDevice(owner, "owner-device")
+	notificationID := h.dueReminder(owner, h.groupBoard(owner, member))
+
+	// worker ก่อนหน้า claim แล้วเริ่มส่งแต่ process ตายก่อนบันทึกผล
+	var n model.Notification
+	if err := h.deps.DB.First(&n, notificationID).Error; err != nil {
+		t.Fatal(err)
+	}
+	expired := time.Now().UTC().Add(-time.Second)
+	crashed := "crashed-worker"
+	if err := h.deps.DB.Model(&n).Updates(map[string]any{"claimed_by": crashed, "claim_expires_at": expired}).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := h.deps.DB.Create(&model.NotificationAttempt{
+		NotificationID: notificationID, Transition: "before", FromState: model.NotifyPending, ToState: model.NotifyBeforeSent,
+		ScheduledFor: *n.BeforeDueDate, Attempt: 1, ClaimedBy: crashed, Status: model.AttemptSending, StartedAt: expired,
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(h.fcm.Messages()); n != 0 {
+		t.Fatalf("resent %d pushes after an interrupted attempt, want 0", n)
+	}
+	if got := h.isSend(notificationID); got != model.NotifyBeforeSent {
+		t.Fatalf("is_send = %q, want %q", got, model.NotifyBeforeSent)
+	}
+	if attempts := h.attempts(notificationID); len(attempts) != 1 || attempts[0].Status != model.AttemptInterrupted {
+		t.Fatalf("attempts = %+v, want the stale attempt marked interrupted", attempts)
+	}
+}
+
+func TestFailedAttemptIsRetriedNextRun(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	h.registerDevice(owner, "owner-device")
+	notificationID := h.dueReminder(owner, h.groupBoard(owner, member))
+
+	recorder := push.NewRecorder()
+	recorder.Fail("owner-device", errors.New("unavailable"))
+	run := func() *notification.NotificationResult {
+		t.Helper()
+		result, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, recorder)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	if result := run(); result.ErrorCount != 1 {
+		t.Fatalf("result = %+v, want one error", result)
+	}
+	if got := h.isSend(notificationID); got != model.NotifyPending {
+		t.Fatalf("is_send = %q after a failed send, want %q", got, model.NotifyPending)
+	}
+
+	recorder.Fail("owner-device", nil)
+	if result := run(); result.SuccessCount != 1 {
+		t.Fatalf("result = %+v, want one success", result)
+	}
+	if got := h.isSend(notificationID); got != model.NotifyBeforeSent {
+		t.Fatalf("is_send = %q, want %q", got, model.NotifyBeforeSent)
+	}
+	attempts := h.attempts(notificationID)
+	if len(attempts) != 2 || attempts[0].Status != model.AttemptFailed || attempts[0].Error == nil ||
+		attempts[1].Status != model.AttemptSent || attempts[1].Attempt != 2 {
+		t.Fatalf("attempts = %+v, want failed then sent", attempts)
+	}
+	if sent := recorder.Sent(); len(sent) != 1 {
+		t.Fatalf("recorder sent %d pushes, want 1", len(sent))
+	}
+}
+
+func TestUnregisteredTokenIsRemovedFromLogin(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	h.registerDevice(owner, "owner-device")
+	h.registerDevice(member, "member-device")
+	notificationID := h.dueReminder(owner, h.groupBoard(owner, member))
+	h.fcm.Unregister("member-device")
+
+	if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+	if messages := h.fcm.Messages(); len(messages) != 1 || messages[0].Token != "owner-device" {
+		t.Fatalf("messages = %+v, want only the owner's device", messages)
+	}
+	// ส่งถึงบางเครื่องถือว่าส่งสำเร็จ
+	if got := h.isSend(notificationID); got != model.NotifyBeforeSent {
+		t.Fatalf("is_send = %q, want %q", got, model.NotifyBeforeSent)
+	}
+	if login, _ := h.fb.Doc("usersLogin/" + member.Email); login["FMCToken"] != nil {
+		t.Fatalf("member login = %v, want the unregistered token removed", login)
+	}
+	if login, _ := h.fb.Doc("usersLogin/" + owner.Email); login["FMCToken"] != "owner-device" {
+		t.Fatalf("owner login = %v, want the token kept", login)
+	}
+}
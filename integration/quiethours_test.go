@@ -0,0 +1,189 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/controller/notification"
+	"mydayplanner/model"
+	"net/http"
+	"slices"
+	"testing"
+	"time"
+)
+
+// setTimezone ตั้งเขตเวลาของผู้ใช้ผ่าน profile
+func (h *harness) setTimezone(u user, name string) {
+	h.t.Helper()
+	h.expect(http.StatusOK, http.MethodPut, "/v1/users/me/profile", u.AccessToken, map[string]any{"timezone": name})
+}
+
+// quietNow ตั้ง quiet hours ของผู้ใช้ให้ครอบเวลาปัจจุบันไปอีกสองชั่วโมงทุกวัน ตามเวลา UTC
+func (h *harness) quietNow(u user) {
+	h.t.Helper()
+	h.setTimezone(u, "UTC")
+	now := time.Now().UTC()
+	h.expect(http.StatusOK, http.MethodPut, "/v1/users/me/quiet-hours", u.AccessToken, map[string]any{
+		"windows": []map[string]any{{
+			"days":  []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"},
+			"start": now.Add(-2 * time.Hour).Format("15:04"),
+			"end":   now.Add(2 * time.Hour).Format("15:04"),
+		}},
+	})
+}
+
+func (h *harness) deferredPushes() []model.DeferredPush {
+	h.t.Helper()
+	var out []model.DeferredPush
+	if err := h.deps.DB.Order("push_id").Find(&out).Error; err != nil {
+		h.t.Fatal(err)
+	}
+	return out
+}
+
+func (h *harness) processDeferred() *notification.NotificationResult {
+	h.t.Helper()
+	result, err := notification.ProcessDeferredPushes(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return result
+}
+
+func TestQuietHoursSettings(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+
+	got := h.expect(http.StatusOK, http.MethodGet, "/v1/users/me/quiet-hours", owner.AccessToken, nil)
+	if got["time_zone"] != "Asia/Bangkok" || len(got["windows"].([]any)) != 0 {
+		t.Fatalf("quiet hours = %v, want none in the default zone", got)
+	}
+
+	h.setTimezone(owner, "Europe/Berlin")
+	body := map[string]any{
+		"windows": []map[string]any{
+			{"days": []string{"mon", "tue", "wed", "thu", "fri"}, "start": "22:00", "end": "07:00"},
+			{"days": []string{"sat", "sun"}, "start": "00:00", "end": "00:00"},
+		},
+	}
+	h.expect(http.StatusOK, http.MethodPut, "/v1/users/me/quiet-hours", owner.AccessToken, body)
+	got = h.expect(http.StatusOK, http.MethodGet, "/v1/users/me/quiet-hours", owner.AccessToken, nil)
+	windows := got["windows"].([]any)
+	if got["time_zone"] != "Europe/Berlin" || len(windows) != 2 {
+		t.Fatalf("quiet hours = %v, want the saved windows", got)
+	}
+	if weekend := windows[1].(map[string]any); fmt.Sprint(weekend["days"]) != "[sun sat]" || weekend["start"] != "00:00" {
+		t.Fatalf("weekend window = %v", weekend)
+	}
+
+	body["windows"] = []map[string]any{{"days": []string{"mon"}, "start": "25:00", "end": "07:00"}}
+	h.expectError(http.StatusBadRequest, "INVALID_INPUT", http.MethodPut, "/v1/users/me/quiet-hours", owner.AccessToken, body)
+}
+
+func TestQuietHoursDeferPushUntilWindowEnds(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	h.registerDevice(owner, "owner-device")
+	h.registerDevice(member, "member-device")
+	h.quietNow(member)
+	notificationID := h.dueReminder(owner, h.groupBoard(owner, member))
+
+	if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+	if got := h.pushedTo(); !slices.Equal(got, []string{"owner-device"}) {
+		t.Fatalf("pushed to %v, want only the owner during the member's quiet hours", got)
+	}
+	if got := h.isSend(notificationID); got != model.NotifyBeforeSent {
+		t.Fatalf("is_send = %q, want %q", got, model.NotifyBeforeSent)
+	}
+	// inbox ได้รายการทันที push เท่านั้นที่รอ
+	if items := h.inbox(member, ""); len(items) != 1 || items[0]["type"] != "before" {
+		t.Fatalf("member inbox = %v, want the reminder", items)
+	}
+	deferred := h.deferredPushes()
+	if len(deferred) != 1 || deferred[0].UserID != member.ID || deferred[0].Type != "before" ||
+		deferred[0].DeliverAt.Before(time.Now().Add(time.Hour)) {
+		t.Fatalf("deferred = %+v, want the member's push held until the window ends", deferred)
+	}
+
+	// ยังไม่ถึงเวลา
+	if result := h.processDeferred(); result.TotalCount != 0 {
+		t.Fatalf("result = %+v, want nothing due yet", result)
+	}
+
+	// ถึงเวลาแต่ผู้ใช้ยังอยู่ใน quiet hours จึงถูกเลื่อนต่อ
+	past := time.Now().UTC().Add(-time.Minute)
+	if err := h.deps.DB.Model(&model.DeferredPush{}).Where("push_id = ?", deferred[0].PushID).Update("deliver_at", past).Error; err != nil {
+		t.Fatal(err)
+	}
+	if result := h.processDeferred(); result.DeferredCount != 1 || len(h.fcm.Messages()) != 1 {
+		t.Fatalf("result = %+v, want the push rescheduled", result)
+	}
+
+	// quiet hours จบ (ผู้ใช้ปิด) push ที่ค้างถูกส่งครั้งเดียว
+	h.expect(http.StatusOK, http.MethodPut, "/v1/users/me/quiet-hours", member.AccessToken, map[string]any{"windows": []any{}})
+	if err := h.deps.DB.Model(&model.DeferredPush{}).Where("push_id = ?", deferred[0].PushID).Update("deliver_at", past).Error; err != nil {
+		t.Fatal(err)
+	}
+	if result := h.processDeferred(); result.SuccessCount != 1 {
+		t.Fatalf("result = %+v, want one deferred push sent", result)
+	}
+	if result := h.processDeferred(); result.TotalCount != 0 {
+		t.Fatalf("result = %+v, want the deferred push gone", result)
+	}
+	if got := h.pushedTo(); !slices.Equal(got, []string{"member-device", "owner-device"}) {
+		t.Fatalf("pushed to %v, want the member's device once", got)
+	}
+	var logged []model.PushDelivery
+	if err := h.deps.DB.Where("user_id = ?", member.ID).Find(&logged).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 || logged[0].AttemptID == nil || logged[0].Type != "before" {
+		t.Fatalf("member deliveries = %+v, want the deferred push logged with its attempt", logged)
+	}
+}
+
+func TestCriticalDueReminderIgnoresQuietHours(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	h.registerDevice(owner, "owner-device")
+	h.quietNow(owner)
+	boardID := h.groupBoard(owner, h.signupVerified("Member", "member@example.test"))
+
+	due := func(critical bool) int {
+		created := h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/v1/boards/%d/tasks", boardID), owner.AccessToken, map[string]any{
+			"task_name": "Pay rent",
+			"status":    "0",
+			"reminder":  map[string]any{"due_date": time.Now().UTC().Add(time.Hour).Format(time.RFC3339), "critical": critical},
+		})
+		id := int(created["notificationID"].(float64))
+		if err := h.deps.DB.Model(&model.Notification{}).Where("notification_id = ?", id).
+			Update("due_date", time.Now().UTC().Add(-time.Minute)).Error; err != nil {
+			t.Fatal(err)
+		}
+		return id
+	}
+	critical := due(true)
+	normal := due(false)
+
+	result, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.SuccessCount != 1 || result.DeferredCount != 1 {
+		t.Fatalf("result = %+v, want one sent and one deferred", result)
+	}
+	if n := len(h.fcm.Messages()); n != 1 {
+		t.Fatalf("sent %d pushes, want only the critical reminder", n)
+	}
+	if attempts := h.attempts(critical); len(attempts) != 1 || attempts[0].Status != model.AttemptSent {
+		t.Fatalf("critical attempts = %+v, want sent", attempts)
+	}
+	if attempts := h.attempts(normal); len(attempts) != 1 || attempts[0].Status != model.AttemptDeferred || attempts[0].Tokens != 0 {
+		t.Fatalf("normal attempts = %+v, want deferred", attempts)
+	}
+	if got := h.isSend(normal); got != model.NotifyDueSent {
+		t.Fatalf("is_send = %q, want %q once deferred", got, model.NotifyDueSent)
+	}
+}
@@ -0,0 +1,91 @@
+package integration
+
+import (
+	"fmt"
+	"mydayplanner/config"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAuthRoutesAreRateLimitedPerIP(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.RateLimit.Auth = config.Rate{Limit: 3, Per: time.Minute}
+	})
+	signin := map[string]string{"email": "nobody@example.test", "password": "guess"}
+
+	for i := 0; i < 3; i++ {
+		h.expectError(http.StatusNotFound, "USER_NOT_FOUND", http.MethodPost, "/v1/auth/signin", "", signin)
+	}
+	res := h.do(http.MethodPost, "/v1/auth/signin", "", signin)
+	if res.Status != http.StatusTooManyRequests || res.JSON(t)["code"] != "RATE_LIMITED" {
+		t.Fatalf("4th signin: status %d %s, want 429 RATE_LIMITED", res.Status, res.Body)
+	}
+	if retry, err := strconv.Atoi(res.Header.Get("Retry-After")); err != nil || retry < 1 || retry > 20 {
+		t.Fatalf("Retry-After = %q, want seconds until one request is refilled (20s at 3/1m)", res.Header.Get("Retry-After"))
+	}
+
+	// route เดิมและ route อื่นใน class เดียวกันใช้ bucket เดียวกัน
+	h.expectError(http.StatusTooManyRequests, "RATE_LIMITED", http.MethodPost, "/auth/signin", "", signin)
+	h.expectError(http.StatusTooManyRequests, "RATE_LIMITED", http.MethodPost, "/v1/auth/signup", "", map[string]string{
+		"name": "Eve", "email": "eve@example.test", "password": "secret",
+	})
+}
+
+func TestForwardedForIsIgnoredWithoutTrustedProxy(t *testing.T) {
+	signin := map[string]string{"email": "nobody@example.test", "password": "guess"}
+	// ส่ง X-Forwarded-For ใหม่ทุกครั้งเพื่อให้ได้ bucket ใหม่
+	statuses := func(h *harness) []int {
+		var out []int
+		for i := 0; i < 4; i++ {
+			header := http.Header{"X-Forwarded-For": {fmt.Sprintf("203.0.113.%d", i+1)}}
+			out = append(out, h.doWithHeader(http.MethodPost, "/v1/auth/signin", "", signin, header).Status)
+		}
+		return out
+	}
+	limit := func(cfg *config.Config) {
+		cfg.RateLimit.Auth = config.Rate{Limit: 3, Per: time.Minute}
+	}
+
+	// ค่าเริ่มต้นไม่เชื่อ proxy ใด ทุก request มาจาก 127.0.0.1 bucket เดียวกัน
+	if got := statuses(newHarness(t, limit)); got[3] != http.StatusTooManyRequests {
+		t.Fatalf("statuses = %v, want the 4th request limited despite a new X-Forwarded-For", got)
+	}
+
+	// เมื่อ server อยู่หลัง proxy ที่ตั้งไว้ แต่ละ IP ใน X-Forwarded-For มี bucket ของตัวเอง
+	trusted := newHarness(t, limit, func(cfg *config.Config) {
+		cfg.Server.TrustedProxies = []string{"127.0.0.1"}
+	})
+	for i, status := range statuses(trusted) {
+		if status != http.StatusNotFound {
+			t.Fatalf("request %d behind a trusted proxy: status %d, want 404 from its own bucket", i+1, status)
+		}
+	}
+}
+
+func TestSearchIsRateLimitedPerUser(t *testing.T) {
+	h := newHarness(t, func(cfg *config.Config) {
+		cfg.RateLimit.Search = config.Rate{Limit: 2, Per: time.Minute}
+	})
+	alice := h.signupVerified("Alice", "alice@example.test")
+	bob := h.signupVerified("Bob", "bob@example.test")
+	search := map[string]string{"email": "example.test"}
+
+	searchAs := func(u user) int {
+		t.Helper()
+		return h.do(http.MethodPost, "/v1/users/search", u.AccessToken, search).Status
+	}
+
+	for i := 0; i < 2; i++ {
+		if status := searchAs(alice); status != http.StatusOK {
+			t.Fatalf("search %d: status %d, want 200", i+1, status)
+		}
+	}
+	h.expectError(http.StatusTooManyRequests, "RATE_LIMITED", http.MethodPost, "/v1/users/search", alice.AccessToken, search)
+
+	// ผู้ใช้อื่นจาก IP เดียวกันยังมีโควตาของตัวเอง
+	if status := searchAs(bob); status != http.StatusOK {
+		t.Fatalf("bob: status %d, want 200 from a separate bucket", status)
+	}
+}
@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"fmt"
+	"mydayplanner/store"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReconcileReportsAndRepairsDrift(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	admin := h.admin("Admin", "admin@example.test")
+	boardID := h.groupBoard(owner, member)
+
+	createTask := func(name string) int {
+		created := h.expect(http.StatusCreated, http.MethodPost, fmt.Sprintf("/v1/boards/%d/tasks", boardID), owner.AccessToken, map[string]any{
+			"task_name": name, "status": "0",
+		})
+		return int(created["taskID"].(float64))
+	}
+	kept, stale, missing := createTask("Keep"), createTask("Stale"), createTask("Missing")
+	taskPath := func(taskID int) string { return fmt.Sprintf("Boards/%d/Tasks/%d", boardID, taskID) }
+	h.waitFor("task mirrors in Firestore", func() bool {
+		_, ok := h.fb.Doc(taskPath(missing))
+		return ok
+	})
+
+	// ทำให้ mirror คลาดจาก MySQL ทั้งสามแบบ
+	ctx := t.Context()
+	if err := h.fb.Boards().UpdateTask(ctx, boardID, stale, store.Fields{"status": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.fb.Boards().DeleteTask(ctx, boardID, missing); err != nil {
+		t.Fatal(err)
+	}
+	orphan := fmt.Sprintf("Boards/%d/Tasks/999", boardID)
+	if err := h.fb.Boards().SetTask(ctx, boardID, 999, store.Fields{"taskName": "Deleted long ago"}); err != nil {
+		t.Fatal(err)
+	}
+
+	drifts := func(report map[string]any) map[string]string {
+		out := map[string]string{}
+		for _, d := range report["drifts"].([]any) {
+			d := d.(map[string]any)
+			out[d["path"].(string)] = d["kind"].(string)
+		}
+		return out
+	}
+	want := map[string]string{
+		taskPath(stale):   "stale",
+		taskPath(missing): "missing",
+		orphan:            "orphaned",
+	}
+
+	h.expectError(http.StatusForbidden, "ADMIN_REQUIRED", http.MethodPost, "/v1/admin/reconcile", owner.AccessToken, nil)
+
+	report := h.expect(http.StatusOK, http.MethodPost, "/v1/admin/reconcile", admin.AccessToken, map[string]any{"board_id": boardID})
+	if got := drifts(report); fmt.Sprint(got) != fmt.Sprint(want) || report["dry_run"] != true {
+		t.Fatalf("dry run = %v, want drifts %v", report, want)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if _, ok := h.fb.Doc(taskPath(missing)); ok {
+		t.Fatal("dry run repaired the mirror")
+	}
+
+	report = h.expect(http.StatusOK, http.MethodPost, "/v1/admin/reconcile", admin.AccessToken, map[string]any{"board_id": boardID, "apply": true})
+	if report["repaired"] != float64(3) {
+		t.Fatalf("apply = %v, want 3 repairs", report)
+	}
+	h.waitFor("repairs applied by the outbox relay", func() bool {
+		_, restored := h.fb.Doc(taskPath(missing))
+		_, orphaned := h.fb.Doc(orphan)
+		return restored && !orphaned
+	})
+	if doc, _ := h.fb.Doc(taskPath(stale)); doc["status"] != "0" {
+		t.Fatalf("stale task = %v, want status 0 from MySQL", doc)
+	}
+	if doc, _ := h.fb.Doc(taskPath(kept)); doc["taskName"] != "Keep" {
+		t.Fatalf("untouched task = %v", doc)
+	}
+
+	report = h.expect(http.StatusOK, http.MethodPost, "/v1/admin/reconcile", admin.AccessToken, nil)
+	if got := drifts(report); len(got) != 0 {
+		t.Fatalf("after repair found %v, want no drift", got)
+	}
+}
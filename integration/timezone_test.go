@@ -0,0 +1,171 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/controller/notification"
+	"mydayplanner/model"
+	"mydayplanner/timezone"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProfileTimezone(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+
+	me := h.expect(http.StatusOK, http.MethodGet, "/v1/users/me", owner.AccessToken, nil)
+	if got := me["user"].(map[string]any)["Timezone"]; got != timezone.Default {
+		t.Fatalf("time zone = %v, want the default %s", got, timezone.Default)
+	}
+
+	h.setTimezone(owner, "Europe/Berlin")
+	me = h.expect(http.StatusOK, http.MethodGet, "/v1/users/me", owner.AccessToken, nil)
+	if got := me["user"].(map[string]any)["Timezone"]; got != "Europe/Berlin" {
+		t.Fatalf("time zone = %v, want Europe/Berlin", got)
+	}
+
+	for _, name := range []string{"Mars/Olympus", "Local"} {
+		h.expectError(http.StatusBadRequest, "INVALID_INPUT", http.MethodPut, "/v1/users/me/profile", owner.AccessToken, map[string]any{"timezone": name})
+	}
+}
+
+func TestLocalDueDateAndTodayTasks(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	other := h.signupVerified("Other", "other@example.test")
+	h.setTimezone(owner, "Europe/Berlin")
+
+	create := func(name, due string) int {
+		created := h.expect(http.StatusCreated, http.MethodPost, "/v1/tasks", owner.AccessToken, map[string]any{
+			"task_name": name,
+			"status":    "0",
+			"reminder":  map[string]string{"due_date": due},
+		})
+		return int(created["notificationID"].(float64))
+	}
+	// ไม่มี offset จึงเป็นเวลาของเบอร์ลิน (+01:00 ในเดือนธันวาคม)
+	morning := create("Morning", "2030-12-16T09:30")
+	create("Late", "2030-12-16T23:30")
+	create("Explicit", "2030-12-16T23:30:00Z")
+
+	var n model.Notification
+	if err := h.deps.DB.First(&n, morning).Error; err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2030, time.December, 16, 8, 30, 0, 0, time.UTC); !n.DueDate.Equal(want) {
+		t.Fatalf("due_date = %v, want %v", n.DueDate.UTC(), want)
+	}
+
+	names := func(u user, date string) []string {
+		t.Helper()
+		got := h.expect(http.StatusOK, http.MethodGet, "/v1/tasks/today?date="+date, u.AccessToken, nil)
+		var out []string
+		for _, task := range got["tasks"].([]any) {
+			out = append(out, task.(map[string]any)["task_name"].(string))
+		}
+		return out
+	}
+	// 23:30Z ของวันที่ 16 เป็น 00:30 ของวันที่ 17 ในเบอร์ลิน
+	if got := names(owner, "2030-12-16"); strings.Join(got, ",") != "Morning,Late" {
+		t.Fatalf("tasks on the 16th = %v, want Morning and Late", got)
+	}
+	if got := names(owner, "2030-12-17"); strings.Join(got, ",") != "Explicit" {
+		t.Fatalf("tasks on the 17th = %v, want Explicit", got)
+	}
+	if got := names(other, "2030-12-16"); len(got) != 0 {
+		t.Fatalf("other user sees %v, want none", got)
+	}
+
+	got := h.expect(http.StatusOK, http.MethodGet, "/v1/tasks/today?date=2030-12-16", owner.AccessToken, nil)
+	first := got["tasks"].([]any)[0].(map[string]any)
+	if got["time_zone"] != "Europe/Berlin" || first["due_date"] != "2030-12-16T09:30:00+01:00" {
+		t.Fatalf("today = %v, want local due dates in Europe/Berlin", got)
+	}
+	h.expectError(http.StatusBadRequest, "INVALID_INPUT", http.MethodGet, "/v1/tasks/today?date=16/12/2030", owner.AccessToken, nil)
+}
+
+func TestPushTextUsesRecipientTimezone(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	member := h.signupVerified("Member", "member@example.test")
+	h.setTimezone(owner, "Europe/Berlin")
+	h.registerDevice(owner, "owner-device")
+	h.registerDevice(member, "member-device")
+	notificationID := h.dueReminder(owner, h.groupBoard(owner, member))
+
+	if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+	var n model.Notification
+	if err := h.deps.DB.First(&n, notificationID).Error; err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"owner-device":  timezone.Format(*n.DueDate, timezone.Load("Europe/Berlin")),
+		"member-device": timezone.Format(*n.DueDate, timezone.Load(timezone.Default)),
+	}
+	messages := h.fcm.Messages()
+	if len(messages) != 2 {
+		t.Fatalf("sent %d pushes, want 2", len(messages))
+	}
+	for _, m := range messages {
+		if !strings.Contains(m.Notification.Body, want[m.Token]) {
+			t.Fatalf("%s body = %q, want the due time %s", m.Token, m.Notification.Body, want[m.Token])
+		}
+	}
+	// ข้อความต่างกันจึงเป็นสอง request แต่ยังเป็น attempt เดียว
+	if attempts := h.attempts(notificationID); len(attempts) != 1 || attempts[0].Status != model.AttemptSent || attempts[0].Tokens != 2 {
+		t.Fatalf("attempts = %+v, want one sent attempt", attempts)
+	}
+}
+
+func TestRecurringRollForwardKeepsLocalTime(t *testing.T) {
+	h := newHarness(t)
+	owner := h.signupVerified("Owner", "owner@example.test")
+	h.setTimezone(owner, "Europe/Berlin")
+	now := time.Now().UTC()
+	created := h.expect(http.StatusCreated, http.MethodPost, "/v1/tasks", owner.AccessToken, map[string]any{
+		"task_name": "Standup",
+		"status":    "0",
+		"reminder": map[string]string{
+			"due_date":          now.Add(time.Hour).Format(time.RFC3339),
+			"before_due_date":   now.Add(30 * time.Minute).Format(time.RFC3339),
+			"recurring_pattern": "daily",
+		},
+	})
+	notificationID := int(created["notificationID"].(float64))
+	// เอกสารตอนสร้างเขียนด้วย Set ผ่าน outbox ต้องรอให้ลงก่อน ไม่อย่างนั้นจะทับผลของรอบนี้
+	notificationPath := fmt.Sprintf("Notifications/%s/Tasks/%d", owner.Email, notificationID)
+	h.waitFor("notification mirror in Firestore", func() bool {
+		_, ok := h.fb.Doc(notificationPath)
+		return ok
+	})
+
+	// เตือนล่วงหน้า 09:00 ของวันก่อนเบอร์ลินเลื่อนนาฬิกากลับ (25 ตุลาคม 2026) และถึงกำหนดแล้ว
+	berlin := timezone.Load("Europe/Berlin")
+	before := time.Date(2026, time.October, 24, 9, 0, 0, 0, berlin).UTC()
+	if err := h.deps.DB.Model(&model.Notification{}).Where("notification_id = ?", notificationID).Updates(map[string]any{
+		"is_send": model.NotifyBeforeSent, "beforedue_date": before, "due_date": now.Add(-time.Minute),
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := notification.ProcessNotifications(context.Background(), h.deps.DB, h.deps.FB, h.deps.Push); err != nil {
+		t.Fatal(err)
+	}
+	doc, ok := h.fb.Doc(notificationPath)
+	if !ok {
+		t.Fatal("notification document is missing")
+	}
+	next, ok := doc["remindMeBefore"].(time.Time)
+	if !ok {
+		t.Fatalf("remindMeBefore = %#v, want a time", doc["remindMeBefore"])
+	}
+	// วันถัดไปยังเป็น 09:00 ตามเวลาเบอร์ลิน ห่างกัน 25 ชั่วโมง ไม่ใช่ 24
+	if local := next.In(berlin); local.Day() != 25 || local.Hour() != 9 || next.Sub(before) != 25*time.Hour {
+		t.Fatalf("remindMeBefore = %v, want 09:00 on 25 October in Europe/Berlin", local)
+	}
+}
@@ -0,0 +1,186 @@
+// Package leader เลือก instance เดียวให้รัน scheduler ผ่าน lease ในตาราง scheduler_leases
+// leader ต่ออายุ lease ทุก RenewInterval ถ้าหยุดต่อ (process ตายหรือติดต่อ MySQL ไม่ได้)
+// instance อื่นจะรับ lease ไปได้เมื่อครบ LeaseTTL
+// เวลาหมดอายุคำนวณจากนาฬิกาของแต่ละ instance นาฬิกาของทุก instance จึงต้องตรงกัน (NTP) ในระดับที่น้อยกว่า LeaseTTL มาก
+package leader
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/config"
+	"mydayplanner/logging"
+	"mydayplanner/metrics"
+	"os"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SchedulerLease ชื่อ lease ของ cron job และ outbox relay
+const SchedulerLease = "scheduler"
+
+type lease struct {
+	Name      string    `gorm:"column:name;primaryKey"`
+	Holder    string    `gorm:"column:holder"`
+	ExpiresAt time.Time `gorm:"column:expires_at"`
+	RenewedAt time.Time `gorm:"column:renewed_at"`
+}
+
+func (lease) TableName() string {
+	return "scheduler_leases"
+}
+
+// Elector ถือหรือรอ lease หนึ่งชื่อแทน instance นี้
+// Elector ที่เป็น nil ถือว่าเป็น leader เสมอ ใช้กับ process เดียวเช่นใน test
+type Elector struct {
+	db   *gorm.DB
+	name string
+	id   string
+	cfg  config.LeaderConfig
+	now  func() time.Time
+
+	mu      sync.RWMutex
+	leading bool
+	since   time.Time
+	expires time.Time
+}
+
+// New สร้าง Elector ที่ยังไม่ได้เป็น leader จนกว่า Run หรือ Tick จะรับ lease ได้
+func New(db *gorm.DB, name string, cfg config.LeaderConfig) *Elector {
+	host, _ := os.Hostname()
+	return &Elector{
+		db:   db,
+		name: name,
+		id:   fmt.Sprintf("%s-%d-%s", host, os.Getpid(), logging.NewID()[:8]),
+		cfg:  cfg,
+		now:  time.Now,
+	}
+}
+
+// ID ชื่อของ instance นี้ที่บันทึกใน holder
+func (e *Elector) ID() string {
+	if e == nil {
+		return ""
+	}
+	return e.id
+}
+
+// IsLeader ถือ lease อยู่และ lease ยังไม่หมดอายุ
+// ตรวจเวลาหมดอายุด้วย จึงหยุดเป็น leader ตรงเวลาแม้รอบต่อ lease จะค้างอยู่
+func (e *Elector) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.leading && e.now().Before(e.expires)
+}
+
+// Status สถานะของ instance นี้สำหรับ /readyz
+type Status struct {
+	Instance string     `json:"instance"`
+	Leader   bool       `json:"leader"`
+	Since    *time.Time `json:"leader_since,omitempty"`
+}
+
+func (e *Elector) Status() Status {
+	leading := e.IsLeader()
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	st := Status{Instance: e.id, Leader: leading}
+	if leading {
+		since := e.since
+		st.Since = &since
+	}
+	return st
+}
+
+// Run รับหรือต่อ lease ทุก RenewInterval จน ctx ถูกยกเลิก
+// ไม่คืน lease เอง ผู้เรียกต้องเรียก Release หลัง job ที่กำลังรันเสร็จแล้ว
+func (e *Elector) Run(ctx context.Context) {
+	if e == nil {
+		return
+	}
+	ticker := time.NewTicker(e.cfg.RenewInterval)
+	defer ticker.Stop()
+	for {
+		e.Tick(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Tick รับหรือต่อ lease หนึ่งครั้ง คืนว่าเป็น leader หลังรอบนี้หรือไม่
+func (e *Elector) Tick(ctx context.Context) bool {
+	logger := logging.FromContext(ctx)
+	now := e.now().UTC()
+	expires := now.Add(e.cfg.LeaseTTL)
+	held, err := e.acquire(ctx, now, expires)
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if err != nil {
+		// ไม่รู้ว่า lease ยังเป็นของเราหรือไม่ แต่ไม่มีใครรับไปได้ก่อน lease ที่ต่อไว้ล่าสุดหมดอายุ
+		logger.Error("failed to renew scheduler lease", "lease", e.name, "error", err)
+		held, expires = e.leading && now.Before(e.expires), e.expires
+	}
+	switch {
+	case held && !e.leading:
+		e.since = now
+		logger.Info("became scheduler leader", "lease", e.name, "instance", e.id)
+	case !held && e.leading:
+		logger.Warn("lost scheduler leadership", "lease", e.name, "instance", e.id)
+	}
+	e.leading = held
+	if held {
+		e.expires = expires
+		metrics.SchedulerLeader.Set(1)
+	} else {
+		metrics.SchedulerLeader.Set(0)
+	}
+	return held
+}
+
+// acquire ต่อ lease ถ้าเป็นของเรา หรือรับมาถ้าหมดอายุแล้ว ใน UPDATE เดียวจึงไม่มีสอง instance รับพร้อมกัน
+func (e *Elector) acquire(ctx context.Context, now, expires time.Time) (bool, error) {
+	db := e.db.WithContext(ctx)
+	// แถวแรกของ lease สร้างแบบหมดอายุแล้ว และไม่ทับแถวที่ instance อื่นสร้างไว้
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&lease{Name: e.name, ExpiresAt: now, RenewedAt: now}).Error; err != nil {
+		return false, err
+	}
+	res := db.Model(&lease{}).
+		Where("name = ? AND (holder = ? OR expires_at <= ?)", e.name, e.id, now).
+		Updates(map[string]any{"holder": e.id, "expires_at": expires, "renewed_at": now})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// Release คืน lease ถ้าเป็นของเรา instance อื่นรับต่อได้ในรอบถัดไปโดยไม่ต้องรอ LeaseTTL
+func (e *Elector) Release(ctx context.Context) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.leading {
+		return
+	}
+	e.leading = false
+	metrics.SchedulerLeader.Set(0)
+	now := e.now().UTC()
+	if err := e.db.WithContext(ctx).Model(&lease{}).
+		Where("name = ? AND holder = ?", e.name, e.id).
+		Update("expires_at", now).Error; err != nil {
+		logging.FromContext(ctx).Error("failed to release scheduler lease", "lease", e.name, "error", err)
+		return
+	}
+	logging.FromContext(ctx).Info("released scheduler lease", "lease", e.name, "instance", e.id)
+}
@@ -0,0 +1,88 @@
+package leader
+
+import (
+	"context"
+	"mydayplanner/config"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "leader.db")), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&lease{}); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestLeaseFailsOverWhenLeaderStopsRenewing(t *testing.T) {
+	db := openTestDB(t)
+	clock := time.Now()
+	cfg := config.LeaderConfig{LeaseTTL: 15 * time.Second, RenewInterval: 5 * time.Second}
+	newElector := func() *Elector {
+		e := New(db, SchedulerLease, cfg)
+		e.now = func() time.Time { return clock }
+		return e
+	}
+	a, b := newElector(), newElector()
+	ctx := context.Background()
+
+	if !a.Tick(ctx) || b.Tick(ctx) {
+		t.Fatal("first instance should take the lease and the second should wait")
+	}
+	clock = clock.Add(cfg.RenewInterval)
+	if !a.Tick(ctx) || b.Tick(ctx) {
+		t.Fatal("leader should renew while the lease is held")
+	}
+
+	// a หยุดต่อ lease (เช่น process ค้าง) เมื่อครบ TTL ต้องไม่ถือว่าตัวเองเป็น leader และ b รับต่อได้
+	clock = clock.Add(cfg.LeaseTTL)
+	if a.IsLeader() {
+		t.Fatal("leader kept leadership past its lease")
+	}
+	if !b.Tick(ctx) {
+		t.Fatal("standby did not take over an expired lease")
+	}
+	if a.Tick(ctx) {
+		t.Fatal("old leader took the lease back")
+	}
+	if st := b.Status(); !st.Leader || st.Instance != b.ID() || !st.Since.Equal(clock.UTC()) {
+		t.Fatalf("status = %+v", st)
+	}
+}
+
+func TestReleaseHandsOverWithoutWaitingForTTL(t *testing.T) {
+	db := openTestDB(t)
+	clock := time.Now()
+	cfg := config.LeaderConfig{LeaseTTL: time.Minute, RenewInterval: time.Second}
+	a, b := New(db, SchedulerLease, cfg), New(db, SchedulerLease, cfg)
+	a.now = func() time.Time { return clock }
+	b.now = a.now
+	ctx := context.Background()
+
+	if !a.Tick(ctx) {
+		t.Fatal("lease not acquired")
+	}
+	a.Release(ctx)
+	if a.IsLeader() {
+		t.Fatal("still leader after release")
+	}
+	clock = clock.Add(cfg.RenewInterval)
+	if !b.Tick(ctx) {
+		t.Fatal("standby did not take a released lease")
+	}
+}
@@ -0,0 +1,59 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// GinKey key ที่ middleware ใช้เก็บ logger ใน gin.Context (c.Set)
+const GinKey = "logger"
+
+type ctxKey struct{}
+
+// New สร้าง logger แบบ JSON ตามระดับที่กำหนด ("debug", "info", "warn", "error")
+func New(w io.Writer, level string) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: ParseLevel(level)}))
+}
+
+// ParseLevel แปลงชื่อระดับเป็น slog.Level ค่าที่ไม่รู้จักถือเป็น info
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithContext ผูก logger ไว้กับ ctx ให้ฟังก์ชันที่รับ ctx ต่อไปดึงไปใช้ได้
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext คืน logger ของ request หรือของรอบ job ที่ผูกไว้ ถ้าไม่มีคืน slog.Default()
+// รับ *gin.Context ได้โดยตรงเพราะ gin คืนค่าจาก c.Get เมื่อ key เป็น string
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx == nil {
+		return slog.Default()
+	}
+	if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return l
+	}
+	if l, ok := ctx.Value(GinKey).(*slog.Logger); ok {
+		return l
+	}
+	return slog.Default()
+}
+
+// NewID สร้าง correlation ID สำหรับ request หรือรอบของ job
+func NewID() string {
+	return uuid.NewString()
+}
@@ -0,0 +1,147 @@
+// Package mail ส่งอีเมลของระบบผ่าน Sender ที่เลือกจาก config
+// handler สร้าง Message แล้วส่งผ่าน Sender เท่านั้น จึงสลับ SMTP กับ Maildir หรือ Memory ได้ตอนพัฒนาและทดสอบ
+package mail
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	netmail "net/mail"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+// Message อีเมลหนึ่งฉบับ ต้องมี Text หรือ HTML อย่างน้อยหนึ่งอย่าง ถ้ามีทั้งคู่จะส่งเป็น multipart/alternative
+type Message struct {
+	// From ว่างได้ จะใช้ผู้ส่งที่ตั้งไว้ใน Sender (MAIL_FROM)
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Sender ช่องทางส่งอีเมล
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// ErrNoBody คืนเมื่อ Message ไม่มีทั้ง Text และ HTML
+var ErrNoBody = errors.New("mail: message has no body")
+
+// withFrom ใส่ผู้ส่งเริ่มต้นเมื่อ msg ไม่ได้ระบุ
+func (m Message) withFrom(from string) Message {
+	if m.From == "" {
+		m.From = from
+	}
+	return m
+}
+
+// envelope ตรวจที่อยู่ทั้งหมด คืนที่อยู่ผู้ส่งและผู้รับสำหรับ MAIL FROM และ RCPT TO
+func (m Message) envelope() (*netmail.Address, []*netmail.Address, error) {
+	from, err := netmail.ParseAddress(m.From)
+	if err != nil {
+		return nil, nil, fmt.Errorf("mail: invalid sender %q: %w", m.From, err)
+	}
+	if len(m.To) == 0 {
+		return nil, nil, errors.New("mail: message has no recipient")
+	}
+	to := make([]*netmail.Address, len(m.To))
+	for i, addr := range m.To {
+		if to[i], err = netmail.ParseAddress(addr); err != nil {
+			return nil, nil, fmt.Errorf("mail: invalid recipient %q: %w", addr, err)
+		}
+	}
+	return from, to, nil
+}
+
+// Compose สร้างอีเมลตาม RFC 5322 ที่พร้อมส่ง header ที่ไม่ใช่ ASCII ถูก encode ตาม RFC 2047
+// และเนื้อหาเข้ารหัสแบบ quoted-printable บรรทัดจึงไม่ยาวเกินที่ SMTP กำหนด
+func Compose(msg Message, now time.Time) ([]byte, error) {
+	if msg.Text == "" && msg.HTML == "" {
+		return nil, ErrNoBody
+	}
+	from, to, err := msg.envelope()
+	if err != nil {
+		return nil, err
+	}
+	recipients := make([]string, len(to))
+	for i, addr := range to {
+		recipients[i] = addr.String()
+	}
+
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", from.String())
+	header("To", strings.Join(recipients, ", "))
+	header("Subject", mime.QEncoding.Encode("UTF-8", msg.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("Message-ID", messageID(from.Address))
+	header("MIME-Version", "1.0")
+
+	if msg.Text == "" || msg.HTML == "" {
+		contentType, body := "text/plain; charset=UTF-8", msg.Text
+		if msg.HTML != "" {
+			contentType, body = "text/html; charset=UTF-8", msg.HTML
+		}
+		header("Content-Type", contentType)
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		if err := writeQuotedPrintable(&buf, body); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	header("Content-Type", mime.FormatMediaType("multipart/alternative", map[string]string{"boundary": parts.Boundary()}))
+	buf.WriteString("\r\n")
+	// client แสดงส่วนสุดท้ายที่รองรับ จึงใส่ plain text ก่อน HTML
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeQuotedPrintable(w, part.body); err != nil {
+			return nil, err
+		}
+	}
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeQuotedPrintable(w io.Writer, body string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func messageID(from string) string {
+	domain := "localhost"
+	if _, d, ok := strings.Cut(from, "@"); ok {
+		domain = d
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + domain + ">"
+}
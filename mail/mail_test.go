@@ -0,0 +1,103 @@
+package mail
+
+import (
+	"io"
+	"mime"
+	"mime/multipart"
+	netmail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestComposeEncodesHeadersAndAlternatives(t *testing.T) {
+	msg := Message{
+		From:    "Myday-Planner <noreply@mydayplanner.test>",
+		To:      []string{"alice@example.test"},
+		Subject: "รหัส OTP สำหรับยืนยันตัวตน",
+		Text:    "OTP : 123456",
+		HTML:    `<strong style="color:#000">123456</strong>` + strings.Repeat(" ", 100),
+	}
+	raw, err := Compose(msg, time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("line longer than RFC 5322 allows: %d", len(line))
+		}
+	}
+
+	parsed, err := netmail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+	if err != nil || subject != msg.Subject {
+		t.Fatalf("Subject = %q (%v), want %q", subject, err, msg.Subject)
+	}
+	if to := parsed.Header.Get("To"); to != "<alice@example.test>" {
+		t.Fatalf("To = %q", to)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q (%v)", mediaType, err)
+	}
+	parts := multipart.NewReader(parsed.Body, params["boundary"])
+	var got []string
+	for {
+		part, err := parts.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, _ := io.ReadAll(part)
+		got = append(got, part.Header.Get("Content-Type")+"|"+strings.TrimSpace(string(body)))
+	}
+	want := []string{
+		"text/plain; charset=UTF-8|" + msg.Text,
+		"text/html; charset=UTF-8|" + strings.TrimSpace(msg.HTML),
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("parts = %q, want %q", got, want)
+	}
+}
+
+func TestComposeRejectsInvalidMessages(t *testing.T) {
+	valid := Message{From: "noreply@mydayplanner.test", To: []string{"a@example.test"}, Text: "hi"}
+	for name, msg := range map[string]Message{
+		"no body":      {From: valid.From, To: valid.To},
+		"no recipient": {From: valid.From, Text: "hi"},
+		"bad address":  {From: valid.From, To: []string{"not an address"}, Text: "hi"},
+		"header break": {From: valid.From, To: []string{"a@example.test\r\nBcc: x@example.test"}, Text: "hi"},
+	} {
+		if _, err := Compose(msg, time.Now()); err == nil {
+			t.Errorf("%s: Compose accepted %+v", name, msg)
+		}
+	}
+	if _, err := Compose(valid, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMaildirDeliversIntoNew(t *testing.T) {
+	dir := t.TempDir()
+	sender := NewMaildir(dir, "noreply@mydayplanner.test")
+	for range 2 {
+		if err := sender.Send(t.Context(), Message{To: []string{"a@example.test"}, Subject: "s", Text: "hi"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := os.ReadDir(filepath.Join(dir, "new"))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("new/ = %v (%v), want two messages", files, err)
+	}
+	if tmp, _ := os.ReadDir(filepath.Join(dir, "tmp")); len(tmp) != 0 {
+		t.Fatalf("tmp/ still has %v", tmp)
+	}
+}
@@ -0,0 +1,51 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/logging"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Maildir เขียนแต่ละข้อความเป็นไฟล์ใน dir/new ตามรูปแบบ Maildir เปิดด้วย mail client หรือ cat ได้โดยตรง
+// เขียนลง dir/tmp ก่อนแล้ว rename เพื่อให้ผู้อ่านไม่เห็นไฟล์ที่เขียนไม่เสร็จ
+type Maildir struct {
+	dir  string
+	from string
+	seq  atomic.Uint64
+}
+
+// NewMaildir สร้างไดเรกทอรี tmp, new และ cur ตอนส่งครั้งแรกถ้ายังไม่มี
+func NewMaildir(dir, from string) *Maildir {
+	return &Maildir{dir: dir, from: from}
+}
+
+func (m *Maildir) Send(ctx context.Context, msg Message) error {
+	now := time.Now()
+	data, err := Compose(msg.withFrom(m.from), now)
+	if err != nil {
+		return err
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(m.dir, sub), 0o755); err != nil {
+			return fmt.Errorf("maildir: %w", err)
+		}
+	}
+
+	host, _ := os.Hostname()
+	name := fmt.Sprintf("%d.%d_%d.%s", now.Unix(), os.Getpid(), m.seq.Add(1), host)
+	tmp := filepath.Join(m.dir, "tmp", name)
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("maildir: %w", err)
+	}
+	dst := filepath.Join(m.dir, "new", name)
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("maildir: %w", err)
+	}
+	logging.FromContext(ctx).Info("email written to maildir", "path", dst)
+	return nil
+}
@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Received ข้อความที่ Memory เก็บไว้
+type Received struct {
+	Message
+	SentAt time.Time
+}
+
+// Memory เก็บอีเมลไว้ในหน่วยความจำของ process ใช้ตอนพัฒนาคู่กับ /v1/dev/mailbox
+// ไม่มีการลบอัตโนมัติ จึงไม่ควรใช้กับ production
+type Memory struct {
+	from string
+
+	mu       sync.Mutex
+	received []Received
+}
+
+func NewMemory(from string) *Memory {
+	return &Memory{from: from}
+}
+
+// Send ตรวจข้อความแบบเดียวกับ SMTP ข้อความที่ SMTP ไม่ยอมส่งจึงไม่ผ่านที่นี่เช่นกัน
+func (m *Memory) Send(ctx context.Context, msg Message) error {
+	msg = msg.withFrom(m.from)
+	if _, err := Compose(msg, time.Now()); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received = append(m.received, Received{Message: msg, SentAt: time.Now()})
+	return nil
+}
+
+// Messages ข้อความที่ส่งถึง to เรียงจากเก่าไปใหม่ to ว่างคืนทุกข้อความ
+func (m *Memory) Messages(to string) []Received {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []Received
+	for _, r := range m.received {
+		if to == "" || slices.Contains(r.To, to) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// Reset ลบข้อความทั้งหมด
+func (m *Memory) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.received = nil
+}
@@ -0,0 +1,89 @@
+package mail
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mydayplanner/config"
+	"mydayplanner/logging"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// smtpTimeout ใช้เมื่อ ctx ไม่มี deadline เพื่อไม่ให้ request ค้างตาม mail server ที่ไม่ตอบ
+const smtpTimeout = 30 * time.Second
+
+// SMTP ส่งผ่าน mail server ด้วย STARTTLS เมื่อ server รองรับ
+// ถ้า server ไม่ประกาศ STARTTLS, smtp.PlainAuth จะไม่ยอมส่งรหัสผ่าน ยกเว้น host เป็น localhost
+type SMTP struct {
+	cfg  config.SMTPConfig
+	from string
+}
+
+// NewSMTP from เป็นผู้ส่งเริ่มต้นของทุกข้อความ (MAIL_FROM)
+func NewSMTP(cfg config.SMTPConfig, from string) *SMTP {
+	return &SMTP{cfg: cfg, from: from}
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	msg = msg.withFrom(s.from)
+	data, err := Compose(msg, time.Now())
+	if err != nil {
+		return err
+	}
+	from, to, err := msg.envelope()
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, s.cfg.Port)
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("SMTP dial: %w", err)
+	}
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	client, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("SMTP handshake: %w", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return fmt.Errorf("SMTP STARTTLS: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return fmt.Errorf("SMTP auth: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("SMTP MAIL FROM: %w", err)
+	}
+	for _, rcpt := range to {
+		if err := client.Rcpt(rcpt.Address); err != nil {
+			return fmt.Errorf("SMTP RCPT TO %s: %w", rcpt.Address, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("SMTP DATA: %w", err)
+	}
+	logging.FromContext(ctx).Debug("email sent", "smtp_addr", addr, "recipients", len(to))
+	return client.Quit()
+}
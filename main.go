@@ -1,14 +1,67 @@
 package main
 
 import (
+	"context"
+	"log/slog"
+	"mydayplanner/config"
 	"mydayplanner/connection"
+	"mydayplanner/logging"
 	"mydayplanner/scheduler"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	// ฝังฐานข้อมูลเขตเวลา IANA ไว้ใน binary เขตเวลาของผู้ใช้จึงไม่ขึ้นกับ zoneinfo ของเครื่องที่รัน
+	_ "time/tzdata"
 
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	cfg, err := config.Load()
+	if err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	slog.SetDefault(logging.New(os.Stdout, cfg.Log.Level))
+
+	// ctx ถูกยกเลิกเมื่อได้รับ SIGINT/SIGTERM หรือเมื่อฝั่งใดฝั่งหนึ่งหยุดด้วย error
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	gin.SetMode(gin.ReleaseMode)
-	go scheduler.StartScheduler()
-	connection.StartServer()
+
+	// เปิด DB pool และ Firebase ครั้งเดียว ใช้ร่วมกันทั้ง API และ scheduler
+	deps, err := connection.NewDeps(ctx, cfg)
+	if err != nil {
+		slog.Error("failed to initialize dependencies", "error", err)
+		os.Exit(1)
+	}
+
+	var wg sync.WaitGroup
+	var schedulerErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		schedulerErr = scheduler.StartScheduler(ctx, deps)
+		stop()
+	}()
+
+	serverErr := connection.StartServer(ctx, deps)
+	stop()
+	wg.Wait()
+
+	// ปิด client หลังจากทั้ง server และ scheduler หยุดแล้ว
+	deps.Close()
+
+	if serverErr != nil || schedulerErr != nil {
+		if serverErr != nil {
+			slog.Error("server stopped with error", "error", serverErr)
+		}
+		if schedulerErr != nil {
+			slog.Error("scheduler stopped with error", "error", schedulerErr)
+		}
+		os.Exit(1)
+	}
+	slog.Info("shutdown complete")
 }
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "mydayplanner"
+
+// ชื่อ job ที่ใช้เป็น label ของ JobDuration
+const (
+	JobProcessNotifications          = "process_notifications"
+	JobProcessRecurringNotifications = "process_recurring_notifications"
+	JobReconcile                     = "reconcile"
+)
+
+var (
+	// HTTPRequestDuration เวลาตอบของแต่ละ route แยกตาม method และ status
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by Gin route, method and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	// DeprecatedRequests จำนวนครั้งที่ client ยังเรียก route เดิมที่ย้ายไป /v1 แล้ว ใช้ดูว่าถอด route ไหนได้
+	DeprecatedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "deprecated_requests_total",
+		Help:      "Requests to deprecated pre-/v1 routes by Gin route.",
+	}, []string{"route"})
+
+	// RateLimitedRequests จำนวน request ที่ถูกตอบ 429 แยกตาม class ของ ratelimit
+	RateLimitedRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Requests rejected by the rate limiter by route class.",
+	}, []string{"class"})
+
+	// NotificationsTotal ผลการส่ง push แยกตามประเภท (before, due, snooze, recurring)
+	// result เป็น sent, failed, skipped หรือ deferred (ผู้รับทุกคนอยู่ใน quiet hours)
+	NotificationsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "notifications_total",
+		Help:      "Push notifications processed by message type and result.",
+	}, []string{"type", "result"})
+
+	// FCMBatchFailures จำนวน multicast batch ที่ FCM ตอบ error ทั้ง batch
+	FCMBatchFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "fcm_batch_failures_total",
+		Help:      "FCM multicast batches that failed as a whole.",
+	})
+
+	// PushTokenFailures ความล้มเหลวราย token แยกตาม push.Reason
+	PushTokenFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "push_token_failures_total",
+		Help:      "Per-token push failures by reason.",
+	}, []string{"reason"})
+
+	// PushTokensPruned จำนวน token ที่ถูกลบออกจาก usersLogin แยกตาม reason (หรือ failures เมื่อล้มติดกันครบจำนวน)
+	PushTokensPruned = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "push_tokens_pruned_total",
+		Help:      "Device tokens removed from user login documents by reason.",
+	}, []string{"reason"})
+
+	// FirestoreMirrorFailures จำนวนครั้งที่อัปเดต Firestore หลัง MySQL ไม่สำเร็จ
+	FirestoreMirrorFailures = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "firestore_mirror_failures_total",
+		Help:      "Failed Firestore writes that mirror notification state from MySQL.",
+	})
+
+	// OutboxMutations ผลการเขียน Firestore จาก outbox result เป็น applied, retried หรือ dead
+	OutboxMutations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "outbox_mutations_total",
+		Help:      "Firestore mutations processed by the outbox relay by result.",
+	}, []string{"result"})
+
+	// ReconcileDrift เอกสาร Firestore ที่ไม่ตรงกับ MySQL ที่พบในแต่ละรอบ แยกตาม mirror และ kind (missing, stale, orphaned)
+	ReconcileDrift = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reconcile_drift_total",
+		Help:      "Firestore documents found out of sync with MySQL by mirror and kind.",
+	}, []string{"mirror", "kind"})
+
+	// SchedulerLeader 1 เมื่อ instance นี้ถือ lease ของ scheduler อยู่
+	SchedulerLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scheduler_leader",
+		Help:      "Whether this instance currently holds the scheduler lease (1) or not (0).",
+	})
+
+	// JobDuration เวลาที่ใช้ของแต่ละรอบ job
+	JobDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "job_duration_seconds",
+		Help:      "Duration of scheduler job runs.",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"job"})
+)
+
+// RecordNotification นับผลจาก worker ซึ่งคืน "success", "error", "skipped" หรือ "deferred"
+func RecordNotification(messageType, result string) {
+	switch result {
+	case "success":
+		NotificationsTotal.WithLabelValues(messageType, "sent").Inc()
+	case "error":
+		NotificationsTotal.WithLabelValues(messageType, "failed").Inc()
+	case "skipped":
+		NotificationsTotal.WithLabelValues(messageType, "skipped").Inc()
+	case "deferred":
+		NotificationsTotal.WithLabelValues(messageType, "deferred").Inc()
+	}
+}
+
+// ObserveJob ใช้กับ defer: defer metrics.ObserveJob(metrics.JobProcessNotifications, time.Now())
+func ObserveJob(job string, start time.Time) {
+	JobDuration.WithLabelValues(job).Observe(time.Since(start).Seconds())
+}
@@ -2,8 +2,8 @@ package middleware
 
 import (
 	"fmt"
-	"net/http"
-	"os"
+	"mydayplanner/apperror"
+	"mydayplanner/config"
 	"strings"
 	"time"
 
@@ -11,11 +11,18 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+var jwtConfig config.JWTConfig
+
+// Configure ตั้งค่า secret ของ JWT ต้องเรียกก่อนลงทะเบียน route
+func Configure(cfg config.JWTConfig) {
+	jwtConfig = cfg
+}
+
 func AccessTokenMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		header := c.Request.Header.Get("Authorization")
 		if header == "" {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Authorization header is missing"})
+			apperror.Respond(c, apperror.TokenMissing)
 			return
 		}
 
@@ -25,12 +32,12 @@ func AccessTokenMiddleware() gin.HandlerFunc {
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 			}
-			hmacSampleSecret := []byte(os.Getenv("JWT_SECRET_KEY"))
+			hmacSampleSecret := []byte(jwtConfig.AccessSecret)
 			return hmacSampleSecret, nil
 		})
 
 		if err != nil {
-			c.AbortWithStatusJSON(403, gin.H{"error": "Token is expired or invalid: " + err.Error()})
+			apperror.Respond(c, apperror.TokenInvalid.Wrap(err))
 			return
 		}
 
@@ -43,13 +50,13 @@ func AccessTokenMiddleware() gin.HandlerFunc {
 				userID := uint(userIDFloat)
 				c.Set("userId", userID)
 			} else {
-				c.AbortWithStatusJSON(401, gin.H{"error": "Invalid userId in token claims"})
+				apperror.Respond(c, apperror.TokenClaimsInvalid)
 				return
 			}
 
 			c.Next()
 		} else {
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token claims"})
+			apperror.Respond(c, apperror.TokenClaimsInvalid)
 			return
 		}
 	}
@@ -59,19 +66,19 @@ func AdminMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		claimsValue, exists := c.Get("claims")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Claims not found"})
+			apperror.Respond(c, apperror.TokenClaimsInvalid)
 			return
 		}
 
 		claims, ok := claimsValue.(jwt.MapClaims)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid claims format"})
+			apperror.Respond(c, apperror.TokenClaimsInvalid)
 			return
 		}
 
 		role, ok := claims["role"].(string)
 		if !ok || role != "admin" {
-			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Forbidden"})
+			apperror.Respond(c, apperror.AdminRequired)
 			return
 		}
 
@@ -84,23 +91,21 @@ func RefreshTokenMiddleware() gin.HandlerFunc {
 		// รับ refresh token จาก Header
 		authHeader := c.Request.Header.Get("Authorization")
 		if authHeader == "" {
-			c.JSON(401, gin.H{"error": "Refresh token is missing"})
-			c.Abort()
+			apperror.Respond(c, apperror.TokenMissing)
 			return
 		}
 
 		// ตรวจสอบรูปแบบของ token
 		bearerToken := strings.Split(authHeader, " ")
 		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			c.JSON(401, gin.H{"error": "Invalid token format"})
-			c.Abort()
+			apperror.Respond(c, apperror.RefreshTokenInvalid)
 			return
 		}
 
 		refreshToken := bearerToken[1]
 
 		// Decode และตรวจสอบ token โดยใช้ JWT key
-		hmacSampleSecret := []byte(os.Getenv("JWT_REFRESH_SECRET_KEY"))
+		hmacSampleSecret := []byte(jwtConfig.RefreshSecret)
 		token, err := jwt.Parse(refreshToken, func(token *jwt.Token) (interface{}, error) {
 			// ตรวจสอบว่า token ใช้ algorithm HMAC
 			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -110,8 +115,7 @@ func RefreshTokenMiddleware() gin.HandlerFunc {
 		})
 
 		if err != nil {
-			c.JSON(403, gin.H{"error": "Invalid refresh token: " + err.Error()})
-			c.Abort()
+			apperror.Respond(c, apperror.TokenInvalid.Wrap(err))
 			return
 		}
 
@@ -119,8 +123,7 @@ func RefreshTokenMiddleware() gin.HandlerFunc {
 			// ตรวจสอบว่า token หมดอายุหรือไม่ (ถ้ามีการกำหนด expiration ใน claims)
 			if exp, ok := claims["expiresAt"].(float64); ok {
 				if int64(exp) < time.Now().Unix() {
-					c.JSON(401, gin.H{"error": "Refresh token has expired"})
-					c.Abort()
+					apperror.Respond(c, apperror.RefreshTokenExpired)
 					return
 				}
 			}
@@ -134,8 +137,7 @@ func RefreshTokenMiddleware() gin.HandlerFunc {
 			} else if userIDFloat, ok := claims["UserID"].(float64); ok {
 				userID = uint(userIDFloat)
 			} else {
-				c.JSON(401, gin.H{"error": "Invalid token claims: UserID not found"})
-				c.Abort()
+				apperror.Respond(c, apperror.TokenClaimsInvalid)
 				return
 			}
 
@@ -146,8 +148,7 @@ func RefreshTokenMiddleware() gin.HandlerFunc {
 			// ดำเนินการต่อไปยัง handler
 			c.Next()
 		} else {
-			c.JSON(401, gin.H{"error": "Invalid refresh token claims"})
-			c.Abort()
+			apperror.Respond(c, apperror.TokenClaimsInvalid)
 			return
 		}
 	}
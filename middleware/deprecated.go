@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"mydayplanner/metrics"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated ใช้กับ route เดิมที่มี route ใหม่ใน /v1 แล้ว ส่ง header Deprecation ทุกครั้ง
+// และ Link rel="successor-version" ไปยัง successor เมื่อเติม path parameter จาก request ได้ครบ
+// ต้องใส่ไว้ก่อน middleware ตรวจ token เพื่อให้ response ที่ถูกปฏิเสธมี header ด้วย
+func Deprecated(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		if link, ok := successorLink(c, successor); ok {
+			c.Header("Link", "<"+link+`>; rel="successor-version"`)
+		}
+		metrics.DeprecatedRequests.WithLabelValues(c.FullPath()).Inc()
+		c.Next()
+	}
+}
+
+// successorLink แทน :name ใน successor ด้วยค่าจาก path ของ request เดิม
+// คืน false ถ้า route เดิมไม่มีค่านั้น (เช่น route เดิมรับ id ใน body)
+func successorLink(c *gin.Context, successor string) (string, bool) {
+	segments := strings.Split(successor, "/")
+	for i, seg := range segments {
+		if !strings.HasPrefix(seg, ":") {
+			continue
+		}
+		value := c.Param(seg[1:])
+		if value == "" {
+			return "", false
+		}
+		segments[i] = value
+	}
+	return strings.Join(segments, "/"), true
+}
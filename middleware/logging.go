@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"log/slog"
+	"mydayplanner/logging"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader header ที่รับและส่งกลับ correlation ID ของ request
+const RequestIDHeader = "X-Request-ID"
+
+// RequestLogger กำหนด request ID (ใช้ของ client ถ้าส่งมา) ผูก logger ที่มี request_id ไว้กับ request
+// แล้ว log หนึ่งบรรทัดเมื่อ request จบ
+func RequestLogger() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		requestID := c.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > 128 {
+			requestID = logging.NewID()
+		}
+		c.Header(RequestIDHeader, requestID)
+
+		logger := slog.Default().With("request_id", requestID)
+		c.Set(logging.GinKey, logger)
+		c.Request = c.Request.WithContext(logging.WithContext(c.Request.Context(), logger))
+
+		c.Next()
+
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		attrs := []any{
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", status,
+			"latency_ms", time.Since(start).Milliseconds(),
+			"client_ip", c.ClientIP(),
+		}
+		if len(c.Errors) > 0 {
+			attrs = append(attrs, "errors", c.Errors.String())
+		}
+		logger.Log(c.Request.Context(), level, "request completed", attrs...)
+	}
+}
@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"mydayplanner/metrics"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics บันทึกเวลาตอบของทุก request โดยใช้ route pattern ของ gin เป็น label
+// request ที่ไม่ตรง route ใดรวมไว้ที่ "unmatched" เพื่อไม่ให้ label แตกตาม path ที่ client ส่งมา
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.HTTPRequestDuration.
+			WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).
+			Observe(time.Since(start).Seconds())
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"mydayplanner/apperror"
+	"mydayplanner/logging"
+	"mydayplanner/metrics"
+	"mydayplanner/ratelimit"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+var limiter *ratelimit.Limiter
+
+// ConfigureRateLimit ตั้ง limiter ที่ RateLimit ใช้ ถ้าไม่เรียก (เช่นตอนสร้าง OpenAPI) ทุก request ผ่านหมด
+func ConfigureRateLimit(l *ratelimit.Limiter) {
+	limiter = l
+}
+
+// RateLimit จำกัดจำนวน request ตาม policy ของ class ตอบ 429 RATE_LIMITED พร้อม Retry-After เป็นวินาที
+// class ที่ key ด้วย userId ต้องวางหลัง AccessTokenMiddleware
+// ถ้า backend ใช้งานไม่ได้จะปล่อย request ผ่าน เพื่อไม่ให้ MySQL ล่มแล้วทั้ง API ใช้ไม่ได้ไปด้วย
+func RateLimit(class ratelimit.Class) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limiter == nil {
+			c.Next()
+			return
+		}
+		policy, ok := limiter.Policy(class)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		subject := "ip:" + ClientIP(c)
+		if policy.KeyBy == ratelimit.ByUser {
+			if userID, ok := c.Get("userId"); ok {
+				subject = fmt.Sprintf("user:%v", userID)
+			}
+		}
+
+		result, err := limiter.Allow(c.Request.Context(), class, subject)
+		if err != nil {
+			logging.FromContext(c).Warn("rate limiter unavailable, allowing request", "class", class, "error", err)
+			c.Next()
+			return
+		}
+		if !result.Allowed {
+			metrics.RateLimitedRequests.WithLabelValues(string(class)).Inc()
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(result.RetryAfter.Seconds()))))
+			apperror.Respond(c, apperror.RateLimited)
+			return
+		}
+		c.Next()
+	}
+}
+
+// ClientIP IP ของผู้เรียกตาม trusted proxy ของ gin (config.ServerConfig.TrustedProxies)
+// X-Forwarded-For ถูกอ่านเฉพาะเมื่อ connection มาจาก proxy ที่เชื่อ ถ้ามีหลาย IP ใช้ตัวแรก
+func ClientIP(c *gin.Context) string {
+	userIPAddress := c.ClientIP()
+	if userIPAddress == "" {
+		userIPAddress = c.Request.RemoteAddr
+	}
+	// ถ้ามีหลาย IP ให้ใช้ตัวแรก
+	if idx := strings.Index(userIPAddress, ","); idx != -1 {
+		userIPAddress = strings.TrimSpace(userIPAddress[:idx])
+	}
+	return userIPAddress
+}
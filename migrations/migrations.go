@@ -0,0 +1,218 @@
+package migrations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// versionTable ตารางที่เก็บว่า migration ไหนรันไปแล้ว
+const versionTable = "schema_migrations"
+
+// lockName ใช้ GET_LOCK ของ MySQL กันไม่ให้สอง process migrate พร้อมกัน
+const lockName = "mydayplanner_schema_migrations"
+
+// Migration ขั้นหนึ่งของ schema
+// MySQL commit DDL ทันทีทีละคำสั่ง Up และ Down จึงควรมีผลเพียงอย่างเดียวต่อขั้น
+type Migration struct {
+	Version int
+	Name    string
+	Up      []string
+	Down    []string
+}
+
+// Status สถานะของ migration แต่ละขั้นเทียบกับฐานข้อมูล
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type appliedRow struct {
+	Version   int       `gorm:"column:version"`
+	Name      string    `gorm:"column:name"`
+	AppliedAt time.Time `gorm:"column:applied_at"`
+}
+
+// Migrator รัน migration ตามลำดับ version บนฐานข้อมูลหนึ่ง
+type Migrator struct {
+	db         *gorm.DB
+	migrations []Migration
+}
+
+// New สร้าง Migrator ด้วยชุด migration ทั้งหมดของแอป
+func New(db *gorm.DB) *Migrator {
+	return &Migrator{db: db, migrations: All()}
+}
+
+// All คืน migration ทั้งหมดเรียงตาม version
+func All() []Migration {
+	out := make([]Migration, len(all))
+	copy(out, all)
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out
+}
+
+// Up รัน migration ที่ยังไม่ได้รันทั้งหมด คืนรายการที่รันในครั้งนี้
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for _, mig := range m.migrations {
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := run(conn, mig.Up); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", mig.Version, mig.Name, err)
+			}
+			if err := conn.Table(versionTable).Create(&appliedRow{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now().UTC()}).Error; err != nil {
+				return fmt.Errorf("failed to record migration %d: %w", mig.Version, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Down ย้อน migration ล่าสุดที่รันไปแล้วจำนวน steps ขั้น
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	if steps < 1 {
+		return nil, errors.New("steps must be at least 1")
+	}
+	var done []Migration
+	err := m.withLock(ctx, func(conn *gorm.DB) error {
+		applied, err := appliedVersions(conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			mig := m.migrations[i]
+			if _, ok := applied[mig.Version]; !ok {
+				continue
+			}
+			if err := run(conn, mig.Down); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", mig.Version, mig.Name, err)
+			}
+			if err := conn.Table(versionTable).Where("version = ?", mig.Version).Delete(&appliedRow{}).Error; err != nil {
+				return fmt.Errorf("failed to remove migration record %d: %w", mig.Version, err)
+			}
+			done = append(done, mig)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Status คืนทุก migration พร้อมเวลาที่รัน (nil ถ้ายังไม่รัน)
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	conn := m.db.WithContext(ctx)
+	if err := ensureVersionTable(conn); err != nil {
+		return nil, err
+	}
+	applied, err := appliedVersions(conn)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		st := Status{Migration: mig}
+		if at, ok := applied[mig.Version]; ok {
+			st.AppliedAt = &at
+		}
+		out = append(out, st)
+	}
+	return out, nil
+}
+
+// Version คืน version สูงสุดที่รันแล้ว (0 ถ้ายังไม่มี)
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	conn := m.db.WithContext(ctx)
+	if err := ensureVersionTable(conn); err != nil {
+		return 0, err
+	}
+	var version int
+	if err := conn.Table(versionTable).Select("COALESCE(MAX(version), 0)").Scan(&version).Error; err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// withLock ผูกทุกคำสั่งไว้กับ connection เดียว เพราะ GET_LOCK เป็นของ session
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	if err := validate(m.migrations); err != nil {
+		return err
+	}
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		var got int
+		if err := conn.Raw("SELECT GET_LOCK(?, 30)", lockName).Scan(&got).Error; err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if got != 1 {
+			return errors.New("another migration is running")
+		}
+		defer conn.Exec("SELECT RELEASE_LOCK(?)", lockName)
+
+		if err := ensureVersionTable(conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	})
+}
+
+func ensureVersionTable(conn *gorm.DB) error {
+	err := conn.Exec("CREATE TABLE IF NOT EXISTS `" + versionTable + "` (" +
+		"`version` INT NOT NULL PRIMARY KEY, " +
+		"`name` VARCHAR(255) NOT NULL, " +
+		"`applied_at` DATETIME(3) NOT NULL" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4").Error
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", versionTable, err)
+	}
+	return nil
+}
+
+func appliedVersions(conn *gorm.DB) (map[int]time.Time, error) {
+	var rows []appliedRow
+	if err := conn.Table(versionTable).Order("version").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", versionTable, err)
+	}
+	applied := make(map[int]time.Time, len(rows))
+	for _, row := range rows {
+		applied[row.Version] = row.AppliedAt
+	}
+	return applied, nil
+}
+
+func run(conn *gorm.DB, statements []string) error {
+	for _, stmt := range statements {
+		if err := conn.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validate ตรวจว่า version ไม่ซ้ำและทุกขั้นย้อนกลับได้
+func validate(migrations []Migration) error {
+	seen := make(map[int]bool, len(migrations))
+	for _, mig := range migrations {
+		if mig.Version < 1 {
+			return fmt.Errorf("migration %q has invalid version %d", mig.Name, mig.Version)
+		}
+		if seen[mig.Version] {
+			return fmt.Errorf("duplicate migration version %d", mig.Version)
+		}
+		seen[mig.Version] = true
+		if len(mig.Up) == 0 || len(mig.Down) == 0 {
+			return fmt.Errorf("migration %d_%s must have both up and down steps", mig.Version, mig.Name)
+		}
+	}
+	return nil
+}
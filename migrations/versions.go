@@ -0,0 +1,422 @@
+package migrations
+
+// all schema ของ MySQL ตาม struct ใน package model
+// ห้ามแก้ migration ที่ปล่อยไปแล้ว ให้เพิ่ม version ใหม่ต่อท้ายแทน
+var all = []Migration{
+	{
+		Version: 1,
+		Name:    "create_user",
+		Up: []string{
+			"CREATE TABLE `user` (" +
+				"`user_id` INT NOT NULL AUTO_INCREMENT, " +
+				"`name` VARCHAR(255) NOT NULL, " +
+				"`email` VARCHAR(255) NOT NULL, " +
+				"`hashed_password` VARCHAR(255) NOT NULL, " +
+				"`profile` VARCHAR(255) NULL, " +
+				"`role` ENUM('user','admin') DEFAULT 'user', " +
+				"`is_verify` ENUM('0','1') DEFAULT '0', " +
+				"`is_active` ENUM('0','1','2') DEFAULT '1', " +
+				"`create_at` DATETIME(3) NULL, " +
+				"PRIMARY KEY (`user_id`), " +
+				"UNIQUE KEY `uni_user_email` (`email`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `user`"},
+	},
+	{
+		Version: 2,
+		Name:    "create_board",
+		Up: []string{
+			"CREATE TABLE `board` (" +
+				"`board_id` INT NOT NULL AUTO_INCREMENT, " +
+				"`board_name` VARCHAR(255) NOT NULL, " +
+				"`create_at` DATETIME(3) NULL, " +
+				"`create_by` INT NOT NULL, " +
+				"PRIMARY KEY (`board_id`), " +
+				"CONSTRAINT `fk_board_creator` FOREIGN KEY (`create_by`) REFERENCES `user` (`user_id`) ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `board`"},
+	},
+	{
+		Version: 3,
+		Name:    "create_board_user",
+		Up: []string{
+			"CREATE TABLE `board_user` (" +
+				"`board_user_id` INT NOT NULL AUTO_INCREMENT, " +
+				"`board_id` INT NOT NULL, " +
+				"`user_id` INT NOT NULL, " +
+				"`added_at` DATETIME(3) NULL, " +
+				"PRIMARY KEY (`board_user_id`), " +
+				"CONSTRAINT `fk_board_user_board` FOREIGN KEY (`board_id`) REFERENCES `board` (`board_id`) ON DELETE CASCADE ON UPDATE CASCADE, " +
+				"CONSTRAINT `fk_board_user_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`user_id`) ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `board_user`"},
+	},
+	{
+		Version: 4,
+		Name:    "create_board_token",
+		Up: []string{
+			"CREATE TABLE `board_token` (" +
+				"`token_id` INT NOT NULL AUTO_INCREMENT, " +
+				"`board_id` INT NOT NULL, " +
+				"`token` VARCHAR(255) NOT NULL, " +
+				"`expires_at` DATETIME(3) NULL, " +
+				"`create_at` DATETIME(3) NULL, " +
+				"PRIMARY KEY (`token_id`), " +
+				"CONSTRAINT `fk_board_token_board` FOREIGN KEY (`board_id`) REFERENCES `board` (`board_id`) ON DELETE CASCADE ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `board_token`"},
+	},
+	{
+		Version: 5,
+		Name:    "create_tasks",
+		Up: []string{
+			"CREATE TABLE `tasks` (" +
+				"`task_id` INT NOT NULL AUTO_INCREMENT, " +
+				"`board_id` INT NULL, " +
+				"`task_name` VARCHAR(255) NOT NULL, " +
+				"`description` TEXT NULL, " +
+				"`status` ENUM('0','1','2') NOT NULL DEFAULT '0', " +
+				"`priority` ENUM('1','2','3') NULL, " +
+				"`create_by` INT NULL, " +
+				"`create_at` DATETIME(3) NULL, " +
+				"PRIMARY KEY (`task_id`), " +
+				"CONSTRAINT `fk_tasks_board` FOREIGN KEY (`board_id`) REFERENCES `board` (`board_id`) ON DELETE CASCADE ON UPDATE CASCADE, " +
+				"CONSTRAINT `fk_tasks_creator` FOREIGN KEY (`create_by`) REFERENCES `user` (`user_id`) ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `tasks`"},
+	},
+	{
+		Version: 6,
+		Name:    "create_notification",
+		Up: []string{
+			"CREATE TABLE `notification` (" +
+				"`notification_id` INT NOT NULL AUTO_INCREMENT, " +
+				"`task_id` INT NOT NULL, " +
+				"`due_date` DATETIME(3) NULL, " +
+				"`beforedue_date` DATETIME(3) NULL, " +
+				"`snooze` DATETIME(3) NULL, " +
+				"`recurring_pattern` VARCHAR(255) DEFAULT 'onetime', " +
+				"`is_send` ENUM('0','1','2','3','4') DEFAULT '0', " +
+				"`created_at` DATETIME(3) NULL, " +
+				"PRIMARY KEY (`notification_id`), " +
+				"CONSTRAINT `fk_notification_task` FOREIGN KEY (`task_id`) REFERENCES `tasks` (`task_id`) ON DELETE CASCADE ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `notification`"},
+	},
+	{
+		Version: 7,
+		Name:    "create_checklists",
+		Up: []string{
+			"CREATE TABLE `checklists` (" +
+				"`checklist_id` INT NOT NULL AUTO_INCREMENT, " +
+				"`task_id` INT NOT NULL, " +
+				"`checklist_name` VARCHAR(255) NOT NULL, " +
+				"`status` ENUM('0','1') NOT NULL DEFAULT '0', " +
+				"PRIMARY KEY (`checklist_id`), " +
+				"CONSTRAINT `fk_checklists_task` FOREIGN KEY (`task_id`) REFERENCES `tasks` (`task_id`) ON DELETE CASCADE ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `checklists`"},
+	},
+	{
+		Version: 8,
+		Name:    "create_attachments",
+		Up: []string{
+			"CREATE TABLE `attachments` (" +
+				"`attachment_id` INT NOT NULL AUTO_INCREMENT, " +
+				"`tasks_id` INT NOT NULL, " +
+				"`file_name` VARCHAR(255) NOT NULL, " +
+				"`file_path` VARCHAR(255) NOT NULL, " +
+				"`file_type` ENUM('picture','pdf','link','') NOT NULL, " +
+				"`upload_at` DATETIME(3) NULL, " +
+				"PRIMARY KEY (`attachment_id`), " +
+				"CONSTRAINT `fk_attachments_task` FOREIGN KEY (`tasks_id`) REFERENCES `tasks` (`task_id`) ON DELETE CASCADE ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `attachments`"},
+	},
+	{
+		Version: 9,
+		Name:    "create_reports",
+		Up: []string{
+			"CREATE TABLE `reports` (" +
+				"`report_id` INT NOT NULL AUTO_INCREMENT, " +
+				"`user_id` INT NOT NULL, " +
+				"`description` TEXT NOT NULL, " +
+				"`create_at` DATETIME(3) NULL, " +
+				"`category` ENUM('Suggestions','Incorrect Information','Problems or Issues','Accessibility Issues','Notification Issues','Security Issues') NOT NULL, " +
+				"PRIMARY KEY (`report_id`), " +
+				"CONSTRAINT `fk_reports_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`user_id`) ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `reports`"},
+	},
+	{
+		Version: 10,
+		Name:    "create_rate_limit_buckets",
+		Up: []string{
+			"CREATE TABLE `rate_limit_buckets` (" +
+				"`bucket_key` VARCHAR(255) NOT NULL, " +
+				"`tokens` DOUBLE NOT NULL, " +
+				"`updated_at` DATETIME(6) NOT NULL, " +
+				"PRIMARY KEY (`bucket_key`), " +
+				"KEY `idx_rate_limit_buckets_updated_at` (`updated_at`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `rate_limit_buckets`"},
+	},
+	{
+		Version: 11,
+		Name:    "create_firestore_outbox",
+		Up: []string{
+			"CREATE TABLE `firestore_outbox` (" +
+				"`outbox_id` BIGINT NOT NULL AUTO_INCREMENT, " +
+				"`doc_path` VARCHAR(512) NOT NULL, " +
+				"`op` ENUM('set','merge','update','delete') NOT NULL, " +
+				"`payload` MEDIUMTEXT NULL, " +
+				"`attempts` INT NOT NULL DEFAULT 0, " +
+				"`next_attempt_at` DATETIME(6) NOT NULL, " +
+				"`last_error` TEXT NULL, " +
+				"`created_at` DATETIME(6) NOT NULL, " +
+				"PRIMARY KEY (`outbox_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `firestore_outbox`"},
+	},
+	{
+		Version: 12,
+		Name:    "create_firestore_outbox_dead",
+		Up: []string{
+			"CREATE TABLE `firestore_outbox_dead` (" +
+				"`outbox_id` BIGINT NOT NULL, " +
+				"`doc_path` VARCHAR(512) NOT NULL, " +
+				"`op` ENUM('set','merge','update','delete') NOT NULL, " +
+				"`payload` MEDIUMTEXT NULL, " +
+				"`attempts` INT NOT NULL, " +
+				"`last_error` TEXT NULL, " +
+				"`created_at` DATETIME(6) NOT NULL, " +
+				"`failed_at` DATETIME(6) NOT NULL, " +
+				"PRIMARY KEY (`outbox_id`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `firestore_outbox_dead`"},
+	},
+	{
+		Version: 13,
+		Name:    "create_scheduler_leases",
+		Up: []string{
+			"CREATE TABLE `scheduler_leases` (" +
+				"`name` VARCHAR(64) NOT NULL, " +
+				"`holder` VARCHAR(255) NOT NULL, " +
+				"`expires_at` DATETIME(6) NOT NULL, " +
+				"`renewed_at` DATETIME(6) NOT NULL, " +
+				"PRIMARY KEY (`name`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `scheduler_leases`"},
+	},
+	{
+		Version: 14,
+		Name:    "add_notification_claim",
+		Up: []string{
+			"ALTER TABLE `notification` " +
+				"ADD COLUMN `claimed_by` VARCHAR(255) NULL, " +
+				"ADD COLUMN `claim_expires_at` DATETIME(6) NULL",
+		},
+		Down: []string{"ALTER TABLE `notification` DROP COLUMN `claim_expires_at`, DROP COLUMN `claimed_by`"},
+	},
+	{
+		Version: 15,
+		Name:    "create_notification_attempts",
+		Up: []string{
+			"CREATE TABLE `notification_attempts` (" +
+				"`attempt_id` BIGINT NOT NULL AUTO_INCREMENT, " +
+				"`notification_id` INT NOT NULL, " +
+				"`transition` VARCHAR(32) NOT NULL, " +
+				"`from_state` VARCHAR(8) NOT NULL, " +
+				"`to_state` VARCHAR(8) NOT NULL, " +
+				"`scheduled_for` DATETIME(3) NOT NULL, " +
+				"`attempt` INT NOT NULL, " +
+				"`claimed_by` VARCHAR(255) NOT NULL, " +
+				"`status` ENUM('sending','sent','skipped','failed','interrupted') NOT NULL, " +
+				"`tokens` INT NOT NULL DEFAULT 0, " +
+				"`failed_tokens` INT NOT NULL DEFAULT 0, " +
+				"`error` TEXT NULL, " +
+				"`started_at` DATETIME(6) NOT NULL, " +
+				"`finished_at` DATETIME(6) NULL, " +
+				"PRIMARY KEY (`attempt_id`), " +
+				"UNIQUE KEY `uq_notification_attempts_round` (`notification_id`, `transition`, `scheduled_for`, `attempt`), " +
+				"CONSTRAINT `fk_notification_attempts_notification` FOREIGN KEY (`notification_id`) REFERENCES `notification` (`notification_id`) ON DELETE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `notification_attempts`"},
+	},
+	{
+		Version: 16,
+		Name:    "create_push_token_failures",
+		Up: []string{
+			"CREATE TABLE `push_token_failures` (" +
+				"`token` VARCHAR(512) NOT NULL, " +
+				"`failures` INT NOT NULL DEFAULT 0, " +
+				"`last_error` TEXT NULL, " +
+				"`first_failed_at` DATETIME(6) NOT NULL, " +
+				"`last_failed_at` DATETIME(6) NOT NULL, " +
+				"PRIMARY KEY (`token`)" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `push_token_failures`"},
+	},
+	{
+		Version: 17,
+		Name:    "create_push_devices",
+		Up: []string{
+			"CREATE TABLE `push_devices` (" +
+				"`device_id` BIGINT NOT NULL AUTO_INCREMENT, " +
+				"`user_id` INT NOT NULL, " +
+				"`token` VARCHAR(512) NOT NULL, " +
+				"`platform` ENUM('android','ios','web') NOT NULL, " +
+				"`app_version` VARCHAR(64) NULL, " +
+				"`created_at` DATETIME(6) NOT NULL, " +
+				"`last_seen_at` DATETIME(6) NOT NULL, " +
+				"PRIMARY KEY (`device_id`), " +
+				"UNIQUE KEY `uq_push_devices_token` (`token`), " +
+				"KEY `idx_push_devices_user` (`user_id`, `last_seen_at`), " +
+				"CONSTRAINT `fk_push_devices_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`user_id`) ON DELETE CASCADE ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `push_devices`"},
+	},
+	{
+		Version: 18,
+		Name:    "create_push_deliveries",
+		Up: []string{
+			"CREATE TABLE `push_deliveries` (" +
+				"`delivery_id` BIGINT NOT NULL AUTO_INCREMENT, " +
+				"`user_id` INT NOT NULL, " +
+				"`type` VARCHAR(32) NOT NULL, " +
+				"`notification_id` INT NULL, " +
+				"`attempt_id` BIGINT NULL, " +
+				"`task_id` INT NULL, " +
+				"`board_id` INT NULL, " +
+				"`token` VARCHAR(512) NOT NULL, " +
+				"`status` ENUM('sent','failed') NOT NULL, " +
+				"`message_id` VARCHAR(255) NULL, " +
+				"`reason` VARCHAR(32) NULL, " +
+				"`error` TEXT NULL, " +
+				"`sent_at` DATETIME(6) NOT NULL, " +
+				"PRIMARY KEY (`delivery_id`), " +
+				"KEY `idx_push_deliveries_user` (`user_id`, `delivery_id`), " +
+				"KEY `idx_push_deliveries_task` (`task_id`), " +
+				"KEY `idx_push_deliveries_notification` (`notification_id`), " +
+				"CONSTRAINT `fk_push_deliveries_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`user_id`) ON DELETE CASCADE ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `push_deliveries`"},
+	},
+	{
+		Version: 19,
+		Name:    "create_inbox_items",
+		Up: []string{
+			"CREATE TABLE `inbox_items` (" +
+				"`item_id` BIGINT NOT NULL AUTO_INCREMENT, " +
+				"`user_id` INT NOT NULL, " +
+				"`type` VARCHAR(32) NOT NULL, " +
+				"`title` VARCHAR(255) NOT NULL, " +
+				"`body` VARCHAR(1024) NOT NULL, " +
+				"`task_id` INT NULL, " +
+				"`board_id` INT NULL, " +
+				"`notification_id` INT NULL, " +
+				"`dedup_key` VARCHAR(191) NULL, " +
+				"`created_at` DATETIME(6) NOT NULL, " +
+				"`read_at` DATETIME(6) NULL, " +
+				"`dismissed_at` DATETIME(6) NULL, " +
+				"PRIMARY KEY (`item_id`), " +
+				"UNIQUE KEY `uq_inbox_items_dedup` (`user_id`, `dedup_key`), " +
+				"KEY `idx_inbox_items_user` (`user_id`, `dismissed_at`, `read_at`), " +
+				"CONSTRAINT `fk_inbox_items_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`user_id`) ON DELETE CASCADE ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `inbox_items`"},
+	},
+	{
+		Version: 20,
+		Name:    "add_notification_critical",
+		Up: []string{
+			"ALTER TABLE `notification` ADD COLUMN `critical` TINYINT(1) NOT NULL DEFAULT 0",
+		},
+		Down: []string{"ALTER TABLE `notification` DROP COLUMN `critical`"},
+	},
+	{
+		Version: 21,
+		Name:    "create_quiet_hours",
+		Up: []string{
+			"CREATE TABLE `quiet_hours` (" +
+				"`window_id` BIGINT NOT NULL AUTO_INCREMENT, " +
+				"`user_id` INT NOT NULL, " +
+				"`days` TINYINT UNSIGNED NOT NULL, " +
+				"`start_minute` SMALLINT NOT NULL, " +
+				"`end_minute` SMALLINT NOT NULL, " +
+				"PRIMARY KEY (`window_id`), " +
+				"KEY `idx_quiet_hours_user` (`user_id`), " +
+				"CONSTRAINT `fk_quiet_hours_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`user_id`) ON DELETE CASCADE ON UPDATE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `quiet_hours`"},
+	},
+	{
+		Version: 22,
+		Name:    "create_deferred_pushes",
+		Up: []string{
+			"CREATE TABLE `deferred_pushes` (" +
+				"`push_id` BIGINT NOT NULL AUTO_INCREMENT, " +
+				"`user_id` INT NOT NULL, " +
+				"`notification_id` INT NOT NULL, " +
+				"`attempt_id` BIGINT NULL, " +
+				"`type` VARCHAR(32) NOT NULL, " +
+				"`title` VARCHAR(255) NOT NULL, " +
+				"`body` VARCHAR(1024) NOT NULL, " +
+				"`data` TEXT NOT NULL, " +
+				"`deliver_at` DATETIME(6) NOT NULL, " +
+				"`created_at` DATETIME(6) NOT NULL, " +
+				"PRIMARY KEY (`push_id`), " +
+				"KEY `idx_deferred_pushes_deliver_at` (`deliver_at`), " +
+				"CONSTRAINT `fk_deferred_pushes_user` FOREIGN KEY (`user_id`) REFERENCES `user` (`user_id`) ON DELETE CASCADE ON UPDATE CASCADE, " +
+				"CONSTRAINT `fk_deferred_pushes_notification` FOREIGN KEY (`notification_id`) REFERENCES `notification` (`notification_id`) ON DELETE CASCADE" +
+				") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4",
+		},
+		Down: []string{"DROP TABLE IF EXISTS `deferred_pushes`"},
+	},
+	{
+		Version: 23,
+		Name:    "add_attempt_deferred_status",
+		Up: []string{
+			"ALTER TABLE `notification_attempts` MODIFY COLUMN `status` ENUM('sending','sent','skipped','failed','interrupted','deferred') NOT NULL",
+		},
+		Down: []string{
+			"ALTER TABLE `notification_attempts` MODIFY COLUMN `status` ENUM('sending','sent','skipped','failed','interrupted') NOT NULL",
+		},
+	},
+	{
+		Version: 24,
+		Name:    "add_user_timezone",
+		Up: []string{
+			"ALTER TABLE `user` ADD COLUMN `timezone` VARCHAR(64) NOT NULL DEFAULT 'Asia/Bangkok'",
+		},
+		Down: []string{"ALTER TABLE `user` DROP COLUMN `timezone`"},
+	},
+	{
+		// relay ตรวจว่า path เดียวกันมีแถวก่อนหน้าที่ยังรอลองใหม่หรือไม่ทุกแถวที่อ่าน
+		Version: 25,
+		Name:    "index_firestore_outbox_doc_path",
+		Up: []string{
+			"CREATE INDEX `idx_firestore_outbox_doc_path` ON `firestore_outbox` (`doc_path`, `outbox_id`)",
+		},
+		Down: []string{"DROP INDEX `idx_firestore_outbox_doc_path` ON `firestore_outbox`"},
+	},
+}
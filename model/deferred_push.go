@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// DeferredPush แจ้งเตือนงานที่ถึงกำหนดระหว่าง quiet hours ของผู้รับ ถูกส่งเมื่อถึง DeliverAt
+// transition ของ notification เลื่อนไปแล้วตั้งแต่ตอนเลื่อนการส่ง แถวนี้จึงเป็นแค่ push ที่ค้างอยู่ของผู้รับคนเดียว
+type DeferredPush struct {
+	PushID         int64     `gorm:"column:push_id;primaryKey;autoIncrement"`
+	UserID         int       `gorm:"column:user_id"`
+	NotificationID int       `gorm:"column:notification_id"`
+	AttemptID      *int64    `gorm:"column:attempt_id"`
+	Type           string    `gorm:"column:type"` // before, due, snooze, recurring
+	Title          string    `gorm:"column:title"`
+	Body           string    `gorm:"column:body"`
+	Data           string    `gorm:"column:data"` // JSON ของ push data
+	DeliverAt      time.Time `gorm:"column:deliver_at"`
+	CreatedAt      time.Time `gorm:"column:created_at"`
+}
+
+func (DeferredPush) TableName() string {
+	return "deferred_pushes"
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// InboxItem รายการหนึ่งในกล่องแจ้งเตือนของผู้ใช้ในแอป เป็นข้อมูลหลักแทน flag ใน Firestore
+// (isShow, isNotiRemindShow, notiCount, userNotifications) ซึ่งยังเขียนต่อให้ client รุ่นเก่า
+// DedupKey กันรายการซ้ำเมื่อ scheduler ลองส่ง transition เดิมใหม่ ว่างได้สำหรับรายการที่ไม่ต้องกัน
+type InboxItem struct {
+	ItemID         int64      `gorm:"column:item_id;primaryKey;autoIncrement" json:"item_id"`
+	UserID         int        `gorm:"column:user_id" json:"-"`
+	Type           string     `gorm:"column:type" json:"type"` // before, due, snooze, recurring, invite, accepted, assigned, unassigned
+	Title          string     `gorm:"column:title" json:"title"`
+	Body           string     `gorm:"column:body" json:"body"`
+	TaskID         *int       `gorm:"column:task_id" json:"task_id"`
+	BoardID        *int       `gorm:"column:board_id" json:"board_id"`
+	NotificationID *int       `gorm:"column:notification_id" json:"notification_id"`
+	DedupKey       *string    `gorm:"column:dedup_key" json:"-"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+	ReadAt         *time.Time `gorm:"column:read_at" json:"read_at"`
+	DismissedAt    *time.Time `gorm:"column:dismissed_at" json:"-"`
+}
+
+func (InboxItem) TableName() string {
+	return "inbox_items"
+}
@@ -4,6 +4,19 @@ import (
 	"time"
 )
 
+// สถานะใน is_send ของ notification scheduler เปลี่ยนได้เฉพาะตาม transition ใน controller/notification
+//
+//	Pending --before--> BeforeSent --due--> DueSent
+//	Pending --due (ไม่มี beforedue_date)--> DueSent
+//	Snoozed --snooze--> SnoozeSent
+const (
+	NotifyPending    = "0" // ยังไม่ได้ส่ง
+	NotifyBeforeSent = "1" // ส่งแจ้งเตือนล่วงหน้าแล้ว รอกำหนดส่ง
+	NotifyDueSent    = "2" // ส่งตอนถึงกำหนดแล้ว หรืองานเสร็จแล้ว
+	NotifySnoozed    = "3" // ผู้ใช้เลื่อนการแจ้งเตือน รอถึงเวลา snooze
+	NotifySnoozeSent = "4" // ส่งหลังเลื่อนแล้ว
+)
+
 type Notification struct {
 	NotificationID   int        `gorm:"column:notification_id;primaryKey;autoIncrement"`
 	TaskID           int        `gorm:"column:task_id;not null"`
@@ -11,8 +24,12 @@ type Notification struct {
 	BeforeDueDate    *time.Time `gorm:"column:beforedue_date"`
 	Snooze           *time.Time `gorm:"column:snooze"`
 	RecurringPattern string     `gorm:"column:recurring_pattern;type:varchar(255);default:'onetime'"`
+	Critical         bool       `gorm:"column:critical"`                                           // แจ้งเตือน due ส่งแม้อยู่ใน quiet hours ของผู้รับ
 	IsSend           string     `gorm:"column:is_send;type:enum('0','1','2','3','4');default:'0'"` // enum string
 	CreatedAt        time.Time  `gorm:"column:created_at;autoCreateTime"`
+	// worker ที่กำลังส่ง notification นี้ และเวลาที่ claim หมดอายุให้ worker อื่นรับต่อได้
+	ClaimedBy      *string    `gorm:"column:claimed_by"`
+	ClaimExpiresAt *time.Time `gorm:"column:claim_expires_at"`
 
 	// Relations
 	Task Tasks `gorm:"foreignKey:TaskID;references:TaskID;constraint:OnDelete:CASCADE,OnUpdate:CASCADE"`
@@ -0,0 +1,36 @@
+package model
+
+import "time"
+
+// สถานะของ NotificationAttempt
+const (
+	AttemptSending     = "sending"     // claim แล้ว กำลังส่ง
+	AttemptSent        = "sent"        // ส่งสำเร็จอย่างน้อยหนึ่ง token และเลื่อน is_send แล้ว
+	AttemptSkipped     = "skipped"     // ไม่มี token เลื่อน is_send โดยไม่ส่ง
+	AttemptFailed      = "failed"      // ส่งไม่สำเร็จ is_send ไม่เปลี่ยน รอบถัดไปลองใหม่
+	AttemptInterrupted = "interrupted" // worker หยุดกลางทางโดยไม่รู้ผล ไม่ส่งซ้ำ
+	AttemptDeferred    = "deferred"    // ผู้รับทุกคนอยู่ใน quiet hours push ถูกเลื่อนไป deferred_pushes และเลื่อน is_send แล้ว
+)
+
+// NotificationAttempt การส่งหนึ่งครั้งของ transition หนึ่งของ notification
+// ScheduledFor คือเวลาที่ transition ถึงกำหนด แยกรอบเมื่อ notification ถูกตั้งเวลาใหม่
+type NotificationAttempt struct {
+	AttemptID      int64      `gorm:"column:attempt_id;primaryKey;autoIncrement"`
+	NotificationID int        `gorm:"column:notification_id"`
+	Transition     string     `gorm:"column:transition"` // before, due, snooze, recurring
+	FromState      string     `gorm:"column:from_state"`
+	ToState        string     `gorm:"column:to_state"`
+	ScheduledFor   time.Time  `gorm:"column:scheduled_for"`
+	Attempt        int        `gorm:"column:attempt"`
+	ClaimedBy      string     `gorm:"column:claimed_by"`
+	Status         string     `gorm:"column:status"`
+	Tokens         int        `gorm:"column:tokens"`
+	FailedTokens   int        `gorm:"column:failed_tokens"`
+	Error          *string    `gorm:"column:error"`
+	StartedAt      time.Time  `gorm:"column:started_at"`
+	FinishedAt     *time.Time `gorm:"column:finished_at"`
+}
+
+func (NotificationAttempt) TableName() string {
+	return "notification_attempts"
+}
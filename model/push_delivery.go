@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// สถานะของ PushDelivery
+const (
+	DeliverySent   = "sent"   // provider รับไปส่งแล้ว
+	DeliveryFailed = "failed" // provider ปฏิเสธหรือส่งไม่ได้
+)
+
+// PushDelivery ผลการส่ง push หนึ่งข้อความไปยังเครื่องหนึ่งของผู้รับ
+// ต่างจาก NotificationAttempt ที่เป็นการส่งหนึ่งครั้งของ notification ซึ่งมีได้หลายผู้รับและหลายเครื่อง
+// NotificationID และ AttemptID มีเฉพาะแจ้งเตือนจาก scheduler ส่วน push จาก notify.go มีแค่ task หรือ board
+type PushDelivery struct {
+	DeliveryID     int64     `gorm:"column:delivery_id;primaryKey;autoIncrement" json:"delivery_id"`
+	UserID         int       `gorm:"column:user_id" json:"user_id"`
+	Type           string    `gorm:"column:type" json:"type"` // before, due, snooze, recurring, invite, accepted, assigned, unassigned
+	NotificationID *int      `gorm:"column:notification_id" json:"notification_id"`
+	AttemptID      *int64    `gorm:"column:attempt_id" json:"attempt_id"`
+	TaskID         *int      `gorm:"column:task_id" json:"task_id"`
+	BoardID        *int      `gorm:"column:board_id" json:"board_id"`
+	Token          string    `gorm:"column:token" json:"token"`
+	Status         string    `gorm:"column:status" json:"status"`
+	MessageID      *string   `gorm:"column:message_id" json:"message_id"`
+	Reason         *string   `gorm:"column:reason" json:"reason"` // ประเภทของ error ตาม push.Classify
+	Error          *string   `gorm:"column:error" json:"error"`
+	SentAt         time.Time `gorm:"column:sent_at" json:"sent_at"`
+}
+
+func (PushDelivery) TableName() string {
+	return "push_deliveries"
+}
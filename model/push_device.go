@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// PushDevice เครื่องหนึ่งเครื่องที่รับ push ของผู้ใช้ token หนึ่งเป็นของผู้ใช้คนเดียวเสมอ
+type PushDevice struct {
+	DeviceID   int64     `gorm:"column:device_id;primaryKey;autoIncrement" json:"device_id"`
+	UserID     int       `gorm:"column:user_id" json:"-"`
+	Token      string    `gorm:"column:token;unique" json:"token"`
+	Platform   string    `gorm:"column:platform" json:"platform"` // android, ios, web
+	AppVersion *string   `gorm:"column:app_version" json:"app_version"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+	LastSeenAt time.Time `gorm:"column:last_seen_at" json:"last_seen_at"`
+}
+
+func (PushDevice) TableName() string {
+	return "push_devices"
+}
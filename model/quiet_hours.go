@@ -0,0 +1,16 @@
+package model
+
+// QuietHourWindow ช่วงเวลาที่ผู้ใช้ไม่ต้องการรับแจ้งเตือนที่ไม่เร่งด่วน ตามเวลาท้องถิ่นในเขตเวลาของผู้ใช้ (user.timezone)
+// Days เป็น bit ของวันที่ช่วงเริ่ม (bit 0 = อาทิตย์ ตาม time.Weekday) ช่วงที่ EndMinute <= StartMinute ข้ามเที่ยงคืน
+// และ StartMinute == EndMinute คือทั้งวัน
+type QuietHourWindow struct {
+	WindowID    int64 `gorm:"column:window_id;primaryKey;autoIncrement"`
+	UserID      int   `gorm:"column:user_id"`
+	Days        uint8 `gorm:"column:days"`
+	StartMinute int   `gorm:"column:start_minute"`
+	EndMinute   int   `gorm:"column:end_minute"`
+}
+
+func (QuietHourWindow) TableName() string {
+	return "quiet_hours"
+}
@@ -13,6 +13,7 @@ type User struct {
 	Role           string    `gorm:"column:role;type:enum('user','admin');default:'user'"`
 	IsVerify       string    `gorm:"column:is_verify;type:enum('0','1');default:0"`
 	IsActive       string    `gorm:"column:is_active;type:enum('0','1','2');default:'1'"`
+	Timezone       string    `gorm:"column:timezone;type:varchar(64);default:'Asia/Bangkok'"` // ชื่อเขตเวลา IANA
 	CreatedAt      time.Time `gorm:"column:create_at;autoCreateTime"`
 }
 
@@ -0,0 +1,363 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"mydayplanner/apperror"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Document เอกสาร OpenAPI 3 เฉพาะส่วนที่ API นี้ใช้
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components Components           `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem operation ของ path หนึ่ง แยกตาม HTTP method (key เป็นตัวพิมพ์เล็ก)
+type PathItem map[string]*Operation
+
+type Operation struct {
+	Tags        []string              `json:"tags"`
+	Summary     string                `json:"summary"`
+	OperationID string                `json:"operationId"`
+	Description string                `json:"description,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Roles       []string              `json:"x-roles,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+// Schema JSON Schema แบบย่อตามที่ OpenAPI 3.0 รองรับ
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+}
+
+// Build สร้างเอกสารจาก route ที่ลงทะเบียนไว้ใน gin กับตาราง Routes และ Legacy
+// คืน error ถ้ามี route ที่ไม่มีในตาราง มีในตารางแต่ไม่ได้ลงทะเบียนจริง หรือ alias ชี้ไปยัง route ที่ไม่มี
+func Build(registered gin.RoutesInfo) (*Document, error) {
+	byKey := make(map[string]Route, len(Routes))
+	for _, r := range Routes {
+		byKey[r.Method+" "+r.Path] = r
+	}
+	aliases := make(map[string]Alias, len(Legacy))
+	for _, a := range Legacy {
+		aliases[a.Method+" "+a.Path] = a
+	}
+
+	doc := &Document{
+		OpenAPI: "3.0.3",
+		Info:    Info{Title: "MydayPlanner API", Version: Version},
+		Paths:   map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]SecurityScheme{
+				"accessToken": {
+					Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+					Description: "Access token from /auth/signin or /auth/newaccesstoken.",
+				},
+				"refreshToken": {
+					Type: "http", Scheme: "bearer", BearerFormat: "JWT",
+					Description: "Refresh token, accepted only by /auth/newaccesstoken.",
+				},
+			},
+		},
+	}
+	g := &generator{schemas: doc.Components.Schemas}
+	for _, v := range Schemas {
+		g.schemaOf(reflect.TypeOf(v))
+	}
+	errorRef := g.schemaOf(reflect.TypeOf(ErrorResponse{}))
+	codeSchema := doc.Components.Schemas["ErrorResponse"].Properties["code"]
+	for _, code := range apperror.Codes() {
+		codeSchema.Enum = append(codeSchema.Enum, string(code))
+	}
+
+	var problems []string
+	seen := map[string]bool{}
+	for _, ri := range registered {
+		key := ri.Method + " " + ri.Path
+		seen[key] = true
+		r, ok := byKey[key]
+		alias, deprecated := aliases[key]
+		if deprecated {
+			r, ok = byKey[alias.Method+" "+alias.Successor]
+			if !ok {
+				problems = append(problems, "successor of "+key+" not in openapi.Routes: "+alias.Successor)
+				continue
+			}
+			if alias.Body != nil {
+				r.Body = alias.Body
+			}
+		} else if !ok {
+			problems = append(problems, "route not documented in openapi.Routes: "+key)
+			continue
+		}
+
+		path, params := convertPath(ri.Path)
+		op := &Operation{
+			Tags:        []string{r.Tag},
+			Summary:     r.Summary,
+			OperationID: operationID(ri.Method, ri.Path),
+			Parameters:  params,
+			Responses: map[string]Response{
+				"200": {Description: "OK"},
+				"default": {
+					Description: "Error",
+					Content:     map[string]MediaType{"application/json": {Schema: errorRef}},
+				},
+			},
+		}
+		if deprecated {
+			successor, _ := convertPath(alias.Successor)
+			op.Deprecated = true
+			op.Description = "Use " + alias.Method + " " + successor + " instead."
+		}
+		if r.Body != nil && ri.Method == http.MethodGet {
+			op.Parameters = append(op.Parameters, g.queryParams(reflect.TypeOf(r.Body))...)
+		} else if r.Body != nil {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: g.schemaOf(reflect.TypeOf(r.Body))}},
+			}
+		}
+		switch r.Auth {
+		case AuthAccess, AuthAdmin:
+			op.Security = []map[string][]string{{"accessToken": {}}}
+		case AuthRefresh:
+			op.Security = []map[string][]string{{"refreshToken": {}}}
+		}
+		if r.Auth == AuthAdmin {
+			op.Roles = []string{"admin"}
+		}
+
+		item := doc.Paths[path]
+		if item == nil {
+			item = &PathItem{}
+			doc.Paths[path] = item
+		}
+		(*item)[strings.ToLower(ri.Method)] = op
+	}
+	for _, r := range Routes {
+		if !seen[r.Method+" "+r.Path] {
+			problems = append(problems, "route in openapi.Routes is not registered: "+r.Method+" "+r.Path)
+		}
+	}
+	for _, a := range Legacy {
+		if !seen[a.Method+" "+a.Path] {
+			problems = append(problems, "route in openapi.Legacy is not registered: "+a.Method+" "+a.Path)
+		}
+	}
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return nil, fmt.Errorf("openapi: %s", strings.Join(problems, "; "))
+	}
+	return doc, nil
+}
+
+// convertPath แปลง /board/:boardId เป็น /board/{boardId} และคืน path parameter
+func convertPath(path string) (string, []Parameter) {
+	var params []Parameter
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if strings.HasPrefix(seg, ":") || strings.HasPrefix(seg, "*") {
+			name := seg[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID สร้างชื่อคงที่จาก method และ path เช่น PUT /board/newtoken/:boardId -> putBoardNewtokenBoardId
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	segments := strings.FieldsFunc(path, func(r rune) bool { return strings.ContainsRune("/:*_.", r) })
+	if len(segments) == 0 {
+		segments = []string{"root"}
+	}
+	for _, seg := range segments {
+		b.WriteString(strings.ToUpper(seg[:1]) + seg[1:])
+	}
+	return b.String()
+}
+
+type generator struct {
+	schemas map[string]*Schema
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf คืน schema ของ type โดย struct ที่มีชื่อจะถูกเก็บใน components แล้วอ้างด้วย $ref
+func (g *generator) schemaOf(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var s *Schema
+	switch {
+	case t == timeType:
+		s = &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		if _, ok := g.schemas[t.Name()]; !ok {
+			g.schemas[t.Name()] = nil // กัน type ที่อ้างถึงตัวเอง
+			g.schemas[t.Name()] = g.structSchema(t)
+		}
+		s = &Schema{Ref: "#/components/schemas/" + t.Name()}
+	case t.Kind() == reflect.Struct:
+		s = g.structSchema(t)
+	case t.Kind() == reflect.Slice || t.Kind() == reflect.Array:
+		s = &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case t.Kind() == reflect.Map:
+		s = &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case t.Kind() == reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case t.Kind() >= reflect.Int && t.Kind() <= reflect.Uint64:
+		s = &Schema{Type: "integer"}
+		if t.Kind() == reflect.Int64 || t.Kind() == reflect.Uint64 {
+			s.Format = "int64"
+		}
+	case t.Kind() == reflect.Float32 || t.Kind() == reflect.Float64:
+		s = &Schema{Type: "number"}
+	case t.Kind() == reflect.String:
+		s = &Schema{Type: "string"}
+	default:
+		s = &Schema{}
+	}
+
+	// $ref ใส่ keyword อื่นคู่กันไม่ได้ใน OpenAPI 3.0 จึงไม่ใส่ nullable ให้ struct ที่อ้างผ่าน $ref
+	if nullable && s.Ref == "" {
+		s.Nullable = true
+	}
+	return s
+}
+
+// structSchema อ่าน field ตาม tag json แบบเดียวกับ encoding/json
+// และถือว่า field ที่ binding มี required เป็น field บังคับ (tag validate ไม่ถูก gin ตรวจ จึงไม่นับ)
+func (g *generator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n, _, _ := strings.Cut(tag, ","); n != "" {
+				name = n
+			}
+		}
+
+		fs := g.schemaOf(f.Type)
+		for _, rule := range strings.Split(f.Tag.Get("binding"), ",") {
+			key, value, _ := strings.Cut(rule, "=")
+			switch key {
+			case "required":
+				s.Required = append(s.Required, name)
+			case "email":
+				fs.Format = "email"
+			case "min", "max":
+				var n int
+				if _, err := fmt.Sscan(value, &n); err == nil && fs.Type == "string" {
+					if key == "min" {
+						fs.MinLength = &n
+					} else {
+						fs.MaxLength = &n
+					}
+				}
+			}
+		}
+		s.Properties[name] = fs
+	}
+	return s
+}
+
+// queryParams query parameter ของ DTO ที่ bind ด้วย ShouldBindQuery ใช้ชื่อจาก tag form
+func (g *generator) queryParams(t reflect.Type) []Parameter {
+	var params []Parameter
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("form"), ",")
+		if !f.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		schema := g.schemaOf(f.Type)
+		schema.Nullable = false
+		required := slices.Contains(strings.Split(f.Tag.Get("binding"), ","), "required")
+		params = append(params, Parameter{Name: name, In: "query", Required: required, Schema: schema})
+	}
+	return params
+}
+
+// Marshal เขียนเอกสารแบบจัดย่อหน้า key ของ map ถูกเรียงโดย encoding/json จึงได้ผลเหมือนเดิมทุกครั้ง
+func Marshal(doc *Document) ([]byte, error) {
+	b, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(b, '\n'), nil
+}
@@ -0,0 +1,25 @@
+// Package openapi สร้างและให้บริการเอกสาร OpenAPI 3 ของ API จาก route table ของ gin และ DTO
+//
+// แก้ route หรือ DTO แล้วให้รัน go generate ./openapi เพื่ออัปเดต openapi.json
+// ถ้าลืม test ใน package นี้จะล้มเหลว
+package openapi
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:generate go run ../cmd/openapi -o openapi.json
+
+// Spec เอกสารที่สร้างไว้แล้ว ฝังมากับ binary
+//
+//go:embed openapi.json
+var Spec []byte
+
+func OpenAPIController(router *gin.Engine) {
+	router.GET("/openapi.json", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", Spec)
+	})
+}
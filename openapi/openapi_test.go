@@ -0,0 +1,84 @@
+package openapi_test
+
+import (
+	"bytes"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"mydayplanner/config"
+	"mydayplanner/connection"
+	"mydayplanner/openapi"
+	"slices"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func buildDocument(t *testing.T) *openapi.Document {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	router := connection.NewRouter(&connection.Deps{Config: &config.Config{}})
+	doc, err := openapi.Build(router.Routes())
+	if err != nil {
+		t.Fatalf("%v\nupdate openapi.Routes to match the registered routes", err)
+	}
+	return doc
+}
+
+// เปลี่ยน route หรือ DTO โดยไม่รัน go generate ./openapi แล้ว test นี้ต้องล้ม
+func TestSpecIsUpToDate(t *testing.T) {
+	got, err := openapi.Marshal(buildDocument(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, openapi.Spec) {
+		t.Fatal("openapi/openapi.json is out of date; run: go generate ./openapi")
+	}
+}
+
+// DTO ใหม่ใน package dto ต้องถูกเพิ่มใน openapi.Schemas ด้วย
+func TestSchemasCoverDTOPackage(t *testing.T) {
+	doc := buildDocument(t)
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, "../dto", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pkg := range pkgs {
+		for name, file := range pkg.Files {
+			for _, decl := range file.Decls {
+				gen, ok := decl.(*ast.GenDecl)
+				if !ok || gen.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gen.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if _, isStruct := ts.Type.(*ast.StructType); !isStruct || !ts.Name.IsExported() {
+						continue
+					}
+					if _, ok := doc.Components.Schemas[ts.Name.Name]; !ok {
+						t.Errorf("dto.%s (%s) is missing from openapi.Schemas", ts.Name.Name, name)
+					}
+				}
+			}
+		}
+	}
+}
+
+// DTO ของ GET เป็น query parameter ไม่ใช่ request body
+func TestGetBodyIsDocumentedAsQuery(t *testing.T) {
+	op := (*buildDocument(t).Paths["/v1/users/me/deliveries"])["get"]
+	if op == nil || op.RequestBody != nil {
+		t.Fatalf("operation = %+v, want a GET without request body", op)
+	}
+	var names []string
+	for _, p := range op.Parameters {
+		if p.In == "query" {
+			names = append(names, p.Name)
+		}
+	}
+	if !slices.Equal(names, []string{"task_id", "notification_id", "type", "before", "limit"}) {
+		t.Fatalf("query parameters = %v", names)
+	}
+}
@@ -0,0 +1,268 @@
+package openapi
+
+import (
+	"mydayplanner/apperror"
+	"mydayplanner/dto"
+)
+
+// Version เวอร์ชันของ API ที่แสดงใน info.version
+const Version = "1.0.0"
+
+// Auth ระดับสิทธิ์ที่ route ต้องการ ตาม middleware ที่ controller ใส่ไว้
+type Auth int
+
+const (
+	AuthNone    Auth = iota
+	AuthAccess       // AccessTokenMiddleware
+	AuthRefresh      // RefreshTokenMiddleware
+	AuthAdmin        // AccessTokenMiddleware + AdminMiddleware
+)
+
+// Route ข้อมูลของ route หนึ่งที่ gin ไม่มีให้ ต้องแก้คู่กับ controller ทุกครั้ง
+// Body คือค่า zero ของ DTO ที่ handler ใช้ ShouldBindJSON (nil ถ้าไม่มี body)
+// สำหรับ GET คือ DTO ที่ใช้ ShouldBindQuery และเอกสารเป็น query parameter ตาม tag form
+type Route struct {
+	Method  string
+	Path    string
+	Tag     string
+	Summary string
+	Body    any
+	Auth    Auth
+}
+
+// ErrorResponse รูปแบบ error ที่ทุก handler ตอบกลับผ่าน apperror.Respond
+type ErrorResponse apperror.Response
+
+// Routes ทุก route ที่ลงทะเบียนใน connection.NewRouter ยกเว้น route เดิมที่อยู่ใน Legacy เรียงตาม resource
+var Routes = []Route{
+	{"GET", "/", "system", "Liveness message", nil, AuthNone},
+	{"GET", "/healthz", "system", "Process liveness", nil, AuthNone},
+	{"GET", "/readyz", "system", "Readiness of MySQL, Firestore and the notification job", nil, AuthNone},
+	{"GET", "/metrics", "system", "Prometheus metrics", nil, AuthNone},
+	{"GET", "/openapi.json", "system", "This document", nil, AuthNone},
+
+	{"POST", "/v1/auth/signin", "auth", "Sign in with email and password", dto.SigninRequest{}, AuthNone},
+	{"POST", "/v1/auth/signup", "auth", "Create an account", dto.SignupRequest{}, AuthNone},
+	{"POST", "/v1/auth/signout", "auth", "Sign out and revoke the refresh token", nil, AuthAccess},
+	{"POST", "/v1/auth/token", "auth", "Exchange a refresh token for a new access token", nil, AuthRefresh},
+	{"POST", "/v1/auth/google", "auth", "Sign in with Google", dto.GoogleSignInRequest{}, AuthNone},
+	{"PUT", "/v1/auth/password", "auth", "Set a new password after OTP verification", dto.ResetPasswordRequest{}, AuthNone},
+	{"POST", "/v1/auth/captcha", "auth", "Verify a reCAPTCHA token", dto.CaptchaRequest{}, AuthNone},
+	{"POST", "/v1/auth/otp/identity", "auth", "Send an identity verification OTP", dto.IdentityOTPRequest{}, AuthNone},
+	{"POST", "/v1/auth/otp/password-reset", "auth", "Send a password reset OTP", dto.ResetpasswordOTPRequest{}, AuthNone},
+	{"POST", "/v1/auth/otp/email", "auth", "Send an OTP email", dto.SendemailRequest{}, AuthNone},
+	{"POST", "/v1/auth/otp/resend", "auth", "Resend an OTP", dto.ResendOTPRequest{}, AuthNone},
+	{"PUT", "/v1/auth/otp/verify", "auth", "Verify an OTP", dto.VerifyRequest{}, AuthNone},
+
+	{"POST", "/v1/users/lookup", "user", "Look up a user by email", dto.EmailRequest{}, AuthNone},
+	{"GET", "/v1/users", "user", "List users", nil, AuthAccess},
+	{"POST", "/v1/users/search", "user", "Search users by email", dto.EmailText{}, AuthAccess},
+	{"GET", "/v1/users/me", "user", "Current user with boards and tasks", nil, AuthAccess},
+	{"PUT", "/v1/users/me/profile", "user", "Update the current profile", dto.UpdateProfileRequest{}, AuthAccess},
+	{"PUT", "/v1/users/me/password", "user", "Change or remove the password", dto.PasswordRequest{}, AuthAccess},
+	{"DELETE", "/v1/users/me", "user", "Delete the current account", nil, AuthAccess},
+	{"GET", "/v1/users/me/quiet-hours", "user", "Get the current user's quiet hours", nil, AuthAccess},
+	{"PUT", "/v1/users/me/quiet-hours", "user", "Replace the current user's quiet hours", dto.QuietHoursRequest{}, AuthAccess},
+	{"GET", "/v1/devices", "user", "List the current user's push devices", nil, AuthAccess},
+	{"POST", "/v1/devices", "user", "Register or refresh a push device", dto.RegisterDeviceRequest{}, AuthAccess},
+	{"DELETE", "/v1/devices", "user", "Unregister a push device", dto.UnregisterDeviceRequest{}, AuthAccess},
+	{"GET", "/v1/users/me/deliveries", "notification", "List push deliveries to the current user", dto.DeliveryQuery{}, AuthAccess},
+
+	{"GET", "/v1/inbox", "inbox", "List inbox items", dto.InboxQuery{}, AuthAccess},
+	{"GET", "/v1/inbox/unread-count", "inbox", "Count unread inbox items", nil, AuthAccess},
+	{"PUT", "/v1/inbox/read", "inbox", "Mark every inbox item as read", nil, AuthAccess},
+	{"PUT", "/v1/inbox/:itemid/read", "inbox", "Mark an inbox item as read", nil, AuthAccess},
+	{"DELETE", "/v1/inbox/:itemid", "inbox", "Dismiss an inbox item", nil, AuthAccess},
+
+	{"PUT", "/v1/admin/users/:id/active", "admin", "Toggle whether an account is active", nil, AuthAdmin},
+	{"PUT", "/v1/admin/users/:id/deleted", "admin", "Toggle whether an account is deleted", nil, AuthAdmin},
+	{"POST", "/v1/admin/admins", "admin", "Create an admin account", dto.AdminRequest{}, AuthAdmin},
+	{"POST", "/v1/admin/reconcile", "admin", "Compare Firestore mirrors with MySQL and optionally repair them", dto.ReconcileRequest{}, AuthAdmin},
+	{"GET", "/v1/admin/users/:id/deliveries", "admin", "List push deliveries to a user", dto.DeliveryQuery{}, AuthAdmin},
+
+	{"GET", "/v1/reports", "report", "List all reports", nil, AuthAdmin},
+	{"GET", "/v1/reports/categories/:categoryid", "report", "List reports in a category", nil, AuthAdmin},
+	{"POST", "/v1/reports", "report", "Send a report", dto.ReportdataRequest{}, AuthAccess},
+	{"DELETE", "/v1/reports/:rid", "report", "Delete a report", nil, AuthAdmin},
+
+	{"POST", "/v1/boards", "board", "Create a board", dto.CreateBoardRequest{}, AuthAccess},
+	{"DELETE", "/v1/boards", "board", "Delete boards", dto.DeleteBoardRequest{}, AuthAccess},
+	{"PUT", "/v1/boards/:boardid", "board", "Rename a board", dto.AdjustBoardRequest{}, AuthAccess},
+	{"POST", "/v1/boards/:boardid/members", "board", "Join a board", nil, AuthAccess},
+	{"DELETE", "/v1/boards/:boardid/members/:userid", "board", "Remove a member from a board", nil, AuthAccess},
+	{"POST", "/v1/boards/:boardid/invites", "board", "Invite a user to a board", dto.InviteBoardRequest{}, AuthAccess},
+	{"POST", "/v1/invites/:inviteid", "board", "Accept or decline a board invitation", dto.AcceptBoardRequest{}, AuthAccess},
+	{"PUT", "/v1/boards/:boardid/token", "board", "Create or refresh a board share token", nil, AuthAccess},
+	{"POST", "/v1/boards/:boardid/share", "board", "Create a board share link", nil, AuthAccess},
+
+	{"GET", "/v1/boards/:boardid/tasks", "task", "List tasks in a board", nil, AuthAccess},
+	{"POST", "/v1/boards/:boardid/tasks", "task", "Create a task in a board", dto.CreateTodayTaskRequest{}, AuthAccess},
+	{"POST", "/v1/tasks", "task", "Create a today task", dto.CreateTodayTaskRequest{}, AuthAccess},
+	{"GET", "/v1/tasks/today", "task", "List tasks due on a day in the user's time zone", dto.TodayQuery{}, AuthAccess},
+	{"DELETE", "/v1/tasks", "task", "Delete tasks", dto.DeletetaskRequest{}, AuthAccess},
+	{"PUT", "/v1/tasks/:taskid", "task", "Update a task", dto.AdjustTaskRequest{}, AuthAccess},
+	{"DELETE", "/v1/tasks/:taskid", "task", "Delete a task", nil, AuthAccess},
+	{"PUT", "/v1/tasks/:taskid/finish", "task", "Toggle task completion", nil, AuthAccess},
+	{"PUT", "/v1/tasks/:taskid/status", "task", "Set task status", dto.StatusRequest{}, AuthAccess},
+	{"PUT", "/v1/tasks/:taskid/done", "task", "Mark a task as done", nil, AuthAccess},
+	{"POST", "/v1/tasks/:taskid/assignees", "task", "Assign a task to a board member", dto.AssigneeRequest{}, AuthAccess},
+	{"DELETE", "/v1/tasks/:taskid/assignees/:assignid", "task", "Remove a task assignment", nil, AuthAccess},
+
+	{"POST", "/v1/tasks/:taskid/checklists", "checklist", "Add a checklist item", dto.CreateChecklistTaskRequest{}, AuthAccess},
+	{"DELETE", "/v1/tasks/:taskid/checklists", "checklist", "Delete checklist items", dto.DeleteChecklistRequest{}, AuthAccess},
+	{"PUT", "/v1/tasks/:taskid/checklists/:checklistid", "checklist", "Rename a checklist item", dto.UpdateChecklistRequest{}, AuthAccess},
+	{"DELETE", "/v1/tasks/:taskid/checklists/:checklistid", "checklist", "Delete a checklist item", nil, AuthAccess},
+	{"PUT", "/v1/tasks/:taskid/checklists/:checklistid/finish", "checklist", "Toggle checklist item completion", nil, AuthAccess},
+
+	{"POST", "/v1/tasks/:taskid/attachments", "attachment", "Add an attachment", dto.CreateAttachmentsTaskRequest{}, AuthAccess},
+	{"DELETE", "/v1/tasks/:taskid/attachments/:attachmentid", "attachment", "Delete an attachment", nil, AuthAccess},
+
+	{"PUT", "/v1/tasks/:taskid/reminder", "notification", "Update a task reminder", dto.UpdateNotificationRequest{}, AuthAccess},
+	{"PUT", "/v1/tasks/:taskid/reminder/snooze", "notification", "Snooze a task reminder", nil, AuthNone},
+	{"POST", "/v1/push/board-invites", "notification", "Push a board invitation", dto.InviteNotify{}, AuthAccess},
+	{"POST", "/v1/push/boards/:boardid/accepted", "notification", "Push an accepted invitation to board members", nil, AuthAccess},
+	{"POST", "/v1/push/assignments", "notification", "Push a task assignment", dto.AssignedNotify{}, AuthAccess},
+	{"POST", "/v1/push/unassignments", "notification", "Push a task unassignment", dto.UnAssignedNotify{}, AuthAccess},
+	{"POST", "/v1/jobs/notifications", "notification", "Run the notification job once", nil, AuthNone},
+}
+
+// Alias route เดิมก่อนมี /v1 ที่ยังลงทะเบียนไว้พร้อม middleware.Deprecated
+// Successor คือ path ใน Routes ที่ใช้ method เดียวกัน เอกสารของ alias คัดลอกจาก successor แล้วทำเครื่องหมาย deprecated
+// Body ใช้แทน body ของ successor เมื่อ route เดิมรับ id ใน body แทน path
+type Alias struct {
+	Method    string
+	Path      string
+	Successor string
+	Body      any
+}
+
+// Legacy route เดิมทั้งหมด ต้องตรงกับ successor ที่ส่งให้ middleware.Deprecated ใน controller
+var Legacy = []Alias{
+	{"POST", "/auth/signin", "/v1/auth/signin", nil},
+	{"POST", "/auth/signup", "/v1/auth/signup", nil},
+	{"POST", "/auth/signout", "/v1/auth/signout", nil},
+	{"POST", "/auth/newaccesstoken", "/v1/auth/token", nil},
+	{"POST", "/auth/googlelogin", "/v1/auth/google", nil},
+	{"PUT", "/auth/resetpassword", "/v1/auth/password", nil},
+	{"POST", "/auth/captcha", "/v1/auth/captcha", nil},
+	{"POST", "/auth/IdentityOTP", "/v1/auth/otp/identity", nil},
+	{"POST", "/auth/resetpasswordOTP", "/v1/auth/otp/password-reset", nil},
+	{"POST", "/auth/sendemail", "/v1/auth/otp/email", nil},
+	{"POST", "/auth/resendotp", "/v1/auth/otp/resend", nil},
+	{"PUT", "/auth/verifyOTP", "/v1/auth/otp/verify", nil},
+
+	{"POST", "/email", "/v1/users/lookup", nil},
+	{"GET", "/user/alluser", "/v1/users", nil},
+	{"POST", "/user/search", "/v1/users/search", nil},
+	{"GET", "/user/data", "/v1/users/me", nil},
+	{"PUT", "/user/profile", "/v1/users/me/profile", nil},
+	{"PUT", "/user/removepassword", "/v1/users/me/password", nil},
+	{"DELETE", "/user/account", "/v1/users/me", nil},
+
+	{"PUT", "/admin/disableactive/:id", "/v1/admin/users/:id/active", nil},
+	{"PUT", "/admin/deleteaccount/:id", "/v1/admin/users/:id/deleted", nil},
+	{"POST", "/admin/createadmin", "/v1/admin/admins", nil},
+
+	{"GET", "/report/allreport", "/v1/reports", nil},
+	{"GET", "/report/category/:categoryid", "/v1/reports/categories/:categoryid", nil},
+	{"POST", "/report/send", "/v1/reports", nil},
+	{"DELETE", "/report/delete/:rid", "/v1/reports/:rid", nil},
+
+	{"POST", "/board", "/v1/boards", nil},
+	{"DELETE", "/board", "/v1/boards", nil},
+	{"PUT", "/board/adjust", "/v1/boards/:boardid", nil},
+	{"POST", "/board/addboard/:boardid", "/v1/boards/:boardid/members", nil},
+	{"DELETE", "/board/boarduser", "/v1/boards/:boardid/members/:userid", dto.BoarduserRequest{}},
+	{"POST", "/board/invite", "/v1/boards/:boardid/invites", nil},
+	{"POST", "/board/accept", "/v1/invites/:inviteid", nil},
+	{"PUT", "/board/newtoken/:boardid", "/v1/boards/:boardid/token", nil},
+	{"POST", "/shareboard/create/:boardid", "/v1/boards/:boardid/share", nil},
+
+	{"GET", "/task/user/:boardid", "/v1/boards/:boardid/tasks", nil},
+	{"POST", "/task", "/v1/boards/:boardid/tasks", dto.CreateTaskRequest{}},
+	{"POST", "/todaytasks/create", "/v1/tasks", nil},
+	{"DELETE", "/deltask", "/v1/tasks", nil},
+	{"PUT", "/updatetask/:taskid", "/v1/tasks/:taskid", nil},
+	{"DELETE", "/deltask/:taskid", "/v1/tasks/:taskid", nil},
+	{"PUT", "/taskfinish/:taskid", "/v1/tasks/:taskid/finish", nil},
+	{"PUT", "/updatestatus/:taskid", "/v1/tasks/:taskid/status", nil},
+	{"PUT", "/markasdoneTask/:taskid", "/v1/tasks/:taskid/done", nil},
+	{"POST", "/assigned", "/v1/tasks/:taskid/assignees", dto.AssignedTaskRequest{}},
+	{"DELETE", "/assigned/:taskid/:assignid", "/v1/tasks/:taskid/assignees/:assignid", nil},
+
+	{"POST", "/checklist/:taskid", "/v1/tasks/:taskid/checklists", nil},
+	{"DELETE", "/checklist/:taskid", "/v1/tasks/:taskid/checklists", nil},
+	{"PUT", "/checklist/:taskid/:checklistid", "/v1/tasks/:taskid/checklists/:checklistid", nil},
+	{"DELETE", "/checklist/:taskid/:checklistid", "/v1/tasks/:taskid/checklists/:checklistid", nil},
+	{"PUT", "/checklistfinish/:checklistid", "/v1/tasks/:taskid/checklists/:checklistid/finish", nil},
+
+	{"POST", "/attachment/create/:taskid", "/v1/tasks/:taskid/attachments", nil},
+	{"DELETE", "/attachment/delete/:taskid/:attachmentid", "/v1/tasks/:taskid/attachments/:attachmentid", nil},
+
+	{"PUT", "/notification/update/:taskid", "/v1/tasks/:taskid/reminder", nil},
+	{"PUT", "/snoozeNotify/:taskid", "/v1/tasks/:taskid/reminder/snooze", nil},
+	{"POST", "/inviteboardNotify", "/v1/push/board-invites", nil},
+	{"POST", "/acceptinviteboardNotify/:boardid", "/v1/push/boards/:boardid/accepted", nil},
+	{"POST", "/assignedtaskNotify", "/v1/push/assignments", nil},
+	{"POST", "/unassignedtaskNotify", "/v1/push/unassignments", nil},
+	{"POST", "/send_notification", "/v1/jobs/notifications", nil},
+}
+
+// Schemas DTO ทุกตัวใน package dto รวมตัวที่ยังไม่มี route ใช้ ให้ spec เปลี่ยนเมื่อ DTO ใดเปลี่ยน
+var Schemas = []any{
+	dto.AdminRequest{},
+	dto.ReconcileRequest{},
+	dto.CreateAttachmentsTodayTaskRequest{},
+	dto.CreateAttachmentsTaskRequest{},
+	dto.DeleteAttachmentRequest{},
+	dto.SigninRequest{},
+	dto.SignupRequest{},
+	dto.GoogleSignInRequest{},
+	dto.ResetPasswordRequest{},
+	dto.GetBoardsRequest{},
+	dto.CreateBoardRequest{},
+	dto.DeleteBoardRequest{},
+	dto.AdjustBoardRequest{},
+	dto.InviteBoardRequest{},
+	dto.AcceptBoardRequest{},
+	dto.BoarduserRequest{},
+	dto.CaptchaRequest{},
+	dto.AssessmentResult{},
+	dto.CreateChecklistTodayTaskRequest{},
+	dto.CreateChecklistTaskRequest{},
+	dto.UpdateChecklistRequest{},
+	dto.FinishTodayChecklistRequest{},
+	dto.AdjustChecklistRequest{},
+	dto.DeleteChecklistRequest{},
+	dto.DeleteChecklistTodayRequest{},
+	dto.NotificationRequest{},
+	dto.UpdateNotificationRequest{},
+	dto.InviteNotify{},
+	dto.AssignedNotify{},
+	dto.UnAssignedNotify{},
+	dto.DeliveryQuery{},
+	dto.InboxQuery{},
+	dto.TodayQuery{},
+	dto.IdentityOTPRequest{},
+	dto.ResetpasswordOTPRequest{},
+	dto.SendemailRequest{},
+	dto.ResendOTPRequest{},
+	dto.VerifyRequest{},
+	dto.ReportdataRequest{},
+	dto.CreateTaskRequest{},
+	dto.CreateTodayTaskRequest{},
+	dto.Reminder{},
+	dto.DeletetaskRequest{},
+	dto.AdjustTaskRequest{},
+	dto.StatusRequest{},
+	dto.AssignedTaskRequest{},
+	dto.AssigneeRequest{},
+	dto.UpdateProfileRequest{},
+	dto.EmailRequest{},
+	dto.PasswordRequest{},
+	dto.EmailText{},
+	dto.RegisterDeviceRequest{},
+	dto.UnregisterDeviceRequest{},
+	dto.QuietHoursRequest{},
+	dto.QuietHourWindow{},
+}
@@ -0,0 +1,85 @@
+// Package outbox บันทึกการเขียน Firestore ลง MySQL ใน transaction เดียวกับข้อมูลหลัก
+// แล้วให้ Relay เขียนลง Firestore ภายหลังพร้อมลองใหม่ ทำให้ mirror ไม่คลาดจาก MySQL ถาวร
+// เมื่อ Firestore ล่มชั่วคราว
+package outbox
+
+import (
+	"fmt"
+	"mydayplanner/store"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Entry แถวใน firestore_outbox ที่ยังไม่ได้เขียนลง Firestore
+type Entry struct {
+	OutboxID      int64 `gorm:"primaryKey"`
+	DocPath       string
+	Op            store.Op
+	Payload       *string
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     *string
+	CreatedAt     time.Time
+}
+
+func (Entry) TableName() string { return "firestore_outbox" }
+
+// DeadEntry แถวที่เขียนไม่สำเร็จครบจำนวนครั้งหรือผิดพลาดแบบลองใหม่ไม่ได้
+// ไม่มีใครลบอัตโนมัติ ต้องตรวจสอบแล้วลบหรือย้ายกลับ firestore_outbox เอง
+type DeadEntry struct {
+	OutboxID  int64 `gorm:"primaryKey"`
+	DocPath   string
+	Op        store.Op
+	Payload   *string
+	Attempts  int
+	LastError *string
+	CreatedAt time.Time
+	FailedAt  time.Time
+}
+
+func (DeadEntry) TableName() string { return "firestore_outbox_dead" }
+
+// Writer คืน Store ที่การเขียนทุกครั้งกลายเป็นแถวใน firestore_outbox ผ่าน tx
+// ใช้แทน Store จริงภายใน transaction ถ้า rollback การเขียน Firestore ก็หายไปด้วย
+// การอ่านผ่าน Store นี้คืน store.ErrWriteOnly
+func Writer(tx *gorm.DB) store.Store {
+	return store.Recorder(func(m store.Mutation) error {
+		return Enqueue(tx, m)
+	})
+}
+
+// Enqueue เพิ่ม Mutation หนึ่งรายการลง outbox ผ่าน tx
+func Enqueue(tx *gorm.DB, m store.Mutation) error {
+	entry := Entry{
+		DocPath:       m.Path,
+		Op:            m.Op,
+		NextAttemptAt: time.Now().UTC(),
+		CreatedAt:     time.Now().UTC(),
+	}
+	if m.Data != nil {
+		payload, err := store.EncodeFields(m.Data)
+		if err != nil {
+			return fmt.Errorf("outbox %s %s: %w", m.Op, m.Path, err)
+		}
+		s := string(payload)
+		entry.Payload = &s
+	}
+	if err := tx.Create(&entry).Error; err != nil {
+		return fmt.Errorf("outbox %s %s: %w", m.Op, m.Path, err)
+	}
+	return nil
+}
+
+// mutation แปลงแถวกลับเป็น Mutation ที่ Store.Apply รับ
+func (e Entry) mutation() (store.Mutation, error) {
+	m := store.Mutation{Op: e.Op, Path: e.DocPath}
+	if e.Payload != nil {
+		data, err := store.DecodeFields([]byte(*e.Payload))
+		if err != nil {
+			return m, err
+		}
+		m.Data = data
+	}
+	return m, nil
+}
@@ -0,0 +1,183 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mydayplanner/config"
+	"mydayplanner/logging"
+	"mydayplanner/metrics"
+	"mydayplanner/store"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// batchSize จำนวนแถวที่อ่านต่อรอบ
+const batchSize = 500
+
+// Relay อ่าน firestore_outbox ตามลำดับ outbox_id แล้วเขียนลง Firestore
+// เอกสารเดียวกันถูกเขียนตามลำดับเสมอ ถ้าแถวหนึ่งยังรอลองใหม่ แถวหลังจากนั้นของ path เดียวกันจะรอด้วย
+// เอกสารอื่นไม่ต้องรอ แม้ path ที่รออยู่จะมีแถวค้างมากกว่า batchSize
+type Relay struct {
+	db  *gorm.DB
+	fb  store.Store
+	cfg config.OutboxConfig
+	now func() time.Time
+	// active ถ้าไม่เป็น nil, Run ข้ามรอบที่ active คืน false
+	active func() bool
+}
+
+// NewRelay สร้าง relay ที่เขียนลง fb
+func NewRelay(db *gorm.DB, fb store.Store, cfg config.OutboxConfig) *Relay {
+	return &Relay{db: db, fb: fb, cfg: cfg, now: time.Now}
+}
+
+// OnlyWhen ให้ Run เขียนเฉพาะรอบที่ active คืน true เช่นเมื่อเป็น scheduler leader
+// relay สองตัวที่อ่าน outbox พร้อมกันอาจเขียนเอกสารเดียวกันสลับลำดับ จึงต้องมีตัวเดียว
+func (r *Relay) OnlyWhen(active func() bool) *Relay {
+	r.active = active
+	return r
+}
+
+// Run เรียก Flush ทุก PollInterval จน ctx ถูกยกเลิก
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		if r.active == nil || r.active() {
+			if _, err := r.Flush(ctx); err != nil && ctx.Err() == nil {
+				logging.FromContext(ctx).Error("outbox relay failed", "error", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush เขียนแถวที่ถึงเวลาแล้วหนึ่งรอบ คืนจำนวนแถวที่เขียนสำเร็จ
+// อ่านเฉพาะแถวที่ถึงเวลาและไม่มีแถวก่อนหน้าของ path เดียวกันที่ยังรอลองใหม่
+// แถวที่ยังไม่ถึงเวลาจึงไม่กินที่ใน batch จนเอกสารอื่นต้องรอ
+func (r *Relay) Flush(ctx context.Context) (int, error) {
+	now := r.now().UTC()
+	var entries []Entry
+	err := r.db.WithContext(ctx).
+		Where("next_attempt_at <= ?", now).
+		Where("NOT EXISTS (SELECT 1 FROM firestore_outbox AS earlier WHERE earlier.doc_path = firestore_outbox.doc_path AND earlier.outbox_id < firestore_outbox.outbox_id AND earlier.next_attempt_at > ?)", now).
+		Order("outbox_id").Limit(batchSize).Find(&entries).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to read outbox: %w", err)
+	}
+
+	// path ที่เขียนไม่สำเร็จในรอบนี้ แถวหลังจากนั้นของ path เดียวกันรอรอบถัดไป
+	blocked := make(map[string]bool)
+	applied := 0
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return applied, ctx.Err()
+		}
+		if blocked[entry.DocPath] {
+			continue
+		}
+
+		err := r.apply(ctx, entry)
+		if err == nil {
+			if err := r.db.WithContext(ctx).Delete(&Entry{}, entry.OutboxID).Error; err != nil {
+				return applied, fmt.Errorf("failed to remove outbox entry %d: %w", entry.OutboxID, err)
+			}
+			metrics.OutboxMutations.WithLabelValues("applied").Inc()
+			applied++
+			continue
+		}
+		if ctx.Err() != nil {
+			return applied, ctx.Err()
+		}
+
+		blocked[entry.DocPath] = true
+		if err := r.fail(ctx, entry, err, now); err != nil {
+			return applied, err
+		}
+	}
+	return applied, nil
+}
+
+func (r *Relay) apply(ctx context.Context, entry Entry) error {
+	m, err := entry.mutation()
+	if err != nil {
+		return permanent{err}
+	}
+	if err := r.fb.Apply(ctx, m); err != nil {
+		// update เอกสารที่ไม่มีอยู่ ลองใหม่กี่ครั้งก็ไม่สำเร็จ
+		if errors.Is(err, store.ErrNotFound) {
+			return permanent{err}
+		}
+		return err
+	}
+	return nil
+}
+
+// fail เลื่อนแถวไปลองใหม่ หรือย้ายไป firestore_outbox_dead เมื่อครบจำนวนครั้ง
+func (r *Relay) fail(ctx context.Context, entry Entry, cause error, now time.Time) error {
+	log := logging.FromContext(ctx).With("outbox_id", entry.OutboxID, "path", entry.DocPath, "op", entry.Op)
+	attempts := entry.Attempts + 1
+	msg := cause.Error()
+
+	var perm permanent
+	if attempts < r.cfg.MaxAttempts && !errors.As(cause, &perm) {
+		next := now.Add(r.backoff(attempts))
+		log.Warn("outbox write failed, will retry", "attempts", attempts, "next_attempt_at", next, "error", cause)
+		metrics.OutboxMutations.WithLabelValues("retried").Inc()
+		err := r.db.WithContext(ctx).Model(&Entry{}).Where("outbox_id = ?", entry.OutboxID).Updates(map[string]interface{}{
+			"attempts":        attempts,
+			"next_attempt_at": next,
+			"last_error":      msg,
+		}).Error
+		if err != nil {
+			return fmt.Errorf("failed to reschedule outbox entry %d: %w", entry.OutboxID, err)
+		}
+		return nil
+	}
+
+	log.Error("outbox write failed, moving to dead letter", "attempts", attempts, "error", cause)
+	metrics.OutboxMutations.WithLabelValues("dead").Inc()
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&DeadEntry{
+			OutboxID:  entry.OutboxID,
+			DocPath:   entry.DocPath,
+			Op:        entry.Op,
+			Payload:   entry.Payload,
+			Attempts:  attempts,
+			LastError: &msg,
+			CreatedAt: entry.CreatedAt,
+			FailedAt:  now,
+		}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&Entry{}, entry.OutboxID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter outbox entry %d: %w", entry.OutboxID, err)
+	}
+	return nil
+}
+
+// backoff 1s, 2s, 4s, ... ไม่เกิน MaxBackoff
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := time.Second
+	for i := 1; i < attempts && d < r.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	if r.cfg.MaxBackoff > 0 && d > r.cfg.MaxBackoff {
+		d = r.cfg.MaxBackoff
+	}
+	return d
+}
+
+// permanent ความผิดพลาดที่ลองใหม่ไม่ช่วย ย้ายไป dead letter ทันที
+type permanent struct{ err error }
+
+func (p permanent) Error() string { return p.err.Error() }
+func (p permanent) Unwrap() error { return p.err }
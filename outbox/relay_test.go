@@ -0,0 +1,180 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"mydayplanner/config"
+	"mydayplanner/store"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// flakyStore เขียนลง Memory แต่คืน error สำหรับ path ใน failing
+type flakyStore struct {
+	*store.Memory
+	failing map[string]bool
+}
+
+func (f *flakyStore) Apply(ctx context.Context, m store.Mutation) error {
+	if f.failing[m.Path] {
+		return errors.New("firestore unavailable")
+	}
+	return f.Memory.Apply(ctx, m)
+}
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "outbox.db")), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&Entry{}, &DeadEntry{}); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+	return db
+}
+
+func TestRelayOrdersPerDocumentAndRetries(t *testing.T) {
+	db := openTestDB(t)
+	fb := &flakyStore{Memory: store.NewMemory(), failing: map[string]bool{"Boards/1": true}}
+	relay := NewRelay(db, fb, config.OutboxConfig{MaxAttempts: 3, MaxBackoff: time.Minute})
+	// Enqueue ใช้เวลาจริง นาฬิกาของ relay จึงต้องอยู่หลังจากนั้น
+	start := time.Now().UTC().Add(time.Minute)
+	relay.now = func() time.Time { return start }
+	ctx := context.Background()
+
+	createdAt := time.Date(2024, 12, 31, 9, 30, 0, 0, time.UTC)
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		w := Writer(tx)
+		if err := w.Boards().Set(ctx, 1, store.Fields{"boardName": "Plan", "createdAt": createdAt}); err != nil {
+			return err
+		}
+		if err := w.Boards().Update(ctx, 1, store.Fields{"boardName": "Plan v2"}); err != nil {
+			return err
+		}
+		return w.Boards().Set(ctx, 2, store.Fields{"boardName": "Other"})
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := relay.Flush(ctx); err != nil || n != 1 {
+		t.Fatalf("first flush applied %d (%v), want only Boards/2", n, err)
+	}
+	if _, ok := fb.Doc("Boards/2"); !ok {
+		t.Fatal("Boards/2 was not written")
+	}
+	var pending []Entry
+	db.Order("outbox_id").Find(&pending)
+	if len(pending) != 2 || pending[0].Attempts != 1 || pending[1].Attempts != 0 {
+		t.Fatalf("pending = %+v, want the failed set retried and the update behind it untouched", pending)
+	}
+	if !pending[0].NextAttemptAt.Equal(start.Add(time.Second)) {
+		t.Fatalf("next attempt at %v, want 1s backoff", pending[0].NextAttemptAt)
+	}
+
+	fb.failing = nil
+	if n, _ := relay.Flush(ctx); n != 0 {
+		t.Fatalf("flush before backoff applied %d, want 0", n)
+	}
+	relay.now = func() time.Time { return start.Add(time.Second) }
+	if n, err := relay.Flush(ctx); err != nil || n != 2 {
+		t.Fatalf("flush after backoff applied %d (%v), want 2", n, err)
+	}
+	doc, _ := fb.Doc("Boards/1")
+	if doc["boardName"] != "Plan v2" {
+		t.Fatalf("Boards/1 = %v, want the update applied after the set", doc)
+	}
+	if got, ok := doc["createdAt"].(time.Time); !ok || !got.Equal(createdAt) {
+		t.Fatalf("createdAt = %#v, want the original time.Time", doc["createdAt"])
+	}
+}
+
+func TestRelayDoesNotStallBehindBackoff(t *testing.T) {
+	db := openTestDB(t)
+	fb := &flakyStore{Memory: store.NewMemory()}
+	relay := NewRelay(db, fb, config.OutboxConfig{MaxAttempts: 3, MaxBackoff: time.Minute})
+	now := time.Now().UTC().Add(time.Minute)
+	relay.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Boards/1 มีแถวรอลองใหม่เต็มทั้ง batch ส่วน Boards/2 ถึงเวลาแล้ว
+	payload := `{"t":"map","v":{}}`
+	waiting := make([]Entry, batchSize)
+	for i := range waiting {
+		waiting[i] = Entry{DocPath: "Boards/1", Op: store.OpSet, Payload: &payload, Attempts: 1, NextAttemptAt: now.Add(time.Minute), CreatedAt: now}
+	}
+	if err := db.CreateInBatches(waiting, 100).Error; err != nil {
+		t.Fatal(err)
+	}
+	// แถวที่ถึงเวลาแต่อยู่หลังแถวที่ยังรอของ path เดียวกันต้องรอด้วย
+	due := []Entry{
+		{DocPath: "Boards/1", Op: store.OpSet, Payload: &payload, NextAttemptAt: now, CreatedAt: now},
+		{DocPath: "Boards/2", Op: store.OpSet, Payload: &payload, NextAttemptAt: now, CreatedAt: now},
+	}
+	if err := db.Create(&due).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if n, err := relay.Flush(ctx); err != nil || n != 1 {
+		t.Fatalf("flush applied %d (%v), want Boards/2 only", n, err)
+	}
+	if _, ok := fb.Doc("Boards/2"); !ok {
+		t.Fatal("Boards/2 was not written")
+	}
+	if _, ok := fb.Doc("Boards/1"); ok {
+		t.Fatal("Boards/1 was written ahead of its earlier entries")
+	}
+}
+
+func TestRelayDeadLettersPermanentFailures(t *testing.T) {
+	db := openTestDB(t)
+	fb := &flakyStore{Memory: store.NewMemory(), failing: map[string]bool{"Boards/2": true}}
+	relay := NewRelay(db, fb, config.OutboxConfig{MaxAttempts: 2, MaxBackoff: time.Minute})
+	now := time.Now().UTC().Add(time.Minute)
+	relay.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// update เอกสารที่ไม่มีอยู่ย้ายไป dead letter ทันที ส่วน Boards/2 ย้ายเมื่อครบ MaxAttempts
+	w := Writer(db)
+	if err := w.Boards().Update(ctx, 1, store.Fields{"status": "2"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Boards().Set(ctx, 2, store.Fields{"status": "0"}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := relay.Flush(ctx); err != nil {
+			t.Fatal(err)
+		}
+		now = now.Add(time.Minute)
+	}
+
+	var pending, dead int64
+	db.Model(&Entry{}).Count(&pending)
+	db.Model(&DeadEntry{}).Count(&dead)
+	if pending != 0 || dead != 2 {
+		t.Fatalf("pending %d dead %d, want 0 and 2", pending, dead)
+	}
+
+	// transaction ที่ rollback ไม่ทิ้งอะไรไว้ใน outbox
+	_ = db.Transaction(func(tx *gorm.DB) error {
+		if err := Writer(tx).Boards().Set(ctx, 3, store.Fields{"status": "0"}); err != nil {
+			return err
+		}
+		return errors.New("abort")
+	})
+	db.Model(&Entry{}).Count(&pending)
+	if pending != 0 {
+		t.Fatalf("rolled back write left %d outbox entries", pending)
+	}
+}
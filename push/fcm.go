@@ -0,0 +1,87 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/logging"
+	"mydayplanner/metrics"
+	"strings"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// fcmBatchSize FCM รับได้ไม่เกิน 500 token ต่อ multicast
+const fcmBatchSize = 500
+
+// FCM ส่งผ่าน Firebase Cloud Messaging
+type FCM struct {
+	client *messaging.Client
+}
+
+// NewFCM ใช้ Messaging client ที่เปิดไว้แล้วของ process
+func NewFCM(client *messaging.Client) *FCM {
+	return &FCM{client: client}
+}
+
+func (f *FCM) Send(ctx context.Context, tokens []string, msg Message) ([]Result, error) {
+	logger := logging.FromContext(ctx)
+	results := make([]Result, 0, len(tokens))
+	var lastErr error
+	batchFailed := 0
+
+	for i := 0; i < len(tokens); i += fcmBatchSize {
+		end := i + fcmBatchSize
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		batch := tokens[i:end]
+
+		response, err := f.client.SendEachForMulticast(ctx, &messaging.MulticastMessage{
+			Data: msg.Data,
+			Notification: &messaging.Notification{
+				Title: msg.Title,
+				Body:  msg.Body,
+			},
+			Tokens: batch,
+		})
+		if err != nil {
+			metrics.FCMBatchFailures.Inc()
+			logger.Error("FCM batch failed", "batch_start", i, "batch_end", end-1, "error", err)
+			results = append(results, failAll(batch, err)...)
+			lastErr = err
+			batchFailed++
+			continue
+		}
+
+		logger.Debug("FCM batch sent", "batch_start", i, "batch_end", end-1,
+			"success", response.SuccessCount, "failure", response.FailureCount)
+		for idx, resp := range response.Responses {
+			results = append(results, Result{Token: batch[idx], MessageID: resp.MessageID, Err: fcmError(resp.Error)})
+			if !resp.Success {
+				logger.Warn("FCM send to token failed", "token_index", i+idx, "error", resp.Error)
+			}
+		}
+	}
+
+	if batchFailed > 0 && batchFailed == (len(tokens)+fcmBatchSize-1)/fcmBatchSize {
+		return results, lastErr
+	}
+	return results, nil
+}
+
+// fcmError ห่อ error ราย token ของ FCM ด้วย error ของ package นี้ตามประเภท
+// INVALID_ARGUMENT ใช้ทั้งกับ token ผิดรูปแบบและข้อความผิดรูปแบบ จึงนับเป็น token เสียเฉพาะเมื่อ FCM ระบุว่าเป็นที่ token
+func fcmError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case messaging.IsUnregistered(err):
+		return fmt.Errorf("%w: %w", ErrUnregistered, err)
+	case messaging.IsSenderIDMismatch(err),
+		messaging.IsInvalidArgument(err) && strings.Contains(strings.ToLower(err.Error()), "registration token"):
+		return fmt.Errorf("%w: %w", ErrInvalidToken, err)
+	case messaging.IsQuotaExceeded(err):
+		return fmt.Errorf("%w: %w", ErrQuota, err)
+	}
+	return err
+}
@@ -0,0 +1,126 @@
+// Package push ส่ง push notification ผ่าน provider ที่เลือกจาก config
+// controller และ scheduler ใช้แค่ Provider จึงเปลี่ยนจาก FCM เป็น webhook หรือ Recorder ได้โดยไม่แก้โค้ดที่เรียก
+package push
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Message เนื้อหาที่ส่งเหมือนกันไปทุก token
+type Message struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Result ผลการส่งไปยัง token หนึ่ง Err เป็น nil เมื่อ provider รับไปส่งแล้ว
+// MessageID คือ id ที่ provider ให้กับข้อความที่รับไป (ว่างถ้า provider ไม่มี)
+type Result struct {
+	Token     string
+	MessageID string
+	Err       error
+}
+
+// Provider ช่องทางส่ง push
+type Provider interface {
+	// Send ส่ง msg ไปยังทุก token คืนผลหนึ่งรายการต่อ token ตามลำดับเดียวกับ tokens
+	// error คืนเฉพาะเมื่อส่งไม่ได้เลยสักรายการ เช่น provider ล่มหรือปฏิเสธทั้ง request
+	Send(ctx context.Context, tokens []string, msg Message) ([]Result, error)
+}
+
+// ErrNoTokens คืนจาก SendOne และ SendAll เมื่อไม่มี token ให้ส่ง
+var ErrNoTokens = errors.New("push: no device token")
+
+// ความล้มเหลวราย token ที่ provider แยกประเภทได้ Result.Err ห่อ error เหล่านี้ไว้ ตรวจด้วย errors.Is หรือ Classify
+var (
+	ErrUnregistered = errors.New("push: token is no longer registered")
+	ErrInvalidToken = errors.New("push: invalid token")
+	ErrQuota        = errors.New("push: quota exceeded")
+)
+
+// Reason ประเภทของความล้มเหลวราย token
+type Reason string
+
+const (
+	ReasonUnregistered Reason = "unregistered"     // แอปถูกถอนหรือ token หมดอายุ ใช้ต่อไม่ได้
+	ReasonInvalidToken Reason = "invalid_argument" // token ผิดรูปแบบหรือเป็นของ Firebase project อื่น
+	ReasonQuota        Reason = "quota"            // เกินโควตาของ project ไม่ได้เป็นความผิดของ token
+	ReasonTransient    Reason = "transient"        // อื่นๆ เช่น provider ไม่ว่าง ลองใหม่ได้
+)
+
+// Classify ประเภทของ error ใน Result.Err
+func Classify(err error) Reason {
+	switch {
+	case errors.Is(err, ErrUnregistered):
+		return ReasonUnregistered
+	case errors.Is(err, ErrInvalidToken):
+		return ReasonInvalidToken
+	case errors.Is(err, ErrQuota):
+		return ReasonQuota
+	default:
+		return ReasonTransient
+	}
+}
+
+// Permanent token ใช้ไม่ได้อีกไม่ว่าจะลองกี่ครั้ง
+func (r Reason) Permanent() bool {
+	return r == ReasonUnregistered || r == ReasonInvalidToken
+}
+
+// SendOne ส่งไปยัง token เดียว คืน error ของ token นั้น
+func SendOne(ctx context.Context, p Provider, token string, msg Message) error {
+	if token == "" {
+		return ErrNoTokens
+	}
+	results, err := p.Send(ctx, []string{token}, msg)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if r.Err != nil {
+			return r.Err
+		}
+	}
+	return nil
+}
+
+// SendAll ส่งไปยังทุก token คืนผลครบหนึ่งรายการต่อ token แม้ทั้ง request จะล้ม
+// ล้มบางเครื่องไม่ถือว่าล้ม คืน error เมื่อไม่มีเครื่องไหนได้รับเลย
+func SendAll(ctx context.Context, p Provider, tokens []string, msg Message) ([]Result, error) {
+	if len(tokens) == 0 {
+		return nil, ErrNoTokens
+	}
+	results, err := p.Send(ctx, tokens, msg)
+	if err != nil {
+		if len(results) != len(tokens) {
+			results = failAll(tokens, err)
+		}
+		return results, err
+	}
+	if failed := Failed(results); failed > 0 && failed == len(results) {
+		return results, fmt.Errorf("push failed for all %d tokens: %w", failed, results[0].Err)
+	}
+	return results, nil
+}
+
+// Failed จำนวน token ที่ส่งไม่สำเร็จ
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Err != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// failAll ใช้เมื่อทั้ง request ล้ม ให้ทุก token มี error เดียวกัน
+func failAll(tokens []string, err error) []Result {
+	results := make([]Result, len(tokens))
+	for i, token := range tokens {
+		results[i] = Result{Token: token, Err: err}
+	}
+	return results
+}
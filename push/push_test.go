@@ -0,0 +1,69 @@
+package push
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWebhookReportsPerTokenResults(t *testing.T) {
+	var got webhookRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer s3cret" {
+			t.Errorf("Authorization = %q", r.Header.Get("Authorization"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"results":[{"token":"a","message_id":"m-1"},{"token":"b","error":"unregistered"}]}`))
+	}))
+	defer srv.Close()
+
+	msg := Message{Title: "t", Body: "b", Data: map[string]string{"taskid": "1"}}
+	results, err := NewWebhook(srv.URL, "s3cret", nil).Send(t.Context(), []string{"a", "b"}, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Title != "t" || got.Data["taskid"] != "1" || len(got.Tokens) != 2 {
+		t.Fatalf("request = %+v", got)
+	}
+	if results[0].Err != nil || results[0].MessageID != "m-1" || results[1].Err == nil || Failed(results) != 1 {
+		t.Fatalf("results = %+v, want only b failed", results)
+	}
+	if reason := Classify(results[1].Err); reason != ReasonUnregistered {
+		t.Fatalf("reason = %q, want %q", reason, ReasonUnregistered)
+	}
+}
+
+func TestWebhookFailsWholeRequestOnErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	results, err := NewWebhook(srv.URL, "", nil).Send(t.Context(), []string{"a", "b"}, Message{})
+	if err == nil || Failed(results) != 2 {
+		t.Fatalf("err = %v, results = %+v, want every token failed", err, results)
+	}
+}
+
+func TestSendOneReturnsTokenError(t *testing.T) {
+	rec := NewRecorder()
+	unregistered := errors.New("unregistered")
+	rec.Fail("gone", unregistered)
+
+	if err := SendOne(t.Context(), rec, "gone", Message{Title: "x"}); !errors.Is(err, unregistered) {
+		t.Fatalf("err = %v, want %v", err, unregistered)
+	}
+	if err := SendOne(t.Context(), rec, "", Message{}); !errors.Is(err, ErrNoTokens) {
+		t.Fatalf("err = %v, want ErrNoTokens", err)
+	}
+	if err := SendOne(t.Context(), rec, "ok", Message{Title: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if sent := rec.Sent(); len(sent) != 1 || sent[0].Token != "ok" {
+		t.Fatalf("sent = %+v, want only ok", sent)
+	}
+}
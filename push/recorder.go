@@ -0,0 +1,60 @@
+package push
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/logging"
+	"sync"
+)
+
+// Sent ข้อความที่ Recorder ได้รับสำหรับ token หนึ่ง
+type Sent struct {
+	Token   string
+	Message Message
+}
+
+// Recorder ไม่ส่งจริง เก็บทุกข้อความไว้ให้ test ตรวจ และ log ไว้ให้ดูตอนพัฒนาบนเครื่อง
+// ข้อความที่เก็บไว้ไม่เคยถูกลบ config จึงรับ PUSH_PROVIDER log เฉพาะ APP_ENV development
+type Recorder struct {
+	mu      sync.Mutex
+	sent    []Sent
+	failing map[string]error
+}
+
+// NewRecorder สร้าง Recorder เปล่า
+func NewRecorder() *Recorder {
+	return &Recorder{failing: make(map[string]error)}
+}
+
+// Fail ให้การส่งไปยัง token นี้คืน err ตั้งแต่ครั้งถัดไป (nil เพื่อยกเลิก)
+func (r *Recorder) Fail(token string, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err == nil {
+		delete(r.failing, token)
+		return
+	}
+	r.failing[token] = err
+}
+
+// Sent สำเนาของข้อความที่ส่งสำเร็จทั้งหมดตามลำดับ
+func (r *Recorder) Sent() []Sent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Sent(nil), r.sent...)
+}
+
+func (r *Recorder) Send(ctx context.Context, tokens []string, msg Message) ([]Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	results := make([]Result, len(tokens))
+	for i, token := range tokens {
+		results[i] = Result{Token: token, Err: r.failing[token]}
+		if results[i].Err == nil {
+			r.sent = append(r.sent, Sent{Token: token, Message: msg})
+			results[i].MessageID = fmt.Sprintf("recorded-%d", len(r.sent))
+		}
+	}
+	logging.FromContext(ctx).Info("push recorded", "tokens", len(tokens), "title", msg.Title, "body", msg.Body)
+	return results, nil
+}
@@ -0,0 +1,112 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Webhook ส่งข้อความเป็น JSON ไปยัง HTTP endpoint ภายนอก ซึ่งรับหน้าที่ส่งต่อให้อุปกรณ์เอง
+//
+// request: POST {"tokens": [...], "title": "...", "body": "...", "data": {...}}
+// response 2xx อาจมี {"results": [{"token": "...", "message_id": "...", "error": "..."}]} เพื่อแจ้งผลราย token
+// error ที่เป็น unregistered, invalid_argument หรือ quota ถูกแยกประเภทแบบเดียวกับ FCM
+// ถ้าไม่มี results ถือว่าทุก token สำเร็จ ส่วนสถานะอื่นนอกจาก 2xx ถือว่าล้มทั้ง request
+type Webhook struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhook secret ที่ไม่ว่างจะส่งเป็น Authorization: Bearer ให้ปลายทางตรวจ
+func NewWebhook(url, secret string, client *http.Client) *Webhook {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Webhook{url: url, secret: secret, client: client}
+}
+
+type webhookRequest struct {
+	Tokens []string          `json:"tokens"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+}
+
+type webhookResponse struct {
+	Results []struct {
+		Token     string `json:"token"`
+		MessageID string `json:"message_id"`
+		Error     string `json:"error"`
+	} `json:"results"`
+}
+
+func (w *Webhook) Send(ctx context.Context, tokens []string, msg Message) ([]Result, error) {
+	payload, err := json.Marshal(webhookRequest{Tokens: tokens, Title: msg.Title, Body: msg.Body, Data: msg.Data})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.secret != "" {
+		req.Header.Set("Authorization", "Bearer "+w.secret)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return failAll(tokens, err), err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return failAll(tokens, err), err
+	}
+	if resp.StatusCode/100 != 2 {
+		err := fmt.Errorf("push webhook: status %d", resp.StatusCode)
+		return failAll(tokens, err), err
+	}
+
+	results := make([]Result, len(tokens))
+	for i, token := range tokens {
+		results[i] = Result{Token: token}
+	}
+	var decoded webhookResponse
+	if len(bytes.TrimSpace(body)) == 0 || json.Unmarshal(body, &decoded) != nil {
+		return results, nil
+	}
+	byToken := make(map[string]int, len(decoded.Results))
+	for i, r := range decoded.Results {
+		byToken[r.Token] = i
+	}
+	for i := range results {
+		j, ok := byToken[results[i].Token]
+		if !ok {
+			continue
+		}
+		results[i].MessageID = decoded.Results[j].MessageID
+		if reason := decoded.Results[j].Error; reason != "" {
+			results[i].Err = webhookError(reason)
+		}
+	}
+	return results, nil
+}
+
+// webhookError แปลง error ราย token ของ webhook ที่ตรงกับชื่อของ Reason เป็น error ของ package นี้
+func webhookError(reason string) error {
+	switch Reason(reason) {
+	case ReasonUnregistered:
+		return ErrUnregistered
+	case ReasonInvalidToken:
+		return ErrInvalidToken
+	case ReasonQuota:
+		return ErrQuota
+	}
+	return errors.New(reason)
+}
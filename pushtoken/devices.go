@@ -0,0 +1,169 @@
+package pushtoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mydayplanner/logging"
+	"mydayplanner/model"
+	"mydayplanner/store"
+	"slices"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ActiveWindow เครื่องที่ไม่ได้ลงทะเบียนซ้ำนานกว่านี้ถือว่าเลิกใช้แล้ว ไม่ส่ง push ไปอีก
+// แอปลงทะเบียนทุกครั้งที่เปิด last_seen_at จึงบอกได้ว่าเครื่องยังใช้งานอยู่หรือไม่
+const ActiveWindow = 60 * 24 * time.Hour
+
+// ErrUnknownDevice token นี้ไม่ได้ลงทะเบียนไว้กับผู้ใช้
+var ErrUnknownDevice = errors.New("push device not registered for user")
+
+// Register บันทึกเครื่องของผู้ใช้หรืออัปเดต last_seen_at ถ้าลงทะเบียนไว้แล้ว
+// token ที่เคยเป็นของผู้ใช้อื่น (sign in บัญชีใหม่บนเครื่องเดิม) ถูกย้ายมาเป็นของผู้ใช้นี้
+func Register(ctx context.Context, db *gorm.DB, userID int, token, platform string, appVersion *string, now time.Time) (model.PushDevice, error) {
+	db = db.WithContext(ctx)
+	now = now.UTC()
+	device := model.PushDevice{UserID: userID, Token: token, Platform: platform, AppVersion: appVersion, CreatedAt: now, LastSeenAt: now}
+	if err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "token"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"user_id":      userID,
+			"platform":     platform,
+			"app_version":  appVersion,
+			"last_seen_at": now,
+		}),
+	}).Create(&device).Error; err != nil {
+		return model.PushDevice{}, fmt.Errorf("register push device: %w", err)
+	}
+	if err := db.First(&device, "token = ?", token).Error; err != nil {
+		return model.PushDevice{}, fmt.Errorf("load push device: %w", err)
+	}
+	return device, nil
+}
+
+// Unregister ลบเครื่องของผู้ใช้ เช่นตอน sign out รวมถึง token เดิมใน usersLogin ถ้าเป็น token เดียวกัน
+// คืน ErrUnknownDevice เมื่อไม่พบ token ทั้งสองที่
+func Unregister(ctx context.Context, db *gorm.DB, fb store.Store, user model.User, token string) error {
+	res := db.WithContext(ctx).Where("user_id = ? AND token = ?", user.UserID, token).Delete(&model.PushDevice{})
+	if res.Error != nil {
+		return fmt.Errorf("unregister push device: %w", res.Error)
+	}
+	removed := res.RowsAffected > 0
+
+	data, err := fb.Logins().Get(ctx, user.Email)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("read login document: %w", err)
+	}
+	if current, _ := data["FMCToken"].(string); err == nil && current == token {
+		if err := fb.Logins().Update(ctx, user.Email, store.Fields{"FMCToken": store.DeleteField}); err != nil {
+			return fmt.Errorf("remove legacy push token: %w", err)
+		}
+		removed = true
+	}
+	if !removed {
+		return ErrUnknownDevice
+	}
+	return nil
+}
+
+// Devices เครื่องทั้งหมดของผู้ใช้ เรียงจากที่ใช้ล่าสุด
+func Devices(ctx context.Context, db *gorm.DB, userID int) ([]model.PushDevice, error) {
+	devices := []model.PushDevice{}
+	if err := db.WithContext(ctx).Where("user_id = ?", userID).Order("last_seen_at DESC").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("list push devices: %w", err)
+	}
+	return devices, nil
+}
+
+// Tokens token ของทุกเครื่องที่ยังใช้งานของผู้ใช้แต่ละคน โดยใช้ user_id เป็น key
+// รวม FMCToken ใน usersLogin ที่แอปรุ่นเก่ายังเขียนอยู่ด้วย ถ้าอ่าน Firestore ไม่ได้แค่ log ไว้แล้วใช้เฉพาะ registry
+// FMCToken ที่ registry บอกว่าเป็นของผู้ใช้อื่นแล้ว (sign in บัญชีใหม่บนเครื่องเดิม) ไม่ถูกรวม
+// ไม่อย่างนั้นเครื่องนั้นจะได้ push ของบัญชีเดิมต่อไป
+func Tokens(ctx context.Context, db *gorm.DB, fb store.Store, users []model.User, now time.Time) (map[int][]string, error) {
+	out := make(map[int][]string, len(users))
+	if len(users) == 0 {
+		return out, nil
+	}
+	ids := make([]int, len(users))
+	for i, u := range users {
+		ids[i] = u.UserID
+	}
+	var devices []model.PushDevice
+	if err := db.WithContext(ctx).
+		Where("user_id IN ? AND last_seen_at >= ?", ids, now.UTC().Add(-ActiveWindow)).
+		Order("last_seen_at DESC").Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("load push devices: %w", err)
+	}
+	for _, d := range devices {
+		out[d.UserID] = append(out[d.UserID], d.Token)
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		sem    = make(chan struct{}, 5) // จำกัด concurrent Firestore calls
+		legacy = make(map[int]string)
+	)
+	for _, u := range users {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			data, err := fb.Logins().Get(ctx, u.Email)
+			if err != nil {
+				if !errors.Is(err, store.ErrNotFound) {
+					logging.FromContext(ctx).Warn("failed to load FCM token", "user_id", u.UserID, "error", err)
+				}
+				return
+			}
+			token, _ := data["FMCToken"].(string)
+			if token == "" {
+				return
+			}
+			mu.Lock()
+			legacy[u.UserID] = token
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if len(legacy) == 0 {
+		return out, nil
+	}
+
+	tokens := make([]string, 0, len(legacy))
+	for _, token := range legacy {
+		tokens = append(tokens, token)
+	}
+	var registered []model.PushDevice
+	if err := db.WithContext(ctx).Select("token", "user_id").Where("token IN ?", tokens).Find(&registered).Error; err != nil {
+		return nil, fmt.Errorf("load owners of legacy push tokens: %w", err)
+	}
+	owner := make(map[string]int, len(registered))
+	for _, d := range registered {
+		owner[d.Token] = d.UserID
+	}
+	for userID, token := range legacy {
+		if id, ok := owner[token]; ok && id != userID {
+			continue
+		}
+		if !slices.Contains(out[userID], token) {
+			out[userID] = append(out[userID], token)
+		}
+	}
+	return out, nil
+}
+
+// UserTokens token ของทุกเครื่องที่ยังใช้งานของผู้ใช้คนเดียว
+func UserTokens(ctx context.Context, db *gorm.DB, fb store.Store, user model.User, now time.Time) ([]string, error) {
+	tokens, err := Tokens(ctx, db, fb, []model.User{user}, now)
+	if err != nil {
+		return nil, err
+	}
+	return tokens[user.UserID], nil
+}
@@ -0,0 +1,125 @@
+package pushtoken
+
+import (
+	"errors"
+	"mydayplanner/model"
+	"mydayplanner/push"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRegisterMovesTokenToLatestUser(t *testing.T) {
+	p, _, _ := newTestProvider(t)
+	now := time.Now()
+	version := "2.1.0"
+
+	first, err := Register(t.Context(), p.db, 1, "shared", "android", nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Register(t.Context(), p.db, 2, "shared", "android", &version, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.DeviceID != first.DeviceID || again.UserID != 2 || again.AppVersion == nil || *again.AppVersion != version ||
+		!again.LastSeenAt.After(first.LastSeenAt) {
+		t.Fatalf("device = %+v, want the same row moved to user 2", again)
+	}
+	if devices, _ := Devices(t.Context(), p.db, 1); len(devices) != 0 {
+		t.Fatalf("user 1 still has %+v", devices)
+	}
+}
+
+func TestTokensMergesRegistryAndLegacyLogin(t *testing.T) {
+	p, _, fb := newTestProvider(t)
+	now := time.Now()
+	alice := model.User{UserID: 1, Email: "alice@example.test"}
+	bob := model.User{UserID: 2, Email: "bob@example.test"}
+	for _, d := range []struct {
+		user  int
+		token string
+		seen  time.Time
+	}{
+		{1, "phone", now},
+		{1, "tablet", now.Add(-time.Hour)},
+		{1, "old-phone", now.Add(-ActiveWindow - time.Hour)},
+	} {
+		if _, err := Register(t.Context(), p.db, d.user, d.token, "android", nil, d.seen); err != nil {
+			t.Fatal(err)
+		}
+	}
+	login(t, fb, alice.Email, "phone")
+	login(t, fb, bob.Email, "legacy")
+
+	tokens, err := Tokens(t.Context(), p.db, fb, []model.User{alice, bob}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tokens[1]; !slices.Equal(got, []string{"phone", "tablet"}) {
+		t.Fatalf("alice tokens = %v, want active devices without duplicates", got)
+	}
+	if got := tokens[2]; !slices.Equal(got, []string{"legacy"}) {
+		t.Fatalf("bob tokens = %v, want the legacy login token", got)
+	}
+}
+
+func TestTokensSkipsLegacyTokenMovedToAnotherUser(t *testing.T) {
+	p, _, fb := newTestProvider(t)
+	now := time.Now()
+	alice := model.User{UserID: 1, Email: "alice@example.test"}
+	bob := model.User{UserID: 2, Email: "bob@example.test"}
+	// แอปรุ่นเก่าเขียน token ไว้ใน usersLogin ของ alice แล้ว bob sign in บนเครื่องเดียวกัน
+	login(t, fb, alice.Email, "shared")
+	if _, err := Register(t.Context(), p.db, bob.UserID, "shared", "android", nil, now); err != nil {
+		t.Fatal(err)
+	}
+
+	tokens, err := Tokens(t.Context(), p.db, fb, []model.User{alice, bob}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tokens[alice.UserID]; len(got) != 0 {
+		t.Fatalf("alice tokens = %v, want none once the device belongs to bob", got)
+	}
+	if got := tokens[bob.UserID]; !slices.Equal(got, []string{"shared"}) {
+		t.Fatalf("bob tokens = %v, want the shared device", got)
+	}
+}
+
+func TestUnregisterRemovesDeviceAndLegacyToken(t *testing.T) {
+	p, _, fb := newTestProvider(t)
+	alice := model.User{UserID: 1, Email: "alice@example.test"}
+	if _, err := Register(t.Context(), p.db, 1, "phone", "ios", nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	login(t, fb, alice.Email, "phone")
+
+	if err := Unregister(t.Context(), p.db, fb, alice, "phone"); err != nil {
+		t.Fatal(err)
+	}
+	if devices, _ := Devices(t.Context(), p.db, 1); len(devices) != 0 {
+		t.Fatalf("devices = %+v, want none", devices)
+	}
+	if got := token(fb, alice.Email); got != nil {
+		t.Fatalf("legacy token kept: %v", got)
+	}
+	if err := Unregister(t.Context(), p.db, fb, alice, "phone"); !errors.Is(err, ErrUnknownDevice) {
+		t.Fatalf("second unregister err = %v, want ErrUnknownDevice", err)
+	}
+}
+
+func TestPermanentFailureRemovesRegisteredDevice(t *testing.T) {
+	p, rec, _ := newTestProvider(t)
+	if _, err := Register(t.Context(), p.db, 1, "gone", "android", nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	rec.Fail("gone", push.ErrUnregistered)
+
+	if _, err := p.Send(t.Context(), []string{"gone"}, push.Message{}); err != nil {
+		t.Fatal(err)
+	}
+	if devices, _ := Devices(t.Context(), p.db, 1); len(devices) != 0 {
+		t.Fatalf("devices = %+v, want the unregistered device removed", devices)
+	}
+}
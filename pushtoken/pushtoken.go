@@ -0,0 +1,155 @@
+// Package pushtoken ดูแล device token ของผู้ใช้ ทั้งทะเบียนเครื่องในตาราง push_devices
+// และการลบ token ที่ส่ง push ไม่ได้แล้วออกจาก push_devices และ usersLogin/{email}
+// token ที่ provider แจ้งว่าใช้ไม่ได้ (unregistered, invalid_argument) ถูกลบทันที
+// ความล้มเหลวชั่วคราวถูกนับในตาราง push_token_failures และลบเมื่อล้มติดกันครบ PruneAfterFailures ครั้ง
+// ในช่วงเวลาไม่น้อยกว่า PruneMinAge ส่งสำเร็จครั้งเดียวล้างตัวนับ ส่วน quota เป็นปัญหาของ project จึงไม่นับ
+package pushtoken
+
+import (
+	"context"
+	"errors"
+	"mydayplanner/config"
+	"mydayplanner/logging"
+	"mydayplanner/metrics"
+	"mydayplanner/model"
+	"mydayplanner/push"
+	"mydayplanner/store"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reasonFailures label ของ metrics เมื่อลบเพราะล้มชั่วคราวติดกันครบจำนวน
+const reasonFailures = "failures"
+
+type failure struct {
+	Token         string    `gorm:"column:token;primaryKey"`
+	Failures      int       `gorm:"column:failures"`
+	LastError     *string   `gorm:"column:last_error"`
+	FirstFailedAt time.Time `gorm:"column:first_failed_at"`
+	LastFailedAt  time.Time `gorm:"column:last_failed_at"`
+}
+
+func (failure) TableName() string {
+	return "push_token_failures"
+}
+
+// Provider ห่อ push.Provider แล้วจัดการ token จากผลของการส่งทุกครั้ง
+// ผู้เรียกใช้แทน provider เดิมได้เลย การลบ token ที่ล้มเหลวแค่ log ไว้และไม่ทำให้การส่งล้ม
+type Provider struct {
+	inner push.Provider
+	db    *gorm.DB
+	fb    store.Store
+	cfg   config.PushConfig
+	now   func() time.Time
+}
+
+func New(inner push.Provider, db *gorm.DB, fb store.Store, cfg config.PushConfig) *Provider {
+	return &Provider{inner: inner, db: db, fb: fb, cfg: cfg, now: time.Now}
+}
+
+func (p *Provider) Send(ctx context.Context, tokens []string, msg push.Message) ([]push.Result, error) {
+	results, err := p.inner.Send(ctx, tokens, msg)
+	if err != nil {
+		// ทั้ง request ล้มเป็นปัญหาของ provider ไม่ใช่ของ token จึงไม่นับ
+		return results, err
+	}
+	p.observe(ctx, results)
+	return results, nil
+}
+
+// observe ล้างตัวนับของ token ที่ส่งสำเร็จ และนับหรือลบ token ที่ล้มตามประเภท
+func (p *Provider) observe(ctx context.Context, results []push.Result) {
+	var delivered []string
+	for _, r := range results {
+		if r.Err == nil {
+			delivered = append(delivered, r.Token)
+			continue
+		}
+		reason := push.Classify(r.Err)
+		metrics.PushTokenFailures.WithLabelValues(string(reason)).Inc()
+		switch {
+		case reason.Permanent():
+			p.prune(ctx, r.Token, string(reason))
+		case reason == push.ReasonTransient:
+			p.countFailure(ctx, r.Token, r.Err)
+		}
+	}
+	if len(delivered) > 0 {
+		if err := p.db.WithContext(ctx).Where("token IN ?", delivered).Delete(&failure{}).Error; err != nil {
+			logging.FromContext(ctx).Error("failed to reset push token failures", "error", err)
+		}
+	}
+}
+
+// countFailure เพิ่มตัวนับของ token แล้วลบ token ถ้าล้มติดกันครบทั้งจำนวนครั้งและระยะเวลา
+func (p *Provider) countFailure(ctx context.Context, token string, sendErr error) {
+	logger := logging.FromContext(ctx)
+	db := p.db.WithContext(ctx)
+	now := p.now().UTC()
+	msg := sendErr.Error()
+	row := failure{Token: token, Failures: 1, LastError: &msg, FirstFailedAt: now, LastFailedAt: now}
+	if err := db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "token"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"failures":       gorm.Expr("failures + 1"),
+			"last_error":     msg,
+			"last_failed_at": now,
+		}),
+	}).Create(&row).Error; err != nil {
+		logger.Error("failed to count push token failure", "error", err)
+		return
+	}
+	if err := db.First(&row, "token = ?", token).Error; err != nil {
+		logger.Error("failed to read push token failures", "error", err)
+		return
+	}
+	if row.Failures >= p.cfg.PruneAfterFailures && now.Sub(row.FirstFailedAt) >= p.cfg.PruneMinAge {
+		p.prune(ctx, token, reasonFailures)
+	}
+}
+
+// prune ลบ token ออกจาก push_devices และทุกเอกสารใน usersLogin ที่ยังใช้ token นี้อยู่ แล้วลบตัวนับ
+// อ่านเอกสารอีกครั้งก่อนลบ ถ้าผู้ใช้ลงทะเบียน token ใหม่ไปแล้วจะไม่ลบ token ใหม่ทิ้ง
+func (p *Provider) prune(ctx context.Context, token, reason string) {
+	logger := logging.FromContext(ctx)
+	db := p.db.WithContext(ctx)
+	res := db.Where("token = ?", token).Delete(&model.PushDevice{})
+	if res.Error != nil {
+		logger.Error("failed to remove push device", "reason", reason, "error", res.Error)
+		return
+	}
+	if res.RowsAffected > 0 {
+		metrics.PushTokensPruned.WithLabelValues(reason).Inc()
+		logger.Info("removed push device", "reason", reason)
+	}
+
+	emails, err := p.fb.Logins().FindByToken(ctx, token)
+	if err != nil {
+		logger.Error("failed to find users of push token", "reason", reason, "error", err)
+		return
+	}
+	for _, email := range emails {
+		data, err := p.fb.Logins().Get(ctx, email)
+		if errors.Is(err, store.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			logger.Error("failed to read login document", "email", email, "error", err)
+			return
+		}
+		if current, _ := data["FMCToken"].(string); current != token {
+			continue
+		}
+		if err := p.fb.Logins().Update(ctx, email, store.Fields{"FMCToken": store.DeleteField}); err != nil {
+			logger.Error("failed to remove push token", "email", email, "reason", reason, "error", err)
+			return
+		}
+		metrics.PushTokensPruned.WithLabelValues(reason).Inc()
+		logger.Info("removed push token", "email", email, "reason", reason)
+	}
+	if err := db.Where("token = ?", token).Delete(&failure{}).Error; err != nil {
+		logger.Error("failed to clear push token failures", "error", err)
+	}
+}
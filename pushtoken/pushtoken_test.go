@@ -0,0 +1,131 @@
+package pushtoken
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mydayplanner/config"
+	"mydayplanner/model"
+	"mydayplanner/push"
+	"mydayplanner/store"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func newTestProvider(t *testing.T) (*Provider, *push.Recorder, *store.Memory) {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "pushtoken.db")), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&failure{}, &model.PushDevice{}); err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { sqlDB.Close() })
+
+	rec := push.NewRecorder()
+	fb := store.NewMemory()
+	cfg := config.PushConfig{PruneAfterFailures: 3, PruneMinAge: time.Hour}
+	return New(rec, db, fb, cfg), rec, fb
+}
+
+func login(t *testing.T, fb *store.Memory, email, token string) {
+	t.Helper()
+	if err := fb.Logins().Merge(context.Background(), email, store.Fields{"FMCToken": token}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func token(fb *store.Memory, email string) any {
+	doc, _ := fb.Doc("usersLogin/" + email)
+	return doc["FMCToken"]
+}
+
+func TestPermanentFailureRemovesTokenImmediately(t *testing.T) {
+	p, rec, fb := newTestProvider(t)
+	login(t, fb, "gone@example.test", "gone")
+	login(t, fb, "ok@example.test", "ok")
+	rec.Fail("gone", fmt.Errorf("%w: app uninstalled", push.ErrUnregistered))
+
+	results, err := p.Send(t.Context(), []string{"gone", "ok"}, push.Message{Title: "x"})
+	if err != nil || push.Failed(results) != 1 {
+		t.Fatalf("err = %v, results = %+v", err, results)
+	}
+	if got := token(fb, "gone@example.test"); got != nil {
+		t.Fatalf("unregistered token kept: %v", got)
+	}
+	if got := token(fb, "ok@example.test"); got != "ok" {
+		t.Fatalf("delivered token = %v, want ok", got)
+	}
+}
+
+func TestTransientFailuresRemoveTokenAfterThresholdAndAge(t *testing.T) {
+	p, rec, fb := newTestProvider(t)
+	login(t, fb, "flaky@example.test", "flaky")
+	rec.Fail("flaky", errors.New("unavailable"))
+	clock := time.Now()
+	p.now = func() time.Time { return clock }
+	send := func() {
+		t.Helper()
+		if _, err := p.Send(t.Context(), []string{"flaky"}, push.Message{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// ครบจำนวนครั้งแต่ยังไม่นานพอ
+	for range 3 {
+		send()
+	}
+	if token(fb, "flaky@example.test") == nil {
+		t.Fatal("token removed before PruneMinAge")
+	}
+
+	// ส่งสำเร็จหนึ่งครั้งเริ่มนับใหม่
+	rec.Fail("flaky", nil)
+	send()
+	rec.Fail("flaky", errors.New("unavailable"))
+	clock = clock.Add(2 * time.Hour)
+	send()
+	if token(fb, "flaky@example.test") == nil {
+		t.Fatal("success did not reset the failure counter")
+	}
+
+	clock = clock.Add(2 * time.Hour)
+	send()
+	send()
+	if got := token(fb, "flaky@example.test"); got != nil {
+		t.Fatalf("token kept after repeated failures: %v", got)
+	}
+}
+
+func TestQuotaFailuresAreNotCounted(t *testing.T) {
+	p, rec, fb := newTestProvider(t)
+	login(t, fb, "busy@example.test", "busy")
+	rec.Fail("busy", fmt.Errorf("%w: project quota", push.ErrQuota))
+	clock := time.Now()
+	p.now = func() time.Time { return clock }
+
+	for range 5 {
+		clock = clock.Add(time.Hour)
+		if _, err := p.Send(t.Context(), []string{"busy"}, push.Message{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if token(fb, "busy@example.test") != "busy" {
+		t.Fatal("token removed because of project quota")
+	}
+	var n int64
+	p.db.Model(&failure{}).Count(&n)
+	if n != 0 {
+		t.Fatalf("%d failure rows, want 0", n)
+	}
+}
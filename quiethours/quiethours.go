@@ -0,0 +1,232 @@
+// Package quiethours ช่วงเวลาที่ผู้ใช้ไม่ต้องการรับแจ้งเตือนงานที่ไม่เร่งด่วน ตาราง quiet_hours
+// แต่ละช่วงกำหนดวันในสัปดาห์กับเวลาเริ่มและสิ้นสุดตามเขตเวลาของผู้ใช้ (user.timezone) ช่วงที่สิ้นสุดก่อนเวลาเริ่มข้ามเที่ยงคืน
+// และช่วงที่เริ่มและสิ้นสุดเวลาเดียวกันคือทั้งวัน ใช้กำหนดวันหยุดสุดสัปดาห์ทั้งวันได้
+// scheduler ใช้ Until หาเวลาที่จะส่ง push ที่เลื่อนไว้
+package quiethours
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/model"
+	"mydayplanner/timezone"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// MaxWindows จำนวนช่วงสูงสุดต่อผู้ใช้
+	MaxWindows = 14
+	// maxQuiet ช่วงเงียบที่ต่อกันยาวเกินนี้ (เช่นตั้งทั้งวันทุกวัน) ถือว่าไม่เงียบ
+	// แจ้งเตือนจะไม่ถูกเลื่อนไปไม่มีกำหนด
+	maxQuiet      = 7 * 24 * time.Hour
+	minutesPerDay = 24 * 60
+)
+
+// Days วันในสัปดาห์ที่ช่วงเริ่ม bit ตาม time.Weekday (bit 0 = อาทิตย์)
+type Days uint8
+
+var dayNames = [7]string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// ParseDays แปลงชื่อวันแบบย่อ (sun ... sat) เป็น Days
+func ParseDays(names []string) (Days, error) {
+	var d Days
+	for _, name := range names {
+		found := false
+		for i, n := range dayNames {
+			if strings.EqualFold(name, n) {
+				d |= 1 << i
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown day %q", name)
+		}
+	}
+	return d, nil
+}
+
+// Has ช่วงเริ่มในวัน wd หรือไม่
+func (d Days) Has(wd time.Weekday) bool {
+	return d&(1<<wd) != 0
+}
+
+// Names ชื่อวันแบบย่อเรียงจากอาทิตย์
+func (d Days) Names() []string {
+	names := []string{}
+	for i, n := range dayNames {
+		if d.Has(time.Weekday(i)) {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// Window ช่วงเงียบหนึ่งช่วง Start และ End เป็นนาทีนับจากเที่ยงคืนตามเวลาท้องถิ่น
+type Window struct {
+	Days  Days
+	Start int
+	End   int
+}
+
+// Validate ตรวจว่ามีวันอย่างน้อยหนึ่งวัน เริ่มก่อน 24:00 และสิ้นสุดไม่เกิน 24:00
+func (w Window) Validate() error {
+	switch {
+	case w.Days == 0:
+		return fmt.Errorf("window has no days")
+	case w.Start < 0 || w.Start >= minutesPerDay:
+		return fmt.Errorf("start must be between 00:00 and 23:59")
+	case w.End < 0 || w.End > minutesPerDay:
+		return fmt.Errorf("end must be between 00:00 and 24:00")
+	}
+	return nil
+}
+
+// Schedule quiet hours ทั้งหมดของผู้ใช้หนึ่งคน ค่าว่างคือไม่มีช่วงเงียบ
+type Schedule struct {
+	Location *time.Location
+	Windows  []Window
+}
+
+// ParseClock แปลง HH:MM (00:00 ถึง 24:00) เป็นนาทีนับจากเที่ยงคืน
+func ParseClock(s string) (int, error) {
+	var h, m int
+	if n, err := fmt.Sscanf(s, "%d:%d", &h, &m); err != nil || n != 2 || len(s) != 5 {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	minute := h*60 + m
+	if h < 0 || m < 0 || m > 59 || minute > minutesPerDay {
+		return 0, fmt.Errorf("invalid time %q, want HH:MM", s)
+	}
+	return minute, nil
+}
+
+// FormatClock แปลงนาทีนับจากเที่ยงคืนเป็น HH:MM
+func FormatClock(minute int) string {
+	return fmt.Sprintf("%02d:%02d", minute/60, minute%60)
+}
+
+// Until เวลาที่ช่วงเงียบซึ่งครอบ t สิ้นสุด ช่วงที่ต่อกันหรือซ้อนกันถูกรวมเป็นช่วงเดียว
+// คืน false ถ้า t ไม่อยู่ในช่วงเงียบ หรือช่วงที่ต่อกันยาวเกิน 7 วัน
+func (s Schedule) Until(t time.Time) (time.Time, bool) {
+	if len(s.Windows) == 0 {
+		return t, false
+	}
+	end := t
+	for {
+		next, ok := s.covering(end)
+		if !ok {
+			break
+		}
+		end = next
+		if end.Sub(t) > maxQuiet {
+			return t, false
+		}
+	}
+	return end, end.After(t)
+}
+
+// covering เวลาสิ้นสุดที่ช้าที่สุดของช่วงที่ครอบ t ช่วงที่เริ่มเมื่อวานอาจข้ามเที่ยงคืนมาถึงวันนี้
+// ใช้ time.Date ทุกครั้งเวลาท้องถิ่นจึงถูกต้องข้ามวันที่เปลี่ยน daylight saving
+func (s Schedule) covering(t time.Time) (time.Time, bool) {
+	loc := s.Location
+	if loc == nil {
+		loc = time.UTC
+	}
+	y, m, d := t.In(loc).Date()
+	var best time.Time
+	found := false
+	for offset := -1; offset <= 0; offset++ {
+		wd := time.Date(y, m, d+offset, 12, 0, 0, 0, loc).Weekday()
+		for _, w := range s.Windows {
+			if !w.Days.Has(wd) {
+				continue
+			}
+			endMinute := w.End
+			if endMinute <= w.Start {
+				endMinute += minutesPerDay
+			}
+			start := time.Date(y, m, d+offset, 0, w.Start, 0, 0, loc)
+			end := time.Date(y, m, d+offset, 0, endMinute, 0, 0, loc)
+			if !t.Before(start) && t.Before(end) && end.After(best) {
+				best, found = end, true
+			}
+		}
+	}
+	return best, found
+}
+
+// Get quiet hours ของผู้ใช้หนึ่งคน ผู้ใช้ที่ไม่มีช่วงเงียบได้ Schedule ที่มีแค่เขตเวลา
+func Get(ctx context.Context, db *gorm.DB, userID int) (Schedule, error) {
+	var user model.User
+	if err := db.WithContext(ctx).Select("user_id", "timezone").First(&user, userID).Error; err != nil {
+		return Schedule{}, fmt.Errorf("load user time zone: %w", err)
+	}
+	schedules, err := Load(ctx, db, []int{userID})
+	if err != nil {
+		return Schedule{}, err
+	}
+	s := schedules[userID]
+	s.Location = timezone.Of(user)
+	return s, nil
+}
+
+// Load quiet hours ของผู้ใช้หลายคนในครั้งเดียวตามเขตเวลาของแต่ละคน ผู้ใช้ที่ไม่มีช่วงเงียบไม่อยู่ใน map
+func Load(ctx context.Context, db *gorm.DB, userIDs []int) (map[int]Schedule, error) {
+	schedules := make(map[int]Schedule)
+	if len(userIDs) == 0 {
+		return schedules, nil
+	}
+	db = db.WithContext(ctx)
+	var rows []model.QuietHourWindow
+	if err := db.Where("user_id IN ?", userIDs).Order("window_id").Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("load quiet hours: %w", err)
+	}
+	if len(rows) == 0 {
+		return schedules, nil
+	}
+	var users []model.User
+	if err := db.Select("user_id", "timezone").Where("user_id IN ?", userIDs).Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("load user time zones: %w", err)
+	}
+	zones := make(map[int]*time.Location, len(users))
+	for _, u := range users {
+		zones[u.UserID] = timezone.Of(u)
+	}
+	for _, r := range rows {
+		s := schedules[r.UserID]
+		if s.Location == nil {
+			s.Location = zones[r.UserID]
+		}
+		s.Windows = append(s.Windows, Window{Days: Days(r.Days), Start: r.StartMinute, End: r.EndMinute})
+		schedules[r.UserID] = s
+	}
+	return schedules, nil
+}
+
+// Save แทนที่ quiet hours ทั้งหมดของผู้ใช้ใน transaction เดียว
+func Save(ctx context.Context, db *gorm.DB, userID int, windows []Window) error {
+	rows := make([]model.QuietHourWindow, len(windows))
+	for i, w := range windows {
+		rows[i] = model.QuietHourWindow{
+			UserID:      userID,
+			Days:        uint8(w.Days),
+			StartMinute: w.Start,
+			EndMinute:   w.End,
+		}
+	}
+	err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.QuietHourWindow{}).Error; err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+		return tx.Create(&rows).Error
+	})
+	if err != nil {
+		return fmt.Errorf("save quiet hours: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package quiethours
+
+import (
+	"mydayplanner/model"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func mustDays(t *testing.T, names ...string) Days {
+	t.Helper()
+	d, err := ParseDays(names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestUntil(t *testing.T) {
+	bkk := mustLocation(t, "Asia/Bangkok")
+	night := Window{Days: mustDays(t, "mon", "tue", "wed", "thu", "fri"), Start: 22 * 60, End: 7 * 60}
+	weekend := Window{Days: mustDays(t, "sat", "sun"), Start: 0, End: 0}
+	s := Schedule{Location: bkk, Windows: []Window{night, weekend}}
+
+	at := func(day, hour, minute int) time.Time {
+		// ตุลาคม 2026 วันที่ 12 เป็นวันจันทร์
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, bkk)
+	}
+	for _, tc := range []struct {
+		name  string
+		t     time.Time
+		want  time.Time
+		quiet bool
+	}{
+		{"weekday afternoon", at(13, 15, 0), at(13, 15, 0), false},
+		{"before midnight", at(13, 23, 0), at(14, 7, 0), true},
+		{"after midnight", at(14, 6, 59), at(14, 7, 0), true},
+		{"window end is not quiet", at(14, 7, 0), at(14, 7, 0), false},
+		{"weeknight windows do not start on sunday", at(12, 6, 0), at(12, 6, 0), false},
+		// คืนวันศุกร์ต่อด้วยเสาร์อาทิตย์ทั้งวัน แล้วคืนวันอาทิตย์ไม่มีช่วงเงียบ จบเที่ยงคืนวันจันทร์
+		{"friday night runs into the weekend", at(16, 22, 30), at(19, 0, 0), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, quiet := s.Until(tc.t)
+			if quiet != tc.quiet || !got.Equal(tc.want) {
+				t.Fatalf("Until(%v) = %v, %v; want %v, %v", tc.t, got, quiet, tc.want, tc.quiet)
+			}
+		})
+	}
+}
+
+func TestUntilFollowsDaylightSaving(t *testing.T) {
+	ny := mustLocation(t, "America/New_York")
+	s := Schedule{Location: ny, Windows: []Window{{Days: mustDays(t, "sat"), Start: 22 * 60, End: 7 * 60}}}
+
+	// คืนวันเสาร์ที่ 7 มีนาคม 2026 นาฬิกาเดินหน้าตอนตีสอง ช่วงเงียบยังจบ 07:00 ตามเวลาท้องถิ่น
+	start := time.Date(2026, time.March, 7, 23, 0, 0, 0, ny)
+	got, quiet := s.Until(start)
+	want := time.Date(2026, time.March, 8, 7, 0, 0, 0, ny)
+	if !quiet || !got.Equal(want) {
+		t.Fatalf("Until = %v, %v; want %v", got, quiet, want)
+	}
+	if d := got.Sub(start); d != 7*time.Hour {
+		t.Fatalf("quiet for %v, want 7h on the short night", d)
+	}
+}
+
+func TestUntilIgnoresAlwaysQuiet(t *testing.T) {
+	s := Schedule{Location: time.UTC, Windows: []Window{{Days: mustDays(t, "sun", "mon", "tue", "wed", "thu", "fri", "sat")}}}
+	if got, quiet := s.Until(time.Now()); quiet {
+		t.Fatalf("Until = %v, want never quiet when every day is quiet", got)
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	for in, want := range map[string]int{"00:00": 0, "07:30": 450, "24:00": 1440} {
+		if got, err := ParseClock(in); err != nil || got != want {
+			t.Fatalf("ParseClock(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"7:30", "24:01", "12:60", "noon"} {
+		if _, err := ParseClock(in); err == nil {
+			t.Fatalf("ParseClock(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestSaveReplacesWindows(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open("file:"+filepath.Join(t.TempDir(), "quiethours.db")), &gorm.Config{Logger: gormlogger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&model.QuietHourWindow{}); err != nil {
+		t.Fatal(err)
+	}
+	// ตาราง user มีแค่คอลัมน์ที่ใช้ ENUM ของ model.User สร้างใน SQLite ไม่ได้
+	if err := db.Exec("CREATE TABLE user (user_id INTEGER PRIMARY KEY, timezone TEXT NOT NULL DEFAULT 'Asia/Bangkok')").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Exec("INSERT INTO user (user_id, timezone) VALUES (1, 'Asia/Tokyo'), (2, 'Asia/Bangkok')").Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := Save(t.Context(), db, 1, []Window{{Days: 1, Start: 0, End: 60}, {Days: 2, Start: 60, End: 120}}); err != nil {
+		t.Fatal(err)
+	}
+	second := []Window{{Days: 64, Start: 1320, End: 420}}
+	if err := Save(t.Context(), db, 1, second); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Get(t.Context(), db, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Location.String() != "Asia/Tokyo" || len(got.Windows) != 1 || got.Windows[0] != second[0] {
+		t.Fatalf("schedule = %+v, want only the second save in the user's zone", got)
+	}
+	if other, err := Get(t.Context(), db, 2); err != nil || other.Location.String() != "Asia/Bangkok" || len(other.Windows) != 0 {
+		t.Fatalf("schedule of another user = %+v, %v; want no windows", other, err)
+	}
+}
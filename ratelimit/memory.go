@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval ระยะห่างขั้นต่ำระหว่างการลบ bucket ที่เต็มแล้วออกจาก map
+const sweepInterval = time.Minute
+
+type memoryBucket struct {
+	bucket
+	policy Policy
+}
+
+// Memory เก็บ bucket ใน process ใช้เมื่อรัน instance เดียว หรือยอมให้แต่ละ instance นับแยกกัน
+type Memory struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+}
+
+// NewMemory สร้าง backend ในหน่วยความจำ
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*memoryBucket)}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if now.Sub(m.lastSweep) >= sweepInterval {
+		m.sweep(now)
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: bucket{tokens: float64(p.Limit), updated: now}}
+		m.buckets[key] = b
+	}
+	b.policy = p
+	return b.take(p, now), nil
+}
+
+// sweep ลบ bucket ที่เติมจนเต็มแล้ว กัน map โตตามจำนวน IP ที่เคยเรียก
+func (m *Memory) sweep(now time.Time) {
+	for key, b := range m.buckets {
+		if !now.Before(b.fullAt(b.policy)) {
+			delete(m.buckets, key)
+		}
+	}
+	m.lastSweep = now
+}
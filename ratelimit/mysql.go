@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"mydayplanner/logging"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// pruneEvery ลบแถวที่ไม่ได้ใช้ทุกๆ กี่ครั้งที่เรียก Take
+const pruneEvery = 1000
+
+// pruneAfter แถวที่ไม่ถูกแตะนานกว่านี้ถือว่า bucket เต็มแล้ว (policy ทุกตัวเติมเต็มภายในเวลานี้)
+const pruneAfter = 24 * time.Hour
+
+// MySQL เก็บ bucket ในตาราง rate_limit_buckets ให้ทุก instance นับร่วมกัน
+// แต่ละครั้งล็อกแถวของ key ด้วย SELECT ... FOR UPDATE จึงไม่นับซ้อนกันระหว่าง instance
+type MySQL struct {
+	db    *gorm.DB
+	calls atomic.Uint64
+}
+
+// NewMySQL สร้าง backend บน DB pool เดียวกับ API
+func NewMySQL(db *gorm.DB) *MySQL {
+	return &MySQL{db: db}
+}
+
+func (m *MySQL) Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error) {
+	now = now.UTC()
+	var res Result
+	err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// bucket ใหม่เริ่มแบบเต็ม INSERT IGNORE ไม่ทับแถวที่ instance อื่นสร้างไปแล้ว
+		if err := tx.Exec("INSERT IGNORE INTO rate_limit_buckets (bucket_key, tokens, updated_at) VALUES (?, ?, ?)",
+			key, float64(p.Limit), now).Error; err != nil {
+			return err
+		}
+
+		var row struct {
+			Tokens    float64
+			UpdatedAt time.Time
+		}
+		if err := tx.Raw("SELECT tokens, updated_at FROM rate_limit_buckets WHERE bucket_key = ? FOR UPDATE", key).
+			Scan(&row).Error; err != nil {
+			return err
+		}
+
+		b := bucket{tokens: row.Tokens, updated: row.UpdatedAt}
+		res = b.take(p, now)
+		return tx.Exec("UPDATE rate_limit_buckets SET tokens = ?, updated_at = ? WHERE bucket_key = ?",
+			b.tokens, b.updated, key).Error
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("rate limit bucket %s: %w", key, err)
+	}
+
+	if m.calls.Add(1)%pruneEvery == 0 {
+		if err := m.db.WithContext(ctx).Exec("DELETE FROM rate_limit_buckets WHERE updated_at < ?", now.Add(-pruneAfter)).Error; err != nil {
+			logging.FromContext(ctx).Warn("failed to prune rate limit buckets", "error", err)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,114 @@
+// Package ratelimit token bucket แยกตาม class ของ route และผู้เรียก (IP หรือ userId)
+//
+// bucket ของแต่ละ key จุได้ Limit token และเติมกลับจนเต็มภายใน Per
+// request หนึ่งครั้งใช้หนึ่ง token ถ้าไม่มี token เหลือจะได้เวลาที่ต้องรอก่อนลองใหม่
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Class กลุ่มของ route ที่ใช้ policy เดียวกัน
+type Class string
+
+const (
+	// ClassAuth route ที่ไม่ต้อง login และเดารหัสผ่านหรืออีเมลได้ เช่น signin, signup
+	ClassAuth Class = "auth"
+	// ClassSearch ค้นหาผู้ใช้
+	ClassSearch Class = "search"
+	// ClassReport ส่งรายงานถึงผู้ดูแลระบบ
+	ClassReport Class = "report"
+	// ClassJobs route ที่ cron ภายนอกเรียกโดยไม่มี token
+	ClassJobs Class = "jobs"
+)
+
+// KeyBy ตัวระบุผู้เรียกที่ใช้แยก bucket
+type KeyBy int
+
+const (
+	ByIP KeyBy = iota
+	// ByUser ใช้ userId จาก access token ต้องวาง middleware หลัง AccessTokenMiddleware
+	// ถ้า request ไม่มี userId จะใช้ IP แทน
+	ByUser
+)
+
+// Policy ขนาดและอัตราเติมของ bucket
+type Policy struct {
+	Limit int
+	Per   time.Duration
+	KeyBy KeyBy
+}
+
+// rate จำนวน token ที่เติมต่อวินาที
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Per.Seconds()
+}
+
+// Result ผลของการขอ token หนึ่งครั้ง RetryAfter มีค่าเมื่อ Allowed เป็น false เท่านั้น
+type Result struct {
+	Allowed    bool
+	Remaining  int
+	RetryAfter time.Duration
+}
+
+// Backend ที่เก็บ bucket
+// Memory แยกต่อ instance ส่วน MySQL ใช้ร่วมกันทุก instance ที่ต่อฐานข้อมูลเดียวกัน
+type Backend interface {
+	Take(ctx context.Context, key string, p Policy, now time.Time) (Result, error)
+}
+
+// bucket สถานะของ key หนึ่ง ณ เวลา updated
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// take เติม token ตามเวลาที่ผ่านไปแล้วหักหนึ่ง token ถ้ามีพอ
+func (b *bucket) take(p Policy, now time.Time) Result {
+	if elapsed := now.Sub(b.updated).Seconds(); elapsed > 0 {
+		b.tokens = math.Min(float64(p.Limit), b.tokens+elapsed*p.rate())
+	}
+	b.updated = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Result{Allowed: true, Remaining: int(b.tokens)}
+	}
+	wait := (1 - b.tokens) / p.rate()
+	return Result{RetryAfter: time.Duration(math.Ceil(wait * float64(time.Second)))}
+}
+
+// fullAt เวลาที่ bucket จะเต็มอีกครั้ง หลังจากนั้นลบทิ้งได้เพราะเหมือน bucket ใหม่
+func (b *bucket) fullAt(p Policy) time.Time {
+	missing := float64(p.Limit) - b.tokens
+	return b.updated.Add(time.Duration(missing / p.rate() * float64(time.Second)))
+}
+
+// Limiter จับคู่ class กับ policy แล้วขอ token จาก backend
+type Limiter struct {
+	backend  Backend
+	policies map[Class]Policy
+	now      func() time.Time
+}
+
+// New สร้าง Limiter class ที่ไม่มีใน policies ไม่ถูกจำกัด
+func New(backend Backend, policies map[Class]Policy) *Limiter {
+	return &Limiter{backend: backend, policies: policies, now: time.Now}
+}
+
+// Policy คืน policy ของ class
+func (l *Limiter) Policy(class Class) (Policy, bool) {
+	p, ok := l.policies[class]
+	return p, ok && p.Limit > 0 && p.Per > 0
+}
+
+// Allow ขอหนึ่ง token ของ subject ใน class เช่น subject "ip:203.0.113.7" หรือ "user:42"
+func (l *Limiter) Allow(ctx context.Context, class Class, subject string) (Result, error) {
+	p, ok := l.Policy(class)
+	if !ok {
+		return Result{Allowed: true}, nil
+	}
+	return l.backend.Take(ctx, string(class)+":"+subject, p, l.now())
+}
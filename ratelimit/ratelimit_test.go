@@ -0,0 +1,52 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryTokenBucket(t *testing.T) {
+	m := NewMemory()
+	p := Policy{Limit: 2, Per: 10 * time.Second}
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	take := func(key string, at time.Duration) Result {
+		t.Helper()
+		res, err := m.Take(context.Background(), key, p, start.Add(at))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	if res := take("a", 0); !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("first request: %+v, want allowed with 1 remaining", res)
+	}
+	if res := take("a", 0); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("second request: %+v, want allowed with 0 remaining", res)
+	}
+	if res := take("a", time.Second); res.Allowed || res.RetryAfter != 4*time.Second {
+		t.Fatalf("third request after 1s: %+v, want denied with RetryAfter 4s", res)
+	}
+	if res := take("b", time.Second); !res.Allowed {
+		t.Fatalf("other key: %+v, want its own bucket", res)
+	}
+	if res := take("a", 5*time.Second); !res.Allowed || res.Remaining != 0 {
+		t.Fatalf("after one refill: %+v, want allowed with 0 remaining", res)
+	}
+	if res := take("a", time.Hour); !res.Allowed || res.Remaining != 1 {
+		t.Fatalf("after idle hour: %+v, want a full bucket capped at Limit", res)
+	}
+	if len(m.buckets) != 1 {
+		t.Fatalf("sweep kept %d buckets, want only the one just used", len(m.buckets))
+	}
+}
+
+func TestLimiterWithoutPolicyAllows(t *testing.T) {
+	l := New(NewMemory(), map[Class]Policy{ClassAuth: {Limit: 0, Per: time.Minute}})
+	for _, class := range []Class{ClassAuth, ClassJobs} {
+		if res, err := l.Allow(context.Background(), class, "ip:192.0.2.1"); err != nil || !res.Allowed {
+			t.Fatalf("%s: %+v %v, want allowed when the class has no limit", class, res, err)
+		}
+	}
+}
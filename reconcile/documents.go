@@ -0,0 +1,72 @@
+package reconcile
+
+import (
+	"mydayplanner/model"
+	"mydayplanner/store"
+	"time"
+)
+
+// เอกสารที่คาดว่าจะมีใน Firestore สร้างแบบเดียวกับที่ handler เขียนตอนสร้างข้อมูล
+// ใช้เป็นเนื้อหาเมื่อต้องสร้างเอกสารที่ขาดขึ้นใหม่
+
+// taskCompared field ของ Boards/{boardID}/Tasks ที่แอปแสดงและต้องตรงกับ MySQL
+var taskCompared = []string{"taskName", "description", "status", "priority"}
+
+func taskFields(task model.Tasks, boardID int) store.Fields {
+	data := store.Fields{
+		"taskID":      task.TaskID,
+		"boardID":     boardID,
+		"taskName":    task.TaskName,
+		"description": task.Description,
+		"status":      task.Status,
+		"priority":    task.Priority,
+		"createBy":    task.CreateBy,
+		"createAt":    task.CreateAt,
+		"updatedAt":   time.Now(),
+	}
+	if task.Description != nil {
+		data["description"] = *task.Description
+	}
+	if task.Priority != nil {
+		data["priority"] = *task.Priority
+	}
+	if task.CreateBy != nil {
+		data["createBy"] = *task.CreateBy
+	}
+	return data
+}
+
+// notificationCompared field ของการแจ้งเตือนที่แอปใช้ตั้งเวลาและแสดงสถานะ
+var notificationCompared = []string{"dueDate", "beforeDueDate", "isSend"}
+
+func notificationFields(n model.Notification) store.Fields {
+	data := store.Fields{
+		"notificationID": n.NotificationID,
+		"taskID":         n.TaskID,
+		"dueDate":        n.DueDate,
+		"isSend":         n.IsSend,
+		"createdAt":      n.CreatedAt,
+		"updatedAt":      time.Now(),
+	}
+	if n.RecurringPattern != "" {
+		data["recurringPattern"] = n.RecurringPattern
+	}
+	if n.BeforeDueDate != nil {
+		data["beforeDueDate"] = n.BeforeDueDate
+	}
+	return data
+}
+
+var attachmentCompared = []string{"file_name", "file_path", "file_type"}
+
+func attachmentFields(a model.Attachment) store.Fields {
+	return store.Fields{
+		"attachment_id": a.AttachmentID,
+		"tasks_id":      a.TasksID,
+		"file_name":     a.FileName,
+		"file_path":     a.FilePath,
+		"file_type":     a.FileType,
+		"upload_at":     a.UploadAt,
+		"update_at":     time.Now(),
+	}
+}
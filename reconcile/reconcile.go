@@ -0,0 +1,368 @@
+// Package reconcile เทียบข้อมูลใน MySQL กับ Firestore mirror แล้วรายงานเอกสารที่ขาด ไม่ตรง หรือค้างอยู่
+// โหมด apply ซ่อมผ่าน outbox จึงเรียงลำดับร่วมกับการเขียนของ handler และลองใหม่เองเมื่อ Firestore ล่ม
+//
+// mirror ที่ตรวจ:
+//   - Boards/{boardID}/Tasks/{taskID} ของบอร์ดกลุ่ม (บอร์ดที่มีแถวใน board_user)
+//   - BoardTasks/{taskID}/Notifications และ BoardTasks/{taskID}/Attachments ของงานในบอร์ดกลุ่ม
+//   - Notifications/{email}/Tasks ของงานส่วนตัวและงานในบอร์ดเดี่ยว ตามผู้สร้างงาน
+package reconcile
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mydayplanner/metrics"
+	"mydayplanner/model"
+	"mydayplanner/outbox"
+	"mydayplanner/store"
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Kind ชนิดของความคลาดเคลื่อน
+type Kind string
+
+const (
+	// Missing มีใน MySQL แต่ไม่มีเอกสารใน Firestore
+	Missing Kind = "missing"
+	// Stale มีทั้งสองฝั่งแต่ field ที่แอปใช้ไม่ตรงกัน
+	Stale Kind = "stale"
+	// Orphaned มีเอกสารใน Firestore แต่ไม่มีแถวที่ตรงกันใน MySQL แล้ว
+	Orphaned Kind = "orphaned"
+)
+
+// Mirror ชื่อกลุ่มเอกสารที่ใช้ในรายงานและเป็น label ของ metrics
+const (
+	MirrorBoardTasks        = "board_tasks"
+	MirrorTaskNotifications = "task_notifications"
+	MirrorTaskAttachments   = "task_attachments"
+	MirrorUserNotifications = "user_notifications"
+)
+
+// Options ขอบเขตของการตรวจ ถ้าไม่ระบุ BoardID และ UserID จะตรวจทุกบอร์ดกลุ่มและทุกผู้ใช้
+type Options struct {
+	BoardID int
+	UserID  int
+	// Apply ซ่อมเอกสารที่คลาดเคลื่อน ถ้า false แค่รายงาน (dry-run)
+	Apply bool
+}
+
+// Drift เอกสารหนึ่งรายการที่ไม่ตรงกับ MySQL
+type Drift struct {
+	Kind   Kind     `json:"kind"`
+	Mirror string   `json:"mirror"`
+	Path   string   `json:"path"`
+	Fields []string `json:"fields,omitempty"`
+}
+
+// Report ผลการตรวจหนึ่งรอบ
+type Report struct {
+	DryRun     bool      `json:"dry_run"`
+	Boards     int       `json:"boards"`
+	Users      int       `json:"users"`
+	Documents  int       `json:"documents"`
+	Drifts     []Drift   `json:"drifts"`
+	Repaired   int       `json:"repaired"`
+	Skipped    int       `json:"skipped_pending_outbox"`
+	StartedAt  time.Time `json:"started_at"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// Count จำนวน drift ของชนิด kind
+func (r *Report) Count(kind Kind) int {
+	n := 0
+	for _, d := range r.Drifts {
+		if d.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// ErrInvalidScope คืนเมื่อระบุทั้ง BoardID และ UserID พร้อมกัน
+var ErrInvalidScope = errors.New("reconcile: board_id and user_id cannot be combined")
+
+// Run ตรวจตาม opts แล้วคืนรายงาน เอกสารที่ยังมีแถวรออยู่ใน outbox ถูกข้าม เพราะ relay ยังเขียนไม่ถึง
+func Run(ctx context.Context, db *gorm.DB, fb store.Store, opts Options) (*Report, error) {
+	if opts.BoardID != 0 && opts.UserID != 0 {
+		return nil, ErrInvalidScope
+	}
+	r := &reconciler{
+		db:     db,
+		fb:     fb,
+		opts:   opts,
+		report: &Report{DryRun: !opts.Apply, Drifts: []Drift{}, StartedAt: time.Now()},
+	}
+
+	var pending []string
+	if err := db.WithContext(ctx).Model(&outbox.Entry{}).Distinct().Pluck("doc_path", &pending).Error; err != nil {
+		return nil, fmt.Errorf("failed to read pending outbox entries: %w", err)
+	}
+	r.pending = make(map[string]bool, len(pending))
+	for _, path := range pending {
+		r.pending[path] = true
+	}
+
+	if opts.UserID == 0 {
+		if err := r.boards(ctx); err != nil {
+			return nil, err
+		}
+	}
+	if opts.BoardID == 0 {
+		if err := r.users(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Apply && len(r.repairs) > 0 {
+		err := db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			for _, m := range r.repairs {
+				if err := outbox.Enqueue(tx, m); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to enqueue repairs: %w", err)
+		}
+		r.report.Repaired = len(r.repairs)
+	}
+
+	for _, d := range r.report.Drifts {
+		metrics.ReconcileDrift.WithLabelValues(d.Mirror, string(d.Kind)).Inc()
+	}
+	r.report.FinishedAt = time.Now()
+	return r.report, nil
+}
+
+type reconciler struct {
+	db      *gorm.DB
+	fb      store.Store
+	opts    Options
+	pending map[string]bool
+	repairs []store.Mutation
+	report  *Report
+}
+
+// ---------- บอร์ดกลุ่ม ----------
+
+func (r *reconciler) boards(ctx context.Context) error {
+	q := r.db.WithContext(ctx).Model(&model.BoardUser{}).Distinct()
+	if r.opts.BoardID != 0 {
+		q = q.Where("board_id = ?", r.opts.BoardID)
+	}
+	var boardIDs []int
+	if err := q.Order("board_id").Pluck("board_id", &boardIDs).Error; err != nil {
+		return fmt.Errorf("failed to list group boards: %w", err)
+	}
+
+	for _, boardID := range boardIDs {
+		if err := r.board(ctx, boardID); err != nil {
+			return fmt.Errorf("board %d: %w", boardID, err)
+		}
+		r.report.Boards++
+	}
+	return nil
+}
+
+func (r *reconciler) board(ctx context.Context, boardID int) error {
+	var tasks []model.Tasks
+	if err := r.db.WithContext(ctx).Where("board_id = ?", boardID).Order("task_id").Find(&tasks).Error; err != nil {
+		return err
+	}
+	docs, err := r.fb.Boards().ListTasks(ctx, boardID)
+	if err != nil {
+		return err
+	}
+	expected := make(map[string]store.Fields, len(tasks))
+	for _, task := range tasks {
+		expected[strconv.Itoa(task.TaskID)] = taskFields(task, boardID)
+	}
+	r.diff(MirrorBoardTasks, fmt.Sprintf("Boards/%d/Tasks", boardID), expected, docs, taskCompared)
+
+	for _, task := range tasks {
+		if err := r.boardTask(ctx, task.TaskID); err != nil {
+			return fmt.Errorf("task %d: %w", task.TaskID, err)
+		}
+	}
+	return nil
+}
+
+func (r *reconciler) boardTask(ctx context.Context, taskID int) error {
+	var notifications []model.Notification
+	if err := r.db.WithContext(ctx).Where("task_id = ?", taskID).Find(&notifications).Error; err != nil {
+		return err
+	}
+	docs, err := r.fb.BoardTasks().ListItems(ctx, taskID, store.TaskNotifications)
+	if err != nil {
+		return err
+	}
+	expected := make(map[string]store.Fields, len(notifications))
+	for _, n := range notifications {
+		expected[strconv.Itoa(n.NotificationID)] = notificationFields(n)
+	}
+	r.diff(MirrorTaskNotifications, fmt.Sprintf("BoardTasks/%d/%s", taskID, store.TaskNotifications), expected, docs, notificationCompared)
+
+	var attachments []model.Attachment
+	if err := r.db.WithContext(ctx).Where("tasks_id = ?", taskID).Find(&attachments).Error; err != nil {
+		return err
+	}
+	docs, err = r.fb.BoardTasks().ListItems(ctx, taskID, store.TaskAttachments)
+	if err != nil {
+		return err
+	}
+	expected = make(map[string]store.Fields, len(attachments))
+	for _, a := range attachments {
+		expected[strconv.Itoa(a.AttachmentID)] = attachmentFields(a)
+	}
+	r.diff(MirrorTaskAttachments, fmt.Sprintf("BoardTasks/%d/%s", taskID, store.TaskAttachments), expected, docs, attachmentCompared)
+	return nil
+}
+
+// ---------- การแจ้งเตือนของผู้ใช้ ----------
+
+func (r *reconciler) users(ctx context.Context) error {
+	q := r.db.WithContext(ctx).Model(&model.User{})
+	if r.opts.UserID != 0 {
+		q = q.Where("user_id = ?", r.opts.UserID)
+	}
+	var users []model.User
+	if err := q.Order("user_id").Find(&users).Error; err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	for _, user := range users {
+		if err := r.user(ctx, user); err != nil {
+			return fmt.Errorf("user %d: %w", user.UserID, err)
+		}
+		r.report.Users++
+	}
+	return nil
+}
+
+func (r *reconciler) user(ctx context.Context, user model.User) error {
+	// งานที่ผู้ใช้สร้างและไม่ได้อยู่ในบอร์ดกลุ่ม การแจ้งเตือนจึงอยู่ใต้อีเมลของผู้สร้าง
+	var notifications []model.Notification
+	err := r.db.WithContext(ctx).
+		Joins("JOIN tasks ON tasks.task_id = notification.task_id").
+		Where("tasks.create_by = ?", user.UserID).
+		Where("tasks.board_id IS NULL OR tasks.board_id NOT IN (?)", r.db.Model(&model.BoardUser{}).Select("board_id")).
+		Find(&notifications).Error
+	if err != nil {
+		return err
+	}
+	docs, err := r.fb.Notifications().ListTasks(ctx, user.Email)
+	if err != nil {
+		return err
+	}
+	expected := make(map[string]store.Fields, len(notifications))
+	for _, n := range notifications {
+		expected[strconv.Itoa(n.NotificationID)] = notificationFields(n)
+	}
+	r.diff(MirrorUserNotifications, fmt.Sprintf("Notifications/%s/Tasks", user.Email), expected, docs, notificationCompared)
+	return nil
+}
+
+// ---------- เทียบเอกสาร ----------
+
+// diff เทียบเอกสารใน collection กับ expected ที่ key ด้วย document ID
+// compared คือ field ที่ต้องตรงกัน field อื่นเช่น updatedAt เปลี่ยนได้โดยไม่ถือว่าคลาดเคลื่อน
+func (r *reconciler) diff(mirror, collection string, expected map[string]store.Fields, docs []store.Document, compared []string) {
+	actual := make(map[string]store.Fields, len(docs))
+	for _, doc := range docs {
+		actual[doc.ID] = doc.Data
+	}
+
+	ids := make([]string, 0, len(expected)+len(actual))
+	for id := range expected {
+		ids = append(ids, id)
+	}
+	for id := range actual {
+		if _, ok := expected[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, errA := strconv.Atoi(ids[i])
+		b, errB := strconv.Atoi(ids[j])
+		if errA != nil || errB != nil {
+			return ids[i] < ids[j]
+		}
+		return a < b
+	})
+
+	for _, id := range ids {
+		path := collection + "/" + id
+		r.report.Documents++
+		if r.pending[path] {
+			r.report.Skipped++
+			continue
+		}
+
+		want, inSQL := expected[id]
+		got, inFirestore := actual[id]
+		switch {
+		case inSQL && !inFirestore:
+			r.drift(Drift{Kind: Missing, Mirror: mirror, Path: path}, store.Mutation{Op: store.OpSet, Path: path, Data: want})
+		case !inSQL && inFirestore:
+			r.drift(Drift{Kind: Orphaned, Mirror: mirror, Path: path}, store.Mutation{Op: store.OpDelete, Path: path})
+		default:
+			var fields []string
+			patch := store.Fields{}
+			for _, field := range compared {
+				if !sameValue(want[field], got[field]) {
+					fields = append(fields, field)
+					patch[field] = want[field]
+				}
+			}
+			if len(fields) > 0 {
+				patch["updatedAt"] = time.Now()
+				r.drift(Drift{Kind: Stale, Mirror: mirror, Path: path, Fields: fields}, store.Mutation{Op: store.OpMerge, Path: path, Data: patch})
+			}
+		}
+	}
+}
+
+func (r *reconciler) drift(d Drift, repair store.Mutation) {
+	r.report.Drifts = append(r.report.Drifts, d)
+	r.repairs = append(r.repairs, repair)
+}
+
+// sameValue เทียบค่าจาก MySQL กับค่าที่อ่านจาก Firestore
+// Firestore คืนตัวเลขเป็น int64 และเก็บเวลาละเอียดกว่า MySQL จึงเทียบเวลาแค่ระดับวินาที
+func sameValue(want, got interface{}) bool {
+	return reflect.DeepEqual(normalize(want), normalize(got))
+}
+
+func normalize(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil
+	}
+	switch val := rv.Interface().(type) {
+	case time.Time:
+		return val.UTC().Truncate(time.Second)
+	case string:
+		return val
+	}
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float()
+	}
+	return rv.Interface()
+}
@@ -2,46 +2,109 @@
 package scheduler
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
 	"mydayplanner/connection"
 	"mydayplanner/controller/notification"
+	"mydayplanner/logging"
+	"mydayplanner/metrics"
+	"mydayplanner/outbox"
+	"mydayplanner/reconcile"
+	"time"
 
 	"github.com/robfig/cron/v3"
 )
 
-func StartScheduler() {
+// StartScheduler รัน cron จน ctx ถูกยกเลิก แล้วรอ job ที่กำลังทำงานให้เสร็จ
+// ใช้ DB pool และ Firestore client เดียวกับ API จาก deps
+// ทุก instance รัน cron แต่ job และ outbox relay ทำงานเฉพาะบน instance ที่ถือ lease ของ deps.Leader
+// job ที่เริ่มไปแล้วตอนเสีย lease จะรันจนจบรอบนั้น
+func StartScheduler(ctx context.Context, deps *connection.Deps) error {
 	c := cron.New(cron.WithSeconds()) // เปิดใช้ seconds
+	cfg, DB, FB := deps.Config, deps.DB, deps.FB
+	elector := deps.Leader
 
-	// เชื่อมต่อ database
-	DB, err := connection.DBConnection()
-	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+	leaderOnly := func(job func()) func() {
+		return func() {
+			if elector.IsLeader() {
+				job()
+			}
+		}
 	}
 
-	FB, err := connection.FBConnection()
-	if err != nil {
-		log.Fatalf("Failed to initialize Firestore client: %v", err)
+	// Job ส่งแจ้งเตือน ค่าเริ่มต้นรันทุกนาที (ใช้ seconds format: "0 * * * * *")
+	if _, err := c.AddFunc(cfg.Scheduler.NotificationSpec, leaderOnly(func() {
+		// run_id ผูกทุก log ของรอบนี้ รวมถึง worker และการเขียน Firestore
+		logger := slog.Default().With("job", "send_notification", "run_id", logging.NewID())
+		jobCtx := logging.WithContext(context.Background(), logger)
+		err := notification.SendNotificationJob(jobCtx, DB, FB, deps.Push)
+		deps.NotificationJob.Record(time.Now(), err)
+	})); err != nil {
+		return fmt.Errorf("failed to add SendNotificationJob cron: %w", err)
 	}
 
-	// Job ที่รันทุกนาที (ใช้ seconds format: "0 * * * * *")
-	if _, err = c.AddFunc("0 * * * * *", func() {
-		log.Println("Running scheduled notification job...")
-		notification.SendNotificationJob(DB, FB)
-	}); err != nil {
-		log.Fatalf("Failed to add SendNotificationJob cron: %v", err)
+	// Job ตรวจ Firestore mirror เทียบกับ MySQL ค่าเริ่มต้นตีสามทุกวันแบบ dry-run
+	if spec := cfg.Scheduler.ReconcileSpec; spec != "off" {
+		if _, err := c.AddFunc(spec, leaderOnly(func() {
+			logger := slog.Default().With("job", "reconcile", "run_id", logging.NewID())
+			jobCtx := logging.WithContext(context.Background(), logger)
+			defer metrics.ObserveJob(metrics.JobReconcile, time.Now())
+			report, err := reconcile.Run(jobCtx, DB, FB, reconcile.Options{Apply: cfg.Scheduler.ReconcileApply})
+			if err != nil {
+				logger.Error("reconcile failed", "error", err)
+				return
+			}
+			logger.Info("reconcile finished",
+				"dry_run", report.DryRun,
+				"documents", report.Documents,
+				"missing", report.Count(reconcile.Missing),
+				"stale", report.Count(reconcile.Stale),
+				"orphaned", report.Count(reconcile.Orphaned),
+				"repaired", report.Repaired,
+			)
+		})); err != nil {
+			return fmt.Errorf("failed to add reconcile cron: %w", err)
+		}
 	}
 
-	// Job ที่รันทุกชั่วโมง (ใช้ minutes format: "0 * * * *")
-	// if _, err := c.AddFunc("0 0 0 * * *", func() {
-	// 	log.Println("Running midnight daily task...")
-	// 	notification.RepeatNotificationJob(DB, FB)
-	// }); err != nil {
-	// 	log.Fatalf("Failed to add RepeatNotificationJob cron: %v", err)
-	// }
+	// เลือก leader คู่กับ cron lease ถูกคืนหลัง job ที่กำลังรันเสร็จแล้วเท่านั้น
+	electorCtx := logging.WithContext(ctx, slog.Default().With("job", "leader_election"))
+	electorDone := make(chan struct{})
+	go func() {
+		defer close(electorDone)
+		elector.Run(electorCtx)
+	}()
+
+	// relay เขียนการเปลี่ยนแปลงที่ handler บันทึกไว้ใน firestore_outbox ลง Firestore
+	relay := outbox.NewRelay(DB, FB, cfg.Outbox).OnlyWhen(elector.IsLeader)
+	relayCtx := logging.WithContext(ctx, slog.Default().With("job", "outbox_relay"))
+	relayDone := make(chan struct{})
+	go func() {
+		defer close(relayDone)
+		relay.Run(relayCtx)
+	}()
 
 	c.Start()
-	log.Println("Scheduler started")
+	slog.Info("scheduler started", "notification_spec", cfg.Scheduler.NotificationSpec,
+		"outbox_poll_interval", cfg.Outbox.PollInterval, "instance", elector.ID())
+
+	<-ctx.Done()
+	<-relayDone
+	<-electorDone
 
-	// Block forever
-	select {}
+	// Stop ไม่รับ job ใหม่ และคืน context ที่จะ Done เมื่อ job ที่กำลังรันเสร็จหมด
+	slog.Info("stopping scheduler, waiting for running jobs")
+	stopped := c.Stop()
+	timeout, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	select {
+	case <-stopped.Done():
+		slog.Info("scheduler stopped")
+	case <-timeout.Done():
+		return fmt.Errorf("scheduler jobs still running after %s", cfg.Server.ShutdownTimeout)
+	}
+	// ถ้า job ยังค้างอยู่จะไม่คืน lease ให้ instance อื่นรอจน lease หมดอายุเอง
+	elector.Release(logging.WithContext(timeout, logging.FromContext(electorCtx)))
+	return nil
 }
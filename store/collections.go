@@ -0,0 +1,438 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mydayplanner/model"
+	"strconv"
+	"time"
+)
+
+// backend การอ่านเขียนเอกสารตาม path ที่ Firestore และ Memory ต้องทำให้ได้
+// typed store ทุกตัวด้านล่างสร้าง path แล้วเรียก backend นี้
+type backend interface {
+	get(ctx context.Context, path string) (Fields, error)
+	set(ctx context.Context, path string, data Fields, merge bool) error
+	update(ctx context.Context, path string, data Fields) error
+	delete(ctx context.Context, paths ...string) error
+	list(ctx context.Context, collection string) ([]Document, error)
+	// find เอกสารใน collection ที่ field มีค่าเท่ากับ value
+	find(ctx context.Context, collection, field string, value interface{}) ([]Document, error)
+	close() error
+}
+
+type docStore struct {
+	b backend
+}
+
+func (s docStore) Boards() BoardStore               { return boards{s.b} }
+func (s docStore) BoardTasks() BoardTaskStore       { return boardTasks{s.b} }
+func (s docStore) Notifications() NotificationStore { return notifications{s.b} }
+func (s docStore) Invites() InviteStore             { return invites{s.b} }
+func (s docStore) RefreshTokens() RefreshTokenStore { return refreshTokens{s.b} }
+func (s docStore) OTPRecords() OTPRecordStore       { return otpRecords{s.b} }
+func (s docStore) Logins() LoginStore               { return logins{s.b} }
+func (s docStore) Reports() ReportStore             { return reports{s.b} }
+func (s docStore) Close() error                     { return s.b.close() }
+
+// healthDocPath เอกสารที่ไม่มีใครเขียน ใช้อ่านทดสอบใน Ping
+const healthDocPath = "_health/ping"
+
+func (s docStore) Ping(ctx context.Context) error {
+	if _, err := s.b.get(ctx, healthDocPath); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// ---------- Boards ----------
+
+type boards struct{ b backend }
+
+func boardPath(boardID int) string { return fmt.Sprintf("Boards/%d", boardID) }
+
+func (s boards) Get(ctx context.Context, boardID int) (Fields, error) {
+	return s.b.get(ctx, boardPath(boardID))
+}
+
+func (s boards) Set(ctx context.Context, boardID int, data Fields) error {
+	return s.b.set(ctx, boardPath(boardID), data, false)
+}
+
+func (s boards) Update(ctx context.Context, boardID int, data Fields) error {
+	return s.b.update(ctx, boardPath(boardID), data)
+}
+
+func (s boards) Delete(ctx context.Context, boardID int) error {
+	return s.b.delete(ctx, boardPath(boardID))
+}
+
+func (s boards) GetTask(ctx context.Context, boardID, taskID int) (Fields, error) {
+	return s.b.get(ctx, fmt.Sprintf("Boards/%d/Tasks/%d", boardID, taskID))
+}
+
+func (s boards) SetTask(ctx context.Context, boardID, taskID int, data Fields) error {
+	return s.b.set(ctx, fmt.Sprintf("Boards/%d/Tasks/%d", boardID, taskID), data, false)
+}
+
+func (s boards) UpdateTask(ctx context.Context, boardID, taskID int, data Fields) error {
+	return s.b.update(ctx, fmt.Sprintf("Boards/%d/Tasks/%d", boardID, taskID), data)
+}
+
+func (s boards) DeleteTask(ctx context.Context, boardID, taskID int) error {
+	return s.b.delete(ctx, fmt.Sprintf("Boards/%d/Tasks/%d", boardID, taskID))
+}
+
+func (s boards) ListTasks(ctx context.Context, boardID int) ([]Document, error) {
+	return s.b.list(ctx, fmt.Sprintf("Boards/%d/Tasks", boardID))
+}
+
+func (s boards) SetUser(ctx context.Context, boardID, boardUserID int, data Fields) error {
+	return s.b.set(ctx, fmt.Sprintf("Boards/%d/BoardUsers/%d", boardID, boardUserID), data, false)
+}
+
+func (s boards) DeleteUser(ctx context.Context, boardID, boardUserID int) error {
+	return s.b.delete(ctx, fmt.Sprintf("Boards/%d/BoardUsers/%d", boardID, boardUserID))
+}
+
+// ---------- BoardTasks ----------
+
+type boardTasks struct{ b backend }
+
+func boardTaskPath(taskID int) string { return fmt.Sprintf("BoardTasks/%d", taskID) }
+
+func taskItemPath(taskID int, col TaskCollection, id string) string {
+	return fmt.Sprintf("BoardTasks/%d/%s/%s", taskID, col, id)
+}
+
+func (s boardTasks) Get(ctx context.Context, taskID int) (Fields, error) {
+	return s.b.get(ctx, boardTaskPath(taskID))
+}
+
+func (s boardTasks) Merge(ctx context.Context, taskID int, data Fields) error {
+	return s.b.set(ctx, boardTaskPath(taskID), data, true)
+}
+
+func (s boardTasks) DeleteAll(ctx context.Context, taskID int) error {
+	for _, col := range TaskCollections {
+		docs, err := s.ListItems(ctx, taskID, col)
+		if err != nil {
+			return fmt.Errorf("failed to list %s of task %d: %w", col, taskID, err)
+		}
+		paths := make([]string, 0, len(docs))
+		for _, doc := range docs {
+			paths = append(paths, taskItemPath(taskID, col, doc.ID))
+		}
+		if err := s.b.delete(ctx, paths...); err != nil {
+			return fmt.Errorf("failed to delete %s of task %d: %w", col, taskID, err)
+		}
+	}
+	return s.b.delete(ctx, boardTaskPath(taskID))
+}
+
+func (s boardTasks) GetItem(ctx context.Context, taskID int, col TaskCollection, id string) (Fields, error) {
+	return s.b.get(ctx, taskItemPath(taskID, col, id))
+}
+
+func (s boardTasks) SetItem(ctx context.Context, taskID int, col TaskCollection, id string, data Fields) error {
+	return s.b.set(ctx, taskItemPath(taskID, col, id), data, false)
+}
+
+func (s boardTasks) MergeItem(ctx context.Context, taskID int, col TaskCollection, id string, data Fields) error {
+	return s.b.set(ctx, taskItemPath(taskID, col, id), data, true)
+}
+
+func (s boardTasks) UpdateItem(ctx context.Context, taskID int, col TaskCollection, id string, data Fields) error {
+	return s.b.update(ctx, taskItemPath(taskID, col, id), data)
+}
+
+func (s boardTasks) DeleteItem(ctx context.Context, taskID int, col TaskCollection, id string) error {
+	return s.b.delete(ctx, taskItemPath(taskID, col, id))
+}
+
+func (s boardTasks) ListItems(ctx context.Context, taskID int, col TaskCollection) ([]Document, error) {
+	return s.b.list(ctx, fmt.Sprintf("BoardTasks/%d/%s", taskID, col))
+}
+
+// ---------- Notifications ----------
+
+type notifications struct{ b backend }
+
+func notificationPath(email string, notificationID int) string {
+	return fmt.Sprintf("Notifications/%s/Tasks/%d", email, notificationID)
+}
+
+func (s notifications) GetTask(ctx context.Context, email string, notificationID int) (Fields, error) {
+	return s.b.get(ctx, notificationPath(email, notificationID))
+}
+
+func (s notifications) SetTask(ctx context.Context, email string, notificationID int, data Fields) error {
+	return s.b.set(ctx, notificationPath(email, notificationID), data, false)
+}
+
+func (s notifications) MergeTask(ctx context.Context, email string, notificationID int, data Fields) error {
+	return s.b.set(ctx, notificationPath(email, notificationID), data, true)
+}
+
+func (s notifications) UpdateTask(ctx context.Context, email string, notificationID int, data Fields) error {
+	return s.b.update(ctx, notificationPath(email, notificationID), data)
+}
+
+func (s notifications) DeleteTasks(ctx context.Context, email string, notificationIDs ...int) error {
+	paths := make([]string, 0, len(notificationIDs))
+	for _, id := range notificationIDs {
+		paths = append(paths, notificationPath(email, id))
+	}
+	return s.b.delete(ctx, paths...)
+}
+
+func (s notifications) ListTasks(ctx context.Context, email string) ([]Document, error) {
+	return s.b.list(ctx, fmt.Sprintf("Notifications/%s/Tasks", email))
+}
+
+func (s notifications) MergeInvite(ctx context.Context, email, docID string, data Fields) error {
+	return s.b.set(ctx, fmt.Sprintf("Notifications/%s/InviteJoin/%s", email, docID), data, true)
+}
+
+// ---------- BoardInvite ----------
+
+type invites struct{ b backend }
+
+func (s invites) IDs(ctx context.Context) ([]string, error) {
+	docs, err := s.b.list(ctx, "BoardInvite")
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		ids = append(ids, doc.ID)
+	}
+	return ids, nil
+}
+
+func (s invites) Get(ctx context.Context, id string) (*Invite, error) {
+	data, err := s.b.get(ctx, "BoardInvite/"+id)
+	if err != nil {
+		return nil, err
+	}
+	return &Invite{
+		Accept:    asBool(data["accept"]),
+		BoardID:   asInt(data["board_id"]),
+		CreatedAt: asTime(data["created_at"]),
+		InviteID:  asInt(data["invite_id"]),
+		InviterID: asInt(data["inviter_id"]),
+		UpdatedAt: asTime(data["updated_at"]),
+	}, nil
+}
+
+func (s invites) Set(ctx context.Context, id string, invite Invite) error {
+	return s.b.set(ctx, "BoardInvite/"+id, Fields{
+		"accept":     invite.Accept,
+		"board_id":   invite.BoardID,
+		"created_at": invite.CreatedAt,
+		"invite_id":  invite.InviteID,
+		"inviter_id": invite.InviterID,
+		"updated_at": invite.UpdatedAt,
+	}, false)
+}
+
+func (s invites) Accept(ctx context.Context, id string) error {
+	return s.b.update(ctx, "BoardInvite/"+id, Fields{
+		"accept":     true,
+		"updated_at": time.Now(),
+	})
+}
+
+func (s invites) Delete(ctx context.Context, id string) error {
+	return s.b.delete(ctx, "BoardInvite/"+id)
+}
+
+// ---------- refreshTokens ----------
+
+// ชื่อ field ตามชื่อ Go field ของ model.TokenResponse ซึ่งเป็นรูปแบบที่ Firestore เคยบันทึกไว้
+type refreshTokens struct{ b backend }
+
+func refreshTokenPath(userID int) string { return "refreshTokens/" + strconv.Itoa(userID) }
+
+func (s refreshTokens) Get(ctx context.Context, userID int) (*model.TokenResponse, error) {
+	data, err := s.b.get(ctx, refreshTokenPath(userID))
+	if err != nil {
+		return nil, err
+	}
+	return &model.TokenResponse{
+		UserID:       asInt(data["UserID"]),
+		RefreshToken: asString(data["RefreshToken"]),
+		CreatedAt:    int64(asInt(data["CreatedAt"])),
+		Revoked:      asBool(data["Revoked"]),
+		ExpiresIn:    int64(asInt(data["ExpiresIn"])),
+	}, nil
+}
+
+func (s refreshTokens) Save(ctx context.Context, userID int, token model.TokenResponse) error {
+	return s.b.set(ctx, refreshTokenPath(userID), Fields{
+		"UserID":       token.UserID,
+		"RefreshToken": token.RefreshToken,
+		"CreatedAt":    token.CreatedAt,
+		"Revoked":      token.Revoked,
+		"ExpiresIn":    token.ExpiresIn,
+	}, false)
+}
+
+func (s refreshTokens) Delete(ctx context.Context, userID int) error {
+	return s.b.delete(ctx, refreshTokenPath(userID))
+}
+
+// ---------- OTPRecords / EmailBlocked ----------
+
+type otpRecords struct{ b backend }
+
+func otpCollection(email, record string) string {
+	return fmt.Sprintf("OTPRecords/%s/OTPRecords_%s", email, record)
+}
+
+func blockedPath(email, record string) string {
+	return fmt.Sprintf("EmailBlocked/%s/EmailBlocked_%s/%s", email, record, email)
+}
+
+func otpFromFields(data Fields) model.OTPRecord {
+	return model.OTPRecord{
+		Email:     asString(data["email"]),
+		Reference: asString(data["reference"]),
+		Is_used:   asString(data["is_used"]),
+		CreatedAt: asTime(data["createdAt"]),
+		ExpiresAt: asTime(data["expiresAt"]),
+		Type:      asString(data["type"]),
+	}
+}
+
+func (s otpRecords) Get(ctx context.Context, email, record, ref string) (*model.OTPRecord, error) {
+	data, err := s.b.get(ctx, otpCollection(email, record)+"/"+ref)
+	if err != nil {
+		return nil, err
+	}
+	otp := otpFromFields(data)
+	return &otp, nil
+}
+
+func (s otpRecords) Save(ctx context.Context, email, record string, otp model.OTPRecord) error {
+	return s.b.set(ctx, otpCollection(email, record)+"/"+otp.Reference, Fields{
+		"email":     otp.Email,
+		"reference": otp.Reference,
+		"is_used":   otp.Is_used,
+		"createdAt": otp.CreatedAt,
+		"expiresAt": otp.ExpiresAt,
+		"type":      otp.Type,
+	}, false)
+}
+
+func (s otpRecords) MarkUsed(ctx context.Context, email, record, ref string) error {
+	return s.b.update(ctx, otpCollection(email, record)+"/"+ref, Fields{"is_used": "1"})
+}
+
+func (s otpRecords) List(ctx context.Context, email, record string) ([]model.OTPRecord, error) {
+	docs, err := s.b.list(ctx, otpCollection(email, record))
+	if err != nil {
+		return nil, err
+	}
+	records := make([]model.OTPRecord, 0, len(docs))
+	for _, doc := range docs {
+		records = append(records, otpFromFields(doc.Data))
+	}
+	return records, nil
+}
+
+func (s otpRecords) BlockedUntil(ctx context.Context, email, record string) (time.Time, error) {
+	data, err := s.b.get(ctx, blockedPath(email, record))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return asTime(data["expiresAt"]), nil
+}
+
+func (s otpRecords) Block(ctx context.Context, email, record string, from, until time.Time) error {
+	return s.b.set(ctx, blockedPath(email, record), Fields{
+		"email":     email,
+		"createdAt": from,
+		"expiresAt": until,
+	}, false)
+}
+
+func (s otpRecords) Unblock(ctx context.Context, email, record string) error {
+	return s.b.delete(ctx, blockedPath(email, record))
+}
+
+// ---------- usersLogin ----------
+
+type logins struct{ b backend }
+
+func (s logins) Get(ctx context.Context, email string) (Fields, error) {
+	return s.b.get(ctx, "usersLogin/"+email)
+}
+
+func (s logins) Merge(ctx context.Context, email string, data Fields) error {
+	return s.b.set(ctx, "usersLogin/"+email, data, true)
+}
+
+func (s logins) Update(ctx context.Context, email string, data Fields) error {
+	return s.b.update(ctx, "usersLogin/"+email, data)
+}
+
+func (s logins) FindByToken(ctx context.Context, token string) ([]string, error) {
+	docs, err := s.b.find(ctx, "usersLogin", "FMCToken", token)
+	if err != nil {
+		return nil, err
+	}
+	emails := make([]string, 0, len(docs))
+	for _, doc := range docs {
+		emails = append(emails, doc.ID)
+	}
+	return emails, nil
+}
+
+// ---------- Reports ----------
+
+type reports struct{ b backend }
+
+func (s reports) Set(ctx context.Context, email, category string, reportID int, data Fields) error {
+	return s.b.set(ctx, fmt.Sprintf("Reports/%s/%s/report_%d", email, category, reportID), data, false)
+}
+
+// ---------- แปลงค่าจาก Fields ----------
+// Firestore คืนตัวเลขเป็น int64 ส่วน Memory คืนชนิดเดิมที่บันทึกไว้ จึงต้องรองรับทั้งสองแบบ
+
+func asInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case int32:
+		return int(n)
+	case float64:
+		return int(n)
+	}
+	return 0
+}
+
+func asString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func asBool(v interface{}) bool {
+	b, _ := v.(bool)
+	return b
+}
+
+func asTime(v interface{}) time.Time {
+	switch t := v.(type) {
+	case time.Time:
+		return t
+	case *time.Time:
+		if t != nil {
+			return *t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,132 @@
+package store
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Firestore batch รับได้ไม่เกิน 500 write ต่อครั้ง
+const firestoreBatchLimit = 500
+
+type firestoreBackend struct {
+	client *firestore.Client
+}
+
+// NewFirestore สร้าง Store ที่อ่านเขียน Firestore จริงผ่าน client ที่เปิดไว้แล้ว
+func NewFirestore(client *firestore.Client) Store {
+	return docStore{b: &firestoreBackend{client: client}}
+}
+
+// แปลงค่าพิเศษของ store เป็นค่าที่ Firestore เข้าใจ
+func toFirestoreValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case sentinel:
+		switch val {
+		case DeleteField:
+			return firestore.Delete
+		case ServerTimestamp:
+			return firestore.ServerTimestamp
+		}
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, inner := range val {
+			out[k] = toFirestoreValue(inner)
+		}
+		return out
+	}
+	return v
+}
+
+func notFound(err error) error {
+	if status.Code(err) == codes.NotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (f *firestoreBackend) get(ctx context.Context, path string) (Fields, error) {
+	snap, err := f.client.Doc(path).Get(ctx)
+	if err != nil {
+		return nil, notFound(err)
+	}
+	if !snap.Exists() {
+		return nil, ErrNotFound
+	}
+	return snap.Data(), nil
+}
+
+func (f *firestoreBackend) set(ctx context.Context, path string, data Fields, merge bool) error {
+	converted := toFirestoreValue(data).(map[string]interface{})
+	var err error
+	if merge {
+		_, err = f.client.Doc(path).Set(ctx, converted, firestore.MergeAll)
+	} else {
+		_, err = f.client.Doc(path).Set(ctx, converted)
+	}
+	return err
+}
+
+func (f *firestoreBackend) update(ctx context.Context, path string, data Fields) error {
+	updates := make([]firestore.Update, 0, len(data))
+	for k, v := range data {
+		updates = append(updates, firestore.Update{Path: k, Value: toFirestoreValue(v)})
+	}
+	_, err := f.client.Doc(path).Update(ctx, updates)
+	return notFound(err)
+}
+
+func (f *firestoreBackend) delete(ctx context.Context, paths ...string) error {
+	switch len(paths) {
+	case 0:
+		return nil
+	case 1:
+		_, err := f.client.Doc(paths[0]).Delete(ctx)
+		return err
+	}
+
+	for start := 0; start < len(paths); start += firestoreBatchLimit {
+		end := start + firestoreBatchLimit
+		if end > len(paths) {
+			end = len(paths)
+		}
+		batch := f.client.Batch()
+		for _, path := range paths[start:end] {
+			batch.Delete(f.client.Doc(path))
+		}
+		if _, err := batch.Commit(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *firestoreBackend) list(ctx context.Context, collection string) ([]Document, error) {
+	snaps, err := f.client.Collection(collection).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]Document, 0, len(snaps))
+	for _, snap := range snaps {
+		docs = append(docs, Document{ID: snap.Ref.ID, Data: snap.Data()})
+	}
+	return docs, nil
+}
+
+func (f *firestoreBackend) find(ctx context.Context, collection, field string, value interface{}) ([]Document, error) {
+	snaps, err := f.client.Collection(collection).Where(field, "==", toFirestoreValue(value)).Documents(ctx).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	docs := make([]Document, 0, len(snaps))
+	for _, snap := range snaps {
+		docs = append(docs, Document{ID: snap.Ref.ID, Data: snap.Data()})
+	}
+	return docs, nil
+}
+
+func (f *firestoreBackend) close() error {
+	return f.client.Close()
+}
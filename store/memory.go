@@ -0,0 +1,218 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Memory Store ที่เก็บทุกอย่างไว้ใน map ใช้รัน API และ scheduler โดยไม่ต้องมี Firebase project
+// พฤติกรรมเลียนแบบ Firestore: Update เอกสารที่ไม่มีคืน ErrNotFound, Merge รวม map ซ้อนกัน,
+// key ที่มีจุดใน Update หมายถึง field ซ้อน ส่วนใน Set เป็นชื่อ field ตรงตัว
+// การเขียนที่ Firestore ปฏิเสธ (DeleteField ใน Set ที่ไม่ merge, path ใน Update ที่ซ้อนกัน) คืน error เช่นกัน
+type Memory struct {
+	docStore
+	mu   sync.RWMutex
+	docs map[string]Fields
+}
+
+// NewMemory สร้าง Store เปล่าในหน่วยความจำ
+func NewMemory() *Memory {
+	m := &Memory{docs: make(map[string]Fields)}
+	m.docStore = docStore{b: m}
+	return m
+}
+
+// Doc คืนสำเนาของเอกสารตาม path เต็ม เช่น "Boards/1/Tasks/2"
+func (m *Memory) Doc(path string) (Fields, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.docs[path]
+	if !ok {
+		return nil, false
+	}
+	return copyFields(data), true
+}
+
+// Paths คืน path ของเอกสารทั้งหมดที่ขึ้นต้นด้วย prefix เรียงตามตัวอักษร
+func (m *Memory) Paths(prefix string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	var paths []string
+	for path := range m.docs {
+		if strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+func (m *Memory) get(ctx context.Context, path string) (Fields, error) {
+	if data, ok := m.Doc(path); ok {
+		return data, nil
+	}
+	return nil, ErrNotFound
+}
+
+func (m *Memory) set(ctx context.Context, path string, data Fields, merge bool) error {
+	if !merge && hasDeleteField(data) {
+		return fmt.Errorf("store: DeleteField in a set without merge at %s", path)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.docs[path]
+	if !merge || !ok {
+		existing = make(Fields)
+	}
+	mergeFields(existing, data)
+	m.docs[path] = existing
+	return nil
+}
+
+func (m *Memory) update(ctx context.Context, path string, data Fields) error {
+	if err := checkUpdatePaths(data); err != nil {
+		return fmt.Errorf("%w at %s", err, path)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	existing, ok := m.docs[path]
+	if !ok {
+		return ErrNotFound
+	}
+	for key, value := range data {
+		parts := strings.Split(key, ".")
+		target := existing
+		for _, part := range parts[:len(parts)-1] {
+			next, ok := target[part].(map[string]interface{})
+			if !ok {
+				next = make(map[string]interface{})
+				target[part] = next
+			}
+			target = next
+		}
+		last := parts[len(parts)-1]
+		if value == DeleteField {
+			delete(target, last)
+			continue
+		}
+		target[last] = resolveValue(value)
+	}
+	return nil
+}
+
+func (m *Memory) delete(ctx context.Context, paths ...string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, path := range paths {
+		delete(m.docs, path)
+	}
+	return nil
+}
+
+func (m *Memory) list(ctx context.Context, collection string) ([]Document, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	prefix := collection + "/"
+	var docs []Document
+	for path, data := range m.docs {
+		id := strings.TrimPrefix(path, prefix)
+		if id == path || strings.Contains(id, "/") {
+			continue
+		}
+		docs = append(docs, Document{ID: id, Data: copyFields(data)})
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs, nil
+}
+
+func (m *Memory) find(ctx context.Context, collection, field string, value interface{}) ([]Document, error) {
+	docs, err := m.list(ctx, collection)
+	if err != nil {
+		return nil, err
+	}
+	var out []Document
+	for _, doc := range docs {
+		if v, ok := doc.Data[field]; ok && v == value {
+			out = append(out, doc)
+		}
+	}
+	return out, nil
+}
+
+func (m *Memory) close() error { return nil }
+
+// mergeFields รวม src เข้า dst แบบ MergeAll ของ Firestore (map ซ้อนรวมกัน ไม่ทับทั้งก้อน)
+func mergeFields(dst, src map[string]interface{}) {
+	for key, value := range src {
+		if value == DeleteField {
+			delete(dst, key)
+			continue
+		}
+		// map ว่างเป็นค่าปลายทาง Firestore จึงแทนที่ field ทั้งก้อน
+		if inner, ok := value.(map[string]interface{}); ok && len(inner) > 0 {
+			existing, ok := dst[key].(map[string]interface{})
+			if !ok {
+				existing = make(map[string]interface{})
+			}
+			mergeFields(existing, inner)
+			dst[key] = existing
+			continue
+		}
+		dst[key] = resolveValue(value)
+	}
+}
+
+// checkUpdatePaths path ใน Update ต้องไม่ซ้อนกัน เช่น "a" กับ "a.b" และค่าที่เป็น map ห้ามมี DeleteField
+func checkUpdatePaths(data Fields) error {
+	for key, value := range data {
+		if inner, ok := value.(map[string]interface{}); ok && hasDeleteField(inner) {
+			return fmt.Errorf("store: DeleteField inside the value of %q", key)
+		}
+		for other := range data {
+			if strings.HasPrefix(other, key+".") {
+				return fmt.Errorf("store: update path %q is a prefix of %q", key, other)
+			}
+		}
+	}
+	return nil
+}
+
+func hasDeleteField(data map[string]interface{}) bool {
+	for _, value := range data {
+		if value == DeleteField {
+			return true
+		}
+		if inner, ok := value.(map[string]interface{}); ok && hasDeleteField(inner) {
+			return true
+		}
+	}
+	return false
+}
+
+func resolveValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case sentinel:
+		if val == ServerTimestamp {
+			return time.Now()
+		}
+	case map[string]interface{}:
+		return copyFields(val)
+	}
+	return v
+}
+
+func copyFields(src map[string]interface{}) Fields {
+	out := make(Fields, len(src))
+	for key, value := range src {
+		if inner, ok := value.(map[string]interface{}); ok {
+			out[key] = copyFields(inner)
+			continue
+		}
+		out[key] = value
+	}
+	return out
+}
@@ -0,0 +1,238 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const docPath = "Boards/1"
+
+// seeded Memory ที่มีเอกสาร docPath ตาม data (nil คือไม่มีเอกสาร)
+func seeded(t *testing.T, data Fields) *Memory {
+	t.Helper()
+	m := NewMemory()
+	if data != nil {
+		if err := m.Apply(context.Background(), Mutation{Op: OpSet, Path: docPath, Data: data}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return m
+}
+
+// ทุกกรณีด้านล่างคือผลที่ Firestore ให้กับการเขียนเดียวกัน
+func TestMemoryWritesMatchFirestore(t *testing.T) {
+	profile := func() Fields {
+		return Fields{"name": "Board", "owner": map[string]interface{}{"id": 1, "email": "a@example.test"}}
+	}
+	for _, tc := range []struct {
+		name    string
+		seed    Fields
+		write   Mutation
+		want    Fields // nil คือเอกสารต้องไม่มีอยู่
+		wantErr error  // nil และ anyErr เป็น false คือต้องสำเร็จ
+		anyErr  bool
+	}{
+		// ---------- Update ----------
+		{
+			name:  "update dotted key changes only the nested field",
+			seed:  profile(),
+			write: Mutation{Op: OpUpdate, Data: Fields{"owner.email": "b@example.test"}},
+			want:  Fields{"name": "Board", "owner": map[string]interface{}{"id": 1, "email": "b@example.test"}},
+		},
+		{
+			name:  "update map value replaces the whole field",
+			seed:  profile(),
+			write: Mutation{Op: OpUpdate, Data: Fields{"owner": map[string]interface{}{"email": "b@example.test"}}},
+			want:  Fields{"name": "Board", "owner": map[string]interface{}{"email": "b@example.test"}},
+		},
+		{
+			name:  "update dotted key creates missing parents",
+			seed:  Fields{"name": "Board", "owner": "legacy"},
+			write: Mutation{Op: OpUpdate, Data: Fields{"owner.id": 2, "settings.color.hex": "#fff"}},
+			want: Fields{
+				"name":     "Board",
+				"owner":    map[string]interface{}{"id": 2},
+				"settings": map[string]interface{}{"color": map[string]interface{}{"hex": "#fff"}},
+			},
+		},
+		{
+			name:    "update missing document",
+			write:   Mutation{Op: OpUpdate, Data: Fields{"name": "Board"}},
+			wantErr: ErrNotFound,
+		},
+		{
+			name:   "update overlapping paths",
+			seed:   profile(),
+			write:  Mutation{Op: OpUpdate, Data: Fields{"owner": map[string]interface{}{}, "owner.id": 2}},
+			want:   profile(),
+			anyErr: true,
+		},
+
+		// ---------- DeleteField ----------
+		{
+			name:  "update deletes a top-level field",
+			seed:  profile(),
+			write: Mutation{Op: OpUpdate, Data: Fields{"name": DeleteField}},
+			want:  Fields{"owner": map[string]interface{}{"id": 1, "email": "a@example.test"}},
+		},
+		{
+			name:  "update deletes a nested field by dotted key",
+			seed:  profile(),
+			write: Mutation{Op: OpUpdate, Data: Fields{"owner.email": DeleteField, "missing": DeleteField}},
+			want:  Fields{"name": "Board", "owner": map[string]interface{}{"id": 1}},
+		},
+		{
+			name:   "update with DeleteField inside a map value",
+			seed:   profile(),
+			write:  Mutation{Op: OpUpdate, Data: Fields{"owner": map[string]interface{}{"email": DeleteField}}},
+			want:   profile(),
+			anyErr: true,
+		},
+		{
+			name:  "merge deletes a nested field",
+			seed:  profile(),
+			write: Mutation{Op: OpMerge, Data: Fields{"owner": map[string]interface{}{"email": DeleteField}}},
+			want:  Fields{"name": "Board", "owner": map[string]interface{}{"id": 1}},
+		},
+		{
+			name:   "set without merge rejects DeleteField",
+			seed:   profile(),
+			write:  Mutation{Op: OpSet, Data: Fields{"name": DeleteField}},
+			want:   profile(),
+			anyErr: true,
+		},
+
+		// ---------- Set และ merge ----------
+		{
+			name:  "set replaces the document",
+			seed:  profile(),
+			write: Mutation{Op: OpSet, Data: Fields{"owner": map[string]interface{}{"id": 2}}},
+			want:  Fields{"owner": map[string]interface{}{"id": 2}},
+		},
+		{
+			name:  "merge keeps fields it does not mention",
+			seed:  profile(),
+			write: Mutation{Op: OpMerge, Data: Fields{"owner": map[string]interface{}{"id": 2}, "color": "red"}},
+			want:  Fields{"name": "Board", "color": "red", "owner": map[string]interface{}{"id": 2, "email": "a@example.test"}},
+		},
+		{
+			name:  "merge creates a missing document",
+			write: Mutation{Op: OpMerge, Data: Fields{"name": "Board"}},
+			want:  Fields{"name": "Board"},
+		},
+		{
+			name:  "merge with an empty map replaces the field",
+			seed:  profile(),
+			write: Mutation{Op: OpMerge, Data: Fields{"owner": map[string]interface{}{}}},
+			want:  Fields{"name": "Board", "owner": map[string]interface{}{}},
+		},
+		{
+			name:  "merge keys with dots are literal field names",
+			write: Mutation{Op: OpMerge, Data: Fields{"owner.id": 2}},
+			want:  Fields{"owner.id": 2},
+		},
+
+		// ---------- Delete ----------
+		{
+			name:  "delete removes the document",
+			seed:  profile(),
+			write: Mutation{Op: OpDelete},
+		},
+		{
+			name:  "delete missing document",
+			write: Mutation{Op: OpDelete},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			m := seeded(t, tc.seed)
+			tc.write.Path = docPath
+			err := m.Apply(context.Background(), tc.write)
+			switch {
+			case tc.wantErr != nil && !errors.Is(err, tc.wantErr):
+				t.Fatalf("Apply = %v, want %v", err, tc.wantErr)
+			case tc.anyErr && err == nil:
+				t.Fatal("Apply succeeded, want an error")
+			case tc.wantErr == nil && !tc.anyErr && err != nil:
+				t.Fatalf("Apply: %v", err)
+			}
+			// การเขียนที่ล้มเหลวต้องไม่แตะเอกสาร
+			got, ok := m.Doc(docPath)
+			if tc.want == nil {
+				if ok {
+					t.Fatalf("document = %v, want none", got)
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Fatalf("document = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+// Apply ทีละรายการตามลำดับแบบที่ outbox relay เขียน batch ที่บันทึกไว้
+// โดยผ่าน EncodeFields และ DecodeFields เหมือนแถวใน outbox
+func TestMemoryApplyBatch(t *testing.T) {
+	ctx := context.Background()
+	var batch []Mutation
+	rec := Recorder(func(m Mutation) error {
+		b, err := EncodeFields(m.Data)
+		if err != nil {
+			return err
+		}
+		if m.Data, err = DecodeFields(b); err != nil {
+			return err
+		}
+		batch = append(batch, m)
+		return nil
+	})
+	due := time.Date(2026, time.October, 25, 9, 0, 0, 0, time.UTC)
+	steps := []error{
+		rec.Boards().Set(ctx, 1, Fields{"name": "Board", "owner": map[string]interface{}{"id": 1, "email": "a@example.test"}}),
+		rec.Boards().SetTask(ctx, 1, 7, Fields{"title": "Standup", "dueDate": due, "done": false}),
+		rec.Boards().UpdateTask(ctx, 1, 7, Fields{"done": true, "meta.updatedAt": ServerTimestamp}),
+		rec.Boards().Update(ctx, 1, Fields{"owner.email": DeleteField}),
+		rec.Notifications().MergeTask(ctx, "a@example.test", 7, Fields{"remindMeBefore": due}),
+		rec.Notifications().DeleteTasks(ctx, "a@example.test", 7, 8),
+		rec.Boards().SetTask(ctx, 1, 8, Fields{"title": "Retro"}),
+		rec.Boards().DeleteTask(ctx, 1, 8),
+	}
+	for i, err := range steps {
+		if err != nil {
+			t.Fatalf("record step %d: %v", i, err)
+		}
+	}
+
+	m := NewMemory()
+	before := time.Now()
+	for _, mutation := range batch {
+		if err := m.Apply(ctx, mutation); err != nil {
+			t.Fatalf("Apply(%s %s): %v", mutation.Op, mutation.Path, err)
+		}
+	}
+
+	if paths := m.Paths(""); !reflect.DeepEqual(paths, []string{"Boards/1", "Boards/1/Tasks/7"}) {
+		t.Fatalf("documents = %v, want the board and task 7 only", paths)
+	}
+	board, _ := m.Doc("Boards/1")
+	if want := (Fields{"name": "Board", "owner": map[string]interface{}{"id": int64(1)}}); !reflect.DeepEqual(board, want) {
+		t.Fatalf("board = %v, want %v", board, want)
+	}
+	task, _ := m.Doc("Boards/1/Tasks/7")
+	updatedAt, _ := task["meta"].(map[string]interface{})["updatedAt"].(time.Time)
+	if task["done"] != true || !task["dueDate"].(time.Time).Equal(due) || updatedAt.Before(before) {
+		t.Fatalf("task = %v, want done with the original due date and a server timestamp", task)
+	}
+
+	// Update ของเอกสารที่ถูกลบไปแล้วใน batch คืน ErrNotFound ให้ relay ตัดสินใจต่อ
+	err := m.Apply(ctx, Mutation{Op: OpUpdate, Path: "Boards/1/Tasks/8", Data: Fields{"title": "Retro"}})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("update after delete = %v, want ErrNotFound", err)
+	}
+	if err := m.Apply(ctx, Mutation{Op: "upsert", Path: "Boards/1"}); err == nil {
+		t.Fatal("unknown op succeeded, want an error")
+	}
+}
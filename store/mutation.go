@@ -0,0 +1,261 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Op ชนิดของการเขียนเอกสารหนึ่งครั้ง
+type Op string
+
+const (
+	OpSet    Op = "set"
+	OpMerge  Op = "merge"
+	OpUpdate Op = "update"
+	OpDelete Op = "delete"
+)
+
+// Mutation การเขียนเอกสารหนึ่งรายการตาม path เต็ม เช่น "Boards/1/Tasks/2"
+// ใช้บันทึกการเขียนไว้ก่อน (outbox) แล้วค่อยเขียนจริงด้วย Store.Apply
+type Mutation struct {
+	Op   Op
+	Path string
+	Data Fields
+}
+
+// ErrWriteOnly คืนจากการอ่านผ่าน Store ที่ได้จาก Recorder
+var ErrWriteOnly = errors.New("store: recorder does not support reads")
+
+func (s docStore) Apply(ctx context.Context, m Mutation) error {
+	switch m.Op {
+	case OpSet:
+		return s.b.set(ctx, m.Path, m.Data, false)
+	case OpMerge:
+		return s.b.set(ctx, m.Path, m.Data, true)
+	case OpUpdate:
+		return s.b.update(ctx, m.Path, m.Data)
+	case OpDelete:
+		return s.b.delete(ctx, m.Path)
+	}
+	return fmt.Errorf("store: unknown op %q", m.Op)
+}
+
+// Recorder คืน Store ที่ไม่เขียนจริง แต่ส่งการเขียนแต่ละเอกสารให้ record ตามลำดับที่เรียก
+// การอ่านและ method ที่ต้อง list ก่อน (เช่น BoardTasks().DeleteAll) คืน ErrWriteOnly
+func Recorder(record func(Mutation) error) Store {
+	return docStore{b: recorder(record)}
+}
+
+type recorder func(Mutation) error
+
+func (r recorder) get(ctx context.Context, path string) (Fields, error) {
+	return nil, ErrWriteOnly
+}
+
+func (r recorder) set(ctx context.Context, path string, data Fields, merge bool) error {
+	op := OpSet
+	if merge {
+		op = OpMerge
+	}
+	return r(Mutation{Op: op, Path: path, Data: data})
+}
+
+func (r recorder) update(ctx context.Context, path string, data Fields) error {
+	return r(Mutation{Op: OpUpdate, Path: path, Data: data})
+}
+
+func (r recorder) delete(ctx context.Context, paths ...string) error {
+	for _, path := range paths {
+		if err := r(Mutation{Op: OpDelete, Path: path}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r recorder) list(ctx context.Context, collection string) ([]Document, error) {
+	return nil, ErrWriteOnly
+}
+
+func (r recorder) find(ctx context.Context, collection, field string, value interface{}) ([]Document, error) {
+	return nil, ErrWriteOnly
+}
+
+func (r recorder) close() error { return nil }
+
+// ---------- เก็บ Fields เป็น JSON ----------
+// JSON ธรรมดาทำให้ time.Time กลายเป็น string และตัวเลขกลายเป็น float64
+// ซึ่ง Firestore จะเก็บผิดชนิด จึงเก็บชนิดของทุกค่าไว้คู่กัน
+
+type encodedValue struct {
+	Type  string          `json:"t"`
+	Value json.RawMessage `json:"v,omitempty"`
+}
+
+// EncodeFields แปลง Fields เป็น JSON ที่ DecodeFields คืนกลับเป็นชนิดเดิมได้
+// pointer ถูกเก็บเป็นค่าที่ชี้ ตัวเลขจำนวนเต็มทุกชนิดกลับมาเป็น int64 แบบที่ Firestore คืน
+func EncodeFields(data Fields) ([]byte, error) {
+	if data == nil {
+		return []byte("null"), nil
+	}
+	v, err := encodeValue(reflect.ValueOf(map[string]interface{}(data)))
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+// DecodeFields อ่าน JSON จาก EncodeFields
+func DecodeFields(b []byte) (Fields, error) {
+	var v *encodedValue
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	out, err := decodeValue(*v)
+	if err != nil {
+		return nil, err
+	}
+	fields, ok := out.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("store: encoded fields are %s, not a map", v.Type)
+	}
+	return fields, nil
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func encodeValue(rv reflect.Value) (encodedValue, error) {
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return encodedValue{Type: "null"}, nil
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return encodedValue{Type: "null"}, nil
+	}
+
+	raw := func(typ string, v interface{}) (encodedValue, error) {
+		b, err := json.Marshal(v)
+		return encodedValue{Type: typ, Value: b}, err
+	}
+
+	if rv.Type() == timeType {
+		return raw("time", rv.Interface().(time.Time).Format(time.RFC3339Nano))
+	}
+	if s, ok := rv.Interface().(sentinel); ok {
+		switch s {
+		case DeleteField:
+			return encodedValue{Type: "delete"}, nil
+		case ServerTimestamp:
+			return encodedValue{Type: "server_timestamp"}, nil
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		return raw("bool", rv.Bool())
+	case reflect.String:
+		return raw("string", rv.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return raw("int", rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return raw("int", int64(rv.Uint()))
+	case reflect.Float32, reflect.Float64:
+		return raw("float", rv.Float())
+	case reflect.Slice, reflect.Array:
+		items := make([]encodedValue, rv.Len())
+		for i := range items {
+			item, err := encodeValue(rv.Index(i))
+			if err != nil {
+				return encodedValue{}, err
+			}
+			items[i] = item
+		}
+		return raw("array", items)
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		entries := make(map[string]encodedValue, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			entry, err := encodeValue(iter.Value())
+			if err != nil {
+				return encodedValue{}, err
+			}
+			entries[iter.Key().String()] = entry
+		}
+		return raw("map", entries)
+	}
+	return encodedValue{}, fmt.Errorf("store: cannot encode value of type %s", rv.Type())
+}
+
+func decodeValue(v encodedValue) (interface{}, error) {
+	switch v.Type {
+	case "null":
+		return nil, nil
+	case "delete":
+		return DeleteField, nil
+	case "server_timestamp":
+		return ServerTimestamp, nil
+	case "bool":
+		var b bool
+		err := json.Unmarshal(v.Value, &b)
+		return b, err
+	case "string":
+		var s string
+		err := json.Unmarshal(v.Value, &s)
+		return s, err
+	case "int":
+		var n int64
+		err := json.Unmarshal(v.Value, &n)
+		return n, err
+	case "float":
+		var f float64
+		err := json.Unmarshal(v.Value, &f)
+		return f, err
+	case "time":
+		var s string
+		if err := json.Unmarshal(v.Value, &s); err != nil {
+			return nil, err
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	case "array":
+		var items []encodedValue
+		if err := json.Unmarshal(v.Value, &items); err != nil {
+			return nil, err
+		}
+		out := make([]interface{}, len(items))
+		for i, item := range items {
+			decoded, err := decodeValue(item)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = decoded
+		}
+		return out, nil
+	case "map":
+		var entries map[string]encodedValue
+		if err := json.Unmarshal(v.Value, &entries); err != nil {
+			return nil, err
+		}
+		out := make(map[string]interface{}, len(entries))
+		for key, entry := range entries {
+			decoded, err := decodeValue(entry)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = decoded
+		}
+		return out, nil
+	}
+	return nil, fmt.Errorf("store: unknown encoded type %q", v.Type)
+}
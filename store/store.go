@@ -0,0 +1,160 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"mydayplanner/model"
+	"time"
+)
+
+// Fields ข้อมูลของเอกสารใน realtime mirror
+// key ต้องตรงกับชื่อ field ที่แอปมือถืออ่านจาก Firestore
+type Fields = map[string]interface{}
+
+// Document เอกสารหนึ่งรายการพร้อม ID ที่ได้จากการอ่านทั้ง collection
+type Document struct {
+	ID   string
+	Data Fields
+}
+
+// ErrNotFound คืนเมื่อเอกสารที่อ่านหรือ Update ไม่มีอยู่
+var ErrNotFound = errors.New("store: document not found")
+
+type sentinel int
+
+const (
+	// DeleteField ใส่เป็นค่าใน Fields เพื่อลบ field นั้นออกจากเอกสาร
+	DeleteField sentinel = iota + 1
+	// ServerTimestamp ให้ backend ใส่เวลาปัจจุบันแทนค่า
+	ServerTimestamp
+)
+
+// TaskCollection subcollection ที่อยู่ใต้ BoardTasks/{taskID}
+type TaskCollection string
+
+const (
+	TaskNotifications TaskCollection = "Notifications"
+	TaskAssigned      TaskCollection = "Assigned"
+	TaskAttachments   TaskCollection = "Attachments"
+	TaskChecklist     TaskCollection = "Checklist"
+)
+
+// TaskCollections subcollection ทั้งหมดของ BoardTasks ตามลำดับที่ลบ
+var TaskCollections = []TaskCollection{TaskNotifications, TaskAssigned, TaskAttachments, TaskChecklist}
+
+// Store typed interface ของ realtime mirror ที่ controller และ scheduler ใช้แทน *firestore.Client
+type Store interface {
+	Boards() BoardStore
+	BoardTasks() BoardTaskStore
+	Notifications() NotificationStore
+	Invites() InviteStore
+	RefreshTokens() RefreshTokenStore
+	OTPRecords() OTPRecordStore
+	Logins() LoginStore
+	Reports() ReportStore
+	// Apply เขียน Mutation ที่บันทึกไว้ก่อนหน้า เช่นจาก outbox
+	Apply(ctx context.Context, m Mutation) error
+	// Ping อ่านเอกสารหนึ่งรายการเพื่อตรวจว่า backend ตอบสนอง (เอกสารไม่มีอยู่ถือว่าปกติ)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// BoardStore Boards/{boardID} พร้อม subcollection Tasks และ BoardUsers
+type BoardStore interface {
+	Get(ctx context.Context, boardID int) (Fields, error)
+	Set(ctx context.Context, boardID int, data Fields) error
+	Update(ctx context.Context, boardID int, data Fields) error
+	Delete(ctx context.Context, boardID int) error
+
+	GetTask(ctx context.Context, boardID, taskID int) (Fields, error)
+	SetTask(ctx context.Context, boardID, taskID int, data Fields) error
+	UpdateTask(ctx context.Context, boardID, taskID int, data Fields) error
+	DeleteTask(ctx context.Context, boardID, taskID int) error
+	ListTasks(ctx context.Context, boardID int) ([]Document, error)
+
+	SetUser(ctx context.Context, boardID, boardUserID int, data Fields) error
+	DeleteUser(ctx context.Context, boardID, boardUserID int) error
+}
+
+// BoardTaskStore BoardTasks/{taskID} และ subcollection ตาม TaskCollection
+type BoardTaskStore interface {
+	Get(ctx context.Context, taskID int) (Fields, error)
+	Merge(ctx context.Context, taskID int, data Fields) error
+	// DeleteAll ลบทุก subcollection แล้วจึงลบเอกสารหลักของ task
+	DeleteAll(ctx context.Context, taskID int) error
+
+	GetItem(ctx context.Context, taskID int, col TaskCollection, id string) (Fields, error)
+	SetItem(ctx context.Context, taskID int, col TaskCollection, id string, data Fields) error
+	MergeItem(ctx context.Context, taskID int, col TaskCollection, id string, data Fields) error
+	UpdateItem(ctx context.Context, taskID int, col TaskCollection, id string, data Fields) error
+	DeleteItem(ctx context.Context, taskID int, col TaskCollection, id string) error
+	ListItems(ctx context.Context, taskID int, col TaskCollection) ([]Document, error)
+}
+
+// NotificationStore Notifications/{email}/Tasks และ Notifications/{email}/InviteJoin
+type NotificationStore interface {
+	GetTask(ctx context.Context, email string, notificationID int) (Fields, error)
+	SetTask(ctx context.Context, email string, notificationID int, data Fields) error
+	MergeTask(ctx context.Context, email string, notificationID int, data Fields) error
+	UpdateTask(ctx context.Context, email string, notificationID int, data Fields) error
+	// DeleteTasks ลบหลายรายการในครั้งเดียว (Firestore ใช้ batch)
+	DeleteTasks(ctx context.Context, email string, notificationIDs ...int) error
+	ListTasks(ctx context.Context, email string) ([]Document, error)
+
+	MergeInvite(ctx context.Context, email, docID string, data Fields) error
+}
+
+// Invite คำเชิญเข้าบอร์ดใน BoardInvite/{id}
+// InviteID เก็บ user_id ของผู้ถูกเชิญ (ชื่อ field เดิมที่แอปใช้อยู่)
+type Invite struct {
+	Accept    bool      `firestore:"accept"`
+	BoardID   int       `firestore:"board_id"`
+	CreatedAt time.Time `firestore:"created_at"`
+	InviteID  int       `firestore:"invite_id"`
+	InviterID int       `firestore:"inviter_id"`
+	UpdatedAt time.Time `firestore:"updated_at"`
+}
+
+// InviteStore collection BoardInvite
+type InviteStore interface {
+	IDs(ctx context.Context) ([]string, error)
+	Get(ctx context.Context, id string) (*Invite, error)
+	Set(ctx context.Context, id string, invite Invite) error
+	Accept(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
+}
+
+// RefreshTokenStore refreshTokens/{userID}
+type RefreshTokenStore interface {
+	Get(ctx context.Context, userID int) (*model.TokenResponse, error)
+	Save(ctx context.Context, userID int, token model.TokenResponse) error
+	Delete(ctx context.Context, userID int) error
+}
+
+// OTPRecordStore OTPRecords/{email}/OTPRecords_{record} และ EmailBlocked/{email}/EmailBlocked_{record}
+// record คือ "verify" หรือ "resetpassword"
+type OTPRecordStore interface {
+	Get(ctx context.Context, email, record, ref string) (*model.OTPRecord, error)
+	Save(ctx context.Context, email, record string, otp model.OTPRecord) error
+	MarkUsed(ctx context.Context, email, record, ref string) error
+	List(ctx context.Context, email, record string) ([]model.OTPRecord, error)
+
+	// BlockedUntil คืน ErrNotFound เมื่ออีเมลไม่ได้ถูกบล็อก
+	BlockedUntil(ctx context.Context, email, record string) (time.Time, error)
+	Block(ctx context.Context, email, record string, from, until time.Time) error
+	Unblock(ctx context.Context, email, record string) error
+}
+
+// LoginStore usersLogin/{email}
+type LoginStore interface {
+	Get(ctx context.Context, email string) (Fields, error)
+	Merge(ctx context.Context, email string, data Fields) error
+	Update(ctx context.Context, email string, data Fields) error
+	// FindByToken อีเมลของทุกเอกสารที่ FMCToken เท่ากับ token
+	FindByToken(ctx context.Context, token string) ([]string, error)
+}
+
+// ReportStore Reports/{email}/{category}/report_{reportID}
+type ReportStore interface {
+	Set(ctx context.Context, email, category string, reportID int, data Fields) error
+}
@@ -0,0 +1,133 @@
+// Package timezone เขตเวลาของผู้ใช้ (คอลัมน์ user.timezone เป็นชื่อ IANA เช่น Asia/Bangkok)
+// เวลาในฐานข้อมูลเป็น UTC เสมอ เขตเวลาใช้ตีความวันที่ที่ client ส่งมาโดยไม่มี offset
+// คำนวณรอบ recurring และ "วันนี้" ตามเวลาท้องถิ่น และแสดงเวลาในข้อความ push
+package timezone
+
+import (
+	"fmt"
+	"mydayplanner/model"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default เขตเวลาของผู้ใช้ที่ยังไม่ได้ตั้ง ตรงกับค่า default ของคอลัมน์
+const Default = "Asia/Bangkok"
+
+// cached ผลการโหลดชื่อหนึ่ง รวมชื่อที่โหลดไม่ได้ด้วย
+type cached struct {
+	loc *time.Location
+	err error
+}
+
+var (
+	mu        sync.RWMutex
+	locations = make(map[string]cached)
+)
+
+// Valid ชื่อเป็นเขตเวลา IANA ที่โหลดได้ ไม่รับค่าว่างและ Local ซึ่งขึ้นกับเครื่องที่รัน server
+func Valid(name string) bool {
+	if name == "" || name == "Local" {
+		return false
+	}
+	_, err := load(name)
+	return err == nil
+}
+
+// Load คืน location ของชื่อ ถ้าชื่อว่างหรือโหลดไม่ได้คืน Default
+func Load(name string) *time.Location {
+	if Valid(name) {
+		loc, _ := load(name)
+		return loc
+	}
+	loc, err := load(Default)
+	if err != nil {
+		// main ฝัง time/tzdata ไว้ จึงเกิดได้เฉพาะ binary อื่นที่รันบนเครื่องที่ไม่มี zoneinfo
+		return time.FixedZone(Default, 7*60*60)
+	}
+	return loc
+}
+
+// Of เขตเวลาของผู้ใช้
+func Of(u model.User) *time.Location {
+	return Load(u.Timezone)
+}
+
+// load อ่าน location ครั้งเดียวต่อชื่อ time.LoadLocation อ่านไฟล์ทุกครั้งที่เรียก
+// ชื่อที่โหลดไม่ได้ก็จำไว้ ชื่อเสียที่เก็บในฐานข้อมูลจึงไม่อ่าน zoneinfo ซ้ำทุกครั้ง
+func load(name string) (*time.Location, error) {
+	mu.RLock()
+	c, ok := locations[name]
+	mu.RUnlock()
+	if ok {
+		return c.loc, c.err
+	}
+	loc, err := time.LoadLocation(name)
+	mu.Lock()
+	locations[name] = cached{loc: loc, err: err}
+	mu.Unlock()
+	return loc, err
+}
+
+// dateTimeFormats รูปแบบวันที่ที่ client ส่งมา รูปแบบที่ไม่มี offset ถูกตีความใน loc ของ ParseDateTime
+var dateTimeFormats = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05.999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05.999999",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseDateTime แปลงวันที่จาก client เวลาที่ระบุ offset (รวม Z) ใช้ offset นั้น
+// ส่วนเวลาที่ไม่มี offset เป็นเวลาท้องถิ่นใน loc ผลลัพธ์เป็น UTC
+func ParseDateTime(s string, loc *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("date cannot be empty")
+	}
+	for _, layout := range dateTimeFormats {
+		if t, err := time.ParseInLocation(layout, s, loc); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported date format: %s", s)
+}
+
+// Day ช่วง [เที่ยงคืน, เที่ยงคืนถัดไป) ของวันที่ t ตกอยู่ใน loc วันที่เปลี่ยน daylight saving ยาวไม่เท่า 24 ชั่วโมง
+func Day(t time.Time, loc *time.Location) (start, end time.Time) {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc), time.Date(y, m, d+1, 0, 0, 0, 0, loc)
+}
+
+// DaysBetween จำนวนวันตามปฏิทินใน loc จากวันของ from ถึงวันของ to
+func DaysBetween(from, to time.Time, loc *time.Location) int {
+	a, _ := Day(from, loc)
+	b, _ := Day(to, loc)
+	// วันที่เลื่อนนาฬิกายาว 23 หรือ 25 ชั่วโมง ปัดเป็นวันจึงได้จำนวนที่ถูกต้อง
+	return int(b.Sub(a).Round(24*time.Hour) / (24 * time.Hour))
+}
+
+// AddDate เลื่อน t ตามปฏิทินใน loc เวลาท้องถิ่นจึงคงเดิมแม้ข้ามวันที่เปลี่ยน daylight saving ผลลัพธ์เป็น UTC
+func AddDate(t time.Time, loc *time.Location, years, months, days int) time.Time {
+	return t.In(loc).AddDate(years, months, days).UTC()
+}
+
+// DailyRound รอบประจำวันตอน hour:00 ตามเวลาท้องถิ่นใน loc ที่ now ตกอยู่ ผลลัพธ์เป็น UTC
+// คืน false ถ้า now ไม่อยู่ในช่วง tolerance นับจากเวลารอบ job ที่รันทุกนาทีจึงเห็นรอบเดียวกันได้หลายครั้ง
+func DailyRound(now time.Time, loc *time.Location, hour int, tolerance time.Duration) (time.Time, bool) {
+	y, m, d := now.In(loc).Date()
+	round := time.Date(y, m, d, hour, 0, 0, 0, loc)
+	if now.Before(round) || now.Sub(round) >= tolerance {
+		return time.Time{}, false
+	}
+	return round.UTC(), true
+}
+
+// Format แสดงเวลา t ตามเวลาท้องถิ่นของ loc สำหรับข้อความ push เช่น "16/10 09:30"
+func Format(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("02/01 15:04")
+}
@@ -0,0 +1,113 @@
+package timezone
+
+import (
+	"mydayplanner/model"
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestParseDateTime(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	for _, tc := range []struct {
+		in   string
+		want time.Time
+	}{
+		// เวลาที่มี offset ไม่ขึ้นกับเขตเวลาของผู้ใช้
+		{"2026-10-16T09:30:00Z", time.Date(2026, time.October, 16, 9, 30, 0, 0, time.UTC)},
+		{"2026-10-16T09:30:00+07:00", time.Date(2026, time.October, 16, 2, 30, 0, 0, time.UTC)},
+		// ไม่มี offset ตีความเป็นเวลาท้องถิ่น เบอร์ลินเดือนตุลาคมเป็น +02:00 และเดือนธันวาคมเป็น +01:00
+		{"2026-10-16T09:30:00", time.Date(2026, time.October, 16, 7, 30, 0, 0, time.UTC)},
+		{"2026-12-16 09:30", time.Date(2026, time.December, 16, 8, 30, 0, 0, time.UTC)},
+		{"2026-10-16", time.Date(2026, time.October, 15, 22, 0, 0, 0, time.UTC)},
+	} {
+		got, err := ParseDateTime(tc.in, berlin)
+		if err != nil {
+			t.Fatalf("ParseDateTime(%q): %v", tc.in, err)
+		}
+		if !got.Equal(tc.want) || got.Location() != time.UTC {
+			t.Fatalf("ParseDateTime(%q) = %v, want %v", tc.in, got, tc.want)
+		}
+	}
+	for _, in := range []string{"", "16/10/2026", "2026-10-16T25:00"} {
+		if _, err := ParseDateTime(in, berlin); err == nil {
+			t.Fatalf("ParseDateTime(%q) succeeded, want an error", in)
+		}
+	}
+}
+
+func TestValidAndOf(t *testing.T) {
+	for name, want := range map[string]bool{"Europe/Berlin": true, "UTC": true, "": false, "Local": false, "Mars/Olympus": false} {
+		if got := Valid(name); got != want {
+			t.Fatalf("Valid(%q) = %v, want %v", name, got, want)
+		}
+	}
+	// ชื่อที่โหลดไม่ได้ถูกจำไว้เหมือนชื่อที่โหลดได้
+	mu.RLock()
+	c, ok := locations["Mars/Olympus"]
+	mu.RUnlock()
+	if !ok || c.err == nil {
+		t.Fatalf("cache for Mars/Olympus = %+v, %v; want the failed lookup", c, ok)
+	}
+	if got := Of(model.User{}).String(); got != Default {
+		t.Fatalf("Of(no time zone) = %s, want %s", got, Default)
+	}
+	if got := Of(model.User{Timezone: "Europe/Berlin"}).String(); got != "Europe/Berlin" {
+		t.Fatalf("Of(Europe/Berlin) = %s", got)
+	}
+}
+
+func TestCalendarAcrossDST(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	// เบอร์ลินเลื่อนนาฬิกากลับเวลา 03:00 ของวันที่ 25 ตุลาคม 2026 วันนั้นยาว 25 ชั่วโมง
+	start, end := Day(time.Date(2026, time.October, 25, 12, 0, 0, 0, berlin), berlin)
+	if end.Sub(start) != 25*time.Hour {
+		t.Fatalf("Day spans %v, want 25h", end.Sub(start))
+	}
+
+	due := time.Date(2026, time.October, 24, 9, 0, 0, 0, berlin).UTC()
+	next := AddDate(due, berlin, 0, 0, 1)
+	if local := next.In(berlin); local.Hour() != 9 || local.Day() != 25 {
+		t.Fatalf("AddDate = %v, want 09:00 local on the 25th", local)
+	}
+	if next.Sub(due) != 25*time.Hour {
+		t.Fatalf("AddDate moved %v, want 25h across the change", next.Sub(due))
+	}
+
+	later := time.Date(2026, time.October, 27, 0, 30, 0, 0, berlin)
+	if got := DaysBetween(due, later, berlin); got != 3 {
+		t.Fatalf("DaysBetween = %d, want 3", got)
+	}
+}
+
+func TestDailyRound(t *testing.T) {
+	berlin := mustLocation(t, "Europe/Berlin")
+	for _, tc := range []struct {
+		name string
+		now  time.Time
+		ok   bool
+	}{
+		{"before the round", time.Date(2026, time.October, 24, 6, 59, 0, 0, berlin), false},
+		{"summer time", time.Date(2026, time.October, 24, 7, 1, 0, 0, berlin), true},
+		{"winter time", time.Date(2026, time.October, 26, 7, 0, 0, 0, berlin), true},
+		{"after the tolerance", time.Date(2026, time.October, 26, 7, 2, 0, 0, berlin), false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			round, ok := DailyRound(tc.now.UTC(), berlin, 7, 2*time.Minute)
+			if ok != tc.ok {
+				t.Fatalf("DailyRound(%v) ok = %v, want %v", tc.now, ok, tc.ok)
+			}
+			if ok && (round.In(berlin).Hour() != 7 || round.In(berlin).Minute() != 0) {
+				t.Fatalf("DailyRound(%v) = %v, want 07:00 local", tc.now, round.In(berlin))
+			}
+		})
+	}
+}